@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"orderflow/internal/adapter/repository"
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/usecase/catalogimport"
+	"orderflow/internal/usecase/service"
+)
+
+// runCatalog реализует `orderflow catalog import [flags] <file>`: загружает CSV-каталог
+// напрямую в PostgreSQL (переменные POSTGRES_*). Файл "-" читается из stdin.
+func runCatalog(args []string) int {
+	if len(args) == 0 || args[0] != "import" {
+		fmt.Fprintln(os.Stderr, "Usage: orderflow catalog import [flags] <file|->")
+		return 2
+	}
+
+	flags := flag.NewFlagSet("catalog import", flag.ContinueOnError)
+	verbose := flags.Bool("verbose", false, "print the result of every row, not only failed ones")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: orderflow catalog import [flags] <file|->")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+
+	var source io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to open catalog file:", err)
+			return 1
+		}
+		defer file.Close()
+		source = file
+	}
+
+	rows, err := catalogimport.ParseCSV(source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to parse catalog:", err)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, postgresURL())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect to PostgreSQL:", err)
+		return 1
+	}
+	defer pool.Close()
+
+	inventoryService := service.NewInventoryService(repository.NewInventoryPG(pool), 0, nil)
+	result, err := inventoryService.ImportCatalog(ctx, rows)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "catalog import failed:", err)
+		return 1
+	}
+
+	if !*verbose {
+		failed := make([]inventory.ImportRowResult, 0, result.Failed)
+		for _, row := range result.Rows {
+			if row.Action == inventory.ImportFailed {
+				failed = append(failed, row)
+			}
+		}
+		result.Rows = failed
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+
+	if result.Failed > 0 {
+		return 1
+	}
+	return 0
+}
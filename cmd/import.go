@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"orderflow/internal/usecase/batchimport"
+)
+
+// runImport реализует `orderflow import [flags] <file>`: разбирает файл с заказами
+// и запускает BatchOrderImportWorkflow. Файл "-" читается из stdin.
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	formatFlag := flags.String("format", "", "batch format: ndjson or csv (default: detected from file extension)")
+	concurrency := flags.Int("concurrency", 0, "maximum number of orders processed at once")
+	wait := flags.Bool("wait", false, "wait for the batch to finish and print the report")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: orderflow import [flags] <file|->")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+
+	format, err := batchimport.ParseFormat(*formatFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if format == "" {
+		format = batchimport.DetectFormat("", path)
+	}
+
+	var source io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to open batch file:", err)
+			return 1
+		}
+		defer file.Close()
+		source = file
+	}
+
+	lines, err := batchimport.Parse(source, format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to parse batch:", err)
+		return 1
+	}
+
+	temporalClient, err := newTemporalClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect to Temporal:", err)
+		return 1
+	}
+	defer temporalClient.Close()
+
+	ctx := context.Background()
+
+	workflowRun, input, err := batchimport.Start(ctx, temporalClient, lines, *concurrency)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to start batch import:", err)
+		return 1
+	}
+
+	fmt.Printf("Batch %s started: %d orders, workflow %s\n", input.BatchID, len(lines), workflowRun.GetID())
+	if !*wait {
+		return 0
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- workflowRun.Get(ctx, nil)
+	}()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "batch import failed:", err)
+				return 1
+			}
+
+			report, err := batchimport.Progress(ctx, temporalClient, input.BatchID)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "failed to get batch report:", err)
+				return 1
+			}
+
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(report)
+
+			if report.Failed > 0 || report.Invalid > 0 {
+				return 1
+			}
+			return 0
+		case <-ticker.C:
+			report, err := batchimport.Progress(ctx, temporalClient, input.BatchID)
+			if err != nil {
+				continue
+			}
+			fmt.Printf("Progress: %d/%d done (completed %d, failed %d, cancelled %d, invalid %d)\n",
+				report.Completed+report.Failed+report.Cancelled+report.Invalid, report.Total,
+				report.Completed, report.Failed, report.Cancelled, report.Invalid)
+		}
+	}
+}
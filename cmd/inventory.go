@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"orderflow/internal/adapter/repository"
+	"orderflow/internal/usecase/service"
+)
+
+// runInventory реализует `orderflow inventory reconcile [-apply]`: пересчитывает остатки
+// по журналу движений и печатает расхождения. С -apply счётчики исправляются по журналу.
+func runInventory(args []string) int {
+	if len(args) == 0 || args[0] != "reconcile" {
+		fmt.Fprintln(os.Stderr, "Usage: orderflow inventory reconcile [flags]")
+		return 2
+	}
+
+	flags := flag.NewFlagSet("inventory reconcile", flag.ContinueOnError)
+	apply := flags.Bool("apply", false, "overwrite stock counters with the values recomputed from the ledger")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: orderflow inventory reconcile [flags]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, postgresURL())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect to PostgreSQL:", err)
+		return 1
+	}
+	defer pool.Close()
+
+	inventoryService := service.NewInventoryService(repository.NewInventoryPG(pool), 0, nil)
+	report, err := inventoryService.Reconcile(ctx, *apply)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "inventory reconciliation failed:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	// Без -apply найденные расхождения остаются в базе — сигнализируем кодом выхода
+	if len(report.Drifts) > 0 && !report.Applied {
+		return 1
+	}
+	return 0
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -14,31 +15,89 @@ import (
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 
+	"orderflow/config"
+	"orderflow/internal/adapter/email"
+	"orderflow/internal/adapter/publisher"
+	"orderflow/internal/adapter/push"
 	"orderflow/internal/adapter/repository"
+	"orderflow/internal/adapter/sms"
+	"orderflow/internal/adapter/templates"
+	"orderflow/internal/adapter/webapi"
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/notification"
+	"orderflow/internal/domain/outbox"
+	"orderflow/internal/domain/payment"
+	"orderflow/internal/domain/shipping"
+	"orderflow/internal/domain/tax"
 	"orderflow/internal/domain/workflow"
 	"orderflow/internal/httpserver"
 	activ "orderflow/internal/usecase/activity"
+	"orderflow/internal/usecase/paymentevents"
 	"orderflow/internal/usecase/service"
+	"orderflow/internal/usecase/stockalerts"
+	"orderflow/internal/usecase/webhooks"
 	usecaseWorkflow "orderflow/internal/usecase/workflow"
 	"orderflow/pkg/logger"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "catalog" {
+		os.Exit(runCatalog(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "inventory" {
+		os.Exit(runInventory(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "smtp-capture" {
+		os.Exit(runSMTPCapture(os.Args[2:]))
+	}
+
 	appEnv := getEnv("APP_ENV", "development")
-	postgresHost := getEnv("POSTGRES_HOST", "localhost")
-	postgresPort := getEnv("POSTGRES_PORT", "5432")
-	postgresDB := getEnv("POSTGRES_DB", "orderflow")
-	postgresUser := getEnv("POSTGRES_USER", "postgres")
-	postgresPassword := getEnv("POSTGRES_PASSWORD", "password")
+	reservationTTL := getEnvDuration("RESERVATION_TTL", inventory.DefaultReservationTTL)
+	reservationCleanupInterval := getEnvDuration("RESERVATION_CLEANUP_INTERVAL", time.Minute)
+	reservationCleanupBatchSize := getEnvInt("RESERVATION_CLEANUP_BATCH_SIZE", inventory.DefaultCleanupBatchSize)
 
 	logger.Init(appEnv)
 
 	logger.Info("Starting OrderFlow application...")
 
-	postgresURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		postgresUser, postgresPassword, postgresHost, postgresPort, postgresDB)
+	cfg, err := loadConfig(getEnv("CONFIG_PATH", ""))
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	if err := usecaseWorkflow.ConfigureActivities(cfg.Activities); err != nil {
+		logger.Error("Invalid activity configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if err := usecaseWorkflow.ConfigureOrderTimeouts(cfg.Order); err != nil {
+		logger.Error("Invalid order timeouts configuration", "error", err)
+		os.Exit(1)
+	}
 
-	pool, err := pgxpool.New(context.Background(), postgresURL)
+	allocator, err := inventory.NewAllocationStrategy(getEnv("ALLOCATION_STRATEGY", inventory.DefaultAllocationStrategy))
+	if err != nil {
+		logger.Error("Invalid allocation strategy", "error", err)
+		os.Exit(1)
+	}
+
+	taxCalculator, err := tax.NewTableCalculator(cfg.Tax.DefaultJurisdiction, cfg.Tax.Rates)
+	if err != nil {
+		logger.Error("Invalid tax configuration", "error", err)
+		os.Exit(1)
+	}
+
+	shippingProvider, err := shipping.NewRuleProvider(cfg.Shipping.Zones, cfg.Shipping.Methods)
+	if err != nil {
+		logger.Error("Invalid shipping configuration", "error", err)
+		os.Exit(1)
+	}
+
+	pool, err := pgxpool.New(context.Background(), postgresURL())
 	if err != nil {
 		logger.Error("Failed to connect to PostgreSQL", "error", err)
 		os.Exit(1)
@@ -49,17 +108,61 @@ func main() {
 	inventoryRepo := repository.NewInventoryPG(pool)
 	paymentRepo := repository.NewPaymentPG(pool)
 	notificationRepo := repository.NewNotificationPG(pool)
+	subscriptionRepo := repository.NewSubscriptionPG(pool)
+	orderEventRepo := repository.NewOrderEventPG(pool)
+	outboxRepo := repository.NewOutboxPG(pool)
+	webhookRepo := repository.NewWebhookPG(pool)
+	customerRepo := repository.NewCustomerPG(pool)
+	promotionRepo := repository.NewPromotionPG(pool)
 
-	orderService := service.NewOrderService(orderRepo)
-	inventoryService := service.NewInventoryService(inventoryRepo)
+	promotionService := service.NewPromotionService(promotionRepo)
+	inventoryService := service.NewInventoryService(inventoryRepo, reservationTTL, allocator)
+	shippingService := service.NewShippingService(inventoryService, shippingProvider)
+	orderService := service.NewOrderService(orderRepo, promotionService, shippingService)
 	paymentService := service.NewPaymentService(paymentRepo)
-	notificationService := service.NewNotificationService(notificationRepo)
+	notificationSenders, err := newNotificationSenders(cfg)
+	if err != nil {
+		logger.Error("Invalid notification configuration", "error", err)
+		os.Exit(1)
+	}
+
+	notificationTemplates, err := templates.New(cfg.Notifications.TemplatesDir)
+	if err != nil {
+		logger.Error("Invalid notification templates", "error", err)
+		os.Exit(1)
+	}
+
+	customerService := service.NewCustomerService(customerRepo)
+	notificationRetryPolicy := notification.RetryPolicy{
+		MaxAttempts:        cfg.Notifications.Retry.MaxAttempts,
+		InitialInterval:    cfg.Notifications.Retry.InitialInterval,
+		MaxInterval:        cfg.Notifications.Retry.MaxInterval,
+		BackoffCoefficient: cfg.Notifications.Retry.BackoffCoefficient,
+	}
+	notificationDigestPolicy, err := newDigestPolicy(cfg.Notifications.Digest)
+	if err != nil {
+		logger.Error("Invalid notification digest configuration", "error", err)
+		os.Exit(1)
+	}
+	notificationService := service.NewNotificationService(notificationRepo, customerService, notificationTemplates, notificationRetryPolicy, notificationDigestPolicy, notificationSenders...)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo)
+	orderEventService := service.NewOrderEventService(orderEventRepo)
+	webhookService := service.NewWebhookService(webhookRepo, webapi.NewWebhookSender(cfg.Webhooks.SendTimeout), cfg.Webhooks.MaxConsecutiveFailures)
 
 	createOrderActivity := activ.NewCreateOrderActivity(orderService)
 	checkInventoryActivity := activ.NewCheckInventoryActivity(inventoryService, orderService)
-	processPaymentActivity := activ.NewProcessPaymentActivity(paymentService, orderService, inventoryService)
-	sendNotificationActivity := activ.NewSendNotificationActivity(notificationService, orderService)
+	calculateTaxActivity := activ.NewCalculateTaxActivity(orderService, inventoryService, taxCalculator)
+	processPaymentActivity := activ.NewProcessPaymentActivity(paymentService, orderService, inventoryService, promotionService)
+	sendNotificationActivity := activ.NewSendNotificationActivity(notificationService, orderService, paymentService)
 	cancelOrderActivity := activ.NewCancelOrderActivity(orderService, paymentService, inventoryService)
+	failOrderActivity := activ.NewFailOrderActivity(orderService, inventoryService)
+	completeOrderActivity := activ.NewCompleteOrderActivity(orderService)
+	cleanupReservationsActivity := activ.NewCleanupReservationsActivity(inventoryService, orderService)
+	saveSubscriptionActivity := activ.NewSaveSubscriptionActivity(subscriptionService)
+	recordStepEventsActivity := activ.NewRecordStepEventsActivity(orderEventService)
+	deliverWebhookActivity := activ.NewDeliverWebhookActivity(webhookService)
+	finalizeWebhookDeliveryActivity := activ.NewFinalizeWebhookDeliveryActivity(webhookService)
+	retryNotificationsActivity := activ.NewRetryNotificationsActivity(notificationService)
 
 	temporalClient, err := newTemporalClient()
 	if err != nil {
@@ -68,7 +171,13 @@ func main() {
 }
 defer temporalClient.Close()
 
-w := worker.New(temporalClient, workflow.OrderProcessingTaskQueue, worker.Options{})
+workerOptions := worker.Options{}
+if appEnv == "development" && len(cfg.Dev.ActivityLatency) > 0 {
+	logger.Info("Activity latency injection enabled", "latency", cfg.Dev.ActivityLatency)
+	workerOptions.Interceptors = append(workerOptions.Interceptors, activ.NewLatencyInjector(cfg.Dev.ActivityLatency))
+}
+
+w := worker.New(temporalClient, workflow.OrderProcessingTaskQueue, workerOptions)
 
 w.RegisterActivityWithOptions(createOrderActivity.Execute, activity.RegisterOptions{
     Name: "CreateOrderActivity",
@@ -76,6 +185,9 @@ w.RegisterActivityWithOptions(createOrderActivity.Execute, activity.RegisterOpti
 w.RegisterActivityWithOptions(checkInventoryActivity.Execute, activity.RegisterOptions{
     Name: "CheckInventoryActivity",
 })
+w.RegisterActivityWithOptions(calculateTaxActivity.Execute, activity.RegisterOptions{
+    Name: "CalculateTaxActivity",
+})
 w.RegisterActivityWithOptions(processPaymentActivity.Execute, activity.RegisterOptions{
     Name: "ProcessPaymentActivity",
 })
@@ -85,10 +197,84 @@ w.RegisterActivityWithOptions(sendNotificationActivity.Execute, activity.Registe
 w.RegisterActivityWithOptions(cancelOrderActivity.Execute, activity.RegisterOptions{
     Name: "CancelOrderActivity",
 })
+w.RegisterActivityWithOptions(failOrderActivity.Execute, activity.RegisterOptions{
+    Name: "FailOrderActivity",
+})
+w.RegisterActivityWithOptions(completeOrderActivity.Execute, activity.RegisterOptions{
+    Name: "CompleteOrderActivity",
+})
+w.RegisterActivityWithOptions(cleanupReservationsActivity.Execute, activity.RegisterOptions{
+    Name: "CleanupReservationsActivity",
+})
+w.RegisterActivityWithOptions(saveSubscriptionActivity.Execute, activity.RegisterOptions{
+    Name: "SaveSubscriptionActivity",
+})
+w.RegisterActivityWithOptions(recordStepEventsActivity.Execute, activity.RegisterOptions{
+    Name: "RecordStepEventsActivity",
+})
+w.RegisterActivityWithOptions(deliverWebhookActivity.Execute, activity.RegisterOptions{
+    Name: "DeliverWebhookActivity",
+})
+w.RegisterActivityWithOptions(finalizeWebhookDeliveryActivity.Execute, activity.RegisterOptions{
+    Name: "FinalizeWebhookDeliveryActivity",
+})
+w.RegisterActivityWithOptions(retryNotificationsActivity.Execute, activity.RegisterOptions{
+    Name: "RetryNotificationsActivity",
+})
 
 w.RegisterWorkflow(usecaseWorkflow.OrderProcessingWorkflow)
+w.RegisterWorkflow(usecaseWorkflow.ReservationCleanupWorkflow)
+w.RegisterWorkflow(usecaseWorkflow.CustomerSubscriptionWorkflow)
+w.RegisterWorkflow(usecaseWorkflow.BatchOrderImportWorkflow)
+w.RegisterWorkflow(usecaseWorkflow.WebhookDeliveryWorkflow)
+w.RegisterWorkflow(usecaseWorkflow.NotificationRetryWorkflow)
+
+if err := ensureReservationCleanupSchedule(context.Background(), temporalClient, reservationCleanupInterval, reservationCleanupBatchSize); err != nil {
+	logger.Error("Failed to ensure reservation cleanup schedule", "error", err)
+}
+
+notificationRetryInterval := cfg.Notifications.Retry.Interval
+if notificationRetryInterval <= 0 {
+	notificationRetryInterval = time.Minute
+}
+if err := ensureNotificationRetrySchedule(context.Background(), temporalClient, notificationRetryInterval, cfg.Notifications.Retry.BatchSize); err != nil {
+	logger.Error("Failed to ensure notification retry schedule", "error", err)
+}
+
+backgroundCtx, stopBackground := context.WithCancel(context.Background())
+defer stopBackground()
+go orderEventService.Run(backgroundCtx)
+
+// Вебхуки мерчантов получают события всегда, уведомления stock_low — если заданы
+// получатели, внешний публикатор — если задан в конфиге
+eventPublishers := []outbox.EventPublisher{webhooks.NewDispatcher(temporalClient, webhookService)}
+if len(cfg.Notifications.StockAlerts.Recipients) > 0 {
+	eventPublishers = append(eventPublishers, stockalerts.NewNotifier(notificationService, cfg.Notifications.StockAlerts.Recipients))
+}
+if cfg.Outbox.Publisher != "" {
+	eventPublisher, err := newEventPublisher(cfg.Outbox)
+	if err != nil {
+		logger.Error("Failed to create outbox event publisher", "error", err)
+		os.Exit(1)
+	}
+	eventPublishers = append(eventPublishers, eventPublisher)
+}
+relay := service.NewOutboxRelay(outboxRepo, publisher.NewMulti(eventPublishers...), cfg.Outbox.BatchSize, cfg.Outbox.PollInterval)
+go relay.Run(backgroundCtx)
+
+paymentEvents := paymentevents.NewProcessor(temporalClient, paymentService, orderService, newPaymentWebhookParsers(cfg.Payments)...)
 
-httpServer := httpserver.NewServer(8080, temporalClient)
+httpServer := httpserver.NewServer(8080, httpserver.Deps{
+	TemporalClient: temporalClient,
+	OrderEvents:    orderEventService,
+	Webhooks:       webhookService,
+	PaymentEvents:  paymentEvents,
+	Customers:      customerService,
+	Notifications:  notificationService,
+	Catalog:        inventoryService,
+	Promotions:     promotionService,
+	Shipping:       shippingService,
+})
 	go func() {
 		logger.Info("Starting Temporal Worker...")
 		if err := w.Run(worker.InterruptCh()); err != nil {
@@ -122,8 +308,9 @@ httpServer := httpserver.NewServer(8080, temporalClient)
 
 func startOrderWorkflow(temporalClient client.Client, input *workflow.OrderProcessingInput) (client.WorkflowRun, error) {
 	workflowOptions := client.StartWorkflowOptions{
-		ID:        "order-processing-" + input.CustomerID,
-		TaskQueue: workflow.OrderProcessingTaskQueue,
+		ID:                       "order-processing-" + input.CustomerID,
+		TaskQueue:                workflow.OrderProcessingTaskQueue,
+		WorkflowExecutionTimeout: usecaseWorkflow.OrderExecutionTimeout(),
 	}
 
 	return temporalClient.ExecuteWorkflow(context.Background(), workflowOptions, workflow.OrderProcessingWorkflow, input)
@@ -162,9 +349,158 @@ func newTemporalClient() (client.Client, error) {
 	return nil, err
 }
 
+func newEventPublisher(cfg config.OutboxConfig) (outbox.EventPublisher, error) {
+	switch cfg.Publisher {
+	case "webhook":
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("outbox.webhook_url is required for webhook publisher")
+		}
+		return publisher.NewWebhook(cfg.WebhookURL, cfg.WebhookTimeout), nil
+	case "file":
+		return publisher.NewFile(cfg.FilePath)
+	case "stdout":
+		return publisher.NewFile("-")
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher: %s", cfg.Publisher)
+	}
+}
+
+func newPaymentWebhookParsers(cfg config.PaymentsConfig) []payment.WebhookParser {
+	parsers := make([]payment.WebhookParser, 0, len(cfg.Providers))
+	for name, provider := range cfg.Providers {
+		if provider.WebhookSecret == "" {
+			logger.Warn("Payment provider has no webhook secret, callbacks are disabled", "provider", name)
+			continue
+		}
+		parsers = append(parsers, webapi.NewHMACPaymentProvider(name, provider.WebhookSecret, provider.SignatureTolerance))
+	}
+	return parsers
+}
+
+// newNotificationSenders создаёт отправщики для настроенных каналов; уведомления
+// в остальных каналах NotificationService помечает dead как неподдерживаемые.
+func newNotificationSenders(cfg config.Config) ([]notification.Sender, error) {
+	var senders []notification.Sender
+
+	if cfg.Email.SMTP.Host != "" {
+		sender, err := email.NewSMTPSender(email.SMTPConfig{
+			Host:               cfg.Email.SMTP.Host,
+			Port:               cfg.Email.SMTP.Port,
+			Username:           cfg.Email.SMTP.Username,
+			Password:           cfg.Email.SMTP.Password,
+			From:               cfg.Email.SMTP.From,
+			FromName:           cfg.Email.SMTP.FromName,
+			TLS:                email.TLSMode(cfg.Email.SMTP.TLS),
+			InsecureSkipVerify: cfg.Email.SMTP.InsecureSkipVerify,
+			Timeout:            cfg.Email.SMTP.Timeout,
+		}, email.NewStaticAddressBook(cfg.Email.Addresses, cfg.Email.DefaultDomain))
+		if err != nil {
+			return nil, fmt.Errorf("email: %w", err)
+		}
+		senders = append(senders, sender)
+	}
+
+	if cfg.SMS.URL != "" {
+		sender, err := sms.NewHTTPSender(sms.Config{
+			URL:         cfg.SMS.URL,
+			Token:       cfg.SMS.Token,
+			Username:    cfg.SMS.Username,
+			Password:    cfg.SMS.Password,
+			From:        cfg.SMS.From,
+			MaxSegments: cfg.SMS.MaxSegments,
+			RateLimit:   cfg.SMS.RateLimit,
+			Burst:       cfg.SMS.Burst,
+			Timeout:     cfg.SMS.Timeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("sms: %w", err)
+		}
+		senders = append(senders, sender)
+	}
+
+	pushConfig := push.Config{
+		DefaultProvider: push.Provider(cfg.Push.DefaultProvider),
+		Timeout:         cfg.Push.Timeout,
+	}
+	if fcm := cfg.Push.FCM; fcm.CredentialsFile != "" || fcm.AccessToken != "" {
+		pushConfig.FCM = &push.FCMConfig{
+			CredentialsFile: fcm.CredentialsFile,
+			AccessToken:     fcm.AccessToken,
+			ProjectID:       fcm.ProjectID,
+			Endpoint:        fcm.Endpoint,
+			RateLimit:       fcm.RateLimit,
+			Burst:           fcm.Burst,
+		}
+	}
+	if apns := cfg.Push.APNs; apns.KeyFile != "" {
+		pushConfig.APNs = &push.APNsConfig{
+			KeyFile:            apns.KeyFile,
+			KeyID:              apns.KeyID,
+			TeamID:             apns.TeamID,
+			Topic:              apns.Topic,
+			Sandbox:            apns.Sandbox,
+			Endpoint:           apns.Endpoint,
+			InsecureSkipVerify: apns.InsecureSkipVerify,
+			RateLimit:          apns.RateLimit,
+			Burst:              apns.Burst,
+		}
+	}
+	if pushConfig.FCM != nil || pushConfig.APNs != nil {
+		sender, err := push.NewSender(pushConfig)
+		if err != nil {
+			return nil, fmt.Errorf("push: %w", err)
+		}
+		senders = append(senders, sender)
+	}
+
+	return senders, nil
+}
+
+func newDigestPolicy(cfg config.NotificationDigestConfig) (notification.DigestPolicy, error) {
+	policy := notification.DigestPolicy{Enabled: cfg.Enabled, Window: cfg.Window}
+	for _, name := range cfg.Types {
+		notificationType := notification.Type(name)
+		if !notificationType.IsValid() {
+			return policy, fmt.Errorf("unknown notification type %q", name)
+		}
+		policy.Types = append(policy.Types, notificationType)
+	}
+	return policy, nil
+}
+
+// postgresURL собирает строку подключения из переменных окружения POSTGRES_*.
+func postgresURL() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		getEnv("POSTGRES_USER", "postgres"), getEnv("POSTGRES_PASSWORD", "password"),
+		getEnv("POSTGRES_HOST", "localhost"), getEnv("POSTGRES_PORT", "5432"), getEnv("POSTGRES_DB", "orderflow"))
+}
+
+func loadConfig(path string) (config.Config, error) {
+	if path == "" {
+		return config.Config{}, nil
+	}
+	return config.New(path)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+
+	"orderflow/internal/domain/workflow"
+	"orderflow/pkg/logger"
+)
+
+// ensureSchedule создаёт расписание или, если оно уже есть, обновляет его spec и action,
+// чтобы изменения конфигурации применялись при перезапуске.
+func ensureSchedule(ctx context.Context, temporalClient client.Client, opts client.ScheduleOptions) error {
+	scheduleClient := temporalClient.ScheduleClient()
+
+	_, err := scheduleClient.Create(ctx, opts)
+	if err == nil {
+		logger.Info("Schedule created", "schedule_id", opts.ID)
+		return nil
+	}
+	if !errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		return err
+	}
+
+	handle := scheduleClient.GetHandle(ctx, opts.ID)
+	err = handle.Update(ctx, client.ScheduleUpdateOptions{
+		DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+			schedule := input.Description.Schedule
+			schedule.Spec = &opts.Spec
+			schedule.Action = opts.Action
+			return &client.ScheduleUpdate{Schedule: &schedule}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Schedule updated", "schedule_id", opts.ID)
+	return nil
+}
+
+func ensureReservationCleanupSchedule(ctx context.Context, temporalClient client.Client, interval time.Duration, batchSize int) error {
+	return ensureSchedule(ctx, temporalClient, client.ScheduleOptions{
+		ID: workflow.ReservationCleanupScheduleID,
+		Spec: client.ScheduleSpec{
+			Intervals: []client.ScheduleIntervalSpec{{Every: interval}},
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        workflow.ReservationCleanupScheduleID,
+			Workflow:  workflow.ReservationCleanupWorkflow,
+			TaskQueue: workflow.OrderProcessingTaskQueue,
+			Args: []interface{}{&workflow.ReservationCleanupInput{
+				BatchSize: batchSize,
+			}},
+		},
+	})
+}
+
+func ensureNotificationRetrySchedule(ctx context.Context, temporalClient client.Client, interval time.Duration, batchSize int) error {
+	return ensureSchedule(ctx, temporalClient, client.ScheduleOptions{
+		ID: workflow.NotificationRetryScheduleID,
+		Spec: client.ScheduleSpec{
+			Intervals: []client.ScheduleIntervalSpec{{Every: interval}},
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        workflow.NotificationRetryScheduleID,
+			Workflow:  workflow.NotificationRetryWorkflow,
+			TaskQueue: workflow.OrderProcessingTaskQueue,
+			Args: []interface{}{&workflow.NotificationRetryInput{
+				BatchSize: batchSize,
+			}},
+		},
+	})
+}
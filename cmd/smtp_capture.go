@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"mime"
+	"net/mail"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"orderflow/pkg/smtpcapture"
+)
+
+// runSMTPCapture реализует `orderflow smtp-capture [flags]`: локальный SMTP-сервер,
+// который принимает письма уведомлений и печатает их заголовки (и сохраняет .eml, если задан -dir).
+func runSMTPCapture(args []string) int {
+	flags := flag.NewFlagSet("smtp-capture", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:2525", "listen address")
+	dir := flags.String("dir", "", "directory for captured .eml files")
+	startTLS := flags.Bool("starttls", false, "offer STARTTLS with a self-signed certificate")
+	implicitTLS := flags.Bool("tls", false, "accept implicit TLS only, with a self-signed certificate")
+	username := flags.String("username", "", "require AUTH PLAIN with this username")
+	password := flags.String("password", "", "password for -username")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: orderflow smtp-capture [flags]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *startTLS && *implicitTLS {
+		fmt.Fprintln(os.Stderr, "-starttls and -tls are mutually exclusive")
+		return 2
+	}
+
+	opts := []smtpcapture.Option{
+		smtpcapture.Addr(*addr),
+		smtpcapture.OnMessage(printCapturedMessage),
+	}
+	if *dir != "" {
+		opts = append(opts, smtpcapture.Dir(*dir))
+	}
+	if *username != "" {
+		opts = append(opts, smtpcapture.Auth(*username, *password))
+	}
+	if *startTLS || *implicitTLS {
+		tlsConfig, err := smtpcapture.SelfSignedTLSConfig("localhost", "127.0.0.1", "::1")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to create certificate:", err)
+			return 1
+		}
+		if *implicitTLS {
+			opts = append(opts, smtpcapture.ImplicitTLS(tlsConfig))
+		} else {
+			opts = append(opts, smtpcapture.StartTLS(tlsConfig))
+		}
+	}
+
+	server := smtpcapture.New(opts...)
+	if err := server.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to start SMTP capture server:", err)
+		return 1
+	}
+	fmt.Printf("SMTP capture server listening on %s\n", server.Addr())
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	if err := server.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to stop SMTP capture server:", err)
+		return 1
+	}
+	fmt.Printf("Captured %d messages\n", len(server.Messages()))
+	return 0
+}
+
+func printCapturedMessage(message smtpcapture.Message) {
+	subject := ""
+	if parsed, err := mail.ReadMessage(bytes.NewReader(message.Data)); err == nil {
+		subject, err = new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+		if err != nil {
+			subject = parsed.Header.Get("Subject")
+		}
+	}
+
+	fmt.Printf("%s  %s -> %s  %q (%d bytes, tls=%t)\n",
+		message.ReceivedAt.Format("15:04:05"), message.From, strings.Join(message.To, ", "),
+		subject, len(message.Data), message.TLS)
+	if message.Path != "" {
+		fmt.Printf("  saved to %s\n", message.Path)
+	}
+}
@@ -2,11 +2,187 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
+
+	"orderflow/internal/domain/shipping"
+	"orderflow/internal/domain/tax"
+	"orderflow/internal/domain/workflow"
 )
 
-type Config struct{}
+type Config struct {
+	// Activities переопределяет ActivityOptions по имени activity, например ProcessPaymentActivity
+	Activities map[string]workflow.ActivityConfig `mapstructure:"activities"`
+	// Email — отправка email-уведомлений; без smtp.host канал email не поддерживается
+	Email EmailConfig `mapstructure:"email"`
+	// Notifications — шаблоны и очередь повторов уведомлений
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	// Order — дедлайн OrderProcessingWorkflow и SLA шагов
+	Order workflow.OrderTimeouts `mapstructure:"order"`
+	// Outbox — публикация доменных событий из таблицы outbox
+	Outbox OutboxConfig `mapstructure:"outbox"`
+	// Payments — callback-и платёжных провайдеров
+	Payments PaymentsConfig `mapstructure:"payments"`
+	// Push — push-уведомления через FCM и APNs; без провайдеров канал push не поддерживается
+	Push PushConfig `mapstructure:"push"`
+	// Shipping — зоны и способы доставки; без способов доставка бесплатна
+	Shipping ShippingConfig `mapstructure:"shipping"`
+	// SMS — HTTP-провайдер SMS; без url канал sms не поддерживается
+	SMS SMSConfig `mapstructure:"sms"`
+	// Tax — таблица налоговых ставок по юрисдикции и налоговому классу товара
+	Tax TaxConfig `mapstructure:"tax"`
+	// Webhooks — исходящие вебхуки мерчантов
+	Webhooks WebhooksConfig `mapstructure:"webhooks"`
+	Dev      DevConfig      `mapstructure:"dev"`
+}
+
+type EmailConfig struct {
+	SMTP SMTPConfig `mapstructure:"smtp"`
+	// Addresses — email клиентов по customer_id
+	Addresses map[string]string `mapstructure:"addresses"`
+	// DefaultDomain — для клиентов без адреса письмо уходит на <customer_id>@<DefaultDomain>
+	DefaultDomain string `mapstructure:"default_domain"`
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	FromName string `mapstructure:"from_name"`
+	// TLS — none, starttls (по умолчанию) или tls
+	TLS                string        `mapstructure:"tls"`
+	InsecureSkipVerify bool          `mapstructure:"insecure_skip_verify"`
+	Timeout            time.Duration `mapstructure:"timeout"`
+}
+
+type NotificationsConfig struct {
+	// TemplatesDir — каталог с шаблонами, заменяющими встроенные файлы с тем же путём
+	TemplatesDir string                   `mapstructure:"templates_dir"`
+	Retry        NotificationRetryConfig  `mapstructure:"retry"`
+	Digest       NotificationDigestConfig `mapstructure:"digest"`
+	StockAlerts  StockAlertsConfig        `mapstructure:"stock_alerts"`
+}
+
+type NotificationRetryConfig struct {
+	// MaxAttempts — после стольких попыток уведомление получает статус dead
+	MaxAttempts        int           `mapstructure:"max_attempts"`
+	InitialInterval    time.Duration `mapstructure:"initial_interval"`
+	MaxInterval        time.Duration `mapstructure:"max_interval"`
+	BackoffCoefficient float64       `mapstructure:"backoff_coefficient"`
+	// Interval — период запуска NotificationRetryWorkflow (Temporal Schedule)
+	Interval  time.Duration `mapstructure:"interval"`
+	BatchSize int           `mapstructure:"batch_size"`
+}
+
+// NotificationDigestConfig — отправка сводками: уведомления types копятся window
+// с первого из них и уходят одним сообщением. Пустой types — order_confirmed,
+// order_cancelled и order_timeout.
+type NotificationDigestConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Window  time.Duration `mapstructure:"window"`
+	Types   []string      `mapstructure:"types"`
+}
+
+// StockAlertsConfig — кому отправлять уведомления stock_low. Recipients — ID клиентов
+// из справочника (мерчанты, закупщики); пустой список — уведомления не отправляются,
+// отчёт /api/admin/products/low-stock и событие product.stock_low доступны всегда.
+type StockAlertsConfig struct {
+	Recipients []string `mapstructure:"recipients"`
+}
+
+type SMSConfig struct {
+	URL string `mapstructure:"url"`
+	// Token — Bearer-токен; вместо него можно задать username и password для Basic
+	Token    string `mapstructure:"token"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	// MaxSegments — более длинные сообщения обрезаются, по умолчанию 3 части
+	MaxSegments int `mapstructure:"max_segments"`
+	// RateLimit — запросов в секунду, 0 — без ограничения
+	RateLimit float64       `mapstructure:"rate_limit"`
+	Burst     int           `mapstructure:"burst"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+type PushConfig struct {
+	// DefaultProvider — fcm или apns, для токенов устройств без префикса провайдера
+	DefaultProvider string        `mapstructure:"default_provider"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+	FCM             FCMConfig     `mapstructure:"fcm"`
+	APNs            APNsConfig    `mapstructure:"apns"`
+}
+
+type FCMConfig struct {
+	// CredentialsFile — JSON-ключ сервисного аккаунта Google; access_token — готовый OAuth2-токен
+	CredentialsFile string  `mapstructure:"credentials_file"`
+	AccessToken     string  `mapstructure:"access_token"`
+	ProjectID       string  `mapstructure:"project_id"`
+	Endpoint        string  `mapstructure:"endpoint"`
+	RateLimit       float64 `mapstructure:"rate_limit"`
+	Burst           int     `mapstructure:"burst"`
+}
+
+type APNsConfig struct {
+	// KeyFile — ключ .p8 для авторизации по токену, Topic — bundle id приложения
+	KeyFile            string  `mapstructure:"key_file"`
+	KeyID              string  `mapstructure:"key_id"`
+	TeamID             string  `mapstructure:"team_id"`
+	Topic              string  `mapstructure:"topic"`
+	Sandbox            bool    `mapstructure:"sandbox"`
+	Endpoint           string  `mapstructure:"endpoint"`
+	InsecureSkipVerify bool    `mapstructure:"insecure_skip_verify"`
+	RateLimit          float64 `mapstructure:"rate_limit"`
+	Burst              int     `mapstructure:"burst"`
+}
+
+type OutboxConfig struct {
+	// Publisher — webhook, file или stdout; пустое значение — только вебхуки мерчантов
+	Publisher      string        `mapstructure:"publisher"`
+	WebhookURL     string        `mapstructure:"webhook_url"`
+	WebhookTimeout time.Duration `mapstructure:"webhook_timeout"`
+	FilePath       string        `mapstructure:"file_path"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	PollInterval   time.Duration `mapstructure:"poll_interval"`
+}
+
+type PaymentsConfig struct {
+	// Providers — провайдеры, принимаемые на /api/payments/webhooks/{provider}, по имени
+	Providers map[string]PaymentProviderConfig `mapstructure:"providers"`
+}
+
+type PaymentProviderConfig struct {
+	// WebhookSecret — общий секрет для проверки подписи X-Payment-Signature
+	WebhookSecret      string        `mapstructure:"webhook_secret"`
+	SignatureTolerance time.Duration `mapstructure:"signature_tolerance"`
+}
+
+// ShippingConfig — правила для shipping.RuleProvider.
+type ShippingConfig struct {
+	Zones   []shipping.Zone       `mapstructure:"zones"`
+	Methods []shipping.MethodRule `mapstructure:"methods"`
+}
+
+// TaxConfig — ставки для tax.TableCalculator. DefaultJurisdiction применяется к заказам
+// без tax_jurisdiction; если и она пуста, такие заказы налогом не облагаются.
+type TaxConfig struct {
+	DefaultJurisdiction string     `mapstructure:"default_jurisdiction"`
+	Rates               []tax.Rate `mapstructure:"rates"`
+}
+
+type WebhooksConfig struct {
+	// MaxConsecutiveFailures — после стольких неуспешных доставок подряд подписка отключается
+	MaxConsecutiveFailures int           `mapstructure:"max_consecutive_failures"`
+	SendTimeout            time.Duration `mapstructure:"send_timeout"`
+}
+
+type DevConfig struct {
+	// ActivityLatency — искусственная задержка перед activity, работает только при APP_ENV=development
+	ActivityLatency map[string]time.Duration `mapstructure:"activity_latency"`
+}
 
 func New(path string) (Config, error) {
 	viper.SetConfigFile(path)
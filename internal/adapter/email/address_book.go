@@ -0,0 +1,41 @@
+package email
+
+import (
+	"context"
+	"strings"
+
+	"orderflow/internal/domain/notification"
+)
+
+// StaticAddressBook сопоставляет customer_id с email по таблице из конфигурации.
+// Если адрес не задан и указан DefaultDomain, используется <customer_id>@<DefaultDomain>,
+// что удобно для тестовых стендов с SMTP-ловушкой.
+type StaticAddressBook struct {
+	addresses     map[string]string
+	defaultDomain string
+}
+
+func NewStaticAddressBook(addresses map[string]string, defaultDomain string) *StaticAddressBook {
+	normalized := make(map[string]string, len(addresses))
+	for customerID, address := range addresses {
+		normalized[strings.ToLower(customerID)] = address
+	}
+	return &StaticAddressBook{
+		addresses:     normalized,
+		defaultDomain: strings.TrimPrefix(defaultDomain, "@"),
+	}
+}
+
+func (b *StaticAddressBook) Address(ctx context.Context, customerID string, channel notification.Channel) (string, error) {
+	if channel != notification.ChannelEmail {
+		return "", notification.NewRecipientNotFoundError(customerID, channel)
+	}
+
+	if address, ok := b.addresses[strings.ToLower(customerID)]; ok && address != "" {
+		return address, nil
+	}
+	if b.defaultDomain != "" && customerID != "" {
+		return customerID + "@" + b.defaultDomain, nil
+	}
+	return "", notification.NewRecipientNotFoundError(customerID, channel)
+}
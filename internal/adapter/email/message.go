@@ -0,0 +1,135 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"orderflow/internal/domain/notification"
+)
+
+const HeaderNotificationID = "X-Orderflow-Notification-Id"
+
+// BuildMessage собирает письмо RFC 5322: multipart/alternative с текстовой и HTML-частью.
+// Если у уведомления нет HTML из шаблона, HTML-часть получается из текста с экранированием;
+// абзацы разделяются пустой строкой.
+func BuildMessage(from, fromName, to string, n *notification.Notification) ([]byte, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", from, err)
+	}
+	if fromName != "" {
+		fromAddr.Name = fromName
+	}
+	toAddr, err := mail.ParseAddress(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient address %q: %w", to, err)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	if err := writeQuotedPrintablePart(parts, "text/plain; charset=utf-8", n.Message); err != nil {
+		return nil, err
+	}
+	htmlBody := n.HTML
+	if htmlBody == "" {
+		htmlBody = renderHTML(n.Subject, n.Message)
+	}
+	if err := writeQuotedPrintablePart(parts, "text/html; charset=utf-8", htmlBody); err != nil {
+		return nil, err
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	writeHeader(&message, "From", fromAddr.String())
+	writeHeader(&message, "To", toAddr.String())
+	writeHeader(&message, "Subject", mime.QEncoding.Encode("utf-8", n.Subject))
+	writeHeader(&message, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&message, "Message-Id", messageID(n.ID, fromAddr.Address))
+	if n.ID != "" {
+		writeHeader(&message, HeaderNotificationID, n.ID)
+	}
+	writeHeader(&message, "MIME-Version", "1.0")
+	writeHeader(&message, "Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintablePart(parts *multipart.Writer, contentType, content string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, err := parts.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func renderHTML(subject, text string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>")
+	b.WriteString(html.EscapeString(subject))
+	b.WriteString("</title></head>\n<body>\n")
+
+	normalized := strings.ReplaceAll(text, "\r\n", "\n")
+	for _, paragraph := range strings.Split(normalized, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.Join(lines, "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// messageID строится из ID уведомления, чтобы повторная отправка сохраняла Message-Id
+// и почтовые клиенты могли распознать дубликат.
+func messageID(notificationID, from string) string {
+	domain := "orderflow.local"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+
+	local := notificationID
+	if local == "" {
+		random := make([]byte, 16)
+		_, _ = rand.Read(random)
+		local = hex.EncodeToString(random)
+	}
+	return "<" + local + "@" + domain + ">"
+}
@@ -0,0 +1,208 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"orderflow/internal/domain/notification"
+)
+
+type TLSMode string
+
+const (
+	// TLSNone — соединение без шифрования, допустимо только для локальных серверов
+	TLSNone TLSMode = "none"
+	// TLSStartTLS — обычное соединение с обязательным переходом на TLS командой STARTTLS
+	TLSStartTLS TLSMode = "starttls"
+	// TLSImplicit — TLS с первого байта (SMTPS, обычно порт 465)
+	TLSImplicit TLSMode = "tls"
+)
+
+const DefaultSMTPTimeout = 30 * time.Second
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	FromName string
+	TLS      TLSMode
+	// InsecureSkipVerify отключает проверку сертификата сервера (самоподписанные сертификаты в тестах)
+	InsecureSkipVerify bool
+	// Timeout ограничивает весь SMTP-диалог одного письма
+	Timeout time.Duration
+}
+
+// SMTPSender отправляет email-уведомления через SMTP. Адрес получателя — Recipient
+// уведомления, а если он не задан, берётся из AddressBook по customer_id.
+// Ответы сервера 5xx считаются окончательным отказом, 4xx и сетевые ошибки — временными.
+type SMTPSender struct {
+	config    SMTPConfig
+	addresses notification.AddressBook
+	// envelopeFrom — голый адрес из From для MAIL FROM: config.From может быть в виде "Имя <адрес>"
+	envelopeFrom string
+}
+
+func NewSMTPSender(config SMTPConfig, addresses notification.AddressBook) (*SMTPSender, error) {
+	if config.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	if config.From == "" {
+		return nil, errors.New("smtp from address is required")
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from address %q: %w", config.From, err)
+	}
+	if addresses == nil {
+		return nil, errors.New("smtp sender requires an address book")
+	}
+
+	switch config.TLS {
+	case "":
+		config.TLS = TLSStartTLS
+	case TLSNone, TLSStartTLS, TLSImplicit:
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode: %s", config.TLS)
+	}
+
+	if config.Port == 0 {
+		switch config.TLS {
+		case TLSImplicit:
+			config.Port = 465
+		case TLSStartTLS:
+			config.Port = 587
+		default:
+			config.Port = 25
+		}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultSMTPTimeout
+	}
+
+	return &SMTPSender{config: config, addresses: addresses, envelopeFrom: from.Address}, nil
+}
+
+func (s *SMTPSender) SupportedChannels() []notification.Channel {
+	return []notification.Channel{notification.ChannelEmail}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, n *notification.Notification) error {
+	to := n.Recipient
+	if to == "" {
+		address, err := s.addresses.Address(ctx, n.CustomerID, notification.ChannelEmail)
+		if err != nil {
+			return err
+		}
+		to = address
+	}
+
+	message, err := BuildMessage(s.config.From, s.config.FromName, to, n)
+	if err != nil {
+		return notification.NewPermanentSendError(notification.ChannelEmail, err.Error())
+	}
+
+	// BuildMessage уже проверил адрес, в RCPT TO уходит он без имени
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return notification.NewPermanentSendError(notification.ChannelEmail, err.Error())
+	}
+
+	if err := s.send(ctx, recipient.Address, message); err != nil {
+		return classifySMTPError(err)
+	}
+	return nil
+}
+
+func (s *SMTPSender) send(ctx context.Context, to string, message []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// net/smtp не принимает контекст: дедлайн соединения ограничивает весь диалог
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         s.config.Host,
+		InsecureSkipVerify: s.config.InsecureSkipVerify,
+	}
+
+	if s.config.TLS == TLSImplicit {
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return err
+		}
+		conn = tlsConn
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.config.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.envelopeFrom); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return notification.NewPermanentSendError(notification.ChannelEmail,
+			fmt.Sprintf("smtp %d: %s", protoErr.Code, protoErr.Msg))
+	}
+	return notification.NewSendError(notification.ChannelEmail, err.Error())
+}
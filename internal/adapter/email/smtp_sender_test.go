@@ -0,0 +1,168 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+
+	"orderflow/internal/domain/notification"
+	"orderflow/pkg/smtpcapture"
+)
+
+func startCapture(t *testing.T, opts ...smtpcapture.Option) (*smtpcapture.Server, string, int) {
+	t.Helper()
+
+	server := smtpcapture.New(append([]smtpcapture.Option{smtpcapture.Addr("127.0.0.1:0")}, opts...)...)
+	if err := server.Start(); err != nil {
+		t.Fatalf("start capture server: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	host, portStr, err := net.SplitHostPort(server.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return server, host, port
+}
+
+func TestSMTPSenderDeliversMultipartMessage(t *testing.T) {
+	tlsConfig, err := smtpcapture.SelfSignedTLSConfig("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []TLSMode{TLSStartTLS, TLSImplicit} {
+		t.Run(string(mode), func(t *testing.T) {
+			tlsOption := smtpcapture.StartTLS(tlsConfig)
+			if mode == TLSImplicit {
+				tlsOption = smtpcapture.ImplicitTLS(tlsConfig)
+			}
+			server, host, port := startCapture(t, tlsOption, smtpcapture.Auth("orderflow", "secret"))
+
+			sender, err := NewSMTPSender(SMTPConfig{
+				Host:               host,
+				Port:               port,
+				Username:           "orderflow",
+				Password:           "secret",
+				From:               "Shop <orders@shop.example>",
+				FromName:           "Магазин",
+				TLS:                mode,
+				InsecureSkipVerify: true,
+			}, NewStaticAddressBook(map[string]string{"Customer-1": "Buyer <buyer@example.com>"}, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = sender.Send(context.Background(), &notification.Notification{
+				ID:         "n-1",
+				CustomerID: "customer-1",
+				Channel:    notification.ChannelEmail,
+				Subject:    "Заказ подтверждён",
+				Message:    "Order <42> confirmed.\n\nThank you!",
+			})
+			if err != nil {
+				t.Fatalf("send: %v", err)
+			}
+
+			messages := server.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("expected 1 captured message, got %d", len(messages))
+			}
+			captured := messages[0]
+			if !captured.TLS {
+				t.Error("message was not sent over TLS")
+			}
+			if captured.From != "orders@shop.example" || len(captured.To) != 1 || captured.To[0] != "buyer@example.com" {
+				t.Errorf("unexpected envelope: from=%s to=%v", captured.From, captured.To)
+			}
+
+			msg, err := mail.ReadMessage(bytes.NewReader(captured.Data))
+			if err != nil {
+				t.Fatalf("parse message: %v", err)
+			}
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if err != nil || subject != "Заказ подтверждён" {
+				t.Errorf("unexpected subject %q (%v)", subject, err)
+			}
+			if got := msg.Header.Get("Message-Id"); got != "<n-1@shop.example>" {
+				t.Errorf("unexpected Message-Id %q", got)
+			}
+
+			mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			if err != nil || mediaType != "multipart/alternative" {
+				t.Fatalf("unexpected content type %q (%v)", mediaType, err)
+			}
+
+			bodies := map[string]string{}
+			reader := multipart.NewReader(msg.Body, params["boundary"])
+			for {
+				part, err := reader.NextRawPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				content, err := io.ReadAll(quotedprintable.NewReader(part))
+				if err != nil {
+					t.Fatal(err)
+				}
+				partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+				bodies[partType] = string(content)
+			}
+
+			if !strings.Contains(bodies["text/plain"], "Order <42> confirmed.") {
+				t.Errorf("unexpected text part %q", bodies["text/plain"])
+			}
+			if !strings.Contains(bodies["text/html"], "<p>Order &lt;42&gt; confirmed.</p>") {
+				t.Errorf("unexpected html part %q", bodies["text/html"])
+			}
+		})
+	}
+}
+
+func TestSMTPSenderClassifiesFailures(t *testing.T) {
+	_, host, port := startCapture(t, smtpcapture.RejectRecipients("gone@example.com"))
+
+	sender, err := NewSMTPSender(SMTPConfig{
+		Host: host,
+		Port: port,
+		From: "orders@shop.example",
+		TLS:  TLSNone,
+	}, NewStaticAddressBook(map[string]string{"gone": "gone@example.com"}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sendErr *notification.SendError
+	err = sender.Send(context.Background(), &notification.Notification{CustomerID: "gone", Subject: "s", Message: "m"})
+	if !errors.As(err, &sendErr) || !sendErr.Permanent {
+		t.Errorf("expected permanent send error for rejected recipient, got %v", err)
+	}
+
+	var recipientErr *notification.RecipientNotFoundError
+	err = sender.Send(context.Background(), &notification.Notification{CustomerID: "unknown", Subject: "s", Message: "m"})
+	if !errors.As(err, &recipientErr) {
+		t.Errorf("expected recipient not found error, got %v", err)
+	}
+
+	unreachable, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "orders@shop.example", TLS: TLSNone},
+		NewStaticAddressBook(nil, "example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = unreachable.Send(context.Background(), &notification.Notification{CustomerID: "c", Subject: "s", Message: "m"})
+	if !errors.As(err, &sendErr) || sendErr.Permanent {
+		t.Errorf("expected retryable send error for unreachable server, got %v", err)
+	}
+}
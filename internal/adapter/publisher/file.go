@@ -0,0 +1,51 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"orderflow/internal/domain/outbox"
+)
+
+// File пишет события построчно в формате NDJSON — в файл (дозапись) или в stdout.
+// Подходит для локальной разработки и отладки интеграций.
+type File struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewFile открывает файл для дозаписи; путь "" или "-" означает stdout.
+func NewFile(path string) (*File, error) {
+	if path == "" || path == "-" {
+		return &File{w: os.Stdout}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &File{w: f, closer: f}, nil
+}
+
+func (p *File) Publish(_ context.Context, event *outbox.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.w.Write(append(line, '\n'))
+	return err
+}
+
+func (p *File) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
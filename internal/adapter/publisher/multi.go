@@ -0,0 +1,29 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+
+	"orderflow/internal/domain/outbox"
+)
+
+// Multi передаёт событие всем публикаторам. Событие считается опубликованным, только
+// если все публикаторы вернули nil, поэтому при повторе его получат и те, кто уже
+// обработал событие: публикаторы должны быть идемпотентны по DedupKey.
+type Multi struct {
+	publishers []outbox.EventPublisher
+}
+
+func NewMulti(publishers ...outbox.EventPublisher) *Multi {
+	return &Multi{publishers: publishers}
+}
+
+func (p *Multi) Publish(ctx context.Context, event *outbox.Event) error {
+	var errs []error
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
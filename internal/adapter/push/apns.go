@@ -0,0 +1,203 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+
+	"orderflow/internal/domain/notification"
+)
+
+const (
+	APNsProductionEndpoint = "https://api.push.apple.com"
+	APNsSandboxEndpoint    = "https://api.sandbox.push.apple.com"
+	// apnsTokenLifetime — APNs принимает provider token не старше часа и не чаще раза в 20 минут
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// APNsConfig — APNs с авторизацией по токену: KeyFile — ключ .p8, KeyID и TeamID из
+// Apple Developer, Topic — bundle id приложения. Endpoint переопределяет адрес
+// (Sandbox выбирает песочницу Apple); InsecureSkipVerify — для заглушек с самоподписанным сертификатом.
+type APNsConfig struct {
+	KeyFile            string
+	KeyID              string
+	TeamID             string
+	Topic              string
+	Sandbox            bool
+	Endpoint           string
+	InsecureSkipVerify bool
+	RateLimit          float64
+	Burst              int
+}
+
+type apnsProvider struct {
+	config  APNsConfig
+	key     *ecdsa.PrivateKey
+	client  *http.Client
+	limiter *rate.Limiter
+
+	mu      sync.Mutex
+	current string
+	issued  time.Time
+}
+
+func newAPNsProvider(config APNsConfig, timeout time.Duration) (*apnsProvider, error) {
+	if config.KeyFile == "" || config.KeyID == "" || config.TeamID == "" {
+		return nil, errors.New("key file, key id and team id are required")
+	}
+	if config.Topic == "" {
+		return nil, errors.New("topic (app bundle id) is required")
+	}
+
+	data, err := os.ReadFile(config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("key file %s: %w", config.KeyFile, err)
+	}
+	key, ok := signer.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key file %s: private key must be ECDSA P-256", config.KeyFile)
+	}
+
+	if config.Endpoint == "" {
+		config.Endpoint = APNsProductionEndpoint
+		if config.Sandbox {
+			config.Endpoint = APNsSandboxEndpoint
+		}
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	// APNs работает только по HTTP/2: с собственным TLSClientConfig его нужно включить явно
+	transport := &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		ForceAttemptHTTP2: true,
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify},
+		IdleConnTimeout:   90 * time.Second,
+	}
+
+	return &apnsProvider{
+		config:  config,
+		key:     key,
+		client:  &http.Client{Timeout: timeout, Transport: transport},
+		limiter: newLimiter(config.RateLimit, config.Burst),
+	}, nil
+}
+
+func (p *apnsProvider) send(ctx context.Context, deviceToken string, msg *message) error {
+	payload, err := fitPayload(msg, MaxPayloadBytes, buildAPNsPayload)
+	if err != nil {
+		return notification.NewPermanentSendError(notification.ChannelPush, "apns: "+err.Error())
+	}
+
+	if err := waitLimiter(ctx, p.limiter, ProviderAPNs); err != nil {
+		return err
+	}
+
+	providerToken, err := p.token()
+	if err != nil {
+		return notification.NewPermanentSendError(notification.ChannelPush, "apns provider token: "+err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.config.Endpoint+"/3/device/"+url.PathEscape(deviceToken), bytes.NewReader(payload))
+	if err != nil {
+		return notification.NewPermanentSendError(notification.ChannelPush, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("Apns-Topic", p.config.Topic)
+	req.Header.Set("Apns-Push-Type", "alert")
+	req.Header.Set("Apns-Priority", "10")
+	// apns-id должен быть UUID; повтор с тем же id Apple показывает в логах доставки как одно уведомление
+	if _, err := uuid.Parse(msg.ID); err == nil {
+		req.Header.Set("Apns-Id", msg.ID)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return notification.NewSendError(notification.ChannelPush, "apns: "+err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var response struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.Unmarshal(body, &response)
+	reason := fmt.Sprintf("apns %d: %s", resp.StatusCode, response.Reason)
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden && response.Reason == "ExpiredProviderToken":
+		p.invalidate()
+		return notification.NewSendError(notification.ChannelPush, reason)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return notification.NewSendError(notification.ChannelPush, reason)
+	default:
+		// BadDeviceToken, Unregistered (410), InvalidProviderToken, PayloadTooLarge и т. п.
+		return notification.NewPermanentSendError(notification.ChannelPush, reason)
+	}
+}
+
+func buildAPNsPayload(msg *message) ([]byte, error) {
+	payload := map[string]any{
+		"aps": map[string]any{
+			"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+			"sound": "default",
+		},
+	}
+	for key, value := range msg.Data {
+		if value != "" {
+			payload[key] = value
+		}
+	}
+	return json.Marshal(payload)
+}
+
+// token возвращает provider token (JWT ES256) и перевыпускает его раз в apnsTokenLifetime
+func (p *apnsProvider) token() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current != "" && time.Since(p.issued) < apnsTokenLifetime {
+		return p.current, nil
+	}
+
+	now := time.Now()
+	token, err := signES256(p.key, map[string]any{"kid": p.config.KeyID}, map[string]any{
+		"iss": p.config.TeamID,
+		"iat": now.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	p.current = token
+	p.issued = now
+	return token, nil
+}
+
+func (p *apnsProvider) invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = ""
+}
@@ -0,0 +1,294 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"orderflow/internal/domain/notification"
+)
+
+const (
+	DefaultFCMEndpoint = "https://fcm.googleapis.com"
+	fcmScope           = "https://www.googleapis.com/auth/firebase.messaging"
+	defaultTokenURI    = "https://oauth2.googleapis.com/token"
+)
+
+// FCMConfig — FCM HTTP v1. CredentialsFile — JSON-ключ сервисного аккаунта: по нему
+// выпускается OAuth2-токен (JWT bearer grant на token_uri из файла). AccessToken задаёт
+// готовый токен вместо сервисного аккаунта, например для локальной заглушки.
+// ProjectID по умолчанию берётся из файла ключа.
+type FCMConfig struct {
+	CredentialsFile string
+	AccessToken     string
+	ProjectID       string
+	Endpoint        string
+	RateLimit       float64
+	Burst           int
+}
+
+type serviceAccount struct {
+	Type        string `json:"type"`
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+type fcmProvider struct {
+	url     string
+	tokens  tokenSource
+	client  *http.Client
+	limiter *rate.Limiter
+}
+
+type tokenSource interface {
+	token(ctx context.Context) (string, error)
+	// invalidate сбрасывает токен после ответа 401, следующий запрос выпустит новый
+	invalidate()
+}
+
+func newFCMProvider(config FCMConfig, timeout time.Duration) (*fcmProvider, error) {
+	client := &http.Client{Timeout: timeout}
+
+	var tokens tokenSource
+	projectID := config.ProjectID
+	switch {
+	case config.AccessToken != "":
+		tokens = staticToken(config.AccessToken)
+	case config.CredentialsFile != "":
+		account, key, err := loadServiceAccount(config.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		if projectID == "" {
+			projectID = account.ProjectID
+		}
+		tokens = &serviceAccountTokens{account: account, key: key, client: client}
+	default:
+		return nil, errors.New("credentials file or access token is required")
+	}
+	if projectID == "" {
+		return nil, errors.New("project id is required")
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultFCMEndpoint
+	}
+
+	return &fcmProvider{
+		url:     strings.TrimRight(endpoint, "/") + "/v1/projects/" + url.PathEscape(projectID) + "/messages:send",
+		tokens:  tokens,
+		client:  client,
+		limiter: newLimiter(config.RateLimit, config.Burst),
+	}, nil
+}
+
+func (p *fcmProvider) send(ctx context.Context, deviceToken string, msg *message) error {
+	payload, err := fitPayload(msg, MaxPayloadBytes, func(m *message) ([]byte, error) {
+		return buildFCMPayload(deviceToken, m)
+	})
+	if err != nil {
+		return notification.NewPermanentSendError(notification.ChannelPush, "fcm: "+err.Error())
+	}
+
+	if err := waitLimiter(ctx, p.limiter, ProviderFCM); err != nil {
+		return err
+	}
+
+	accessToken, err := p.tokens.token(ctx)
+	if err != nil {
+		return notification.NewSendError(notification.ChannelPush, "fcm access token: "+err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(payload))
+	if err != nil {
+		return notification.NewPermanentSendError(notification.ChannelPush, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return notification.NewSendError(notification.ChannelPush, "fcm: "+err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 16<<10))
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		p.tokens.invalidate()
+	}
+	return classifyFCMError(resp.StatusCode, body)
+}
+
+func buildFCMPayload(deviceToken string, msg *message) ([]byte, error) {
+	type fcmNotification struct {
+		Title string `json:"title,omitempty"`
+		Body  string `json:"body"`
+	}
+	type fcmMessage struct {
+		Token        string            `json:"token"`
+		Notification fcmNotification   `json:"notification"`
+		Data         map[string]string `json:"data,omitempty"`
+	}
+	return json.Marshal(map[string]fcmMessage{
+		"message": {
+			Token:        deviceToken,
+			Notification: fcmNotification{Title: msg.Title, Body: msg.Body},
+			Data:         msg.Data,
+		},
+	})
+}
+
+// classifyFCMError разбирает ошибку FCM v1. 401, 429 и 5xx — временные ошибки;
+// остальные (INVALID_ARGUMENT, UNREGISTERED, SENDER_ID_MISMATCH) повторять бесполезно.
+func classifyFCMError(status int, body []byte) error {
+	var response struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(body, &response)
+
+	code := response.Error.Status
+	for _, detail := range response.Error.Details {
+		if detail.ErrorCode != "" {
+			code = detail.ErrorCode
+		}
+	}
+	reason := fmt.Sprintf("fcm %d %s: %s", status, code, response.Error.Message)
+
+	if status == http.StatusUnauthorized || status == http.StatusTooManyRequests || status >= 500 {
+		return notification.NewSendError(notification.ChannelPush, reason)
+	}
+	return notification.NewPermanentSendError(notification.ChannelPush, reason)
+}
+
+type staticToken string
+
+func (t staticToken) token(context.Context) (string, error) { return string(t), nil }
+
+func (t staticToken) invalidate() {}
+
+// serviceAccountTokens выпускает OAuth2-токены по ключу сервисного аккаунта и кэширует
+// их до истечения срока с запасом в минуту.
+type serviceAccountTokens struct {
+	account *serviceAccount
+	key     *rsa.PrivateKey
+	client  *http.Client
+
+	mu      sync.Mutex
+	current string
+	expiry  time.Time
+}
+
+func loadServiceAccount(path string) (*serviceAccount, *rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, nil, fmt.Errorf("invalid credentials file %s: %w", path, err)
+	}
+	if account.Type != "" && account.Type != "service_account" {
+		return nil, nil, fmt.Errorf("credentials file %s: unsupported type %s", path, account.Type)
+	}
+	if account.ClientEmail == "" {
+		return nil, nil, fmt.Errorf("credentials file %s: client_email is required", path)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = defaultTokenURI
+	}
+
+	signer, err := parsePrivateKey([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("credentials file %s: %w", path, err)
+	}
+	key, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("credentials file %s: private key must be RSA", path)
+	}
+	return &account, key, nil
+}
+
+func (t *serviceAccountTokens) token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current != "" && time.Now().Before(t.expiry) {
+		return t.current, nil
+	}
+
+	now := time.Now()
+	assertion, err := signRS256(t.key, nil, map[string]any{
+		"iss":   t.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   t.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 16<<10))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("invalid token response: %s", strings.TrimSpace(string(body)))
+	}
+
+	t.current = token.AccessToken
+	t.expiry = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return t.current, nil
+}
+
+func (t *serviceAccountTokens) invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current = ""
+}
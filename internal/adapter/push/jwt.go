@@ -0,0 +1,85 @@
+package push
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// signRS256 подписывает JWT ключом сервисного аккаунта Google (обмен на OAuth2-токен FCM)
+func signRS256(key *rsa.PrivateKey, header, claims map[string]any) (string, error) {
+	return signJWT("RS256", header, claims, func(digest []byte) ([]byte, error) {
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+	})
+}
+
+// signES256 подписывает provider token APNs ключом .p8. Подпись JWS — r||s по 32 байта,
+// а не ASN.1, как у ecdsa.SignASN1.
+func signES256(key *ecdsa.PrivateKey, header, claims map[string]any) (string, error) {
+	return signJWT("ES256", header, claims, func(digest []byte) ([]byte, error) {
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			return nil, err
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature, nil
+	})
+}
+
+func signJWT(alg string, header, claims map[string]any, sign func(digest []byte) ([]byte, error)) (string, error) {
+	fullHeader := map[string]any{"alg": alg, "typ": "JWT"}
+	for key, value := range header {
+		fullHeader[key] = value
+	}
+
+	headerJSON, err := json.Marshal(fullHeader)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := sign(digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block in private key")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
@@ -0,0 +1,196 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/time/rate"
+
+	"orderflow/internal/domain/notification"
+)
+
+type Provider string
+
+const (
+	ProviderFCM  Provider = "fcm"
+	ProviderAPNs Provider = "apns"
+)
+
+const (
+	DefaultTimeout = 15 * time.Second
+	// MaxPayloadBytes — предел размера уведомления у FCM и APNs
+	MaxPayloadBytes = 4096
+	// MaxTitleRunes — длиннее заголовки обрезаются, на экране устройства они всё равно не видны
+	MaxTitleRunes = 100
+)
+
+const truncationSuffix = "…"
+
+// Config включает провайдеров: nil — провайдер не используется. DefaultProvider получает
+// токены без префикса; если он не задан и провайдер один, используется он.
+type Config struct {
+	FCM             *FCMConfig
+	APNs            *APNsConfig
+	DefaultProvider Provider
+	Timeout         time.Duration
+}
+
+// message — содержимое push-уведомления, общее для провайдеров
+type message struct {
+	ID    string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+type provider interface {
+	send(ctx context.Context, token string, msg *message) error
+}
+
+// Sender отправляет push-уведомления через FCM HTTP v1 и APNs. Адрес получателя —
+// токен устройства с префиксом провайдера: fcm:<token> или apns:<token>.
+// Длинный текст обрезается, чтобы запрос уложился в MaxPayloadBytes.
+type Sender struct {
+	providers       map[Provider]provider
+	defaultProvider Provider
+}
+
+func NewSender(config Config) (*Sender, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	sender := &Sender{providers: make(map[Provider]provider)}
+	if config.FCM != nil {
+		fcm, err := newFCMProvider(*config.FCM, config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("fcm: %w", err)
+		}
+		sender.providers[ProviderFCM] = fcm
+	}
+	if config.APNs != nil {
+		apns, err := newAPNsProvider(*config.APNs, config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("apns: %w", err)
+		}
+		sender.providers[ProviderAPNs] = apns
+	}
+	if len(sender.providers) == 0 {
+		return nil, errors.New("no push providers configured")
+	}
+
+	sender.defaultProvider = config.DefaultProvider
+	if sender.defaultProvider == "" && len(sender.providers) == 1 {
+		for name := range sender.providers {
+			sender.defaultProvider = name
+		}
+	}
+	if _, ok := sender.providers[sender.defaultProvider]; sender.defaultProvider != "" && !ok {
+		return nil, fmt.Errorf("default push provider %s is not configured", sender.defaultProvider)
+	}
+
+	return sender, nil
+}
+
+func (s *Sender) SupportedChannels() []notification.Channel {
+	return []notification.Channel{notification.ChannelPush}
+}
+
+func (s *Sender) Send(ctx context.Context, n *notification.Notification) error {
+	if n.Recipient == "" {
+		return notification.NewRecipientNotFoundError(n.CustomerID, notification.ChannelPush)
+	}
+
+	name, token := s.parseAddress(n.Recipient)
+	p, ok := s.providers[name]
+	if !ok || token == "" {
+		return notification.NewPermanentSendError(notification.ChannelPush, "no push provider for device token "+n.Recipient)
+	}
+
+	return p.send(ctx, token, &message{
+		ID:    n.ID,
+		Title: truncateRunes(n.Subject, MaxTitleRunes),
+		Body:  n.Message,
+		Data: map[string]string{
+			"notification_id": n.ID,
+			"order_id":        n.OrderID,
+			"type":            string(n.Type),
+		},
+	})
+}
+
+func (s *Sender) parseAddress(address string) (Provider, string) {
+	if prefix, token, ok := strings.Cut(address, ":"); ok {
+		switch Provider(strings.ToLower(prefix)) {
+		case ProviderFCM, ProviderAPNs:
+			return Provider(strings.ToLower(prefix)), token
+		}
+	}
+	return s.defaultProvider, address
+}
+
+// fitPayload собирает тело запроса и, если оно больше limit, укорачивает текст уведомления.
+// JSON-экранирование может удлинить текст, поэтому сборка повторяется до совпадения.
+func fitPayload(msg *message, limit int, build func(*message) ([]byte, error)) ([]byte, error) {
+	body := msg.Body
+	for {
+		payload, err := build(&message{ID: msg.ID, Title: msg.Title, Body: body, Data: msg.Data})
+		if err != nil {
+			return nil, err
+		}
+		overflow := len(payload) - limit
+		if overflow <= 0 {
+			return payload, nil
+		}
+		if body == "" {
+			return nil, fmt.Errorf("payload is %d bytes, limit %d", len(payload), limit)
+		}
+		body = truncateBytes(strings.TrimSuffix(body, truncationSuffix), len(body)-overflow-len(truncationSuffix))
+		if body != "" {
+			body += truncationSuffix
+		}
+	}
+}
+
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + truncationSuffix
+}
+
+// truncateBytes обрезает строку до limit байт, не разрывая UTF-8 последовательность
+func truncateBytes(s string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return strings.TrimRight(s[:limit], " \n\r\t")
+}
+
+func newLimiter(perSecond float64, burst int) *rate.Limiter {
+	if perSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), burst)
+}
+
+func waitLimiter(ctx context.Context, limiter *rate.Limiter, provider Provider) error {
+	if err := limiter.Wait(ctx); err != nil {
+		return notification.NewSendError(notification.ChannelPush, fmt.Sprintf("%s rate limit: %v", provider, err))
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package push
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+
+	"orderflow/internal/domain/notification"
+)
+
+const notificationID = "5f0c6a3e-8c1d-4a5e-9d47-1f2b3c4d5e6f"
+
+func writeKey(t *testing.T, key any) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// verifyJWT проверяет подпись и возвращает заголовок и claims токена
+func verifyJWT(t *testing.T, token string, verify func(digest, signature []byte) bool) (map[string]any, map[string]any) {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed jwt %q", token)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verify(digest[:], signature) {
+		t.Errorf("jwt signature is invalid")
+	}
+
+	decode := func(segment string) map[string]any {
+		data, err := base64.RawURLEncoding.DecodeString(segment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := map[string]any{}
+		if err := json.Unmarshal(data, &values); err != nil {
+			t.Fatal(err)
+		}
+		return values
+	}
+	return decode(parts[0]), decode(parts[1])
+}
+
+func pushNotification(recipient, body string) *notification.Notification {
+	return &notification.Notification{
+		ID:         notificationID,
+		CustomerID: "customer-1",
+		OrderID:    "order-1",
+		Type:       notification.TypeOrderConfirmed,
+		Channel:    notification.ChannelPush,
+		Recipient:  recipient,
+		Subject:    "Order confirmed",
+		Message:    body,
+	}
+}
+
+func TestFCMSenderWithServiceAccount(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tokenRequests, sendRequests atomic.Int32
+	var lastPayload []byte
+	mux := http.NewServeMux()
+	stub := httptest.NewServer(mux)
+	defer stub.Close()
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, claims := verifyJWT(t, r.FormValue("assertion"), func(digest, signature []byte) bool {
+			return rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest, signature) == nil
+		})
+		if claims["iss"] != "push@orderflow.iam.gserviceaccount.com" || claims["aud"] != stub.URL+"/token" || claims["scope"] != fcmScope {
+			t.Errorf("unexpected assertion claims %v", claims)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "oauth-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("POST /v1/projects/orderflow-test/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		sendRequests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer oauth-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Message struct {
+				Token string `json:"token"`
+			} `json:"message"`
+		}
+		lastPayload, _ = io.ReadAll(r.Body)
+		json.Unmarshal(lastPayload, &body)
+		switch body.Message.Token {
+		case "gone":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND",
+				"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`))
+		case "busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"code":503,"status":"UNAVAILABLE"}}`))
+		default:
+			w.Write([]byte(`{"name":"projects/orderflow-test/messages/1"}`))
+		}
+	})
+
+	credentials, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "orderflow-test",
+		"private_key":  writeKey(t, key),
+		"client_email": "push@orderflow.iam.gserviceaccount.com",
+		"token_uri":    stub.URL + "/token",
+	})
+	sender, err := NewSender(Config{FCM: &FCMConfig{
+		CredentialsFile: writeFile(t, "service-account.json", string(credentials)),
+		Endpoint:        stub.URL,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, recipient := range []string{"fcm:device-1", "device-2"} {
+		if err := sender.Send(context.Background(), pushNotification(recipient, "Thank you!")); err != nil {
+			t.Fatalf("send to %s: %v", recipient, err)
+		}
+	}
+	if tokenRequests.Load() != 1 || sendRequests.Load() != 2 {
+		t.Errorf("access token should be cached: %d token requests, %d sends", tokenRequests.Load(), sendRequests.Load())
+	}
+
+	var payload struct {
+		Message struct {
+			Token        string            `json:"token"`
+			Notification map[string]string `json:"notification"`
+			Data         map[string]string `json:"data"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(lastPayload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Message.Token != "device-2" || payload.Message.Notification["title"] != "Order confirmed" ||
+		payload.Message.Data["order_id"] != "order-1" {
+		t.Errorf("unexpected payload %s", lastPayload)
+	}
+
+	var sendErr *notification.SendError
+	if err := sender.Send(context.Background(), pushNotification("fcm:gone", "x")); !errors.As(err, &sendErr) ||
+		!sendErr.Permanent || !strings.Contains(sendErr.Reason, "UNREGISTERED") {
+		t.Errorf("expected permanent UNREGISTERED error, got %v", err)
+	}
+	if err := sender.Send(context.Background(), pushNotification("fcm:busy", "x")); !errors.As(err, &sendErr) || sendErr.Permanent {
+		t.Errorf("expected retryable error for 503, got %v", err)
+	}
+	if err := sender.Send(context.Background(), pushNotification("apns:device", "x")); !errors.As(err, &sendErr) || !sendErr.Permanent {
+		t.Errorf("expected permanent error for unconfigured provider, got %v", err)
+	}
+}
+
+func TestAPNsSenderOverHTTP2(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expired atomic.Bool
+	stub := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("request over %s, APNs requires HTTP/2", r.Proto)
+		}
+		header, claims := verifyJWT(t, strings.TrimPrefix(r.Header.Get("Authorization"), "bearer "), func(digest, signature []byte) bool {
+			if len(signature) != 64 {
+				return false
+			}
+			r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+			return ecdsa.Verify(&key.PublicKey, digest, r, s)
+		})
+		if header["alg"] != "ES256" || header["kid"] != "KEY123" || claims["iss"] != "TEAM123" {
+			t.Errorf("unexpected provider token %v %v", header, claims)
+		}
+		if r.Header.Get("Apns-Topic") != "com.example.shop" || r.Header.Get("Apns-Push-Type") != "alert" ||
+			r.Header.Get("Apns-Id") != notificationID {
+			t.Errorf("unexpected apns headers %v", r.Header)
+		}
+
+		var payload struct {
+			APS struct {
+				Alert map[string]string `json:"alert"`
+			} `json:"aps"`
+			OrderID string `json:"order_id"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > MaxPayloadBytes {
+			t.Errorf("payload is %d bytes", len(body))
+		}
+		if err := json.Unmarshal(body, &payload); err != nil || payload.OrderID != "order-1" || payload.APS.Alert["title"] != "Order confirmed" {
+			t.Errorf("unexpected payload %s (%v)", body, err)
+		}
+
+		switch strings.TrimPrefix(r.URL.Path, "/3/device/") {
+		case "gone":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered","timestamp":1700000000000}`))
+		case "expired":
+			expired.Store(true)
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"reason":"ExpiredProviderToken"}`))
+		}
+	}))
+	stub.EnableHTTP2 = true
+	stub.StartTLS()
+	defer stub.Close()
+
+	sender, err := NewSender(Config{
+		APNs: &APNsConfig{
+			KeyFile:            writeFile(t, "AuthKey_KEY123.p8", writeKey(t, key)),
+			KeyID:              "KEY123",
+			TeamID:             "TEAM123",
+			Topic:              "com.example.shop",
+			Endpoint:           stub.URL,
+			InsecureSkipVerify: true,
+		},
+		FCM:             &FCMConfig{AccessToken: "unused", ProjectID: "p", Endpoint: stub.URL},
+		DefaultProvider: ProviderAPNs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sender.Send(context.Background(), pushNotification("apns:device-1", "Thank you!")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if err := sender.Send(context.Background(), pushNotification("device-2", strings.Repeat("Длинный текст. ", 500))); err != nil {
+		t.Fatalf("send long message: %v", err)
+	}
+
+	var sendErr *notification.SendError
+	if err := sender.Send(context.Background(), pushNotification("apns:gone", "x")); !errors.As(err, &sendErr) || !sendErr.Permanent {
+		t.Errorf("expected permanent error for unregistered device, got %v", err)
+	}
+	if err := sender.Send(context.Background(), pushNotification("apns:expired", "x")); !errors.As(err, &sendErr) || sendErr.Permanent {
+		t.Errorf("expected retryable error for expired provider token, got %v", err)
+	}
+	if !expired.Load() {
+		t.Error("stub did not receive the expired token request")
+	}
+
+	var recipientErr *notification.RecipientNotFoundError
+	if err := sender.Send(context.Background(), pushNotification("", "x")); !errors.As(err, &recipientErr) {
+		t.Errorf("expected recipient not found error, got %v", err)
+	}
+}
+
+func TestFitPayloadTruncatesBody(t *testing.T) {
+	msg := &message{ID: notificationID, Title: "Order", Body: strings.Repeat("\"кавычки\" и текст ", 400)}
+	payload, err := fitPayload(msg, MaxPayloadBytes, buildAPNsPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload) > MaxPayloadBytes {
+		t.Fatalf("payload is %d bytes", len(payload))
+	}
+
+	var decoded struct {
+		APS struct {
+			Alert map[string]string `json:"alert"`
+		} `json:"aps"`
+	}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	body := decoded.APS.Alert["body"]
+	if !utf8.ValidString(body) || !strings.HasSuffix(body, truncationSuffix) {
+		t.Errorf("unexpected truncated body %q", body)
+	}
+
+	if got := truncateRunes(strings.Repeat("з", MaxTitleRunes+10), MaxTitleRunes); utf8.RuneCountInString(got) != MaxTitleRunes {
+		t.Errorf("title truncated to %d runes", utf8.RuneCountInString(got))
+	}
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"orderflow/internal/domain/customer"
+	"orderflow/internal/domain/notification"
+)
+
+type CustomerPG struct {
+	pool *pgxpool.Pool
+}
+
+func NewCustomerPG(pool *pgxpool.Pool) *CustomerPG {
+	return &CustomerPG{pool: pool}
+}
+
+func (r *CustomerPG) GetCustomer(ctx context.Context, id string) (*customer.Customer, error) {
+	const q = `
+		SELECT id, name, locale, preferred_channels, created_at, updated_at
+		FROM customers WHERE id = $1
+	`
+	var customerEntity customer.Customer
+	var preferredChannels []string
+	err := r.pool.QueryRow(ctx, q, id).Scan(
+		&customerEntity.ID, &customerEntity.Name, &customerEntity.Locale, &preferredChannels,
+		&customerEntity.CreatedAt, &customerEntity.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, customer.NewNotFoundError(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	customerEntity.PreferredChannels = make([]notification.Channel, 0, len(preferredChannels))
+	for _, channel := range preferredChannels {
+		customerEntity.PreferredChannels = append(customerEntity.PreferredChannels, notification.Channel(channel))
+	}
+
+	const qContacts = `
+		SELECT channel, address FROM customer_contacts WHERE customer_id = $1 ORDER BY channel
+	`
+	rows, err := r.pool.Query(ctx, qContacts, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customerEntity.Contacts = []customer.ContactPoint{}
+	for rows.Next() {
+		var contact customer.ContactPoint
+		var channel string
+		if err := rows.Scan(&channel, &contact.Address); err != nil {
+			return nil, err
+		}
+		contact.Channel = notification.Channel(channel)
+		customerEntity.Contacts = append(customerEntity.Contacts, contact)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const qConsents = `
+		SELECT notification_type, opted_in FROM customer_consents WHERE customer_id = $1
+	`
+	consentRows, err := r.pool.Query(ctx, qConsents, id)
+	if err != nil {
+		return nil, err
+	}
+	defer consentRows.Close()
+
+	customerEntity.Consents = map[notification.Type]bool{}
+	for consentRows.Next() {
+		var notificationType string
+		var optedIn bool
+		if err := consentRows.Scan(&notificationType, &optedIn); err != nil {
+			return nil, err
+		}
+		customerEntity.Consents[notification.Type(notificationType)] = optedIn
+	}
+
+	return &customerEntity, consentRows.Err()
+}
+
+func (r *CustomerPG) SaveCustomer(ctx context.Context, customerEntity *customer.Customer) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	preferredChannels := make([]string, 0, len(customerEntity.PreferredChannels))
+	for _, channel := range customerEntity.PreferredChannels {
+		preferredChannels = append(preferredChannels, string(channel))
+	}
+
+	const q = `
+		INSERT INTO customers (id, name, locale, preferred_channels, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE
+		SET name = EXCLUDED.name, locale = EXCLUDED.locale,
+		    preferred_channels = EXCLUDED.preferred_channels, updated_at = EXCLUDED.updated_at
+	`
+	_, err = tx.Exec(ctx, q,
+		customerEntity.ID, customerEntity.Name, customerEntity.Locale, preferredChannels,
+		customerEntity.CreatedAt, customerEntity.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM customer_contacts WHERE customer_id = $1`, customerEntity.ID); err != nil {
+		return err
+	}
+	for _, contact := range customerEntity.Contacts {
+		const qContact = `
+			INSERT INTO customer_contacts (customer_id, channel, address) VALUES ($1, $2, $3)
+		`
+		if _, err := tx.Exec(ctx, qContact, customerEntity.ID, string(contact.Channel), contact.Address); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM customer_consents WHERE customer_id = $1`, customerEntity.ID); err != nil {
+		return err
+	}
+	for notificationType, optedIn := range customerEntity.Consents {
+		const qConsent = `
+			INSERT INTO customer_consents (customer_id, notification_type, opted_in, updated_at)
+			VALUES ($1, $2, $3, $4)
+		`
+		if _, err := tx.Exec(ctx, qConsent, customerEntity.ID, string(notificationType), optedIn, customerEntity.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *CustomerPG) DeleteCustomer(ctx context.Context, id string) error {
+	ct, err := r.pool.Exec(ctx, `DELETE FROM customers WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return customer.NewNotFoundError(id)
+	}
+	return nil
+}
+
+func (r *CustomerPG) SetConsent(ctx context.Context, customerID string, notificationType notification.Type, optedIn bool) error {
+	const q = `
+		INSERT INTO customer_consents (customer_id, notification_type, opted_in, updated_at)
+		SELECT id, $2, $3, NOW() FROM customers WHERE id = $1
+		ON CONFLICT (customer_id, notification_type) DO UPDATE
+		SET opted_in = EXCLUDED.opted_in, updated_at = EXCLUDED.updated_at
+	`
+	ct, err := r.pool.Exec(ctx, q, customerID, string(notificationType), optedIn)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return customer.NewNotFoundError(customerID)
+	}
+	return nil
+}
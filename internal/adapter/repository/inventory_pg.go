@@ -3,12 +3,16 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/outbox"
 )
 
 type InventoryPG struct {
@@ -19,30 +23,68 @@ func NewInventoryPG(pool *pgxpool.Pool) *InventoryPG {
 	return &InventoryPG{pool: pool}
 }
 
+const productColumns = `id, name, sku, kind, price, available, reserved, reorder_point, COALESCE(parent_id, ''), attributes,
+	tax_class, weight, created_at, updated_at, deleted_at`
+
+const reservationColumns = `id, order_id, product_id, warehouse_id, quantity, expires_at, created_at`
+
+const warehouseColumns = `id, name, latitude, longitude, shipping_cost, priority, active, created_at, updated_at`
+
 func (r *InventoryPG) CreateProduct(ctx context.Context, product *inventory.Product) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Счётчики создаются нулевыми: начальный остаток записывается движением receipt
 	const q = `
-		INSERT INTO products (id, name, sku, price, available, reserved, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO products (id, name, sku, kind, price, available, reserved, reorder_point, parent_id, attributes,
+		                      tax_class, weight, created_at, updated_at, deleted_at)
+		VALUES ($1, $2, $3, $4, $5, 0, 0, $6, NULLIF($7, ''), $8, $9, $10, $11, $12, $13)
 	`
-	_, err := r.pool.Exec(ctx, q,
-		product.ID, product.Name, product.SKU, product.Price,
-		product.Available, product.Reserved, product.CreatedAt, product.UpdatedAt,
+	_, err = tx.Exec(ctx, q,
+		product.ID, product.Name, product.SKU, string(product.Kind), product.Price, product.ReorderPoint,
+		product.ParentID, attributesOrEmpty(product.Attributes), product.TaxClass, product.Weight,
+		product.CreatedAt, product.UpdatedAt, product.DeletedAt,
 	)
-	return err
+	if isUniqueViolation(err, "products_sku_key") {
+		return inventory.NewDuplicateSKUError(product.SKU)
+	}
+	if isForeignKeyViolation(err, "products_parent_id_fkey") {
+		return inventory.NewProductNotFoundError(product.ParentID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := saveComponents(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if product.Available != 0 {
+		movement := &inventory.StockMovement{
+			ProductID:   product.ID,
+			WarehouseID: inventory.DefaultWarehouseID,
+			Type:        inventory.MovementReceipt,
+			Note:        "initial stock",
+			CreatedAt:   product.CreatedAt,
+		}
+		quantity := product.Available
+		_, err := applyMovement(ctx, tx, movement, func(_ *inventory.Product, l *inventory.StockLevel) error {
+			return l.AdjustStock(quantity)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *InventoryPG) GetProduct(ctx context.Context, productID string) (*inventory.Product, error) {
-	const q = `
-		SELECT id, name, sku, price, available, reserved, created_at, updated_at
-		FROM products WHERE id = $1
-	`
-	row := r.pool.QueryRow(ctx, q, productID)
-
-	var product inventory.Product
-	err := row.Scan(
-		&product.ID, &product.Name, &product.SKU, &product.Price,
-		&product.Available, &product.Reserved, &product.CreatedAt, &product.UpdatedAt,
-	)
+	q := `SELECT ` + productColumns + ` FROM products WHERE id = $1`
+	product, err := scanProduct(r.pool.QueryRow(ctx, q, productID))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, inventory.NewProductNotFoundError(productID)
 	}
@@ -50,34 +92,90 @@ func (r *InventoryPG) GetProduct(ctx context.Context, productID string) (*invent
 		return nil, err
 	}
 
-	return &product, nil
+	return product, loadComponents(ctx, r.pool, []*inventory.Product{product})
 }
 
+func (r *InventoryPG) GetProductBySKU(ctx context.Context, sku string) (*inventory.Product, error) {
+	q := `SELECT ` + productColumns + ` FROM products WHERE sku = $1`
+	product, err := scanProduct(r.pool.QueryRow(ctx, q, sku))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, inventory.NewProductNotFoundError(sku)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return product, loadComponents(ctx, r.pool, []*inventory.Product{product})
+}
+
+// UpdateProduct сверяет новую точку заказа с текущими счётчиками товара: если товар
+// из-за неё стал заканчивающимся, в той же транзакции пишется событие stock_low.
+// Вид товара и родитель варианта не меняются; состав набора заменяется целиком.
 func (r *InventoryPG) UpdateProduct(ctx context.Context, product *inventory.Product) error {
-	const q = `
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	q := `SELECT ` + productColumns + ` FROM products WHERE id = $1 FOR UPDATE`
+	current, err := scanProduct(tx.QueryRow(ctx, q, product.ID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return inventory.NewProductNotFoundError(product.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	const qUpdate = `
 		UPDATE products
-		SET name = $2, sku = $3, price = $4, available = $5, reserved = $6, updated_at = $7
+		SET name = $2, sku = $3, price = $4, reorder_point = $5, attributes = $6, tax_class = $7, weight = $8,
+		    updated_at = $9, deleted_at = $10
 		WHERE id = $1
 	`
-	ct, err := r.pool.Exec(ctx, q,
-		product.ID, product.Name, product.SKU, product.Price,
-		product.Available, product.Reserved, product.UpdatedAt,
+	_, err = tx.Exec(ctx, qUpdate,
+		product.ID, product.Name, product.SKU, product.Price, product.ReorderPoint, attributesOrEmpty(product.Attributes),
+		product.TaxClass, product.Weight, product.UpdatedAt, product.DeletedAt,
 	)
+	if isUniqueViolation(err, "products_sku_key") {
+		return inventory.NewDuplicateSKUError(product.SKU)
+	}
 	if err != nil {
 		return err
 	}
-	if ct.RowsAffected() == 0 {
-		return inventory.NewProductNotFoundError(product.ID)
+
+	if err := saveComponents(ctx, tx, product); err != nil {
+		return err
 	}
-	return nil
+
+	updated := *current
+	updated.Name, updated.SKU, updated.Price = product.Name, product.SKU, product.Price
+	updated.ReorderPoint, updated.DeletedAt = product.ReorderPoint, product.DeletedAt
+	if inventory.CrossedReorderPoint(current, &updated) {
+		if err := appendStockLow(ctx, tx, inventory.NewStockLow(&updated)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (r *InventoryPG) GetProducts(ctx context.Context) ([]*inventory.Product, error) {
-	const q = `
-		SELECT id, name, sku, price, available, reserved, created_at, updated_at
-		FROM products ORDER BY created_at DESC
-	`
-	rows, err := r.pool.Query(ctx, q)
+func (r *InventoryPG) GetProducts(ctx context.Context, includeDeleted bool) ([]*inventory.Product, error) {
+	q := `SELECT ` + productColumns + ` FROM products WHERE $1 OR deleted_at IS NULL ORDER BY created_at DESC`
+	products, err := r.queryProducts(ctx, q, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	return products, loadComponents(ctx, r.pool, products)
+}
+
+func (r *InventoryPG) GetVariants(ctx context.Context, parentID string) ([]*inventory.Product, error) {
+	q := `SELECT ` + productColumns + ` FROM products WHERE parent_id = $1 AND deleted_at IS NULL ORDER BY sku`
+	return r.queryProducts(ctx, q, parentID)
+}
+
+func (r *InventoryPG) queryProducts(ctx context.Context, q string, args ...any) ([]*inventory.Product, error) {
+	rows, err := r.pool.Query(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -85,82 +183,679 @@ func (r *InventoryPG) GetProducts(ctx context.Context) ([]*inventory.Product, er
 
 	var products []*inventory.Product
 	for rows.Next() {
-		var product inventory.Product
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.SKU, &product.Price,
-			&product.Available, &product.Reserved, &product.CreatedAt, &product.UpdatedAt,
-		)
+		product, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
-		products = append(products, &product)
+		products = append(products, product)
 	}
 
 	return products, rows.Err()
 }
 
-func (r *InventoryPG) CreateReservation(ctx context.Context, reservation *inventory.Reservation) error {
-	const q = `
-		INSERT INTO reservations (id, order_id, product_id, quantity, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+func (r *InventoryPG) AdjustStock(ctx context.Context, movement *inventory.StockMovement) (*inventory.Product, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	delta := movement.AvailableDelta
+	product, err := applyMovement(ctx, tx, movement, func(p *inventory.Product, l *inventory.StockLevel) error {
+		if p.IsDeleted() {
+			return inventory.NewProductNotFoundError(p.ID)
+		}
+		return l.AdjustStock(delta)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (r *InventoryPG) GetLowStockProducts(ctx context.Context) ([]*inventory.Product, error) {
+	q := `
+		SELECT ` + productColumns + `
+		FROM products
+		WHERE deleted_at IS NULL AND reorder_point > 0 AND available - reserved <= reorder_point
+		ORDER BY available - reserved - reorder_point, sku
 	`
-	_, err := r.pool.Exec(ctx, q,
-		reservation.ID, reservation.OrderID, reservation.ProductID,
-		reservation.Quantity, reservation.ExpiresAt, reservation.CreatedAt,
-	)
-	return err
+	return r.queryProducts(ctx, q)
 }
 
-func (r *InventoryPG) GetReservationByOrderID(ctx context.Context, orderID string) (*inventory.Reservation, error) {
+func (r *InventoryPG) GetStockLevels(ctx context.Context, productIDs []string) ([]*inventory.StockLevel, error) {
 	const q = `
-		SELECT id, order_id, product_id, quantity, expires_at, created_at
-		FROM reservations WHERE order_id = $1
+		SELECT product_id, warehouse_id, available, reserved, updated_at
+		FROM stock_levels
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, warehouse_id
 	`
-	row := r.pool.QueryRow(ctx, q, orderID)
+	rows, err := r.pool.Query(ctx, q, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	var reservation inventory.Reservation
-	err := row.Scan(
-		&reservation.ID, &reservation.OrderID, &reservation.ProductID,
-		&reservation.Quantity, &reservation.ExpiresAt, &reservation.CreatedAt,
+	var levels []*inventory.StockLevel
+	for rows.Next() {
+		level, err := scanStockLevel(rows)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+
+	return levels, rows.Err()
+}
+
+func (r *InventoryPG) CreateWarehouse(ctx context.Context, warehouse *inventory.Warehouse) error {
+	q := `INSERT INTO warehouses (` + warehouseColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err := r.pool.Exec(ctx, q,
+		warehouse.ID, warehouse.Name, warehouse.Location.Latitude, warehouse.Location.Longitude,
+		warehouse.ShippingCost, warehouse.Priority, warehouse.Active, warehouse.CreatedAt, warehouse.UpdatedAt,
 	)
+	if isUniqueViolation(err, "warehouses_pkey") {
+		return inventory.NewDuplicateWarehouseError(warehouse.ID)
+	}
+	return err
+}
+
+func (r *InventoryPG) GetWarehouse(ctx context.Context, warehouseID string) (*inventory.Warehouse, error) {
+	q := `SELECT ` + warehouseColumns + ` FROM warehouses WHERE id = $1`
+	warehouse, err := scanWarehouse(r.pool.QueryRow(ctx, q, warehouseID))
 	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, inventory.NewReservationNotFoundError(orderID)
+		return nil, inventory.NewWarehouseNotFoundError(warehouseID)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &reservation, nil
+	return warehouse, nil
 }
 
-func (r *InventoryPG) DeleteReservation(ctx context.Context, orderID string) error {
-	const q = `DELETE FROM reservations WHERE order_id = $1`
-	ct, err := r.pool.Exec(ctx, q, orderID)
+func (r *InventoryPG) UpdateWarehouse(ctx context.Context, warehouse *inventory.Warehouse) error {
+	const q = `
+		UPDATE warehouses
+		SET name = $2, latitude = $3, longitude = $4, shipping_cost = $5, priority = $6, active = $7, updated_at = $8
+		WHERE id = $1
+	`
+	ct, err := r.pool.Exec(ctx, q,
+		warehouse.ID, warehouse.Name, warehouse.Location.Latitude, warehouse.Location.Longitude,
+		warehouse.ShippingCost, warehouse.Priority, warehouse.Active, warehouse.UpdatedAt,
+	)
 	if err != nil {
 		return err
 	}
 	if ct.RowsAffected() == 0 {
-		return inventory.NewReservationNotFoundError(orderID)
+		return inventory.NewWarehouseNotFoundError(warehouse.ID)
 	}
 	return nil
 }
 
+func (r *InventoryPG) GetWarehouses(ctx context.Context, activeOnly bool) ([]*inventory.Warehouse, error) {
+	q := `SELECT ` + warehouseColumns + ` FROM warehouses WHERE NOT $1 OR active ORDER BY priority, id`
+	rows, err := r.pool.Query(ctx, q, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warehouses []*inventory.Warehouse
+	for rows.Next() {
+		warehouse, err := scanWarehouse(rows)
+		if err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+
+	return warehouses, rows.Err()
+}
+
+func (r *InventoryPG) ReserveStock(ctx context.Context, reservations []*inventory.Reservation) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	q := `INSERT INTO reservations (` + reservationColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	for _, reservation := range sortedByProduct(reservations) {
+		quantity := reservation.Quantity
+		_, err := applyMovement(ctx, tx, inventory.NewReservationMovement(inventory.MovementReserve, reservation), func(p *inventory.Product, l *inventory.StockLevel) error {
+			if p.IsDeleted() {
+				return inventory.NewProductNotFoundError(p.ID)
+			}
+			return l.Reserve(quantity)
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, q,
+			reservation.ID, reservation.OrderID, reservation.ProductID, reservation.WarehouseID,
+			reservation.Quantity, reservation.ExpiresAt, reservation.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *InventoryPG) ReleaseReservations(ctx context.Context, orderID string) ([]*inventory.Reservation, error) {
+	return r.settleReservations(ctx, orderID, inventory.MovementRelease, func(l *inventory.StockLevel, quantity int) error {
+		l.ReleaseReservation(quantity)
+		return nil
+	})
+}
+
+func (r *InventoryPG) ConfirmReservations(ctx context.Context, orderID string) ([]*inventory.Reservation, error) {
+	return r.settleReservations(ctx, orderID, inventory.MovementSale, func(l *inventory.StockLevel, quantity int) error {
+		return l.Sell(quantity)
+	})
+}
+
+// settleReservations удаляет резервы заказа, применяя change к остатку склада каждого
+// резерва и записывая движение movementType в той же транзакции.
+func (r *InventoryPG) settleReservations(ctx context.Context, orderID string, movementType inventory.MovementType, change func(*inventory.StockLevel, int) error) ([]*inventory.Reservation, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	const q = `DELETE FROM reservations WHERE order_id = $1 RETURNING ` + reservationColumns
+	rows, err := tx.Query(ctx, q, orderID)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := collectReservations(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(reservations) == 0 {
+		return nil, inventory.NewReservationNotFoundError(orderID)
+	}
+
+	for _, reservation := range sortedByProduct(reservations) {
+		quantity := reservation.Quantity
+		_, err := applyMovement(ctx, tx, inventory.NewReservationMovement(movementType, reservation), func(_ *inventory.Product, l *inventory.StockLevel) error {
+			return change(l, quantity)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
 func (r *InventoryPG) GetExpiredReservations(ctx context.Context) ([]*inventory.Reservation, error) {
+	q := `SELECT ` + reservationColumns + ` FROM reservations WHERE expires_at < $1`
+	rows, err := r.pool.Query(ctx, q, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return collectReservations(rows)
+}
+
+func (r *InventoryPG) ReleaseExpiredReservations(ctx context.Context, limit int) ([]*inventory.Reservation, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// SKIP LOCKED позволяет нескольким воркерам чистить резервы параллельно,
+	// не блокируя друг друга и активные резервирования
+	const qSelect = `
+		SELECT ` + reservationColumns + `
+		FROM reservations
+		WHERE expires_at < NOW()
+		ORDER BY expires_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, qSelect, limit)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := collectReservations(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reservations) == 0 {
+		return nil, nil
+	}
+
+	const qDelete = `DELETE FROM reservations WHERE id = $1`
+	for _, reservation := range sortedByProduct(reservations) {
+		movement := inventory.NewReservationMovement(inventory.MovementRelease, reservation)
+		movement.Note = "reservation expired"
+		quantity := reservation.Quantity
+		_, err := applyMovement(ctx, tx, movement, func(_ *inventory.Product, l *inventory.StockLevel) error {
+			l.ReleaseReservation(quantity)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, qDelete, reservation.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+func (r *InventoryPG) GetMovements(ctx context.Context, productID string, limit int) ([]*inventory.StockMovement, error) {
 	const q = `
-		SELECT id, order_id, product_id, quantity, expires_at, created_at
-		FROM reservations WHERE expires_at < $1
+		SELECT id, product_id, warehouse_id, type, available_delta, reserved_delta, available_after, reserved_after,
+		       reason, order_id, reservation_id, note, created_at
+		FROM stock_movements
+		WHERE product_id = $1
+		ORDER BY id DESC
+		LIMIT $2
 	`
-	rows, err := r.pool.Query(ctx, q, time.Now())
+	rows, err := r.pool.Query(ctx, q, productID, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var movements []*inventory.StockMovement
+	for rows.Next() {
+		var movement inventory.StockMovement
+		var movementType, reason string
+		err := rows.Scan(
+			&movement.ID, &movement.ProductID, &movement.WarehouseID, &movementType,
+			&movement.AvailableDelta, &movement.ReservedDelta, &movement.AvailableAfter, &movement.ReservedAfter,
+			&reason, &movement.OrderID, &movement.ReservationID, &movement.Note, &movement.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		movement.Type = inventory.MovementType(movementType)
+		movement.Reason = inventory.AdjustmentReason(reason)
+		movements = append(movements, &movement)
+	}
+
+	return movements, rows.Err()
+}
+
+func (r *InventoryPG) Reconcile(ctx context.Context, apply bool) (*inventory.ReconcileReport, error) {
+	// Счётчики и журнал пишутся в одной транзакции, поэтому для отчёта достаточно снимка;
+	// для исправления товары блокируются, чтобы счётчики не менялись до записи
+	options := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+	if apply {
+		options = pgx.TxOptions{}
+	}
+	tx, err := r.pool.BeginTx(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if apply {
+		if _, err := tx.Exec(ctx, `SELECT id FROM products ORDER BY id FOR UPDATE`); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &inventory.ReconcileReport{Drifts: []inventory.StockDrift{}}
+
+	// Остатки складов сверяются с движениями по товару и складу, итоговые счётчики
+	// товара — со всеми его движениями (такое расхождение идёт с пустым складом)
+	const qLevels = `
+		WITH ledger AS (
+			SELECT product_id, warehouse_id, SUM(available_delta) AS available, SUM(reserved_delta) AS reserved
+			FROM stock_movements
+			GROUP BY product_id, warehouse_id
+		)
+		SELECT p.id, COALESCE(l.warehouse_id, m.warehouse_id), p.sku,
+		       COALESCE(l.available, 0), COALESCE(l.reserved, 0),
+		       COALESCE(m.available, 0), COALESCE(m.reserved, 0)
+		FROM stock_levels l
+		FULL JOIN ledger m ON m.product_id = l.product_id AND m.warehouse_id = l.warehouse_id
+		JOIN products p ON p.id = COALESCE(l.product_id, m.product_id)
+		ORDER BY 1, 2
+	`
+	if err := collectDrifts(ctx, tx, report, qLevels, true); err != nil {
+		return nil, err
+	}
+
+	const qTotals = `
+		SELECT p.id, '', p.sku, p.available, p.reserved,
+		       COALESCE(m.available, 0), COALESCE(m.reserved, 0)
+		FROM products p
+		LEFT JOIN (
+			SELECT product_id, SUM(available_delta) AS available, SUM(reserved_delta) AS reserved
+			FROM stock_movements
+			GROUP BY product_id
+		) m ON m.product_id = p.id
+		ORDER BY p.id
+	`
+	if err := collectDrifts(ctx, tx, report, qTotals, false); err != nil {
+		return nil, err
+	}
+
+	if !apply || len(report.Drifts) == 0 {
+		return report, nil
+	}
+
+	const qFixLevel = `
+		INSERT INTO stock_levels (product_id, warehouse_id, available, reserved, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (product_id, warehouse_id)
+		DO UPDATE SET available = EXCLUDED.available, reserved = EXCLUDED.reserved, updated_at = EXCLUDED.updated_at
+	`
+	const qFixTotals = `UPDATE products SET available = $2, reserved = $3, updated_at = NOW() WHERE id = $1`
+	for _, drift := range report.Drifts {
+		var err error
+		if drift.WarehouseID != "" {
+			_, err = tx.Exec(ctx, qFixLevel, drift.ProductID, drift.WarehouseID, drift.LedgerAvailable, drift.LedgerReserved)
+		} else {
+			_, err = tx.Exec(ctx, qFixTotals, drift.ProductID, drift.LedgerAvailable, drift.LedgerReserved)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// collectDrifts добавляет в отчёт строки запроса q, где счётчики расходятся с журналом.
+// Строки итоговых счётчиков (levels == false) считаются проверенными товарами.
+func collectDrifts(ctx context.Context, tx pgx.Tx, report *inventory.ReconcileReport, q string, levels bool) error {
+	rows, err := tx.Query(ctx, q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var drift inventory.StockDrift
+		err := rows.Scan(&drift.ProductID, &drift.WarehouseID, &drift.SKU, &drift.Available, &drift.Reserved,
+			&drift.LedgerAvailable, &drift.LedgerReserved)
+		if err != nil {
+			return err
+		}
+		addDrift(report, drift, levels)
+	}
+
+	return rows.Err()
+}
+
+// addDrift учитывает в отчёте одну строку сверки: строка итоговых счётчиков
+// (levels == false) — проверенный товар, расхождение с журналом попадает в Drifts.
+func addDrift(report *inventory.ReconcileReport, drift inventory.StockDrift, levels bool) {
+	if !levels {
+		report.Checked++
+	}
+	if drift.Available != drift.LedgerAvailable || drift.Reserved != drift.LedgerReserved {
+		report.Drifts = append(report.Drifts, drift)
+	}
+}
+
+// applyMovement блокирует товар и его остаток на складе movement.WarehouseID, применяет
+// change к остатку склада и в той же транзакции сохраняет остаток, итоговые счётчики
+// товара и движение с фактическим изменением. Движение без изменений не пишется.
+// Если после движения товар опустился до точки заказа, пишется событие stock_low.
+func applyMovement(ctx context.Context, tx pgx.Tx, movement *inventory.StockMovement, change func(*inventory.Product, *inventory.StockLevel) error) (*inventory.Product, error) {
+	q := `SELECT ` + productColumns + ` FROM products WHERE id = $1 FOR UPDATE`
+	product, err := scanProduct(tx.QueryRow(ctx, q, movement.ProductID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, inventory.NewProductNotFoundError(movement.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !product.HasStock() {
+		return nil, inventory.NewValidationError(fmt.Sprintf("%s product %s has no own stock", product.Kind, product.ID))
+	}
+
+	// Остаток на складе создаётся при первом движении; блокировка товара уже
+	// сериализует изменения его остатков
+	const qEnsure = `
+		INSERT INTO stock_levels (product_id, warehouse_id) VALUES ($1, $2)
+		ON CONFLICT (product_id, warehouse_id) DO NOTHING
+	`
+	_, err = tx.Exec(ctx, qEnsure, movement.ProductID, movement.WarehouseID)
+	if isForeignKeyViolation(err, "stock_levels_warehouse_id_fkey") {
+		return nil, inventory.NewWarehouseNotFoundError(movement.WarehouseID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	const qLevel = `
+		SELECT product_id, warehouse_id, available, reserved, updated_at
+		FROM stock_levels
+		WHERE product_id = $1 AND warehouse_id = $2
+		FOR UPDATE
+	`
+	level, err := scanStockLevel(tx.QueryRow(ctx, qLevel, movement.ProductID, movement.WarehouseID))
+	if err != nil {
+		return nil, err
+	}
+
+	available, reserved := level.Available, level.Reserved
+	if err := change(product, level); err != nil {
+		return nil, err
+	}
+	movement.Record(available, reserved, level)
+	if movement.IsEmpty() {
+		return product, nil
+	}
+
+	alert := applyToTotals(product, movement, level.UpdatedAt)
+
+	const qUpdateLevel = `UPDATE stock_levels SET available = $3, reserved = $4, updated_at = $5 WHERE product_id = $1 AND warehouse_id = $2`
+	if _, err := tx.Exec(ctx, qUpdateLevel, level.ProductID, level.WarehouseID, level.Available, level.Reserved, level.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	const qUpdate = `UPDATE products SET available = $2, reserved = $3, updated_at = $4 WHERE id = $1`
+	if _, err := tx.Exec(ctx, qUpdate, product.ID, product.Available, product.Reserved, product.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	const qMovement = `
+		INSERT INTO stock_movements (product_id, warehouse_id, type, available_delta, reserved_delta, available_after, reserved_after,
+		                             reason, order_id, reservation_id, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+	err = tx.QueryRow(ctx, qMovement,
+		movement.ProductID, movement.WarehouseID, string(movement.Type), movement.AvailableDelta, movement.ReservedDelta,
+		movement.AvailableAfter, movement.ReservedAfter, string(movement.Reason),
+		movement.OrderID, movement.ReservationID, movement.Note, movement.CreatedAt,
+	).Scan(&movement.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if alert != nil {
+		alert.MovementID = movement.ID
+		if err := appendStockLow(ctx, tx, alert); err != nil {
+			return nil, err
+		}
+	}
+	return product, nil
+}
+
+// applyToTotals переносит дельты движения в итоговые счётчики товара. Если после
+// движения товар опустился до точки заказа, возвращается событие stock_low со складом
+// и заказом движения, иначе nil.
+func applyToTotals(product *inventory.Product, movement *inventory.StockMovement, updatedAt time.Time) *inventory.StockLow {
+	before := *product
+	product.Available += movement.AvailableDelta
+	product.Reserved += movement.ReservedDelta
+	product.UpdatedAt = updatedAt
+
+	if !inventory.CrossedReorderPoint(&before, product) {
+		return nil
+	}
+	alert := inventory.NewStockLow(product)
+	alert.WarehouseID = movement.WarehouseID
+	alert.OrderID = movement.OrderID
+	return alert
+}
+
+func appendStockLow(ctx context.Context, tx pgx.Tx, alert *inventory.StockLow) error {
+	event, err := outbox.NewStockLowEvent(alert)
+	if err != nil {
+		return err
+	}
+	return appendOutbox(ctx, tx, event)
+}
+
+// sortedByProduct упорядочивает резервы по товару, чтобы транзакции блокировали
+// товары в одном порядке и не попадали во взаимную блокировку.
+func sortedByProduct(reservations []*inventory.Reservation) []*inventory.Reservation {
+	sorted := append([]*inventory.Reservation(nil), reservations...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].ProductID != sorted[j].ProductID {
+			return sorted[i].ProductID < sorted[j].ProductID
+		}
+		return sorted[i].WarehouseID < sorted[j].WarehouseID
+	})
+	return sorted
+}
+
+func scanProduct(row pgx.Row) (*inventory.Product, error) {
+	var product inventory.Product
+	var kind string
+	err := row.Scan(
+		&product.ID, &product.Name, &product.SKU, &kind, &product.Price,
+		&product.Available, &product.Reserved, &product.ReorderPoint, &product.ParentID, &product.Attributes,
+		&product.TaxClass, &product.Weight, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	product.Kind = inventory.ProductKind(kind)
+	return &product, nil
+}
+
+// attributesOrEmpty — колонка attributes не допускает NULL.
+func attributesOrEmpty(attributes map[string]string) map[string]string {
+	if attributes == nil {
+		return map[string]string{}
+	}
+	return attributes
+}
+
+type productQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// loadComponents заполняет состав наборов одним запросом.
+func loadComponents(ctx context.Context, q productQuerier, products []*inventory.Product) error {
+	bundles := make(map[string]*inventory.Product)
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		if product.IsBundle() {
+			bundles[product.ID] = product
+			ids = append(ids, product.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	const qComponents = `
+		SELECT c.bundle_id, c.component_id, p.sku, c.quantity
+		FROM bundle_components c
+		JOIN products p ON p.id = c.component_id
+		WHERE c.bundle_id = ANY($1)
+		ORDER BY c.bundle_id, c.position
+	`
+	rows, err := q.Query(ctx, qComponents, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bundleID string
+		var component inventory.BundleComponent
+		if err := rows.Scan(&bundleID, &component.ProductID, &component.SKU, &component.Quantity); err != nil {
+			return err
+		}
+		bundles[bundleID].Components = append(bundles[bundleID].Components, component)
+	}
+	return rows.Err()
+}
+
+// saveComponents заменяет состав набора; у остальных товаров состава нет.
+func saveComponents(ctx context.Context, tx pgx.Tx, product *inventory.Product) error {
+	if !product.IsBundle() {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM bundle_components WHERE bundle_id = $1`, product.ID); err != nil {
+		return err
+	}
+
+	b := &pgx.Batch{}
+	const q = `INSERT INTO bundle_components (bundle_id, component_id, quantity, position) VALUES ($1, $2, $3, $4)`
+	for i, component := range product.Components {
+		b.Queue(q, product.ID, component.ProductID, component.Quantity, i)
+	}
+	err := tx.SendBatch(ctx, b).Close()
+	if isForeignKeyViolation(err, "bundle_components_component_id_fkey") {
+		return inventory.NewValidationError("unknown bundle component")
+	}
+	return err
+}
+
+func scanStockLevel(row pgx.Row) (*inventory.StockLevel, error) {
+	var level inventory.StockLevel
+	err := row.Scan(&level.ProductID, &level.WarehouseID, &level.Available, &level.Reserved, &level.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+func scanWarehouse(row pgx.Row) (*inventory.Warehouse, error) {
+	var warehouse inventory.Warehouse
+	err := row.Scan(
+		&warehouse.ID, &warehouse.Name, &warehouse.Location.Latitude, &warehouse.Location.Longitude,
+		&warehouse.ShippingCost, &warehouse.Priority, &warehouse.Active, &warehouse.CreatedAt, &warehouse.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func collectReservations(rows pgx.Rows) ([]*inventory.Reservation, error) {
+	defer rows.Close()
+
 	var reservations []*inventory.Reservation
 	for rows.Next() {
 		var reservation inventory.Reservation
 		err := rows.Scan(
-			&reservation.ID, &reservation.OrderID, &reservation.ProductID,
+			&reservation.ID, &reservation.OrderID, &reservation.ProductID, &reservation.WarehouseID,
 			&reservation.Quantity, &reservation.ExpiresAt, &reservation.CreatedAt,
 		)
 		if err != nil {
@@ -171,3 +866,15 @@ func (r *InventoryPG) GetExpiredReservations(ctx context.Context) ([]*inventory.
 
 	return reservations, rows.Err()
 }
+
+// isUniqueViolation сообщает, нарушено ли ограничение уникальности constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+// isForeignKeyViolation сообщает, нарушен ли внешний ключ constraint.
+func isForeignKeyViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.ConstraintName == constraint
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"orderflow/internal/domain/inventory"
+)
+
+func TestApplyToTotalsStockLow(t *testing.T) {
+	updatedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	deletedAt := updatedAt.Add(-time.Hour)
+
+	tests := []struct {
+		name           string
+		product        inventory.Product
+		availableDelta int
+		reservedDelta  int
+		wantAlert      bool
+		wantFree       int
+	}{
+		{"sale crosses reorder point", inventory.Product{Available: 12, ReorderPoint: 10}, -2, 0, true, 10},
+		{"reserve crosses reorder point", inventory.Product{Available: 12, Reserved: 1, ReorderPoint: 10}, 0, 3, true, 8},
+		{"stays above reorder point", inventory.Product{Available: 20, ReorderPoint: 10}, -5, 0, false, 15},
+		{"already low", inventory.Product{Available: 10, ReorderPoint: 10}, -1, 0, false, 9},
+		{"receipt lifts above reorder point", inventory.Product{Available: 5, ReorderPoint: 10}, 20, 0, false, 25},
+		{"no reorder point", inventory.Product{Available: 1}, -1, 0, false, 0},
+		{"deleted product", inventory.Product{Available: 12, ReorderPoint: 10, DeletedAt: &deletedAt}, -5, 0, false, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := tt.product
+			product.ID, product.SKU = "prod-001", "SKU-001"
+			movement := &inventory.StockMovement{
+				ProductID:      product.ID,
+				WarehouseID:    "wh-1",
+				OrderID:        "order-1",
+				AvailableDelta: tt.availableDelta,
+				ReservedDelta:  tt.reservedDelta,
+			}
+
+			alert := applyToTotals(&product, movement, updatedAt)
+
+			if got := product.Available - product.Reserved; got != tt.wantFree {
+				t.Errorf("free after movement = %d, want %d", got, tt.wantFree)
+			}
+			if !product.UpdatedAt.Equal(updatedAt) {
+				t.Errorf("UpdatedAt = %v, want %v", product.UpdatedAt, updatedAt)
+			}
+			if (alert != nil) != tt.wantAlert {
+				t.Fatalf("alert = %+v, want alert = %v", alert, tt.wantAlert)
+			}
+			if alert == nil {
+				return
+			}
+			if alert.ProductID != "prod-001" || alert.WarehouseID != "wh-1" || alert.OrderID != "order-1" ||
+				alert.Free != tt.wantFree || alert.ReorderPoint != product.ReorderPoint {
+				t.Errorf("alert = %+v, want movement warehouse and order with free %d", alert, tt.wantFree)
+			}
+		})
+	}
+}
+
+func TestAddDrift(t *testing.T) {
+	type row struct {
+		drift  inventory.StockDrift
+		levels bool
+	}
+
+	tests := []struct {
+		name        string
+		rows        []row
+		wantChecked int
+		wantDrifts  []inventory.StockDrift
+	}{
+		{"matching counters", []row{
+			{inventory.StockDrift{ProductID: "p1", WarehouseID: "wh-1", Available: 5, LedgerAvailable: 5}, true},
+			{inventory.StockDrift{ProductID: "p1", Available: 5, LedgerAvailable: 5}, false},
+		}, 1, nil},
+		{"level drift does not count as checked product", []row{
+			{inventory.StockDrift{ProductID: "p1", WarehouseID: "wh-1", Available: 7, LedgerAvailable: 5}, true},
+			{inventory.StockDrift{ProductID: "p1", Available: 5, LedgerAvailable: 5}, false},
+		}, 1, []inventory.StockDrift{
+			{ProductID: "p1", WarehouseID: "wh-1", Available: 7, LedgerAvailable: 5},
+		}},
+		{"reserved drift in totals", []row{
+			{inventory.StockDrift{ProductID: "p1", Available: 5, Reserved: 2, LedgerAvailable: 5, LedgerReserved: 1}, false},
+			{inventory.StockDrift{ProductID: "p2", Available: 3, LedgerAvailable: 3}, false},
+		}, 2, []inventory.StockDrift{
+			{ProductID: "p1", Available: 5, Reserved: 2, LedgerAvailable: 5, LedgerReserved: 1},
+		}},
+		{"level missing from stock_levels", []row{
+			{inventory.StockDrift{ProductID: "p1", WarehouseID: "wh-2", LedgerAvailable: 4}, true},
+		}, 0, []inventory.StockDrift{
+			{ProductID: "p1", WarehouseID: "wh-2", LedgerAvailable: 4},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &inventory.ReconcileReport{}
+			for _, r := range tt.rows {
+				addDrift(report, r.drift, r.levels)
+			}
+
+			if report.Checked != tt.wantChecked {
+				t.Errorf("Checked = %d, want %d", report.Checked, tt.wantChecked)
+			}
+			if !reflect.DeepEqual(report.Drifts, tt.wantDrifts) {
+				t.Errorf("Drifts = %+v, want %+v", report.Drifts, tt.wantDrifts)
+			}
+		})
+	}
+}
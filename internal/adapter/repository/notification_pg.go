@@ -3,11 +3,13 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"orderflow/internal/domain/notification"
+	"orderflow/internal/domain/outbox"
 )
 
 type NotificationPG struct {
@@ -18,161 +20,259 @@ func NewNotificationPG(pool *pgxpool.Pool) *NotificationPG {
 	return &NotificationPG{pool: pool}
 }
 
+const notificationColumns = `
+	id, customer_id, COALESCE(order_id, ''), type, channel, recipient, locale, status, subject, message, html_message, metadata,
+	attempts, next_attempt_at, last_error, COALESCE(idempotency_key, ''), sent_at, created_at, updated_at
+`
+
+// CreateNotification и UpdateNotification пишут событие notification.<status>
+// в outbox в той же транзакции. Пустой idempotency_key хранится как NULL и не участвует
+// в уникальном индексе; пустой order_id (уведомление не о заказе) — тоже NULL.
 func (r *NotificationPG) CreateNotification(ctx context.Context, notificationEntity *notification.Notification) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	const q = `
-		INSERT INTO notifications (id, customer_id, order_id, type, channel, status, subject, message, metadata, sent_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO notifications (id, customer_id, order_id, type, channel, recipient, locale, status, subject, message, html_message, metadata,
+		                           attempts, next_attempt_at, last_error, idempotency_key, sent_at, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NULLIF($16, ''), $17, $18, $19)
+		ON CONFLICT (idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
 	`
-	_, err := r.pool.Exec(ctx, q,
+	ct, err := tx.Exec(ctx, q,
 		notificationEntity.ID, notificationEntity.CustomerID, notificationEntity.OrderID,
-		string(notificationEntity.Type), string(notificationEntity.Channel), string(notificationEntity.Status),
-		notificationEntity.Subject, notificationEntity.Message, notificationEntity.Metadata,
-		notificationEntity.SentAt, notificationEntity.CreatedAt, notificationEntity.UpdatedAt,
+		string(notificationEntity.Type), string(notificationEntity.Channel),
+		notificationEntity.Recipient, notificationEntity.Locale, string(notificationEntity.Status),
+		notificationEntity.Subject, notificationEntity.Message, notificationEntity.HTML, notificationEntity.Metadata,
+		notificationEntity.Attempts, notificationEntity.NextAttemptAt, notificationEntity.LastError,
+		notificationEntity.IdempotencyKey, notificationEntity.SentAt, notificationEntity.CreatedAt, notificationEntity.UpdatedAt,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return notification.NewDuplicateError(notificationEntity.IdempotencyKey)
+	}
+
+	if notificationEntity.Status != notification.StatusPending {
+		if err := appendNotificationEvent(ctx, tx, notificationEntity); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *NotificationPG) GetNotification(ctx context.Context, id string) (*notification.Notification, error) {
-	const q = `
-		SELECT id, customer_id, order_id, type, channel, status, subject, message, metadata, sent_at, created_at, updated_at
-		FROM notifications WHERE id = $1
-	`
-	row := r.pool.QueryRow(ctx, q, id)
-
-	var notificationEntity notification.Notification
-	var notificationType, channel, status string
-	err := row.Scan(
-		&notificationEntity.ID, &notificationEntity.CustomerID, &notificationEntity.OrderID,
-		&notificationType, &channel, &status, &notificationEntity.Subject, &notificationEntity.Message,
-		&notificationEntity.Metadata, &notificationEntity.SentAt, &notificationEntity.CreatedAt, &notificationEntity.UpdatedAt,
-	)
+	q := `SELECT ` + notificationColumns + ` FROM notifications WHERE id = $1`
+	notificationEntity, err := scanNotification(r.pool.QueryRow(ctx, q, id))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, notification.NewNotFoundError(id)
 	}
 	if err != nil {
 		return nil, err
 	}
-
-	notificationEntity.Type = notification.Type(notificationType)
-	notificationEntity.Channel = notification.Channel(channel)
-	notificationEntity.Status = notification.Status(status)
-	return &notificationEntity, nil
+	return notificationEntity, nil
 }
 
 func (r *NotificationPG) GetNotificationsByOrderID(ctx context.Context, orderID string) ([]*notification.Notification, error) {
-	const q = `
-		SELECT id, customer_id, order_id, type, channel, status, subject, message, metadata, sent_at, created_at, updated_at
-		FROM notifications WHERE order_id = $1 ORDER BY created_at DESC
-	`
-	rows, err := r.pool.Query(ctx, q, orderID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var notifications []*notification.Notification
-	for rows.Next() {
-		var notificationEntity notification.Notification
-		var notificationType, channel, status string
-		err := rows.Scan(
-			&notificationEntity.ID, &notificationEntity.CustomerID, &notificationEntity.OrderID,
-			&notificationType, &channel, &status, &notificationEntity.Subject, &notificationEntity.Message,
-			&notificationEntity.Metadata, &notificationEntity.SentAt, &notificationEntity.CreatedAt, &notificationEntity.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		notificationEntity.Type = notification.Type(notificationType)
-		notificationEntity.Channel = notification.Channel(channel)
-		notificationEntity.Status = notification.Status(status)
-		notifications = append(notifications, &notificationEntity)
-	}
-
-	return notifications, rows.Err()
+	q := `SELECT ` + notificationColumns + ` FROM notifications WHERE order_id = $1 ORDER BY created_at DESC`
+	return r.queryNotifications(ctx, q, orderID)
 }
 
 func (r *NotificationPG) UpdateNotification(ctx context.Context, notificationEntity *notification.Notification) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	const q = `
-		UPDATE notifications
-		SET customer_id = $2, order_id = $3, type = $4, channel = $5, status = $6,
-		    subject = $7, message = $8, metadata = $9, sent_at = $10, updated_at = $11
-		WHERE id = $1
+		UPDATE notifications n
+		SET customer_id = $2, order_id = NULLIF($3, ''), type = $4, channel = $5, status = $6,
+		    subject = $7, message = $8, metadata = $9, sent_at = $10, updated_at = $11,
+		    recipient = $12, locale = $13, html_message = $14,
+		    attempts = $15, next_attempt_at = $16, last_error = $17
+		FROM (SELECT id, status FROM notifications WHERE id = $1 FOR UPDATE) prev
+		WHERE n.id = prev.id
+		RETURNING prev.status
 	`
-	ct, err := r.pool.Exec(ctx, q,
+	var previousStatus string
+	err = tx.QueryRow(ctx, q,
 		notificationEntity.ID, notificationEntity.CustomerID, notificationEntity.OrderID,
 		string(notificationEntity.Type), string(notificationEntity.Channel), string(notificationEntity.Status),
 		notificationEntity.Subject, notificationEntity.Message, notificationEntity.Metadata,
 		notificationEntity.SentAt, notificationEntity.UpdatedAt,
-	)
+		notificationEntity.Recipient, notificationEntity.Locale, notificationEntity.HTML,
+		notificationEntity.Attempts, notificationEntity.NextAttemptAt, notificationEntity.LastError,
+	).Scan(&previousStatus)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return notification.NewNotFoundError(notificationEntity.ID)
+	}
 	if err != nil {
 		return err
 	}
-	if ct.RowsAffected() == 0 {
-		return notification.NewNotFoundError(notificationEntity.ID)
+
+	if notification.Status(previousStatus) != notificationEntity.Status && notificationEntity.Status != notification.StatusPending {
+		if err := appendNotificationEvent(ctx, tx, notificationEntity); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func appendNotificationEvent(ctx context.Context, tx pgx.Tx, notificationEntity *notification.Notification) error {
+	event, err := outbox.NewNotificationEvent(notificationEntity)
+	if err != nil {
+		return err
 	}
-	return nil
+	return appendOutbox(ctx, tx, event)
 }
 
 func (r *NotificationPG) GetNotifications(ctx context.Context) ([]*notification.Notification, error) {
+	q := `SELECT ` + notificationColumns + ` FROM notifications ORDER BY created_at DESC`
+	return r.queryNotifications(ctx, q)
+}
+
+func (r *NotificationPG) GetNotificationsByStatus(ctx context.Context, status notification.Status, limit int) ([]*notification.Notification, error) {
+	q := `SELECT ` + notificationColumns + ` FROM notifications WHERE status = $1 ORDER BY updated_at DESC LIMIT $2`
+	return r.queryNotifications(ctx, q, string(status), limit)
+}
+
+func (r *NotificationPG) GetNotificationByIdempotencyKey(ctx context.Context, key string) (*notification.Notification, error) {
+	q := `SELECT ` + notificationColumns + ` FROM notifications WHERE idempotency_key = $1`
+	notificationEntity, err := scanNotification(r.pool.QueryRow(ctx, q, key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notification.NewNotFoundError(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return notificationEntity, nil
+}
+
+func (r *NotificationPG) GetDigestDueAt(ctx context.Context, customerID string, channel notification.Channel) (*time.Time, error) {
 	const q = `
-		SELECT id, customer_id, order_id, type, channel, status, subject, message, metadata, sent_at, created_at, updated_at
-		FROM notifications ORDER BY created_at DESC
+		SELECT MIN(next_attempt_at) FROM notifications
+		WHERE customer_id = $1 AND channel = $2 AND status = 'batched'
 	`
-	rows, err := r.pool.Query(ctx, q)
-	if err != nil {
+	var dueAt *time.Time
+	if err := r.pool.QueryRow(ctx, q, customerID, string(channel)).Scan(&dueAt); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return dueAt, nil
+}
 
-	var notifications []*notification.Notification
-	for rows.Next() {
-		var notificationEntity notification.Notification
-		var notificationType, channel, status string
-		err := rows.Scan(
-			&notificationEntity.ID, &notificationEntity.CustomerID, &notificationEntity.OrderID,
-			&notificationType, &channel, &status, &notificationEntity.Subject, &notificationEntity.Message,
-			&notificationEntity.Metadata, &notificationEntity.SentAt, &notificationEntity.CreatedAt, &notificationEntity.UpdatedAt,
+func (r *NotificationPG) ClaimDueNotifications(ctx context.Context, limit int, lease time.Duration) ([]*notification.Notification, error) {
+	return r.claimDue(ctx, notification.StatusFailed, limit, lease)
+}
+
+// ClaimDueDigests ограничивает limit числом получателей, а не строк: LIMIT по строкам
+// разрезал бы сводку клиента между проходами, и он получил бы несколько писем вместо одного.
+// Строку группы, заблокированную параллельным воркером, SKIP LOCKED пропускает — в худшем
+// случае сводка уйдёт двумя частями, но ни одно уведомление не потеряется и не повторится.
+func (r *NotificationPG) ClaimDueDigests(ctx context.Context, limit int, lease time.Duration) ([]*notification.Notification, error) {
+	q := `
+		WITH due_groups AS (
+			SELECT customer_id, channel FROM notifications
+			WHERE status = $3 AND next_attempt_at <= NOW()
+			GROUP BY customer_id, channel
+			ORDER BY MIN(next_attempt_at), customer_id, channel
+			LIMIT $1
 		)
-		if err != nil {
-			return nil, err
-		}
-		notificationEntity.Type = notification.Type(notificationType)
-		notificationEntity.Channel = notification.Channel(channel)
-		notificationEntity.Status = notification.Status(status)
-		notifications = append(notifications, &notificationEntity)
+		UPDATE notifications
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT n.id FROM notifications n
+			JOIN due_groups g ON g.customer_id = n.customer_id AND g.channel = n.channel
+			WHERE n.status = $3 AND n.next_attempt_at <= NOW()
+			FOR UPDATE OF n SKIP LOCKED
+		)
+		RETURNING ` + notificationColumns
+	return r.claim(ctx, q, notification.StatusBatched, limit, lease)
+}
+
+func (r *NotificationPG) claimDue(ctx context.Context, status notification.Status, limit int, lease time.Duration) ([]*notification.Notification, error) {
+	// SKIP LOCKED позволяет нескольким воркерам разбирать очередь параллельно;
+	// сдвиг next_attempt_at на lease — аренда до записи результата попытки
+	q := `
+		UPDATE notifications
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM notifications
+			WHERE status = $3 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, customer_id, channel
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationColumns
+	return r.claim(ctx, q, status, limit, lease)
+}
+
+// claim выполняет запрос аренды q с параметрами limit, lease и status в одной транзакции.
+func (r *NotificationPG) claim(ctx context.Context, q string, status notification.Status, limit int, lease time.Duration) ([]*notification.Notification, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	return notifications, rows.Err()
+	rows, err := tx.Query(ctx, q, limit, lease.Seconds(), string(status))
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := collectNotifications(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return notifications, nil
 }
 
-func (r *NotificationPG) GetFailedNotifications(ctx context.Context) ([]*notification.Notification, error) {
-	const q = `
-		SELECT id, customer_id, order_id, type, channel, status, subject, message, metadata, sent_at, created_at, updated_at
-		FROM notifications WHERE status = 'failed' ORDER BY created_at DESC
-	`
-	rows, err := r.pool.Query(ctx, q)
+func (r *NotificationPG) queryNotifications(ctx context.Context, q string, args ...any) ([]*notification.Notification, error) {
+	rows, err := r.pool.Query(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
+	return collectNotifications(rows)
+}
+
+func collectNotifications(rows pgx.Rows) ([]*notification.Notification, error) {
 	defer rows.Close()
 
 	var notifications []*notification.Notification
 	for rows.Next() {
-		var notificationEntity notification.Notification
-		var notificationType, channel, status string
-		err := rows.Scan(
-			&notificationEntity.ID, &notificationEntity.CustomerID, &notificationEntity.OrderID,
-			&notificationType, &channel, &status, &notificationEntity.Subject, &notificationEntity.Message,
-			&notificationEntity.Metadata, &notificationEntity.SentAt, &notificationEntity.CreatedAt, &notificationEntity.UpdatedAt,
-		)
+		notificationEntity, err := scanNotification(rows)
 		if err != nil {
 			return nil, err
 		}
-		notificationEntity.Type = notification.Type(notificationType)
-		notificationEntity.Channel = notification.Channel(channel)
-		notificationEntity.Status = notification.Status(status)
-		notifications = append(notifications, &notificationEntity)
+		notifications = append(notifications, notificationEntity)
 	}
-
 	return notifications, rows.Err()
 }
+
+func scanNotification(row pgx.Row) (*notification.Notification, error) {
+	var notificationEntity notification.Notification
+	var notificationType, channel, status string
+	err := row.Scan(
+		&notificationEntity.ID, &notificationEntity.CustomerID, &notificationEntity.OrderID,
+		&notificationType, &channel, &notificationEntity.Recipient, &notificationEntity.Locale, &status,
+		&notificationEntity.Subject, &notificationEntity.Message, &notificationEntity.HTML, &notificationEntity.Metadata,
+		&notificationEntity.Attempts, &notificationEntity.NextAttemptAt, &notificationEntity.LastError,
+		&notificationEntity.IdempotencyKey, &notificationEntity.SentAt, &notificationEntity.CreatedAt, &notificationEntity.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	notificationEntity.Type = notification.Type(notificationType)
+	notificationEntity.Channel = notification.Channel(channel)
+	notificationEntity.Status = notification.Status(status)
+	return &notificationEntity, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/orderevent"
+)
+
+type OrderEventPG struct {
+	pool *pgxpool.Pool
+}
+
+func NewOrderEventPG(pool *pgxpool.Pool) *OrderEventPG {
+	return &OrderEventPG{pool: pool}
+}
+
+func (r *OrderEventPG) Append(ctx context.Context, e *orderevent.Event) error {
+	const q = `
+		INSERT INTO order_step_events (workflow_id, seq, order_id, step, step_status, order_status, error_code, error, occurred_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9)
+		ON CONFLICT (workflow_id, seq) DO NOTHING
+		RETURNING id
+	`
+	err := r.pool.QueryRow(ctx, q,
+		e.WorkflowID, e.Seq, e.OrderID, e.Step, e.StepStatus, string(e.OrderStatus), e.ErrorCode, e.Error, e.OccurredAt,
+	).Scan(&e.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // событие уже записано предыдущей попыткой
+	}
+	return err
+}
+
+func (r *OrderEventPG) ListAfter(ctx context.Context, workflowID string, afterID int64, limit int) ([]*orderevent.Event, error) {
+	const q = `
+		SELECT id, workflow_id, seq, COALESCE(order_id, ''), step, step_status, order_status,
+		       COALESCE(error_code, ''), COALESCE(error, ''), occurred_at
+		FROM order_step_events
+		WHERE workflow_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`
+	rows, err := r.pool.Query(ctx, q, workflowID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*orderevent.Event
+	for rows.Next() {
+		var e orderevent.Event
+		var orderStatus string
+		if err := rows.Scan(&e.ID, &e.WorkflowID, &e.Seq, &e.OrderID, &e.Step, &e.StepStatus, &orderStatus,
+			&e.ErrorCode, &e.Error, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		e.OrderStatus = order.Status(orderStatus)
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// Listen держит отдельное соединение из пула на всё время подписки. После выхода
+// соединение закрывается, чтобы в пул не вернулось соединение с активным LISTEN.
+func (r *OrderEventPG) Listen(ctx context.Context, notify func(workflowID string, id int64)) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{orderevent.Channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var payload struct {
+			ID         int64  `json:"id"`
+			WorkflowID string `json:"workflow_id"`
+		}
+		if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+			continue
+		}
+		notify(payload.WorkflowID, payload.ID)
+	}
+}
@@ -3,12 +3,21 @@ package repository
 import (
 	"context"
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/outbox"
+	"orderflow/internal/domain/promotion"
+)
+
+// Значения order_addresses.kind
+const (
+	addressShipping = "shipping"
+	addressBilling  = "billing"
 )
 
 type OrderPG struct {
@@ -25,12 +34,17 @@ func (r *OrderPG) Create(ctx context.Context, o *order.Order) error {
 	defer func() { _ = tx.Rollback(ctx) }()
 
 	const qOrder = `
-		INSERT INTO orders (id, customer_id, status, total_amount, payment_id, failure_reason, created_at, updated_at, completed_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		INSERT INTO orders (id, customer_id, status, total_amount, payment_id, failure_reason, created_at, updated_at, completed_at, workflow_id,
+		                    subtotal, discount_amount, coupon_code, tax_jurisdiction, shipping_method, shipping_amount)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,NULLIF($10, ''),$11,$12,NULLIF($13, ''),NULLIF($14, ''),NULLIF($15, ''),$16)
 	`
 	_, err = tx.Exec(ctx, qOrder,
-		o.ID, o.CustomerID, string(o.Status), o.TotalAmount, nil, nil, o.CreatedAt, o.UpdatedAt, o.CompletedAt,
+		o.ID, o.CustomerID, string(o.Status), o.TotalAmount, nil, nil, o.CreatedAt, o.UpdatedAt, o.CompletedAt, o.WorkflowID,
+		o.Subtotal, o.DiscountAmount, o.CouponCode, o.TaxJurisdiction, o.ShippingMethod, o.ShippingAmount,
 	)
+	if isUniqueViolation(err, "orders_workflow_id_key") {
+		return order.NewDuplicateWorkflowError(o.WorkflowID)
+	}
 	if err != nil {
 		return err
 	}
@@ -43,24 +57,96 @@ func (r *OrderPG) Create(ctx context.Context, o *order.Order) error {
 	for _, it := range o.Items {
 		b.Queue(qItem, o.ID, it.ProductID, it.Name, it.Quantity, it.Price)
 	}
+	const qAdjustment = `
+		INSERT INTO order_adjustments (order_id, promotion_id, code, type, product_id, amount, description)
+		VALUES ($1,$2,NULLIF($3, ''),$4,NULLIF($5, ''),$6,$7)
+	`
+	for _, adjustment := range o.Adjustments {
+		b.Queue(qAdjustment, o.ID, adjustment.PromotionID, adjustment.Code, adjustment.Type, adjustment.ProductID,
+			adjustment.Amount, adjustment.Description)
+	}
+	const qAddress = `
+		INSERT INTO order_addresses (order_id, kind, name, line1, line2, city, region, postal_code, country, phone)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+	`
+	addresses := []struct {
+		kind    string
+		address *order.Address
+	}{{addressShipping, o.ShippingAddress}, {addressBilling, o.BillingAddress}}
+	for _, a := range addresses {
+		if a.address != nil {
+			b.Queue(qAddress, o.ID, a.kind, a.address.Name, a.address.Line1, a.address.Line2, a.address.City,
+				a.address.Region, a.address.PostalCode, a.address.Country, a.address.Phone)
+		}
+	}
 	br := tx.SendBatch(ctx, b)
 	if err := br.Close(); err != nil {
 		return err
 	}
 
+	if err := reserveRedemptions(ctx, tx, o); err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
+// reserveRedemptions резервирует погашение каждого купона из скидок заказа. Строка
+// промоакции блокируется, чтобы параллельные заказы не превысили лимит использований.
+func reserveRedemptions(ctx context.Context, tx pgx.Tx, o *order.Order) error {
+	reserved := make(map[string]bool)
+	for _, adjustment := range o.Adjustments {
+		if adjustment.Code == "" || reserved[adjustment.PromotionID] {
+			continue
+		}
+		reserved[adjustment.PromotionID] = true
+
+		var usageLimit, perCustomerLimit int
+		err := tx.QueryRow(ctx, `SELECT usage_limit, per_customer_limit FROM promotions WHERE id=$1 FOR UPDATE`,
+			adjustment.PromotionID).Scan(&usageLimit, &perCustomerLimit)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return promotion.NewCouponError(adjustment.Code, "unknown coupon")
+		}
+		if err != nil {
+			return err
+		}
+
+		var usage promotion.Usage
+		if err := tx.QueryRow(ctx, qCouponUsage, adjustment.PromotionID, o.CustomerID).Scan(&usage.Total, &usage.Customer); err != nil {
+			return err
+		}
+		if usageLimit > 0 && usage.Total >= usageLimit {
+			return promotion.NewCouponError(adjustment.Code, "usage limit reached")
+		}
+		if perCustomerLimit > 0 && usage.Customer >= perCustomerLimit {
+			return promotion.NewCouponError(adjustment.Code, "customer usage limit reached")
+		}
+
+		const qRedemption = `
+			INSERT INTO coupon_redemptions (promotion_id, order_id, customer_id, code, status)
+			VALUES ($1,$2,$3,$4,'reserved')
+		`
+		if _, err := tx.Exec(ctx, qRedemption, adjustment.PromotionID, o.ID, o.CustomerID, adjustment.Code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *OrderPG) GetByID(ctx context.Context, id string) (*order.Order, error) {
 	const qOrder = `
-		SELECT id, customer_id, status, total_amount, payment_id, failure_reason, created_at, updated_at, completed_at
+		SELECT id, customer_id, status, total_amount, COALESCE(payment_id, ''), COALESCE(failure_reason, ''),
+		       created_at, updated_at, completed_at, COALESCE(workflow_id, ''),
+		       subtotal, discount_amount, COALESCE(coupon_code, ''), COALESCE(tax_jurisdiction, ''), tax_amount,
+		       COALESCE(shipping_method, ''), shipping_amount
 		FROM orders WHERE id=$1
 	`
 	row := r.pool.QueryRow(ctx, qOrder, id)
 
 	var o order.Order
 	var status string
-	err := row.Scan(&o.ID, &o.CustomerID, &status, &o.TotalAmount, &o.PaymentID, &o.FailureReason, &o.CreatedAt, &o.UpdatedAt, &o.CompletedAt)
+	err := row.Scan(&o.ID, &o.CustomerID, &status, &o.TotalAmount, &o.PaymentID, &o.FailureReason, &o.CreatedAt, &o.UpdatedAt, &o.CompletedAt, &o.WorkflowID,
+		&o.Subtotal, &o.DiscountAmount, &o.CouponCode, &o.TaxJurisdiction, &o.TaxAmount, &o.ShippingMethod, &o.ShippingAmount)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, order.NewNotFoundError(id)
 	}
@@ -86,46 +172,329 @@ func (r *OrderPG) GetByID(ctx context.Context, id string) (*order.Order, error)
 		}
 		o.Items = append(o.Items, it)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const qAllocation = `
+		SELECT product_id, warehouse_id, quantity
+		FROM order_allocations WHERE order_id=$1 ORDER BY id
+	`
+	rows, err = r.pool.Query(ctx, qAllocation, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line order.AllocationLine
+		if err := rows.Scan(&line.ProductID, &line.WarehouseID, &line.Quantity); err != nil {
+			return nil, err
+		}
+		o.Allocation = append(o.Allocation, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const qComponents = `
+		SELECT bundle_id, product_id, sku, quantity
+		FROM order_item_components WHERE order_id=$1 ORDER BY id
+	`
+	rows, err = r.pool.Query(ctx, qComponents, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := make(map[string][]order.ItemComponent)
+	for rows.Next() {
+		var bundleID string
+		var component order.ItemComponent
+		if err := rows.Scan(&bundleID, &component.ProductID, &component.SKU, &component.Quantity); err != nil {
+			return nil, err
+		}
+		components[bundleID] = append(components[bundleID], component)
+	}
+	for i := range o.Items {
+		o.Items[i].Components = components[o.Items[i].ProductID]
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const qAdjustments = `
+		SELECT promotion_id, COALESCE(code, ''), type, COALESCE(product_id, ''), amount, description
+		FROM order_adjustments WHERE order_id=$1 ORDER BY id
+	`
+	rows, err = r.pool.Query(ctx, qAdjustments, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var adjustment order.Adjustment
+		if err := rows.Scan(&adjustment.PromotionID, &adjustment.Code, &adjustment.Type, &adjustment.ProductID,
+			&adjustment.Amount, &adjustment.Description); err != nil {
+			return nil, err
+		}
+		o.Adjustments = append(o.Adjustments, adjustment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const qTaxLines = `
+		SELECT product_id, tax_class, jurisdiction, name, rate, taxable_amount, amount
+		FROM order_tax_lines WHERE order_id=$1 ORDER BY id
+	`
+	rows, err = r.pool.Query(ctx, qTaxLines, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line order.TaxLine
+		if err := rows.Scan(&line.ProductID, &line.TaxClass, &line.Jurisdiction, &line.Name, &line.Rate,
+			&line.TaxableAmount, &line.Amount); err != nil {
+			return nil, err
+		}
+		o.TaxLines = append(o.TaxLines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const qAddresses = `
+		SELECT kind, name, line1, line2, city, region, postal_code, country, phone
+		FROM order_addresses WHERE order_id=$1
+	`
+	rows, err = r.pool.Query(ctx, qAddresses, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var address order.Address
+		if err := rows.Scan(&kind, &address.Name, &address.Line1, &address.Line2, &address.City, &address.Region,
+			&address.PostalCode, &address.Country, &address.Phone); err != nil {
+			return nil, err
+		}
+		switch kind {
+		case addressShipping:
+			o.ShippingAddress = &address
+		case addressBilling:
+			o.BillingAddress = &address
+		}
+	}
 	return &o, rows.Err()
 }
 
+func (r *OrderPG) GetByWorkflowID(ctx context.Context, workflowID string) (*order.Order, error) {
+	var id string
+	err := r.pool.QueryRow(ctx, `SELECT id FROM orders WHERE workflow_id=$1`, workflowID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, order.NewNotFoundError(workflowID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// SetAllocation заменяет строки распределения заказа целиком: повторное резервирование
+// после отмены оплаты может собрать заказ с других складов.
+func (r *OrderPG) SetAllocation(ctx context.Context, id string, lines []order.AllocationLine) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM orders WHERE id=$1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return order.NewNotFoundError(id)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM order_allocations WHERE order_id=$1`, id); err != nil {
+		return err
+	}
+
+	b := &pgx.Batch{}
+	const qLine = `
+		INSERT INTO order_allocations (order_id, product_id, warehouse_id, quantity)
+		VALUES ($1,$2,$3,$4)
+	`
+	for _, line := range lines {
+		b.Queue(qLine, id, line.ProductID, line.WarehouseID, line.Quantity)
+	}
+	br := tx.SendBatch(ctx, b)
+	if err := br.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetItemComponents, как и SetAllocation, заменяет состав наборов целиком при повторном резервировании.
+func (r *OrderPG) SetItemComponents(ctx context.Context, id string, components map[string][]order.ItemComponent) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM orders WHERE id=$1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return order.NewNotFoundError(id)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM order_item_components WHERE order_id=$1`, id); err != nil {
+		return err
+	}
+
+	bundleIDs := make([]string, 0, len(components))
+	for bundleID := range components {
+		bundleIDs = append(bundleIDs, bundleID)
+	}
+	sort.Strings(bundleIDs)
+
+	b := &pgx.Batch{}
+	const qComponent = `
+		INSERT INTO order_item_components (order_id, bundle_id, product_id, sku, quantity)
+		VALUES ($1,$2,$3,$4,$5)
+	`
+	for _, bundleID := range bundleIDs {
+		for _, component := range components[bundleID] {
+			b.Queue(qComponent, id, bundleID, component.ProductID, component.SKU, component.Quantity)
+		}
+	}
+	br := tx.SendBatch(ctx, b)
+	if err := br.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetTax заменяет налоговые строки заказа и вместе с ними записывает сумму налога и итог.
+func (r *OrderPG) SetTax(ctx context.Context, o *order.Order) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	ct, err := tx.Exec(ctx, `UPDATE orders SET tax_amount=$2, total_amount=$3, updated_at=NOW() WHERE id=$1`,
+		o.ID, o.TaxAmount, o.TotalAmount)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return order.NewNotFoundError(o.ID)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM order_tax_lines WHERE order_id=$1`, o.ID); err != nil {
+		return err
+	}
+
+	b := &pgx.Batch{}
+	const qLine = `
+		INSERT INTO order_tax_lines (order_id, product_id, tax_class, jurisdiction, name, rate, taxable_amount, amount)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	`
+	for _, line := range o.TaxLines {
+		b.Queue(qLine, o.ID, line.ProductID, line.TaxClass, line.Jurisdiction, line.Name, line.Rate,
+			line.TaxableAmount, line.Amount)
+	}
+	br := tx.SendBatch(ctx, b)
+	if err := br.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Update, UpdateStatus и SetFailure пишут событие в outbox в той же транзакции,
+// если статус заказа изменился.
 func (r *OrderPG) Update(ctx context.Context, o *order.Order) error {
 	const q = `
-		UPDATE orders
+		UPDATE orders o
 		SET customer_id=$2, status=$3, total_amount=$4, payment_id=$5, failure_reason=$6, updated_at=$7, completed_at=$8
-		WHERE id=$1
+		FROM (SELECT id, status FROM orders WHERE id=$1 FOR UPDATE) prev
+		WHERE o.id = prev.id
+		RETURNING prev.status, o.customer_id, o.status, o.total_amount, COALESCE(o.payment_id, ''), COALESCE(o.failure_reason, ''), o.updated_at
 	`
-	_, err := r.pool.Exec(ctx, q,
+	return r.updateWithOutbox(ctx, o.ID, q,
 		o.ID, o.CustomerID, string(o.Status), o.TotalAmount, o.PaymentID, o.FailureReason, time.Now(), o.CompletedAt,
 	)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return order.NewNotFoundError(o.ID)
-	}
-	return err
 }
 
 func (r *OrderPG) UpdateStatus(ctx context.Context, id string, st order.Status) error {
-	const q = `UPDATE orders SET status=$2, updated_at=NOW() WHERE id=$1`
-	ct, err := r.pool.Exec(ctx, q, id, string(st))
+	const q = `
+		UPDATE orders o SET status=$2, updated_at=NOW()
+		FROM (SELECT id, status FROM orders WHERE id=$1 FOR UPDATE) prev
+		WHERE o.id = prev.id
+		RETURNING prev.status, o.customer_id, o.status, o.total_amount, COALESCE(o.payment_id, ''), COALESCE(o.failure_reason, ''), o.updated_at
+	`
+	return r.updateWithOutbox(ctx, id, q, id, string(st))
+}
+
+func (r *OrderPG) SetFailure(ctx context.Context, id, reason string) error {
+	const q = `
+		UPDATE orders o SET status='failed', failure_reason=$2, updated_at=NOW()
+		FROM (SELECT id, status FROM orders WHERE id=$1 FOR UPDATE) prev
+		WHERE o.id = prev.id
+		RETURNING prev.status, o.customer_id, o.status, o.total_amount, COALESCE(o.payment_id, ''), COALESCE(o.failure_reason, ''), o.updated_at
+	`
+	return r.updateWithOutbox(ctx, id, q, id, reason)
+}
+
+// updateWithOutbox выполняет UPDATE, который возвращает предыдущий и новый статус заказа
+// и момент изменения.
+func (r *OrderPG) updateWithOutbox(ctx context.Context, id, q string, args ...interface{}) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
-	if ct.RowsAffected() == 0 {
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	payload := outbox.OrderStatusPayload{OrderID: id}
+	var previousStatus, status string
+	var updatedAt time.Time
+	err = tx.QueryRow(ctx, q, args...).Scan(&previousStatus, &payload.CustomerID, &status, &payload.TotalAmount,
+		&payload.PaymentID, &payload.FailureReason, &updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
 		return order.NewNotFoundError(id)
 	}
-	return nil
-}
-
-func (r *OrderPG) SetFailure(ctx context.Context, id, reason string) error {
-	const q = `UPDATE orders SET status='failed', failure_reason=$2, updated_at=NOW() WHERE id=$1`
-	ct, err := r.pool.Exec(ctx, q, id, reason)
 	if err != nil {
 		return err
 	}
-	if ct.RowsAffected() == 0 {
-		return order.NewNotFoundError(id)
+
+	if previousStatus != status {
+		payload.PreviousStatus = order.Status(previousStatus)
+		payload.Status = order.Status(status)
+
+		event, err := outbox.NewOrderStatusEvent(payload, updatedAt)
+		if err != nil {
+			return err
+		}
+		if err := appendOutbox(ctx, tx, event); err != nil {
+			return err
+		}
 	}
-	return nil
+
+	return tx.Commit(ctx)
 }
 
 func (r *OrderPG) GetByCustomerID(ctx context.Context, customerID string) ([]*order.Order, error) {
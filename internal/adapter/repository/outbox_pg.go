@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"orderflow/internal/domain/outbox"
+)
+
+// outboxRelayLockID — ключ advisory-блокировки, под которой релей забирает пачку: выбор
+// событий идёт по одному и тем самым сохраняет порядок событий внутри агрегата.
+const outboxRelayLockID = 7_301_001
+
+type OutboxPG struct {
+	pool *pgxpool.Pool
+}
+
+func NewOutboxPG(pool *pgxpool.Pool) *OutboxPG {
+	return &OutboxPG{pool: pool}
+}
+
+// appendOutbox пишет событие в транзакции изменения состояния. Повтор того же
+// перехода (тот же dedup_key) игнорируется.
+func appendOutbox(ctx context.Context, tx pgx.Tx, e *outbox.Event) error {
+	const q = `
+		INSERT INTO outbox (dedup_key, aggregate_type, aggregate_id, event_type, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (dedup_key) DO NOTHING
+	`
+	_, err := tx.Exec(ctx, q, e.DedupKey, e.AggregateType, e.AggregateID, e.Type, []byte(e.Payload), e.CreatedAt)
+	return err
+}
+
+// ProcessPending забирает пачку под аренду (next_attempt_at сдвигается на outbox.ClaimLease) в короткой
+// транзакции с advisory-блокировкой, публикует события уже вне транзакции и отмечает результаты во второй.
+// Пока аренда не истекла, события пачки и более поздние события тех же агрегатов не выбираются повторно.
+func (r *OutboxPG) ProcessPending(ctx context.Context, limit int, publish outbox.PublishFunc) (int, error) {
+	events, err := r.claim(ctx, limit)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	results := publish(ctx, events)
+
+	if err := r.complete(ctx, events, results); err != nil {
+		return 0, err
+	}
+	return len(results), nil
+}
+
+func (r *OutboxPG) claim(ctx context.Context, limit int) ([]*outbox.Event, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLockID).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, nil
+	}
+
+	// Событие не выбирается, пока более раннее событие того же агрегата ждёт повторной попытки
+	// или арендовано другим проходом релея
+	const qClaim = `
+		UPDATE outbox c
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM (
+		    SELECT o.id
+		    FROM outbox o
+		    WHERE o.published_at IS NULL
+		      AND o.next_attempt_at <= NOW()
+		      AND NOT EXISTS (
+		          SELECT 1 FROM outbox p
+		          WHERE p.aggregate_type = o.aggregate_type AND p.aggregate_id = o.aggregate_id
+		            AND p.published_at IS NULL AND p.id < o.id AND p.next_attempt_at > NOW()
+		      )
+		    ORDER BY o.id
+		    LIMIT $1
+		) batch
+		WHERE c.id = batch.id
+		RETURNING c.id, c.dedup_key, c.aggregate_type, c.aggregate_id, c.event_type, c.payload, c.created_at,
+		          c.attempts, COALESCE(c.last_error, '')
+	`
+	rows, err := tx.Query(ctx, qClaim, limit, outbox.ClaimLease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*outbox.Event
+	for rows.Next() {
+		var e outbox.Event
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.DedupKey, &e.AggregateType, &e.AggregateID, &e.Type, &payload, &e.CreatedAt,
+			&e.Attempts, &e.LastError); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		e.Payload = payload
+		events = append(events, &e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING не гарантирует порядок, а publish ожидает события в порядке id
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// complete фиксирует результаты publish. Аренда событий без результата снимается,
+// и они выбираются в следующем проходе.
+func (r *OutboxPG) complete(ctx context.Context, events []*outbox.Event, results map[int64]error) error {
+	const qPublished = `UPDATE outbox SET published_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1`
+	const qFailed = `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2,
+		    next_attempt_at = NOW() + LEAST(make_interval(secs => power(2, attempts)), make_interval(secs => $3))
+		WHERE id = $1
+	`
+	const qRelease = `UPDATE outbox SET next_attempt_at = NOW() WHERE id = $1 AND published_at IS NULL`
+
+	b := &pgx.Batch{}
+	for _, e := range events {
+		publishErr, ok := results[e.ID]
+		switch {
+		case !ok:
+			b.Queue(qRelease, e.ID)
+		case publishErr == nil:
+			b.Queue(qPublished, e.ID)
+		default:
+			b.Queue(qFailed, e.ID, publishErr.Error(), outbox.MaxRetryDelay.Seconds())
+		}
+	}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := tx.SendBatch(ctx, b).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
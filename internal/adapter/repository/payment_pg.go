@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"orderflow/internal/domain/outbox"
 	"orderflow/internal/domain/payment"
 )
 
@@ -18,19 +19,36 @@ func NewPaymentPG(pool *pgxpool.Pool) *PaymentPG {
 	return &PaymentPG{pool: pool}
 }
 
+// CreatePayment и UpdatePayment пишут событие payment.<status> в outbox в той же транзакции.
 func (r *PaymentPG) CreatePayment(ctx context.Context, paymentEntity *payment.Payment) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	const q = `
 		INSERT INTO payments (id, order_id, customer_id, amount, currency, status, payment_method, 
 		                     transaction_id, failure_reason, processed_at, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
-	_, err := r.pool.Exec(ctx, q,
+	_, err = tx.Exec(ctx, q,
 		paymentEntity.ID, paymentEntity.OrderID, paymentEntity.CustomerID,
 		paymentEntity.Amount, paymentEntity.Currency, string(paymentEntity.Status),
 		paymentEntity.PaymentMethod, paymentEntity.TransactionID, paymentEntity.FailureReason,
 		paymentEntity.ProcessedAt, paymentEntity.CreatedAt, paymentEntity.UpdatedAt,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if paymentEntity.Status != payment.StatusPending {
+		if err := appendPaymentEvent(ctx, tx, paymentEntity); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *PaymentPG) GetPayment(ctx context.Context, paymentID string) (*payment.Payment, error) {
@@ -88,25 +106,133 @@ func (r *PaymentPG) GetPaymentByOrderID(ctx context.Context, orderID string) (*p
 }
 
 func (r *PaymentPG) UpdatePayment(ctx context.Context, paymentEntity *payment.Payment) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	const q = `
-		UPDATE payments
+		UPDATE payments p
 		SET order_id = $2, customer_id = $3, amount = $4, currency = $5, status = $6,
 		    payment_method = $7, transaction_id = $8, failure_reason = $9, processed_at = $10, updated_at = $11
-		WHERE id = $1
+		FROM (SELECT id, status FROM payments WHERE id = $1 FOR UPDATE) prev
+		WHERE p.id = prev.id
+		RETURNING prev.status
 	`
-	ct, err := r.pool.Exec(ctx, q,
+	var previousStatus string
+	err = tx.QueryRow(ctx, q,
 		paymentEntity.ID, paymentEntity.OrderID, paymentEntity.CustomerID,
 		paymentEntity.Amount, paymentEntity.Currency, string(paymentEntity.Status),
 		paymentEntity.PaymentMethod, paymentEntity.TransactionID, paymentEntity.FailureReason,
 		paymentEntity.ProcessedAt, paymentEntity.UpdatedAt,
-	)
+	).Scan(&previousStatus)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return payment.NewNotFoundError(paymentEntity.ID)
+	}
 	if err != nil {
 		return err
 	}
+
+	if payment.Status(previousStatus) != paymentEntity.Status {
+		if err := appendPaymentEvent(ctx, tx, paymentEntity); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PaymentPG) ApplyProviderEvent(ctx context.Context, event *payment.ProviderEvent) (*payment.ProviderEventResult, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Платёж блокируется до записи события: параллельные callback-и по одному платежу
+	// применяются по очереди
+	const qPayment = `
+		SELECT id, order_id, customer_id, amount, currency, status, payment_method,
+		       transaction_id, failure_reason, processed_at, created_at, updated_at
+		FROM payments
+		WHERE ($1 <> '' AND id = $1) OR ($1 = '' AND transaction_id = $2)
+		FOR UPDATE
+	`
+	var paymentEntity payment.Payment
+	var status string
+	err = tx.QueryRow(ctx, qPayment, event.PaymentID, event.TransactionID).Scan(
+		&paymentEntity.ID, &paymentEntity.OrderID, &paymentEntity.CustomerID,
+		&paymentEntity.Amount, &paymentEntity.Currency, &status,
+		&paymentEntity.PaymentMethod, &paymentEntity.TransactionID, &paymentEntity.FailureReason,
+		&paymentEntity.ProcessedAt, &paymentEntity.CreatedAt, &paymentEntity.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if event.PaymentID != "" {
+			return nil, payment.NewNotFoundError(event.PaymentID)
+		}
+		return nil, payment.NewNotFoundError("for transaction " + event.TransactionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	paymentEntity.Status = payment.Status(status)
+
+	result := &payment.ProviderEventResult{
+		Payment:        &paymentEntity,
+		PreviousStatus: paymentEntity.Status,
+	}
+
+	const qEvent = `
+		INSERT INTO payment_provider_events (provider, event_id, event_type, payment_id, payload, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (provider, event_id) DO NOTHING
+	`
+	payload := event.Payload
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+	ct, err := tx.Exec(ctx, qEvent, event.Provider, event.ID, string(event.Type), paymentEntity.ID, string(payload), event.OccurredAt)
+	if err != nil {
+		return nil, err
+	}
 	if ct.RowsAffected() == 0 {
-		return payment.NewNotFoundError(paymentEntity.ID)
+		result.Duplicate = true
+		return result, nil
+	}
+
+	if event.Apply(&paymentEntity) {
+		result.Changed = true
+
+		const qUpdate = `
+			UPDATE payments
+			SET status = $2, transaction_id = $3, failure_reason = $4, processed_at = $5, updated_at = $6
+			WHERE id = $1
+		`
+		_, err = tx.Exec(ctx, qUpdate,
+			paymentEntity.ID, string(paymentEntity.Status), paymentEntity.TransactionID,
+			paymentEntity.FailureReason, paymentEntity.ProcessedAt, paymentEntity.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := appendPaymentEvent(ctx, tx, &paymentEntity); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func appendPaymentEvent(ctx context.Context, tx pgx.Tx, paymentEntity *payment.Payment) error {
+	event, err := outbox.NewPaymentEvent(paymentEntity)
+	if err != nil {
+		return err
 	}
-	return nil
+	return appendOutbox(ctx, tx, event)
 }
 
 func (r *PaymentPG) GetPayments(ctx context.Context) ([]*payment.Payment, error) {
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"orderflow/internal/domain/promotion"
+)
+
+type PromotionPG struct {
+	pool *pgxpool.Pool
+}
+
+func NewPromotionPG(pool *pgxpool.Pool) *PromotionPG {
+	return &PromotionPG{pool: pool}
+}
+
+const promotionColumns = `
+	id, COALESCE(code, ''), name, type, value, product_ids, buy_quantity, get_quantity, min_subtotal,
+	usage_limit, per_customer_limit, active, starts_at, ends_at, created_at, updated_at
+`
+
+// qCouponUsage считает погашения купона, занимающие лимит: всего и клиентом $2.
+const qCouponUsage = `
+	SELECT COUNT(*), COUNT(*) FILTER (WHERE customer_id = $2)
+	FROM coupon_redemptions
+	WHERE promotion_id = $1 AND status <> 'released'
+`
+
+func (r *PromotionPG) Create(ctx context.Context, p *promotion.Promotion) error {
+	const q = `
+		INSERT INTO promotions (id, code, name, type, value, product_ids, buy_quantity, get_quantity, min_subtotal,
+		                        usage_limit, per_customer_limit, active, starts_at, ends_at, created_at, updated_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+	_, err := r.pool.Exec(ctx, q, p.ID, p.Code, p.Name, string(p.Type), p.Value, productIDsOrEmpty(p.ProductIDs),
+		p.BuyQuantity, p.GetQuantity, p.MinSubtotal, p.UsageLimit, p.PerCustomerLimit, p.Active, p.StartsAt, p.EndsAt,
+		p.CreatedAt, p.UpdatedAt)
+	if isUniqueViolation(err, "promotions_code_key") {
+		return promotion.NewDuplicateCodeError(p.Code)
+	}
+	return err
+}
+
+func (r *PromotionPG) Get(ctx context.Context, id string) (*promotion.Promotion, error) {
+	q := `SELECT ` + promotionColumns + ` FROM promotions WHERE id = $1`
+	p, err := scanPromotion(r.pool.QueryRow(ctx, q, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, promotion.NewNotFoundError(id)
+	}
+	return p, err
+}
+
+func (r *PromotionPG) GetByCode(ctx context.Context, code string) (*promotion.Promotion, error) {
+	q := `SELECT ` + promotionColumns + ` FROM promotions WHERE code = $1`
+	p, err := scanPromotion(r.pool.QueryRow(ctx, q, code))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, promotion.NewNotFoundError(code)
+	}
+	return p, err
+}
+
+func (r *PromotionPG) Update(ctx context.Context, p *promotion.Promotion) error {
+	const q = `
+		UPDATE promotions
+		SET name = $2, active = $3, starts_at = $4, ends_at = $5, usage_limit = $6, per_customer_limit = $7, updated_at = $8
+		WHERE id = $1
+	`
+	ct, err := r.pool.Exec(ctx, q, p.ID, p.Name, p.Active, p.StartsAt, p.EndsAt, p.UsageLimit, p.PerCustomerLimit, p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return promotion.NewNotFoundError(p.ID)
+	}
+	return nil
+}
+
+func (r *PromotionPG) List(ctx context.Context) ([]*promotion.Promotion, error) {
+	q := `SELECT ` + promotionColumns + ` FROM promotions ORDER BY created_at`
+	return r.queryPromotions(ctx, q)
+}
+
+func (r *PromotionPG) ListAutomatic(ctx context.Context, at time.Time) ([]*promotion.Promotion, error) {
+	q := `SELECT ` + promotionColumns + ` FROM promotions
+		WHERE code IS NULL AND active
+		  AND (starts_at IS NULL OR starts_at <= $1)
+		  AND (ends_at IS NULL OR ends_at > $1)
+		ORDER BY created_at, id`
+	return r.queryPromotions(ctx, q, at)
+}
+
+func (r *PromotionPG) GetUsage(ctx context.Context, promotionID, customerID string) (*promotion.Usage, error) {
+	var usage promotion.Usage
+	if err := r.pool.QueryRow(ctx, qCouponUsage, promotionID, customerID).Scan(&usage.Total, &usage.Customer); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (r *PromotionPG) ConfirmRedemptions(ctx context.Context, orderID string) error {
+	const q = `
+		UPDATE coupon_redemptions SET status = 'redeemed', updated_at = NOW()
+		WHERE order_id = $1 AND status = 'reserved'
+	`
+	_, err := r.pool.Exec(ctx, q, orderID)
+	return err
+}
+
+func (r *PromotionPG) ReleaseRedemptions(ctx context.Context, orderID string) error {
+	const q = `
+		UPDATE coupon_redemptions SET status = 'released', updated_at = NOW()
+		WHERE order_id = $1 AND status <> 'released'
+	`
+	_, err := r.pool.Exec(ctx, q, orderID)
+	return err
+}
+
+func (r *PromotionPG) queryPromotions(ctx context.Context, q string, args ...interface{}) ([]*promotion.Promotion, error) {
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []*promotion.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
+
+func scanPromotion(row pgx.Row) (*promotion.Promotion, error) {
+	var p promotion.Promotion
+	var promotionType string
+	err := row.Scan(&p.ID, &p.Code, &p.Name, &promotionType, &p.Value, &p.ProductIDs, &p.BuyQuantity, &p.GetQuantity,
+		&p.MinSubtotal, &p.UsageLimit, &p.PerCustomerLimit, &p.Active, &p.StartsAt, &p.EndsAt, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.Type = promotion.Type(promotionType)
+	return &p, nil
+}
+
+// productIDsOrEmpty — product_ids объявлен NOT NULL, nil-срез записался бы как NULL.
+func productIDsOrEmpty(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
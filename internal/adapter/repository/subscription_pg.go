@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"orderflow/internal/domain/subscription"
+)
+
+type SubscriptionPG struct {
+	pool *pgxpool.Pool
+}
+
+func NewSubscriptionPG(pool *pgxpool.Pool) *SubscriptionPG {
+	return &SubscriptionPG{pool: pool}
+}
+
+func (r *SubscriptionPG) Save(ctx context.Context, s *subscription.Subscription) error {
+	const q = `
+		INSERT INTO subscriptions (id, customer_id, items, interval_seconds, status, cycles_completed, cycles_skipped,
+		                           skip_next, next_run_at, last_order_workflow_id, workflow_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (id) DO UPDATE
+		SET items = EXCLUDED.items, interval_seconds = EXCLUDED.interval_seconds, status = EXCLUDED.status,
+		    cycles_completed = EXCLUDED.cycles_completed, cycles_skipped = EXCLUDED.cycles_skipped,
+		    skip_next = EXCLUDED.skip_next,
+		    next_run_at = EXCLUDED.next_run_at, last_order_workflow_id = EXCLUDED.last_order_workflow_id,
+		    updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.pool.Exec(ctx, q,
+		s.ID, s.CustomerID, s.Items, int64(s.Interval/time.Second), string(s.Status),
+		s.CyclesCompleted, s.CyclesSkipped, s.SkipNext, s.NextRunAt, s.LastOrderWorkflowID, s.WorkflowID,
+		s.CreatedAt, s.UpdatedAt,
+	)
+	return err
+}
+
+func (r *SubscriptionPG) GetByID(ctx context.Context, id string) (*subscription.Subscription, error) {
+	const q = `
+		SELECT id, customer_id, items, interval_seconds, status, cycles_completed, cycles_skipped,
+		       skip_next, next_run_at, COALESCE(last_order_workflow_id, ''), workflow_id, created_at, updated_at
+		FROM subscriptions WHERE id = $1
+	`
+	s, err := scanSubscription(r.pool.QueryRow(ctx, q, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, subscription.NewNotFoundError(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *SubscriptionPG) GetByCustomerID(ctx context.Context, customerID string) ([]*subscription.Subscription, error) {
+	const q = `
+		SELECT id, customer_id, items, interval_seconds, status, cycles_completed, cycles_skipped,
+		       skip_next, next_run_at, COALESCE(last_order_workflow_id, ''), workflow_id, created_at, updated_at
+		FROM subscriptions WHERE customer_id = $1 ORDER BY created_at DESC
+	`
+	rows, err := r.pool.Query(ctx, q, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*subscription.Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func scanSubscription(row pgx.Row) (*subscription.Subscription, error) {
+	var s subscription.Subscription
+	var status string
+	var intervalSeconds int64
+	err := row.Scan(
+		&s.ID, &s.CustomerID, &s.Items, &intervalSeconds, &status, &s.CyclesCompleted, &s.CyclesSkipped,
+		&s.SkipNext, &s.NextRunAt, &s.LastOrderWorkflowID, &s.WorkflowID, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.Status = subscription.Status(status)
+	s.Interval = time.Duration(intervalSeconds) * time.Second
+	return &s, nil
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"orderflow/internal/domain/webhook"
+)
+
+type WebhookPG struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookPG(pool *pgxpool.Pool) *WebhookPG {
+	return &WebhookPG{pool: pool}
+}
+
+const webhookSubscriptionColumns = `
+	id, url, event_types, secret, status, consecutive_failures, COALESCE(disabled_reason, ''), disabled_at,
+	created_at, updated_at
+`
+
+func (r *WebhookPG) CreateSubscription(ctx context.Context, s *webhook.Subscription) error {
+	const q = `
+		INSERT INTO webhook_subscriptions (id, url, event_types, secret, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.pool.Exec(ctx, q, s.ID, s.URL, s.EventTypes, s.Secret, string(s.Status), s.CreatedAt, s.UpdatedAt)
+	return err
+}
+
+func (r *WebhookPG) GetSubscription(ctx context.Context, id string) (*webhook.Subscription, error) {
+	q := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+	s, err := scanWebhookSubscription(r.pool.QueryRow(ctx, q, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, webhook.NewNotFoundError(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *WebhookPG) ListSubscriptions(ctx context.Context) ([]*webhook.Subscription, error) {
+	q := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions ORDER BY created_at`
+	return r.querySubscriptions(ctx, q)
+}
+
+func (r *WebhookPG) ListActiveSubscriptions(ctx context.Context) ([]*webhook.Subscription, error) {
+	q := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE status = 'active' ORDER BY created_at`
+	return r.querySubscriptions(ctx, q)
+}
+
+func (r *WebhookPG) DeleteSubscription(ctx context.Context, id string) error {
+	ct, err := r.pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return webhook.NewNotFoundError(id)
+	}
+	return nil
+}
+
+func (r *WebhookPG) Enable(ctx context.Context, id string) error {
+	const q = `
+		UPDATE webhook_subscriptions
+		SET status = 'active', consecutive_failures = 0, disabled_reason = NULL, disabled_at = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	ct, err := r.pool.Exec(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return webhook.NewNotFoundError(id)
+	}
+	return nil
+}
+
+func (r *WebhookPG) RecordDeliveryOutcome(ctx context.Context, subscriptionID string, delivered bool, maxFailures int) (bool, error) {
+	if delivered {
+		const q = `UPDATE webhook_subscriptions SET consecutive_failures = 0, updated_at = NOW() WHERE id = $1`
+		_, err := r.pool.Exec(ctx, q, subscriptionID)
+		return false, err
+	}
+
+	// Счётчик и отключение меняются одним UPDATE, поэтому параллельные доставки не обходят порог
+	const q = `
+		UPDATE webhook_subscriptions s
+		SET consecutive_failures = s.consecutive_failures + 1,
+		    status = CASE WHEN s.consecutive_failures + 1 >= $2 THEN 'disabled' ELSE s.status END,
+		    disabled_reason = CASE WHEN s.consecutive_failures + 1 >= $2 AND s.status = 'active'
+		                           THEN 'too many consecutive failed deliveries' ELSE s.disabled_reason END,
+		    disabled_at = CASE WHEN s.consecutive_failures + 1 >= $2 AND s.status = 'active'
+		                       THEN NOW() ELSE s.disabled_at END,
+		    updated_at = NOW()
+		FROM (SELECT id, status FROM webhook_subscriptions WHERE id = $1 FOR UPDATE) prev
+		WHERE s.id = prev.id
+		RETURNING prev.status = 'active' AND s.status = 'disabled'
+	`
+	var disabled bool
+	err := r.pool.QueryRow(ctx, q, subscriptionID, maxFailures).Scan(&disabled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, webhook.NewNotFoundError(subscriptionID)
+	}
+	return disabled, err
+}
+
+func (r *WebhookPG) CreateDelivery(ctx context.Context, d *webhook.Delivery) error {
+	const q = `
+		INSERT INTO webhook_deliveries (id, subscription_id, event_id, dedup_key, event_type, body, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO NOTHING
+	`
+	_, err := r.pool.Exec(ctx, q,
+		d.ID, d.SubscriptionID, d.EventID, d.DedupKey, d.EventType, string(d.Body), string(d.Status), d.CreatedAt, d.UpdatedAt,
+	)
+	return err
+}
+
+const webhookDeliveryColumns = `
+	id, subscription_id, event_id, dedup_key, event_type, body, status, attempts, COALESCE(last_status_code, 0),
+	COALESCE(last_error, ''), delivered_at, created_at, updated_at
+`
+
+func (r *WebhookPG) GetDelivery(ctx context.Context, id string) (*webhook.Delivery, error) {
+	q := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	d, err := scanWebhookDelivery(r.pool.QueryRow(ctx, q, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, webhook.NewDeliveryNotFoundError(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *WebhookPG) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*webhook.Delivery, error) {
+	q := `SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := r.pool.Query(ctx, q, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*webhook.Delivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookPG) ListAttempts(ctx context.Context, deliveryID string) ([]*webhook.Attempt, error) {
+	const q = `
+		SELECT id, delivery_id, COALESCE(status_code, 0), COALESCE(error, ''), COALESCE(response_body, ''), duration_ms, created_at
+		FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY id
+	`
+	rows, err := r.pool.Query(ctx, q, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*webhook.Attempt
+	for rows.Next() {
+		var a webhook.Attempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.StatusCode, &a.Error, &a.ResponseBody, &a.Duration, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, &a)
+	}
+	return attempts, rows.Err()
+}
+
+func (r *WebhookPG) AddAttempt(ctx context.Context, a *webhook.Attempt) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	const qAttempt = `
+		INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, response_body, duration_ms, created_at)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, ''), NULLIF($4, ''), $5, $6)
+		RETURNING id
+	`
+	err = tx.QueryRow(ctx, qAttempt, a.DeliveryID, a.StatusCode, a.Error, a.ResponseBody, a.Duration, a.CreatedAt).Scan(&a.ID)
+	if err != nil {
+		return err
+	}
+
+	const qDelivery = `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, last_status_code = NULLIF($2, 0), last_error = NULLIF($3, ''), updated_at = NOW()
+		WHERE id = $1
+	`
+	ct, err := tx.Exec(ctx, qDelivery, a.DeliveryID, a.StatusCode, a.Error)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return webhook.NewDeliveryNotFoundError(a.DeliveryID)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *WebhookPG) SetDeliveryStatus(ctx context.Context, id string, status webhook.DeliveryStatus) error {
+	const q = `
+		UPDATE webhook_deliveries
+		SET status = $2,
+		    delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() ELSE delivered_at END,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+	ct, err := r.pool.Exec(ctx, q, id, string(status))
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return webhook.NewDeliveryNotFoundError(id)
+	}
+	return nil
+}
+
+func (r *WebhookPG) querySubscriptions(ctx context.Context, q string) ([]*webhook.Subscription, error) {
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*webhook.Subscription
+	for rows.Next() {
+		s, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
+
+func scanWebhookSubscription(row pgx.Row) (*webhook.Subscription, error) {
+	var s webhook.Subscription
+	var status string
+	err := row.Scan(&s.ID, &s.URL, &s.EventTypes, &s.Secret, &status, &s.ConsecutiveFailures, &s.DisabledReason,
+		&s.DisabledAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	s.Status = webhook.Status(status)
+	return &s, nil
+}
+
+func scanWebhookDelivery(row pgx.Row) (*webhook.Delivery, error) {
+	var d webhook.Delivery
+	var body, status string
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.DedupKey, &d.EventType, &body, &status, &d.Attempts,
+		&d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.Body = []byte(body)
+	d.Status = webhook.DeliveryStatus(status)
+	return &d, nil
+}
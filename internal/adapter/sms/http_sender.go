@@ -0,0 +1,151 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"orderflow/internal/domain/notification"
+)
+
+const (
+	DefaultTimeout     = 15 * time.Second
+	DefaultMaxSegments = 3
+)
+
+// phonePattern — номер в формате E.164, как в справочнике клиентов
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// Config описывает HTTP-провайдера SMS. Авторизация — Bearer-токен или Basic
+// (Username/Password). RateLimit — запросов в секунду (0 — без ограничения), Burst — размер пачки.
+// MaxSegments ограничивает длину сообщения: более длинный текст обрезается.
+type Config struct {
+	URL         string
+	Token       string
+	Username    string
+	Password    string
+	From        string
+	MaxSegments int
+	RateLimit   float64
+	Burst       int
+	Timeout     time.Duration
+}
+
+type sendRequest struct {
+	To        string `json:"to"`
+	From      string `json:"from,omitempty"`
+	Text      string `json:"text"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// HTTPSender отправляет SMS через HTTP API провайдера: POST JSON {to, from, text, reference}
+// на Config.URL. ID уведомления передаётся в reference и заголовке Idempotency-Key, чтобы
+// провайдер мог отбросить повтор. Ответы 408, 429 и 5xx, сетевые ошибки и исчерпанный
+// лимит запросов считаются временными, остальные 4xx — окончательным отказом.
+type HTTPSender struct {
+	config  Config
+	client  *http.Client
+	limiter *rate.Limiter
+}
+
+func NewHTTPSender(config Config) (*HTTPSender, error) {
+	if config.URL == "" {
+		return nil, errors.New("sms provider url is required")
+	}
+	if config.MaxSegments <= 0 {
+		config.MaxSegments = DefaultMaxSegments
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	return &HTTPSender{
+		config:  config,
+		client:  &http.Client{Timeout: config.Timeout},
+		limiter: newLimiter(config.RateLimit, config.Burst),
+	}, nil
+}
+
+func (s *HTTPSender) SupportedChannels() []notification.Channel {
+	return []notification.Channel{notification.ChannelSMS}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, n *notification.Notification) error {
+	if n.Recipient == "" {
+		return notification.NewRecipientNotFoundError(n.CustomerID, notification.ChannelSMS)
+	}
+	if !phonePattern.MatchString(n.Recipient) {
+		return notification.NewPermanentSendError(notification.ChannelSMS, "phone number must be in E.164 format: "+n.Recipient)
+	}
+
+	body, err := json.Marshal(sendRequest{
+		To:        n.Recipient,
+		From:      s.config.From,
+		Text:      Truncate(n.Message, s.config.MaxSegments),
+		Reference: n.ID,
+	})
+	if err != nil {
+		return notification.NewPermanentSendError(notification.ChannelSMS, err.Error())
+	}
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return notification.NewSendError(notification.ChannelSMS, "rate limit: "+err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return notification.NewPermanentSendError(notification.ChannelSMS, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.ID != "" {
+		req.Header.Set("Idempotency-Key", n.ID)
+	}
+	switch {
+	case s.config.Token != "":
+		req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	case s.config.Username != "":
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return notification.NewSendError(notification.ChannelSMS, err.Error())
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	reason := fmt.Sprintf("sms provider %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	if isRetryableStatus(resp.StatusCode) {
+		return notification.NewSendError(notification.ChannelSMS, reason)
+	}
+	return notification.NewPermanentSendError(notification.ChannelSMS, reason)
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+func newLimiter(perSecond float64, burst int) *rate.Limiter {
+	if perSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), burst)
+}
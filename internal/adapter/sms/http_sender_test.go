@@ -0,0 +1,160 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"orderflow/internal/domain/notification"
+)
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		text     string
+		encoding Encoding
+		length   int
+		segments int
+	}{
+		{"Order 42 confirmed", EncodingGSM7, 18, 1},
+		{strings.Repeat("a", 160), EncodingGSM7, 160, 1},
+		{strings.Repeat("a", 161), EncodingGSM7, 161, 2},
+		{"Total: 10€ [x]", EncodingGSM7, 17, 1},
+		{"Заказ подтверждён", EncodingUCS2, 17, 1},
+		{strings.Repeat("я", 71), EncodingUCS2, 71, 2},
+		{"ok 👍", EncodingUCS2, 5, 1},
+	}
+
+	for _, tt := range tests {
+		encoding, length, segments := Segments(tt.text)
+		if encoding != tt.encoding || length != tt.length || segments != tt.segments {
+			t.Errorf("Segments(%q) = %s, %d, %d; want %s, %d, %d",
+				tt.text, encoding, length, segments, tt.encoding, tt.length, tt.segments)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	short := "Order 42 confirmed"
+	if got := Truncate(short, 1); got != short {
+		t.Errorf("short text changed: %q", got)
+	}
+
+	for _, text := range []string{strings.Repeat("word ", 200), strings.Repeat("слово ", 200)} {
+		for _, maxSegments := range []int{1, 2, 3} {
+			got := Truncate(text, maxSegments)
+			if _, _, segments := Segments(got); segments > maxSegments {
+				t.Errorf("truncated text takes %d segments, max %d", segments, maxSegments)
+			}
+			if !strings.HasSuffix(got, truncationSuffix) {
+				t.Errorf("truncated text has no suffix: %q", got)
+			}
+		}
+	}
+}
+
+func TestHTTPSenderDeliversAndClassifiesFailures(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []sendRequest
+	)
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sms-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req sendRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Idempotency-Key") != req.Reference {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, req)
+		mu.Unlock()
+
+		switch req.To {
+		case "+15550000002":
+			http.Error(w, `{"error":"number blocked"}`, http.StatusUnprocessableEntity)
+		case "+15550000003":
+			http.Error(w, `{"error":"upstream unavailable"}`, http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"id":"msg-1"}`))
+		}
+	}))
+	defer stub.Close()
+
+	sender, err := NewHTTPSender(Config{URL: stub.URL, Token: "sms-token", From: "OrderFlow", MaxSegments: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(recipient, text string) error {
+		return sender.Send(context.Background(), &notification.Notification{
+			ID:         "n-" + recipient,
+			CustomerID: "customer-1",
+			Channel:    notification.ChannelSMS,
+			Recipient:  recipient,
+			Message:    text,
+		})
+	}
+
+	if err := send("+15550000001", strings.Repeat("Заказ подтверждён. ", 10)); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(received) != 1 || received[0].From != "OrderFlow" || received[0].Reference != "n-+15550000001" {
+		t.Fatalf("unexpected requests: %+v", received)
+	}
+	if _, _, segments := Segments(received[0].Text); segments != 1 {
+		t.Errorf("message was not truncated to one segment: %q", received[0].Text)
+	}
+
+	var sendErr *notification.SendError
+	if err := send("+15550000002", "hi"); !errors.As(err, &sendErr) || !sendErr.Permanent {
+		t.Errorf("expected permanent error for 422, got %v", err)
+	}
+	if err := send("+15550000003", "hi"); !errors.As(err, &sendErr) || sendErr.Permanent {
+		t.Errorf("expected retryable error for 503, got %v", err)
+	}
+	if err := send("555-0100", "hi"); !errors.As(err, &sendErr) || !sendErr.Permanent {
+		t.Errorf("expected permanent error for invalid number, got %v", err)
+	}
+
+	var recipientErr *notification.RecipientNotFoundError
+	if err := send("", "hi"); !errors.As(err, &recipientErr) {
+		t.Errorf("expected recipient not found error, got %v", err)
+	}
+	if len(received) != 3 {
+		t.Errorf("invalid recipients must not reach the provider, got %d requests", len(received))
+	}
+}
+
+func TestHTTPSenderRateLimit(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer stub.Close()
+
+	sender, err := NewHTTPSender(Config{URL: stub.URL, RateLimit: 0.1, Burst: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &notification.Notification{CustomerID: "c", Recipient: "+15550000001", Message: "hi"}
+	if err := sender.Send(context.Background(), n); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var sendErr *notification.SendError
+	if err := sender.Send(ctx, n); !errors.As(err, &sendErr) || sendErr.Permanent {
+		t.Errorf("expected retryable rate limit error, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package sms
+
+import "strings"
+
+type Encoding string
+
+const (
+	// EncodingGSM7 — 7-битный алфавит GSM 03.38: 160 символов в одиночном SMS, 153 в части составного
+	EncodingGSM7 Encoding = "gsm7"
+	// EncodingUCS2 — UTF-16 для текста вне алфавита GSM: 70 символов в одиночном SMS, 67 в части составного
+	EncodingUCS2 Encoding = "ucs2"
+)
+
+const truncationSuffix = "..."
+
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension — символы расширенной таблицы, каждый занимает два септета (ESC + код)
+const gsm7Extension = "^{}\\[~]|€\f"
+
+// Segments возвращает кодировку текста, его длину в единицах кодировки (септеты или
+// UTF-16 code units) и число частей, на которые оператор разобьёт сообщение.
+func Segments(text string) (Encoding, int, int) {
+	encoding := detectEncoding(text)
+	length := encodedLength(text, encoding)
+	single, multi := segmentCapacity(encoding)
+	if length <= single {
+		return encoding, length, 1
+	}
+	return encoding, length, (length + multi - 1) / multi
+}
+
+// Truncate укорачивает текст так, чтобы он поместился в maxSegments частей,
+// и заканчивает обрезанный текст многоточием.
+func Truncate(text string, maxSegments int) string {
+	if maxSegments <= 0 {
+		return text
+	}
+	encoding := detectEncoding(text)
+	single, multi := segmentCapacity(encoding)
+	limit := single
+	if maxSegments > 1 {
+		limit = multi * maxSegments
+	}
+	if encodedLength(text, encoding) <= limit {
+		return text
+	}
+
+	budget := limit - encodedLength(truncationSuffix, encoding)
+	var b strings.Builder
+	used := 0
+	for _, r := range text {
+		size := runeLength(r, encoding)
+		if used+size > budget {
+			break
+		}
+		b.WriteRune(r)
+		used += size
+	}
+	return strings.TrimRight(b.String(), " \n\r\t") + truncationSuffix
+}
+
+func detectEncoding(text string) Encoding {
+	for _, r := range text {
+		if !strings.ContainsRune(gsm7Basic, r) && !strings.ContainsRune(gsm7Extension, r) {
+			return EncodingUCS2
+		}
+	}
+	return EncodingGSM7
+}
+
+func encodedLength(text string, encoding Encoding) int {
+	length := 0
+	for _, r := range text {
+		length += runeLength(r, encoding)
+	}
+	return length
+}
+
+func runeLength(r rune, encoding Encoding) int {
+	if encoding == EncodingGSM7 {
+		if strings.ContainsRune(gsm7Extension, r) {
+			return 2
+		}
+		return 1
+	}
+	if r > 0xFFFF {
+		return 2
+	}
+	return 1
+}
+
+func segmentCapacity(encoding Encoding) (single, multi int) {
+	if encoding == EncodingGSM7 {
+		return 160, 153
+	}
+	return 70, 67
+}
@@ -0,0 +1,369 @@
+// Package templates рендерит уведомления из файлов text/template и html/template.
+// Шаблоны встроены в бинарник и могут быть переопределены файлами из каталога.
+//
+// Раскладка: <type>/<channel>.<locale>.<part>.tmpl, где channel — email, sms, push или
+// default (для любого канала), part — subject, txt или html. Файлы partials/*.txt.tmpl и
+// partials/*.html.tmpl подключаются ко всем текстовым и HTML-шаблонам соответственно.
+package templates
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"orderflow/internal/domain/notification"
+)
+
+//go:embed files
+var embedded embed.FS
+
+const (
+	PartSubject = "subject"
+	PartText    = "txt"
+	PartHTML    = "html"
+
+	// DefaultChannel — шаблон для каналов без собственного шаблона
+	DefaultChannel = "default"
+
+	partialsDir = "partials"
+	extension   = ".tmpl"
+)
+
+type Engine struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+// New загружает встроенные шаблоны, поверх них — шаблоны из dir (файл с тем же путём
+// заменяет встроенный), и проверяет набор через Validate. Пустой dir — только встроенные.
+func New(dir string) (*Engine, error) {
+	sources, err := loadSources(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	textPartials := make(map[string]string)
+	htmlPartials := make(map[string]string)
+	for name, source := range sources {
+		if path.Dir(name) != partialsDir {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(name, "."+PartText+extension):
+			textPartials[partialName(name)] = source
+		case strings.HasSuffix(name, "."+PartHTML+extension):
+			htmlPartials[partialName(name)] = source
+		default:
+			return nil, fmt.Errorf("template %s: partial must be a .txt.tmpl or .html.tmpl file", name)
+		}
+	}
+
+	engine := &Engine{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	for name, source := range sources {
+		if path.Dir(name) == partialsDir {
+			continue
+		}
+		notificationType, channel, locale, part, err := parseName(name)
+		if err != nil {
+			return nil, err
+		}
+		key := templateKey(notificationType, channel, locale, part)
+
+		if part == PartHTML {
+			tmpl, err := parseHTML(key, source, htmlPartials)
+			if err != nil {
+				return nil, fmt.Errorf("template %s: %w", name, err)
+			}
+			engine.html[key] = tmpl
+			continue
+		}
+
+		tmpl, err := parseText(key, source, textPartials)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", name, err)
+		}
+		engine.text[key] = tmpl
+	}
+
+	if err := engine.Validate(); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// Validate проверяет, что для каждого типа и канала есть тема и текст в DefaultLocale,
+// и что все шаблоны выполняются на тестовых данных.
+func (e *Engine) Validate() error {
+	var errs []error
+
+	for _, notificationType := range notification.TemplateTypes {
+		for _, channel := range notification.Channels {
+			for _, part := range []string{PartSubject, PartText} {
+				if _, ok := e.lookupText(notificationType, channel, notification.DefaultLocale, part); !ok {
+					errs = append(errs, fmt.Errorf("no %s template for %s/%s in locale %s",
+						part, notificationType, channel, notification.DefaultLocale))
+				}
+			}
+		}
+	}
+
+	// Полные данные находят обращения к несуществующим полям, минимальные — обращения
+	// к необязательным полям (.Payment) без проверки на nil
+	for _, key := range sortedKeys(e.text) {
+		for _, data := range []*notification.TemplateData{sampleData(), minimalData()} {
+			if err := e.text[key].Execute(io.Discard, data); err != nil {
+				errs = append(errs, fmt.Errorf("template %s: %w", key, err))
+				break
+			}
+		}
+	}
+	for _, key := range sortedKeys(e.html) {
+		for _, data := range []*notification.TemplateData{sampleData(), minimalData()} {
+			if err := e.html[key].Execute(io.Discard, data); err != nil {
+				errs = append(errs, fmt.Errorf("template %s: %w", key, err))
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e *Engine) Render(ctx context.Context, notificationType notification.Type, channel notification.Channel, locale string, data *notification.TemplateData) (*notification.Content, error) {
+	if data == nil {
+		data = &notification.TemplateData{}
+	}
+
+	subject, err := e.renderText(notificationType, channel, locale, PartSubject, data)
+	if err != nil {
+		return nil, err
+	}
+	text, err := e.renderText(notificationType, channel, locale, PartText, data)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &notification.Content{
+		Subject: strings.Join(strings.Fields(subject), " "),
+		Text:    collapseBlankLines(strings.TrimSpace(text)),
+	}
+
+	if tmpl, ok := e.lookupHTML(notificationType, channel, locale); ok {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, notification.NewTemplateError(notificationType, err.Error())
+		}
+		content.HTML = b.String()
+	}
+
+	return content, nil
+}
+
+func (e *Engine) renderText(notificationType notification.Type, channel notification.Channel, locale, part string, data *notification.TemplateData) (string, error) {
+	tmpl, ok := e.lookupText(notificationType, channel, locale, part)
+	if !ok {
+		return "", notification.NewTemplateError(notificationType,
+			fmt.Sprintf("no %s template for channel %s and locale %s", part, channel, locale))
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", notification.NewTemplateError(notificationType, err.Error())
+	}
+	return b.String(), nil
+}
+
+func (e *Engine) lookupText(notificationType notification.Type, channel notification.Channel, locale, part string) (*texttemplate.Template, bool) {
+	for _, key := range candidateKeys(notificationType, channel, locale, part) {
+		if tmpl, ok := e.text[key]; ok {
+			return tmpl, true
+		}
+	}
+	return nil, false
+}
+
+func (e *Engine) lookupHTML(notificationType notification.Type, channel notification.Channel, locale string) (*htmltemplate.Template, bool) {
+	for _, key := range candidateKeys(notificationType, channel, locale, PartHTML) {
+		if tmpl, ok := e.html[key]; ok {
+			return tmpl, true
+		}
+	}
+	return nil, false
+}
+
+// candidateKeys строит цепочку поиска: для каждой локали из LocaleChain сначала шаблон
+// канала, затем шаблон default.
+func candidateKeys(notificationType notification.Type, channel notification.Channel, locale, part string) []string {
+	var keys []string
+	for _, candidate := range LocaleChain(locale) {
+		keys = append(keys,
+			templateKey(string(notificationType), string(channel), candidate, part),
+			templateKey(string(notificationType), DefaultChannel, candidate, part),
+		)
+	}
+	return keys
+}
+
+// LocaleChain возвращает локаль и её запасные варианты: "pt-BR" → pt-br, pt, en.
+func LocaleChain(locale string) []string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(candidate string) {
+		if candidate != "" && !seen[candidate] {
+			seen[candidate] = true
+			chain = append(chain, candidate)
+		}
+	}
+
+	for candidate := locale; candidate != ""; {
+		add(candidate)
+		i := strings.LastIndex(candidate, "-")
+		if i < 0 {
+			break
+		}
+		candidate = candidate[:i]
+	}
+	add(notification.DefaultLocale)
+	return chain
+}
+
+// collapseBlankLines убирает повторяющиеся пустые строки, которые остаются от пустых
+// условных блоков шаблона.
+func collapseBlankLines(text string) string {
+	lines := strings.Split(text, "\n")
+	result := lines[:0]
+	blank := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if blank {
+				continue
+			}
+			blank = true
+			result = append(result, "")
+			continue
+		}
+		blank = false
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
+
+func templateKey(notificationType, channel, locale, part string) string {
+	return notificationType + "/" + channel + "." + locale + "." + part
+}
+
+func parseName(name string) (notificationType, channel, locale, part string, err error) {
+	dir, file := path.Split(name)
+	notificationType = strings.TrimSuffix(dir, "/")
+	fields := strings.Split(strings.TrimSuffix(file, extension), ".")
+
+	if strings.Contains(notificationType, "/") || !notification.Type(notificationType).HasTemplate() {
+		return "", "", "", "", fmt.Errorf("template %s: directory must be a notification type", name)
+	}
+	if len(fields) != 3 {
+		return "", "", "", "", fmt.Errorf("template %s: file name must be <channel>.<locale>.<part>%s", name, extension)
+	}
+
+	channel, locale, part = fields[0], strings.ToLower(fields[1]), fields[2]
+	if channel != DefaultChannel && !notification.Channel(channel).IsValid() {
+		return "", "", "", "", fmt.Errorf("template %s: unknown channel %s", name, channel)
+	}
+	if part != PartSubject && part != PartText && part != PartHTML {
+		return "", "", "", "", fmt.Errorf("template %s: unknown part %s", name, part)
+	}
+	return notificationType, channel, locale, part, nil
+}
+
+func partialName(name string) string {
+	return strings.SplitN(path.Base(name), ".", 2)[0]
+}
+
+func parseText(key, source string, partials map[string]string) (*texttemplate.Template, error) {
+	tmpl, err := texttemplate.New(key).Funcs(funcs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	for name, partial := range partials {
+		if _, err := tmpl.New(name).Parse(partial); err != nil {
+			return nil, fmt.Errorf("partial %s: %w", name, err)
+		}
+	}
+	return tmpl, nil
+}
+
+func parseHTML(key, source string, partials map[string]string) (*htmltemplate.Template, error) {
+	tmpl, err := htmltemplate.New(key).Funcs(funcs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	for name, partial := range partials {
+		if _, err := tmpl.New(name).Parse(partial); err != nil {
+			return nil, fmt.Errorf("partial %s: %w", name, err)
+		}
+	}
+	return tmpl, nil
+}
+
+// loadSources читает встроенные шаблоны и накладывает на них файлы из dir.
+func loadSources(dir string) (map[string]string, error) {
+	sources := make(map[string]string)
+
+	builtin, err := fs.Sub(embedded, "files")
+	if err != nil {
+		return nil, err
+	}
+	if err := readTemplates(builtin, sources); err != nil {
+		return nil, err
+	}
+
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("templates directory: %w", err)
+		}
+		if err := readTemplates(os.DirFS(dir), sources); err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+func readTemplates(fsys fs.FS, sources map[string]string) error {
+	return fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(name, extension) {
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		sources[name] = string(content)
+		return nil
+	})
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
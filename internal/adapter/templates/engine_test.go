@@ -0,0 +1,90 @@
+package templates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"orderflow/internal/domain/notification"
+)
+
+func TestEmbeddedTemplatesRenderEveryTypeAndChannel(t *testing.T) {
+	engine, err := New("")
+	if err != nil {
+		t.Fatalf("embedded templates are invalid: %v", err)
+	}
+
+	for _, notificationType := range notification.TemplateTypes {
+		for _, channel := range notification.Channels {
+			for _, locale := range []string{"en", "ru-RU", "de"} {
+				content, err := engine.Render(context.Background(), notificationType, channel, locale, sampleData())
+				if err != nil {
+					t.Fatalf("%s/%s/%s: %v", notificationType, channel, locale, err)
+				}
+				if content.Subject == "" || content.Text == "" {
+					t.Errorf("%s/%s/%s: empty subject or text", notificationType, channel, locale)
+				}
+				// Уведомление не о заказе должно называть товар
+				mention := "order-0001"
+				if !notificationType.RequiresOrder() {
+					mention = sampleData().Stock.SKU
+				}
+				if !strings.Contains(content.Text, mention) {
+					t.Errorf("%s/%s/%s: text does not mention %s: %q", notificationType, channel, locale, mention, content.Text)
+				}
+				if (channel == notification.ChannelEmail) != (content.HTML != "") {
+					t.Errorf("%s/%s/%s: unexpected html presence", notificationType, channel, locale)
+				}
+			}
+		}
+	}
+}
+
+func TestTemplateOverridesAndFallback(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("order_failed/sms.pt.txt.tmpl", "Pedido {{.OrderID}} falhou")
+	engine, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := engine.Render(context.Background(), notification.TypeOrderFailed, notification.ChannelSMS, "pt-BR", sampleData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Text != "Pedido order-0001 falhou" {
+		t.Errorf("override not used: %q", content.Text)
+	}
+	if content.Subject != "Order order-0001 could not be processed" {
+		t.Errorf("subject should fall back to the default locale: %q", content.Subject)
+	}
+
+	if got, want := LocaleChain("pt_BR"), []string{"pt-br", "pt", "en"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LocaleChain = %v, want %v", got, want)
+	}
+
+	write("order_failed/sms.pt.txt.tmpl", "{{.Payment.ID}}")
+	if _, err := New(dir); err == nil {
+		t.Error("expected validation error for nil payment access")
+	}
+
+	write("order_failed/sms.pt.txt.tmpl", "ok")
+	write("order_failed/fax.en.txt.tmpl", "ok")
+	if _, err := New(dir); err == nil {
+		t.Error("expected error for unknown channel")
+	}
+}
@@ -0,0 +1,60 @@
+package templates
+
+import (
+	"fmt"
+
+	"orderflow/internal/domain/notification"
+)
+
+var funcs = map[string]any{
+	// money форматирует сумму с валютой: {{money .TotalAmount .Currency}} → "59.97 USD"
+	"money": func(amount float64, currency string) string {
+		if currency == "" {
+			return fmt.Sprintf("%.2f", amount)
+		}
+		return fmt.Sprintf("%.2f %s", amount, currency)
+	},
+}
+
+// sampleData заполняет все поля TemplateData, чтобы Validate находил обращения к несуществующим полям.
+func sampleData() *notification.TemplateData {
+	return &notification.TemplateData{
+		OrderID:      "order-0001",
+		CustomerID:   "customer-0001",
+		CustomerName: "Sample Customer",
+		Items: []notification.TemplateItem{
+			{ProductID: "product-1", Name: "Sample product", Quantity: 2, Price: 10, Total: 20},
+		},
+		TotalAmount:   20,
+		Currency:      "USD",
+		FailureReason: "sample failure",
+		Payment: &notification.TemplatePayment{
+			ID:            "payment-0001",
+			Method:        "card",
+			Status:        "completed",
+			TransactionID: "txn-0001",
+			Amount:        20,
+			Currency:      "USD",
+		},
+		Digest: []notification.TemplateDigestEntry{
+			{Type: notification.TypeOrderCancelled, OrderID: "order-0001", Subject: "Order order-0001 cancelled", Text: "Your order order-0001 has been cancelled."},
+		},
+		Stock: &notification.TemplateStock{
+			ProductID:    "product-1",
+			SKU:          "SKU-0001",
+			Name:         "Sample product",
+			Available:    5,
+			Reserved:     2,
+			Free:         3,
+			ReorderPoint: 3,
+			WarehouseID:  "main",
+		},
+	}
+}
+
+func minimalData() *notification.TemplateData {
+	return &notification.TemplateData{
+		OrderID:    "order-0001",
+		CustomerID: "customer-0001",
+	}
+}
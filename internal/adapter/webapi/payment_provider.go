@@ -0,0 +1,136 @@
+package webapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"orderflow/internal/domain/payment"
+)
+
+const (
+	PaymentSignatureHeader = "X-Payment-Signature"
+	// DefaultSignatureTolerance — насколько метка времени подписи может отличаться от текущего
+	// времени; защищает от повторной отправки перехваченного запроса
+	DefaultSignatureTolerance = 5 * time.Minute
+)
+
+// HMACPaymentProvider разбирает callback-и провайдеров со схемой подписи
+// "t=<unix>,v1=<hex>", где v1 = HMAC-SHA256(secret, "<unix>.<тело>"). Тело:
+//
+//	{"id": "evt_1", "type": "charge.disputed", "created": 1700000000,
+//	 "data": {"payment_id": "...", "transaction_id": "...", "amount": 10.5, "reason": "fraudulent"}}
+type HMACPaymentProvider struct {
+	name      string
+	secret    string
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+func NewHMACPaymentProvider(name, secret string, tolerance time.Duration) *HMACPaymentProvider {
+	if tolerance <= 0 {
+		tolerance = DefaultSignatureTolerance
+	}
+	return &HMACPaymentProvider{
+		name:      name,
+		secret:    secret,
+		tolerance: tolerance,
+		now:       time.Now,
+	}
+}
+
+type providerWebhookBody struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		PaymentID     string  `json:"payment_id"`
+		TransactionID string  `json:"transaction_id"`
+		Amount        float64 `json:"amount"`
+		Reason        string  `json:"reason"`
+	} `json:"data"`
+}
+
+func (p *HMACPaymentProvider) Provider() string {
+	return p.name
+}
+
+func (p *HMACPaymentProvider) ParseWebhook(headers http.Header, body []byte) (*payment.ProviderEvent, error) {
+	if err := p.verify(headers.Get(PaymentSignatureHeader), body); err != nil {
+		return nil, err
+	}
+
+	var parsed providerWebhookBody
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, payment.NewValidationError("invalid webhook body: " + err.Error())
+	}
+
+	event := &payment.ProviderEvent{
+		Provider:      p.name,
+		ID:            parsed.ID,
+		Type:          payment.ProviderEventType(parsed.Type),
+		PaymentID:     parsed.Data.PaymentID,
+		TransactionID: parsed.Data.TransactionID,
+		Amount:        parsed.Data.Amount,
+		Reason:        parsed.Data.Reason,
+		OccurredAt:    p.now(),
+		Payload:       body,
+	}
+	if parsed.Created > 0 {
+		event.OccurredAt = time.Unix(parsed.Created, 0)
+	}
+	return event, nil
+}
+
+func (p *HMACPaymentProvider) verify(header string, body []byte) error {
+	if header == "" {
+		return payment.NewSignatureError(p.name, "missing "+PaymentSignatureHeader+" header")
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return payment.NewSignatureError(p.name, "malformed signature header")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return payment.NewSignatureError(p.name, "invalid timestamp")
+	}
+	if skew := p.now().Sub(time.Unix(unix, 0)); math.Abs(float64(skew)) > float64(p.tolerance) {
+		return payment.NewSignatureError(p.name, fmt.Sprintf("timestamp outside tolerance of %s", p.tolerance))
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	// Во время ротации секрета провайдер может прислать несколько подписей v1
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return payment.NewSignatureError(p.name, "signature mismatch")
+}
@@ -0,0 +1,78 @@
+package webapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"orderflow/internal/domain/payment"
+)
+
+const testWebhookSecret = "whsec_test"
+
+func signWebhook(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix + "."))
+	mac.Write(body)
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHMACPaymentProviderParseWebhook(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"evt_1","type":"charge.disputed","created":1700000000,"data":{"payment_id":"pay_1","reason":"fraudulent"}}`)
+	tampered := []byte(`{"id":"evt_1","type":"charge.refunded","created":1700000000,"data":{"payment_id":"pay_1","reason":"fraudulent"}}`)
+	valid := signWebhook(testWebhookSecret, now, body)
+
+	tests := []struct {
+		name    string
+		header  string
+		body    []byte
+		wantErr bool
+	}{
+		{name: "valid signature", header: valid, body: body},
+		{name: "one of rotated signatures matches", header: valid + ",v1=" + hex.EncodeToString([]byte("old")), body: body},
+		{name: "tampered body", header: valid, body: tampered, wantErr: true},
+		{name: "wrong secret", header: signWebhook("other", now, body), body: body, wantErr: true},
+		{name: "stale timestamp", header: signWebhook(testWebhookSecret, now.Add(-DefaultSignatureTolerance-time.Second), body), body: body, wantErr: true},
+		{name: "timestamp from the future", header: signWebhook(testWebhookSecret, now.Add(DefaultSignatureTolerance+time.Second), body), body: body, wantErr: true},
+		{name: "missing header", header: "", body: body, wantErr: true},
+		{name: "missing v1", header: "t=1700000000", body: body, wantErr: true},
+		{name: "missing timestamp", header: "v1=abcdef", body: body, wantErr: true},
+		{name: "non-numeric timestamp", header: "t=now,v1=abcdef", body: body, wantErr: true},
+		{name: "non-hex signature", header: "t=1700000000,v1=zz", body: body, wantErr: true},
+		{name: "no key-value pairs", header: "garbage", body: body, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewHMACPaymentProvider("acme", testWebhookSecret, 0)
+			provider.now = func() time.Time { return now }
+
+			headers := http.Header{}
+			if tt.header != "" {
+				headers.Set(PaymentSignatureHeader, tt.header)
+			}
+
+			event, err := provider.ParseWebhook(headers, tt.body)
+			if tt.wantErr {
+				var signatureErr *payment.SignatureError
+				if !errors.As(err, &signatureErr) {
+					t.Fatalf("ParseWebhook() error = %v, want *payment.SignatureError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWebhook() unexpected error: %v", err)
+			}
+			if event.ID != "evt_1" || event.Type != payment.ProviderEventChargeDisputed || event.PaymentID != "pay_1" {
+				t.Errorf("ParseWebhook() = %+v", event)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package webapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"orderflow/internal/domain/webhook"
+)
+
+const DefaultWebhookSendTimeout = 15 * time.Second
+
+// WebhookSender отправляет доставки вебхуков получателям. Редиректы не выполняются:
+// ответ 3xx возвращается как есть и считается неуспешной попыткой.
+type WebhookSender struct {
+	client *http.Client
+}
+
+func NewWebhookSender(timeout time.Duration) *WebhookSender {
+	if timeout <= 0 {
+		timeout = DefaultWebhookSendTimeout
+	}
+	return &WebhookSender{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (s *WebhookSender) Send(ctx context.Context, sendReq *webhook.SendRequest) (*webhook.SendResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendReq.URL, bytes.NewReader(sendReq.Body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range sendReq.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhook.MaxStoredResponseBytes))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return &webhook.SendResponse{StatusCode: resp.StatusCode, Body: body}, nil
+}
@@ -0,0 +1,27 @@
+package customer
+
+import "fmt"
+
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("customer validation error: %s", e.Message)
+}
+
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
+type NotFoundError struct {
+	CustomerID string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("customer not found: %s", e.CustomerID)
+}
+
+func NewNotFoundError(customerID string) *NotFoundError {
+	return &NotFoundError{CustomerID: customerID}
+}
@@ -0,0 +1,148 @@
+package customer
+
+import (
+	"net/mail"
+	"regexp"
+	"time"
+
+	"orderflow/internal/domain/notification"
+)
+
+const DefaultLocale = notification.DefaultLocale
+
+var (
+	localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+	// phonePattern — номер в формате E.164
+	phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+)
+
+// ContactPoint — адрес клиента в канале: email, телефон или токен устройства для push.
+type ContactPoint struct {
+	Channel notification.Channel `json:"channel"`
+	Address string               `json:"address"`
+}
+
+// Customer — контакты клиента и его настройки уведомлений.
+// Consents хранит явное согласие (true) или отказ (false) по типу уведомления;
+// отсутствие записи означает согласие: все текущие уведомления транзакционные.
+type Customer struct {
+	ID                string                     `json:"id"`
+	Name              string                     `json:"name,omitempty"`
+	Locale            string                     `json:"locale"`
+	Contacts          []ContactPoint             `json:"contacts"`
+	PreferredChannels []notification.Channel     `json:"preferred_channels"`
+	Consents          map[notification.Type]bool `json:"consents"`
+	CreatedAt         time.Time                  `json:"created_at"`
+	UpdatedAt         time.Time                  `json:"updated_at"`
+}
+
+type SaveRequest struct {
+	Name              string                     `json:"name,omitempty"`
+	Locale            string                     `json:"locale,omitempty"`
+	Contacts          []ContactPoint             `json:"contacts"`
+	PreferredChannels []notification.Channel     `json:"preferred_channels,omitempty"`
+	Consents          map[notification.Type]bool `json:"consents,omitempty"`
+}
+
+func (c *Customer) Contact(channel notification.Channel) (string, bool) {
+	for _, contact := range c.Contacts {
+		if contact.Channel == channel && contact.Address != "" {
+			return contact.Address, true
+		}
+	}
+	return "", false
+}
+
+func (c *Customer) Allows(notificationType notification.Type) bool {
+	optedIn, ok := c.Consents[notificationType]
+	return !ok || optedIn
+}
+
+// ChannelOrder возвращает предпочитаемые каналы, за которыми следуют остальные
+// каналы в порядке по умолчанию.
+func (c *Customer) ChannelOrder() []notification.Channel {
+	order := make([]notification.Channel, 0, len(notification.Channels))
+	seen := make(map[notification.Channel]bool, len(notification.Channels))
+	for _, channel := range append(append([]notification.Channel{}, c.PreferredChannels...), notification.Channels...) {
+		if !seen[channel] {
+			seen[channel] = true
+			order = append(order, channel)
+		}
+	}
+	return order
+}
+
+// Route выбирает канал и адрес для уведомления. Явно указанный канал используется,
+// только если в нём есть контакт; иначе берётся первый канал из ChannelOrder с контактом.
+func (c *Customer) Route(notificationType notification.Type, channel notification.Channel) (*notification.Recipient, error) {
+	if !c.Allows(notificationType) {
+		return nil, notification.NewOptedOutError(c.ID, notificationType)
+	}
+
+	candidates := c.ChannelOrder()
+	if channel != "" {
+		candidates = []notification.Channel{channel}
+	}
+
+	for _, candidate := range candidates {
+		if address, ok := c.Contact(candidate); ok {
+			return &notification.Recipient{Channel: candidate, Address: address, Locale: c.Locale, Name: c.Name}, nil
+		}
+	}
+	return nil, notification.NewRecipientNotFoundError(c.ID, channel)
+}
+
+func (c *Customer) Validate() error {
+	if c.ID == "" {
+		return NewValidationError("customer_id is required")
+	}
+	if !localePattern.MatchString(c.Locale) {
+		return NewValidationError("locale must be a language tag such as en or ru-RU")
+	}
+
+	channels := make(map[notification.Channel]bool, len(c.Contacts))
+	for _, contact := range c.Contacts {
+		if !contact.Channel.IsValid() {
+			return NewValidationError("unknown contact channel: " + string(contact.Channel))
+		}
+		if channels[contact.Channel] {
+			return NewValidationError("only one contact per channel is allowed: " + string(contact.Channel))
+		}
+		channels[contact.Channel] = true
+
+		if err := validateAddress(contact); err != nil {
+			return err
+		}
+	}
+
+	for _, channel := range c.PreferredChannels {
+		if !channel.IsValid() {
+			return NewValidationError("unknown preferred channel: " + string(channel))
+		}
+	}
+	for notificationType := range c.Consents {
+		if !notificationType.IsValid() {
+			return NewValidationError("unknown notification type in consents: " + string(notificationType))
+		}
+	}
+	return nil
+}
+
+func validateAddress(contact ContactPoint) error {
+	switch contact.Channel {
+	case notification.ChannelEmail:
+		address, err := mail.ParseAddress(contact.Address)
+		if err != nil || address.Address != contact.Address {
+			return NewValidationError("invalid email address: " + contact.Address)
+		}
+	case notification.ChannelSMS:
+		if !phonePattern.MatchString(contact.Address) {
+			return NewValidationError("phone number must be in E.164 format: " + contact.Address)
+		}
+	default:
+		if contact.Address == "" {
+			return NewValidationError("address is required for channel " + string(contact.Channel))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package customer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"orderflow/internal/domain/notification"
+)
+
+func TestCustomerRoute(t *testing.T) {
+	base := Customer{
+		ID:     "customer-1",
+		Name:   "Jane",
+		Locale: "ru",
+		Contacts: []ContactPoint{
+			{Channel: notification.ChannelEmail, Address: "jane@example.com"},
+			{Channel: notification.ChannelSMS, Address: "+15555550100"},
+		},
+		PreferredChannels: []notification.Channel{notification.ChannelSMS},
+		Consents:          map[notification.Type]bool{notification.TypeOrderConfirmed: false, notification.TypeOrderFailed: true},
+	}
+
+	tests := []struct {
+		name     string
+		customer Customer
+		typ      notification.Type
+		channel  notification.Channel
+		want     *notification.Recipient
+		wantErr  error
+	}{
+		{"preferred channel first", base, notification.TypeOrderFailed, "",
+			&notification.Recipient{Channel: notification.ChannelSMS, Address: "+15555550100", Locale: "ru", Name: "Jane"}, nil},
+		{"explicit channel", base, notification.TypeOrderFailed, notification.ChannelEmail,
+			&notification.Recipient{Channel: notification.ChannelEmail, Address: "jane@example.com", Locale: "ru", Name: "Jane"}, nil},
+		{"no consent record means opted in", base, notification.TypeOrderCancelled, "",
+			&notification.Recipient{Channel: notification.ChannelSMS, Address: "+15555550100", Locale: "ru", Name: "Jane"}, nil},
+		{"opted out", base, notification.TypeOrderConfirmed, notification.ChannelEmail,
+			nil, notification.NewOptedOutError("customer-1", notification.TypeOrderConfirmed)},
+		{"explicit channel without contact", base, notification.TypeOrderFailed, notification.ChannelPush,
+			nil, notification.NewRecipientNotFoundError("customer-1", notification.ChannelPush)},
+		{"no contacts", Customer{ID: "customer-2", Locale: "en"}, notification.TypeOrderFailed, "",
+			nil, notification.NewRecipientNotFoundError("customer-2", "")},
+		{"empty address skipped", Customer{ID: "customer-3", Locale: "en", Contacts: []ContactPoint{
+			{Channel: notification.ChannelEmail},
+			{Channel: notification.ChannelPush, Address: "device-token"},
+		}}, notification.TypeOrderFailed, "",
+			&notification.Recipient{Channel: notification.ChannelPush, Address: "device-token", Locale: "en"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.customer.Route(tt.typ, tt.channel)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("Route() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Route() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomerValidate(t *testing.T) {
+	valid := func(modify func(c *Customer)) *Customer {
+		c := &Customer{
+			ID:     "customer-1",
+			Locale: "ru-RU",
+			Contacts: []ContactPoint{
+				{Channel: notification.ChannelEmail, Address: "jane@example.com"},
+				{Channel: notification.ChannelSMS, Address: "+15555550100"},
+				{Channel: notification.ChannelPush, Address: "device-token"},
+			},
+			PreferredChannels: []notification.Channel{notification.ChannelPush},
+			Consents:          map[notification.Type]bool{notification.TypeOrderConfirmed: false},
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		customer *Customer
+		wantErr  bool
+	}{
+		{"valid", valid(nil), false},
+		{"missing id", valid(func(c *Customer) { c.ID = "" }), true},
+		{"bad locale", valid(func(c *Customer) { c.Locale = "Russian" }), true},
+		{"unknown contact channel", valid(func(c *Customer) {
+			c.Contacts = append(c.Contacts, ContactPoint{Channel: "fax", Address: "123"})
+		}), true},
+		{"duplicate channel", valid(func(c *Customer) {
+			c.Contacts = append(c.Contacts, ContactPoint{Channel: notification.ChannelEmail, Address: "other@example.com"})
+		}), true},
+		{"email with display name", valid(func(c *Customer) {
+			c.Contacts[0].Address = "Jane <jane@example.com>"
+		}), true},
+		{"phone not in E.164", valid(func(c *Customer) { c.Contacts[1].Address = "8 555 555 01 00" }), true},
+		{"empty push token", valid(func(c *Customer) { c.Contacts[2].Address = "" }), true},
+		{"unknown preferred channel", valid(func(c *Customer) {
+			c.PreferredChannels = []notification.Channel{"pigeon"}
+		}), true},
+		{"unknown consent type", valid(func(c *Customer) {
+			c.Consents = map[notification.Type]bool{"newsletter": true}
+		}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.customer.Validate()
+			var validationErr *ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package customer
+
+import (
+	"context"
+
+	"orderflow/internal/domain/notification"
+)
+
+type Repository interface {
+	GetCustomer(ctx context.Context, id string) (*Customer, error)
+
+	// SaveCustomer создаёт или обновляет клиента, полностью заменяя контакты и согласия
+	SaveCustomer(ctx context.Context, customer *Customer) error
+
+	DeleteCustomer(ctx context.Context, id string) error
+
+	SetConsent(ctx context.Context, customerID string, notificationType notification.Type, optedIn bool) error
+}
@@ -0,0 +1,18 @@
+package customer
+
+import (
+	"context"
+
+	"orderflow/internal/domain/notification"
+)
+
+type Service interface {
+	GetCustomer(ctx context.Context, id string) (*Customer, error)
+
+	SaveCustomer(ctx context.Context, id string, req *SaveRequest) (*Customer, error)
+
+	DeleteCustomer(ctx context.Context, id string) error
+
+	// SetConsent фиксирует согласие (true) или отказ (false) от уведомлений типа
+	SetConsent(ctx context.Context, id string, notificationType notification.Type, optedIn bool) (*Customer, error)
+}
@@ -0,0 +1,45 @@
+package inventory
+
+import "time"
+
+// EventStockLow — статус доменного события product.stock_low: свободный остаток товара
+// опустился до точки заказа.
+const EventStockLow = "stock_low"
+
+// StockLow — заканчивающийся товар: строка отчёта и содержимое события stock_low.
+// Free — свободный остаток (available - reserved). Для события WarehouseID, OrderID и
+// MovementID указывают на движение, после которого остаток пересёк порог; если порог
+// пересечён изменением точки заказа, они пусты.
+type StockLow struct {
+	ProductID    string    `json:"product_id"`
+	SKU          string    `json:"sku"`
+	Name         string    `json:"name"`
+	Available    int       `json:"available"`
+	Reserved     int       `json:"reserved"`
+	Free         int       `json:"free"`
+	ReorderPoint int       `json:"reorder_point"`
+	WarehouseID  string    `json:"warehouse_id,omitempty"`
+	OrderID      string    `json:"order_id,omitempty"`
+	MovementID   int64     `json:"movement_id,omitempty"`
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+func NewStockLow(product *Product) *StockLow {
+	return &StockLow{
+		ProductID:    product.ID,
+		SKU:          product.SKU,
+		Name:         product.Name,
+		Available:    product.Available,
+		Reserved:     product.Reserved,
+		Free:         product.Available - product.Reserved,
+		ReorderPoint: product.ReorderPoint,
+		DetectedAt:   time.Now(),
+	}
+}
+
+// CrossedReorderPoint сообщает, что товар стал заканчивающимся: до изменения before
+// остаток был выше точки заказа, а после изменения after — нет. Повторное событие
+// возможно только после того, как остаток снова поднимется выше порога.
+func CrossedReorderPoint(before, after *Product) bool {
+	return !before.IsLowStock() && after.IsLowStock()
+}
@@ -0,0 +1,252 @@
+package inventory
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Стратегии распределения заказа по складам
+const (
+	StrategyNearest      = "nearest"
+	StrategyCheapest     = "cheapest"
+	StrategyFewestSplits = "fewest_splits"
+
+	DefaultAllocationStrategy = StrategyNearest
+)
+
+// AllocationStrategy выбирает склады, с которых резервируются позиции заказа.
+// Стратегия не обращается к хранилищу: остатки передаются в AllocationRequest.
+type AllocationStrategy interface {
+	Name() string
+	Allocate(req *AllocationRequest) *Allocation
+}
+
+// AllocationRequest — позиции заказа и свободные остатки активных складов.
+// Без Destination склады сравниваются по Priority вместо расстояния.
+type AllocationRequest struct {
+	Items       []ReserveItem
+	Destination *GeoPoint
+	Warehouses  []*Warehouse
+	Stock       []*StockLevel
+}
+
+// AllocationLine — сколько единиц товара резервируется на складе.
+type AllocationLine struct {
+	ProductID   string `json:"product_id"`
+	WarehouseID string `json:"warehouse_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+// Allocation — результат распределения. Unavailable — позиции, которые не удалось
+// покрыть остатками складов; AvailableQuantity в них — сколько всё-таки нашлось.
+// Bundles — состав наборов из заказа на момент распределения.
+type Allocation struct {
+	Strategy    string              `json:"strategy"`
+	Lines       []AllocationLine    `json:"lines"`
+	Unavailable []UnavailableItem   `json:"unavailable,omitempty"`
+	Bundles     []BundleComposition `json:"bundles,omitempty"`
+}
+
+func (a *Allocation) IsComplete() bool {
+	return len(a.Unavailable) == 0
+}
+
+// Warehouses возвращает склады отгрузки в порядке первого появления.
+func (a *Allocation) Warehouses() []string {
+	seen := make(map[string]bool)
+	var warehouses []string
+	for _, line := range a.Lines {
+		if !seen[line.WarehouseID] {
+			seen[line.WarehouseID] = true
+			warehouses = append(warehouses, line.WarehouseID)
+		}
+	}
+	return warehouses
+}
+
+func (a *Allocation) add(productID, warehouseID string, quantity int) {
+	for i := range a.Lines {
+		if a.Lines[i].ProductID == productID && a.Lines[i].WarehouseID == warehouseID {
+			a.Lines[i].Quantity += quantity
+			return
+		}
+	}
+	a.Lines = append(a.Lines, AllocationLine{ProductID: productID, WarehouseID: warehouseID, Quantity: quantity})
+}
+
+func (a *Allocation) addShortage(item ReserveItem, missing int) {
+	if missing <= 0 {
+		return
+	}
+	a.Unavailable = append(a.Unavailable, UnavailableItem{
+		ProductID:         item.ProductID,
+		BundleID:          item.BundleID,
+		RequestedQuantity: item.Quantity,
+		AvailableQuantity: item.Quantity - missing,
+	})
+}
+
+// NewAllocationStrategy возвращает стратегию по имени; пустое имя — DefaultAllocationStrategy.
+func NewAllocationStrategy(name string) (AllocationStrategy, error) {
+	switch name {
+	case "", StrategyNearest:
+		return &rankedStrategy{name: StrategyNearest, less: nearer}, nil
+	case StrategyCheapest:
+		return &rankedStrategy{name: StrategyCheapest, less: cheaper}, nil
+	case StrategyFewestSplits:
+		return fewestSplitsStrategy{}, nil
+	}
+	return nil, fmt.Errorf("unknown allocation strategy: %s", name)
+}
+
+// freeStock — свободный остаток по товару и складу, уменьшается по мере распределения.
+type freeStock map[string]map[string]int
+
+func newFreeStock(levels []*StockLevel) freeStock {
+	stock := make(freeStock)
+	for _, level := range levels {
+		if stock[level.ProductID] == nil {
+			stock[level.ProductID] = make(map[string]int)
+		}
+		stock[level.ProductID][level.WarehouseID] += level.Free()
+	}
+	return stock
+}
+
+func (s freeStock) take(productID, warehouseID string, quantity int) int {
+	taken := min(quantity, s[productID][warehouseID])
+	if taken > 0 {
+		s[productID][warehouseID] -= taken
+	}
+	return taken
+}
+
+// warehouseLess сравнивает склады для заданного адреса доставки.
+type warehouseLess func(a, b *Warehouse, destination *GeoPoint) bool
+
+func nearer(a, b *Warehouse, destination *GeoPoint) bool {
+	if destination != nil {
+		da, db := a.Location.DistanceKm(*destination), b.Location.DistanceKm(*destination)
+		if da != db {
+			return da < db
+		}
+	}
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	return a.ID < b.ID
+}
+
+func cheaper(a, b *Warehouse, destination *GeoPoint) bool {
+	if a.ShippingCost != b.ShippingCost {
+		return a.ShippingCost < b.ShippingCost
+	}
+	return nearer(a, b, destination)
+}
+
+func rankWarehouses(warehouses []*Warehouse, destination *GeoPoint, less warehouseLess) []*Warehouse {
+	ranked := append([]*Warehouse(nil), warehouses...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return less(ranked[i], ranked[j], destination)
+	})
+	return ranked
+}
+
+// rankedStrategy берёт каждую позицию со складов в порядке less, переходя
+// к следующему складу, когда остаток предыдущего закончился.
+type rankedStrategy struct {
+	name string
+	less warehouseLess
+}
+
+func (s *rankedStrategy) Name() string {
+	return s.name
+}
+
+func (s *rankedStrategy) Allocate(req *AllocationRequest) *Allocation {
+	warehouses := rankWarehouses(req.Warehouses, req.Destination, s.less)
+	stock := newFreeStock(req.Stock)
+	allocation := &Allocation{Strategy: s.name, Lines: []AllocationLine{}}
+
+	for _, item := range req.Items {
+		remaining := item.Quantity
+		for _, warehouse := range warehouses {
+			if remaining == 0 {
+				break
+			}
+			if taken := stock.take(item.ProductID, warehouse.ID, remaining); taken > 0 {
+				allocation.add(item.ProductID, warehouse.ID, taken)
+				remaining -= taken
+			}
+		}
+		allocation.addShortage(item, remaining)
+	}
+
+	return allocation
+}
+
+// fewestSplitsStrategy минимизирует число отправлений: склад, который покрывает весь
+// заказ, выбирается целиком, иначе жадно берётся склад, покрывающий больше всего
+// оставшихся единиц. При равенстве предпочитается ближайший склад.
+type fewestSplitsStrategy struct{}
+
+func (fewestSplitsStrategy) Name() string {
+	return StrategyFewestSplits
+}
+
+func (fewestSplitsStrategy) Allocate(req *AllocationRequest) *Allocation {
+	warehouses := rankWarehouses(req.Warehouses, req.Destination, nearer)
+	stock := newFreeStock(req.Stock)
+	allocation := &Allocation{Strategy: StrategyFewestSplits, Lines: []AllocationLine{}}
+
+	remaining := make([]int, len(req.Items))
+	for i, item := range req.Items {
+		remaining[i] = item.Quantity
+	}
+
+	used := make(map[string]bool)
+	for {
+		var best *Warehouse
+		bestUnits := 0
+		for _, warehouse := range warehouses {
+			if used[warehouse.ID] {
+				continue
+			}
+			if units := coverable(stock, req.Items, remaining, warehouse.ID); units > bestUnits {
+				best, bestUnits = warehouse, units
+			}
+		}
+		if best == nil {
+			break
+		}
+
+		used[best.ID] = true
+		for i, item := range req.Items {
+			if taken := stock.take(item.ProductID, best.ID, remaining[i]); taken > 0 {
+				allocation.add(item.ProductID, best.ID, taken)
+				remaining[i] -= taken
+			}
+		}
+	}
+
+	for i, item := range req.Items {
+		allocation.addShortage(item, remaining[i])
+	}
+	return allocation
+}
+
+// coverable — сколько оставшихся единиц заказа склад может покрыть; позиции
+// одного товара делят его остаток.
+func coverable(stock freeStock, items []ReserveItem, remaining []int, warehouseID string) int {
+	spent := make(map[string]int)
+	units := 0
+	for i, item := range items {
+		free := stock[item.ProductID][warehouseID] - spent[item.ProductID]
+		taken := min(remaining[i], free)
+		if taken > 0 {
+			spent[item.ProductID] += taken
+			units += taken
+		}
+	}
+	return units
+}
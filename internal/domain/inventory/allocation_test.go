@@ -0,0 +1,183 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Москва рядом с адресом доставки, но дорогая; Казань дешевле; в Новосибирске есть всё
+var testWarehouses = []*Warehouse{
+	{ID: "msk", Location: GeoPoint{Latitude: 55.75, Longitude: 37.62}, ShippingCost: 5, Priority: 1, Active: true},
+	{ID: "kzn", Location: GeoPoint{Latitude: 55.79, Longitude: 49.12}, ShippingCost: 2, Priority: 2, Active: true},
+	{ID: "nsk", Location: GeoPoint{Latitude: 55.03, Longitude: 82.92}, ShippingCost: 3, Priority: 3, Active: true},
+}
+
+var testStock = []*StockLevel{
+	{ProductID: "phone", WarehouseID: "msk", Available: 5, Reserved: 3},
+	{ProductID: "phone", WarehouseID: "kzn", Available: 1},
+	{ProductID: "phone", WarehouseID: "nsk", Available: 10},
+	{ProductID: "case", WarehouseID: "msk", Available: 4},
+	{ProductID: "case", WarehouseID: "nsk", Available: 4},
+}
+
+var moscow = &GeoPoint{Latitude: 55.70, Longitude: 37.50}
+
+func TestAllocate(t *testing.T) {
+	items := []ReserveItem{{ProductID: "phone", Quantity: 3}, {ProductID: "case", Quantity: 2}}
+
+	tests := []struct {
+		strategy    string
+		destination *GeoPoint
+		want        []AllocationLine
+	}{
+		{StrategyNearest, moscow, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "msk", Quantity: 2},
+			{ProductID: "phone", WarehouseID: "kzn", Quantity: 1},
+			{ProductID: "case", WarehouseID: "msk", Quantity: 2},
+		}},
+		{StrategyCheapest, moscow, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "kzn", Quantity: 1},
+			{ProductID: "phone", WarehouseID: "nsk", Quantity: 2},
+			{ProductID: "case", WarehouseID: "nsk", Quantity: 2},
+		}},
+		{StrategyFewestSplits, moscow, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "nsk", Quantity: 3},
+			{ProductID: "case", WarehouseID: "nsk", Quantity: 2},
+		}},
+		// Без адреса доставки склады сравниваются по приоритету
+		{StrategyNearest, nil, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "msk", Quantity: 2},
+			{ProductID: "phone", WarehouseID: "kzn", Quantity: 1},
+			{ProductID: "case", WarehouseID: "msk", Quantity: 2},
+		}},
+	}
+
+	for _, tt := range tests {
+		strategy, err := NewAllocationStrategy(tt.strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		allocation := strategy.Allocate(&AllocationRequest{
+			Items:       items,
+			Destination: tt.destination,
+			Warehouses:  testWarehouses,
+			Stock:       testStock,
+		})
+		if !allocation.IsComplete() {
+			t.Errorf("%s: unexpected shortage %+v", tt.strategy, allocation.Unavailable)
+		}
+		if !reflect.DeepEqual(allocation.Lines, tt.want) {
+			t.Errorf("%s: lines = %+v, want %+v", tt.strategy, allocation.Lines, tt.want)
+		}
+	}
+}
+
+// Без адреса доставки порядок складов задаёт priority, даже если он расходится
+// с расстоянием: у заказов workflow координат адреса нет
+func TestAllocateWithoutDestination(t *testing.T) {
+	warehouses := []*Warehouse{
+		{ID: "msk", Location: GeoPoint{Latitude: 55.75, Longitude: 37.62}, ShippingCost: 5, Priority: 3, Active: true},
+		{ID: "kzn", Location: GeoPoint{Latitude: 55.79, Longitude: 49.12}, ShippingCost: 2, Priority: 2, Active: true},
+		{ID: "nsk", Location: GeoPoint{Latitude: 55.03, Longitude: 82.92}, ShippingCost: 2, Priority: 1, Active: true},
+	}
+	order := []ReserveItem{{ProductID: "phone", Quantity: 3}, {ProductID: "case", Quantity: 2}}
+	pair := []ReserveItem{{ProductID: "phone", Quantity: 2}, {ProductID: "case", Quantity: 2}}
+
+	tests := []struct {
+		name        string
+		strategy    string
+		items       []ReserveItem
+		destination *GeoPoint
+		want        []AllocationLine
+	}{
+		{"nearest by priority", StrategyNearest, order, nil, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "nsk", Quantity: 3},
+			{ProductID: "case", WarehouseID: "nsk", Quantity: 2},
+		}},
+		{"nearest by distance", StrategyNearest, order, moscow, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "msk", Quantity: 2},
+			{ProductID: "phone", WarehouseID: "kzn", Quantity: 1},
+			{ProductID: "case", WarehouseID: "msk", Quantity: 2},
+		}},
+		{"cheapest tie broken by priority", StrategyCheapest, order, nil, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "nsk", Quantity: 3},
+			{ProductID: "case", WarehouseID: "nsk", Quantity: 2},
+		}},
+		{"cheapest tie broken by distance", StrategyCheapest, order, moscow, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "kzn", Quantity: 1},
+			{ProductID: "phone", WarehouseID: "nsk", Quantity: 2},
+			{ProductID: "case", WarehouseID: "nsk", Quantity: 2},
+		}},
+		{"fewest splits tie broken by priority", StrategyFewestSplits, pair, nil, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "nsk", Quantity: 2},
+			{ProductID: "case", WarehouseID: "nsk", Quantity: 2},
+		}},
+		{"fewest splits tie broken by distance", StrategyFewestSplits, pair, moscow, []AllocationLine{
+			{ProductID: "phone", WarehouseID: "msk", Quantity: 2},
+			{ProductID: "case", WarehouseID: "msk", Quantity: 2},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := NewAllocationStrategy(tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			allocation := strategy.Allocate(&AllocationRequest{
+				Items:       tt.items,
+				Destination: tt.destination,
+				Warehouses:  warehouses,
+				Stock:       testStock,
+			})
+			if !reflect.DeepEqual(allocation.Lines, tt.want) {
+				t.Errorf("lines = %+v, want %+v", allocation.Lines, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateShortage(t *testing.T) {
+	for _, name := range []string{StrategyNearest, StrategyCheapest, StrategyFewestSplits} {
+		strategy, _ := NewAllocationStrategy(name)
+
+		// Позиции одного товара делят его остаток: свободно 13 телефонов
+		allocation := strategy.Allocate(&AllocationRequest{
+			Items: []ReserveItem{
+				{ProductID: "phone", Quantity: 10},
+				{ProductID: "phone", Quantity: 5},
+				{ProductID: "missing", Quantity: 1},
+			},
+			Destination: moscow,
+			Warehouses:  testWarehouses,
+			Stock:       testStock,
+		})
+
+		want := []UnavailableItem{
+			{ProductID: "phone", RequestedQuantity: 5, AvailableQuantity: 3},
+			{ProductID: "missing", RequestedQuantity: 1, AvailableQuantity: 0},
+		}
+		if !reflect.DeepEqual(allocation.Unavailable, want) {
+			t.Errorf("%s: unavailable = %+v, want %+v", name, allocation.Unavailable, want)
+		}
+
+		allocated := 0
+		for _, line := range allocation.Lines {
+			allocated += line.Quantity
+		}
+		if allocated != 13 {
+			t.Errorf("%s: allocated %d units, want 13", name, allocated)
+		}
+	}
+}
+
+func TestNewAllocationStrategyUnknown(t *testing.T) {
+	if _, err := NewAllocationStrategy("random"); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+	if strategy, _ := NewAllocationStrategy(""); strategy.Name() != DefaultAllocationStrategy {
+		t.Errorf("default strategy = %s, want %s", strategy.Name(), DefaultAllocationStrategy)
+	}
+}
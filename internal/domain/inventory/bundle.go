@@ -0,0 +1,132 @@
+package inventory
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BundleComponent — товар в составе набора и его количество в одном наборе.
+type BundleComponent struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+	Quantity  int    `json:"quantity"`
+}
+
+// BundleComposition — состав набора на момент резервирования: по нему в заказе видно,
+// из каких товаров собран набор, даже если состав потом изменится.
+type BundleComposition struct {
+	BundleID   string            `json:"bundle_id"`
+	Components []BundleComponent `json:"components"`
+}
+
+// ComponentRequest — компонент набора в запросе admin API: товар указывается по SKU.
+type ComponentRequest struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+// ExpandBundles заменяет позиции-наборы позициями их компонентов: на набор из N штук
+// резервируется N × количество каждого компонента. Позиции одного товара не
+// объединяются, чтобы нехватка компонента указывала на свой набор. Товары, которых
+// нет в products, и удалённые остаются как есть и попадают в нехватку при распределении.
+// Карточку с вариантами заказать нельзя — нужен конкретный вариант.
+func ExpandBundles(items []ReserveItem, products map[string]*Product) ([]ReserveItem, []BundleComposition, error) {
+	expanded := make([]ReserveItem, 0, len(items))
+	var bundles []BundleComposition
+	seen := make(map[string]bool)
+
+	for _, item := range items {
+		product, ok := products[item.ProductID]
+		switch {
+		case !ok || product.IsDeleted():
+			expanded = append(expanded, item)
+		case product.Kind == KindParent:
+			return nil, nil, NewValidationError(fmt.Sprintf("product %s has variants, order a specific variant", product.ID))
+		case product.IsBundle():
+			for _, component := range product.Components {
+				expanded = append(expanded, ReserveItem{
+					ProductID: component.ProductID,
+					BundleID:  product.ID,
+					Quantity:  item.Quantity * component.Quantity,
+				})
+			}
+			if !seen[product.ID] {
+				seen[product.ID] = true
+				bundles = append(bundles, BundleComposition{BundleID: product.ID, Components: product.Components})
+			}
+		default:
+			expanded = append(expanded, item)
+		}
+	}
+
+	return expanded, bundles, nil
+}
+
+// validateKind проверяет поля, зависящие от вида товара.
+func validateKind(p *Product) error {
+	if !p.Kind.IsValid() {
+		return NewValidationError("unknown product kind: " + string(p.Kind))
+	}
+
+	if !p.HasStock() {
+		if p.Available != 0 {
+			return NewValidationError(fmt.Sprintf("%s product has no own stock", p.Kind))
+		}
+		if p.ReorderPoint != 0 {
+			return NewValidationError(fmt.Sprintf("reorder_point is not supported for %s product", p.Kind))
+		}
+		if p.IsVariant() {
+			return NewValidationError(fmt.Sprintf("%s product can not be a variant", p.Kind))
+		}
+	}
+
+	if p.IsVariant() && len(p.Attributes) == 0 {
+		return NewValidationError("variant requires attributes")
+	}
+	if !p.IsVariant() && len(p.Attributes) > 0 {
+		return NewValidationError("attributes require parent_id")
+	}
+	for name, value := range p.Attributes {
+		if strings.TrimSpace(name) == "" || strings.TrimSpace(value) == "" {
+			return NewValidationError("attribute names and values must not be empty")
+		}
+	}
+
+	if !p.IsBundle() {
+		if len(p.Components) > 0 {
+			return NewValidationError("components are supported only for bundle products")
+		}
+		return nil
+	}
+
+	if len(p.Components) == 0 {
+		return NewValidationError("bundle requires components")
+	}
+	seen := make(map[string]bool, len(p.Components))
+	for _, component := range p.Components {
+		if component.Quantity <= 0 {
+			return NewValidationError("component quantity must be positive: " + component.SKU)
+		}
+		if component.ProductID == p.ID {
+			return NewValidationError("bundle can not contain itself")
+		}
+		if seen[component.ProductID] {
+			return NewValidationError("duplicate bundle component: " + component.SKU)
+		}
+		seen[component.ProductID] = true
+	}
+	return nil
+}
+
+// SameAttributes сообщает, что у вариантов одинаковый набор атрибутов.
+func SameAttributes(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if other, ok := b[name]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
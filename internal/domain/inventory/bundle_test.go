@@ -0,0 +1,80 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpandBundles(t *testing.T) {
+	deletedAt := time.Now()
+	products := map[string]*Product{
+		"phone": {ID: "phone", Kind: KindSimple},
+		"kit": {ID: "kit", Kind: KindBundle, Components: []BundleComponent{
+			{ProductID: "phone", SKU: "PHONE", Quantity: 1},
+			{ProductID: "case", SKU: "CASE", Quantity: 2},
+		}},
+		"old-kit": {ID: "old-kit", Kind: KindBundle, DeletedAt: &deletedAt, Components: []BundleComponent{
+			{ProductID: "phone", SKU: "PHONE", Quantity: 1},
+		}},
+	}
+
+	items := []ReserveItem{
+		{ProductID: "phone", Quantity: 1},
+		{ProductID: "kit", Quantity: 3},
+		{ProductID: "old-kit", Quantity: 1},
+		{ProductID: "missing", Quantity: 1},
+	}
+	expanded, bundles, err := ExpandBundles(items, products)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ReserveItem{
+		{ProductID: "phone", Quantity: 1},
+		{ProductID: "phone", BundleID: "kit", Quantity: 3},
+		{ProductID: "case", BundleID: "kit", Quantity: 6},
+		{ProductID: "old-kit", Quantity: 1},
+		{ProductID: "missing", Quantity: 1},
+	}
+	if !reflect.DeepEqual(expanded, want) {
+		t.Errorf("expanded = %+v, want %+v", expanded, want)
+	}
+	if len(bundles) != 1 || bundles[0].BundleID != "kit" || len(bundles[0].Components) != 2 {
+		t.Errorf("bundles = %+v, want composition of kit", bundles)
+	}
+}
+
+func TestAllocateBundleShortage(t *testing.T) {
+	products := map[string]*Product{
+		"kit": {ID: "kit", Kind: KindBundle, Components: []BundleComponent{
+			{ProductID: "phone", SKU: "PHONE", Quantity: 1},
+			{ProductID: "case", SKU: "CASE", Quantity: 2},
+		}},
+	}
+	expanded, _, err := ExpandBundles([]ReserveItem{{ProductID: "kit", Quantity: 5}}, products)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Свободно 8 чехлов, на 5 наборов нужно 10: нехватка указывает на набор
+	strategy, _ := NewAllocationStrategy(StrategyNearest)
+	allocation := strategy.Allocate(&AllocationRequest{
+		Items:      expanded,
+		Warehouses: testWarehouses,
+		Stock:      testStock,
+	})
+	want := []UnavailableItem{{ProductID: "case", BundleID: "kit", RequestedQuantity: 10, AvailableQuantity: 8}}
+	if !reflect.DeepEqual(allocation.Unavailable, want) {
+		t.Errorf("unavailable = %+v, want %+v", allocation.Unavailable, want)
+	}
+}
+
+func TestExpandBundlesRejectsParent(t *testing.T) {
+	products := map[string]*Product{"shirt": {ID: "shirt", Kind: KindParent}}
+
+	_, _, err := ExpandBundles([]ReserveItem{{ProductID: "shirt", Quantity: 1}}, products)
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("err = %v, want ValidationError", err)
+	}
+}
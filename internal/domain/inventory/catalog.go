@@ -0,0 +1,163 @@
+package inventory
+
+import "strings"
+
+// AdjustmentReason — причина ручной корректировки остатка.
+type AdjustmentReason string
+
+const (
+	ReasonRestock    AdjustmentReason = "restock"
+	ReasonReturn     AdjustmentReason = "return"
+	ReasonDamaged    AdjustmentReason = "damaged"
+	ReasonLost       AdjustmentReason = "lost"
+	ReasonCorrection AdjustmentReason = "correction"
+	// ReasonStocktake — остаток приведён к результату инвентаризации, в том числе при импорте каталога
+	ReasonStocktake AdjustmentReason = "stocktake"
+)
+
+func (r AdjustmentReason) IsValid() bool {
+	switch r {
+	case ReasonRestock, ReasonReturn, ReasonDamaged, ReasonLost, ReasonCorrection, ReasonStocktake:
+		return true
+	}
+	return false
+}
+
+// AdjustStockRequest меняет остаток на складе WarehouseID; пустой склад — DefaultWarehouseID.
+type AdjustStockRequest struct {
+	WarehouseID string           `json:"warehouse_id,omitempty"`
+	Delta       int              `json:"delta"`
+	Reason      AdjustmentReason `json:"reason"`
+	Note        string           `json:"note,omitempty"`
+}
+
+// MovementType — тип движения, которым корректировка попадает в журнал: поступление
+// и возврат учитываются отдельно от прочих корректировок.
+func (r *AdjustStockRequest) MovementType() MovementType {
+	switch r.Reason {
+	case ReasonRestock:
+		return MovementReceipt
+	case ReasonReturn:
+		return MovementReturn
+	}
+	return MovementAdjustment
+}
+
+func (r *AdjustStockRequest) Validate() error {
+	if r.Delta == 0 {
+		return NewValidationError("delta must not be zero")
+	}
+	if !r.Reason.IsValid() {
+		return NewValidationError("unknown adjustment reason: " + string(r.Reason))
+	}
+	return nil
+}
+
+// CreateProductRequest — начальный остаток Available поступает на склад DefaultWarehouseID.
+// Пустой Kind — KindSimple; вариант задаётся ParentID и Attributes, набор — Components.
+type CreateProductRequest struct {
+	ID           string             `json:"id,omitempty"`
+	Name         string             `json:"name"`
+	SKU          string             `json:"sku"`
+	Kind         ProductKind        `json:"kind,omitempty"`
+	Price        float64            `json:"price"`
+	Available    int                `json:"available"`
+	ReorderPoint int                `json:"reorder_point,omitempty"`
+	TaxClass     string             `json:"tax_class,omitempty"`
+	Weight       float64            `json:"weight,omitempty"`
+	ParentID     string             `json:"parent_id,omitempty"`
+	Attributes   map[string]string  `json:"attributes,omitempty"`
+	Components   []ComponentRequest `json:"components,omitempty"`
+}
+
+// UpdateProductRequest меняет только переданные поля. Остаток меняется через AdjustStock;
+// вид товара и родитель варианта не меняются. Attributes и Components заменяются целиком.
+type UpdateProductRequest struct {
+	Name         *string            `json:"name,omitempty"`
+	SKU          *string            `json:"sku,omitempty"`
+	Price        *float64           `json:"price,omitempty"`
+	ReorderPoint *int               `json:"reorder_point,omitempty"`
+	TaxClass     *string            `json:"tax_class,omitempty"`
+	Weight       *float64           `json:"weight,omitempty"`
+	Attributes   map[string]string  `json:"attributes,omitempty"`
+	Components   []ComponentRequest `json:"components,omitempty"`
+}
+
+// ValidateProduct проверяет карточку товара перед сохранением.
+func ValidateProduct(p *Product) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return NewValidationError("name is required")
+	}
+	if strings.TrimSpace(p.SKU) == "" {
+		return NewValidationError("sku is required")
+	}
+	if p.Price < 0 {
+		return NewValidationError("price must not be negative")
+	}
+	if p.Available < 0 {
+		return NewValidationError("available must not be negative")
+	}
+	if p.ReorderPoint < 0 {
+		return NewValidationError("reorder_point must not be negative")
+	}
+	if p.Weight < 0 {
+		return NewValidationError("weight must not be negative")
+	}
+	if strings.TrimSpace(p.TaxClass) == "" {
+		return NewValidationError("tax_class is required")
+	}
+	return validateKind(p)
+}
+
+// CatalogRow — строка импорта каталога. Товар ищется по SKU; Available == nil — остаток
+// существующего товара не меняется. Error — ошибка разбора строки.
+type CatalogRow struct {
+	Line      int     `json:"line"`
+	SKU       string  `json:"sku"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Available *int    `json:"available,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type ImportAction string
+
+const (
+	ImportCreated   ImportAction = "created"
+	ImportUpdated   ImportAction = "updated"
+	ImportUnchanged ImportAction = "unchanged"
+	ImportFailed    ImportAction = "failed"
+)
+
+type ImportRowResult struct {
+	Line      int          `json:"line"`
+	SKU       string       `json:"sku"`
+	ProductID string       `json:"product_id,omitempty"`
+	Action    ImportAction `json:"action"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// ImportResult — итог импорта каталога; ошибка строки не прерывает импорт остальных.
+type ImportResult struct {
+	Total     int               `json:"total"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+func (r *ImportResult) Add(row ImportRowResult) {
+	r.Total++
+	switch row.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportUnchanged:
+		r.Unchanged++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
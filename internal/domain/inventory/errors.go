@@ -68,3 +68,39 @@ func (e *ReservationExpiredError) Error() string {
 func NewReservationExpiredError(reservationID string) *ReservationExpiredError {
 	return &ReservationExpiredError{ReservationID: reservationID}
 }
+
+type WarehouseNotFoundError struct {
+	WarehouseID string
+}
+
+func (e *WarehouseNotFoundError) Error() string {
+	return fmt.Sprintf("warehouse not found: %s", e.WarehouseID)
+}
+
+func NewWarehouseNotFoundError(warehouseID string) *WarehouseNotFoundError {
+	return &WarehouseNotFoundError{WarehouseID: warehouseID}
+}
+
+type DuplicateWarehouseError struct {
+	WarehouseID string
+}
+
+func (e *DuplicateWarehouseError) Error() string {
+	return fmt.Sprintf("warehouse %s already exists", e.WarehouseID)
+}
+
+func NewDuplicateWarehouseError(warehouseID string) *DuplicateWarehouseError {
+	return &DuplicateWarehouseError{WarehouseID: warehouseID}
+}
+
+type DuplicateSKUError struct {
+	SKU string
+}
+
+func (e *DuplicateSKUError) Error() string {
+	return fmt.Sprintf("product with sku %s already exists", e.SKU)
+}
+
+func NewDuplicateSKUError(sku string) *DuplicateSKUError {
+	return &DuplicateSKUError{SKU: sku}
+}
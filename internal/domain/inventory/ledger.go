@@ -0,0 +1,80 @@
+package inventory
+
+import "time"
+
+// MovementType — вид движения в журнале остатков stock_movements.
+type MovementType string
+
+const (
+	MovementReceipt    MovementType = "receipt"
+	MovementAdjustment MovementType = "adjustment"
+	MovementReserve    MovementType = "reserve"
+	MovementRelease    MovementType = "release"
+	MovementSale       MovementType = "sale"
+	MovementReturn     MovementType = "return"
+)
+
+const DefaultMovementsLimit = 100
+
+// StockMovement — запись журнала остатков. Журнал только дополняется, а сумма дельт
+// по товару и складу равна остатку StockLevel; AvailableAfter и ReservedAfter — остаток склада.
+type StockMovement struct {
+	ID             int64            `json:"id"`
+	ProductID      string           `json:"product_id"`
+	WarehouseID    string           `json:"warehouse_id"`
+	Type           MovementType     `json:"type"`
+	AvailableDelta int              `json:"available_delta"`
+	ReservedDelta  int              `json:"reserved_delta"`
+	AvailableAfter int              `json:"available_after"`
+	ReservedAfter  int              `json:"reserved_after"`
+	Reason         AdjustmentReason `json:"reason,omitempty"`
+	OrderID        string           `json:"order_id,omitempty"`
+	ReservationID  string           `json:"reservation_id,omitempty"`
+	Note           string           `json:"note,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// NewReservationMovement — движение по резерву заказа.
+func NewReservationMovement(movementType MovementType, reservation *Reservation) *StockMovement {
+	return &StockMovement{
+		ProductID:     reservation.ProductID,
+		WarehouseID:   reservation.WarehouseID,
+		Type:          movementType,
+		OrderID:       reservation.OrderID,
+		ReservationID: reservation.ID,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// Record фиксирует фактическое изменение остатка склада относительно значений
+// available и reserved до изменения.
+func (m *StockMovement) Record(available, reserved int, level *StockLevel) {
+	m.AvailableDelta = level.Available - available
+	m.ReservedDelta = level.Reserved - reserved
+	m.AvailableAfter = level.Available
+	m.ReservedAfter = level.Reserved
+}
+
+func (m *StockMovement) IsEmpty() bool {
+	return m.AvailableDelta == 0 && m.ReservedDelta == 0
+}
+
+// StockDrift — расхождение счётчиков с суммой движений журнала: остатка на складе
+// WarehouseID или, если склад пуст, итоговых счётчиков товара.
+type StockDrift struct {
+	ProductID       string `json:"product_id"`
+	WarehouseID     string `json:"warehouse_id,omitempty"`
+	SKU             string `json:"sku"`
+	Available       int    `json:"available"`
+	Reserved        int    `json:"reserved"`
+	LedgerAvailable int    `json:"ledger_available"`
+	LedgerReserved  int    `json:"ledger_reserved"`
+}
+
+// ReconcileReport — итог сверки счётчиков с журналом. Applied — счётчики
+// расходящихся товаров заменены значениями из журнала.
+type ReconcileReport struct {
+	Checked int          `json:"checked"`
+	Drifts  []StockDrift `json:"drifts"`
+	Applied bool         `json:"applied"`
+}
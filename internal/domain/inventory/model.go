@@ -2,29 +2,73 @@ package inventory
 
 import "time"
 
+const (
+	DefaultReservationTTL   = 30 * time.Minute
+	DefaultCleanupBatchSize = 100
+	// DefaultTaxClass — налоговый класс товара, для которого класс не задан
+	DefaultTaxClass = "standard"
+)
+
+// ProductKind — вид товара. Остатки есть только у KindSimple; вариант (размер, цвет) —
+// это KindSimple с ParentID, указывающим на товар KindParent.
+type ProductKind string
+
+const (
+	KindSimple ProductKind = "simple"
+	// KindParent — карточка с вариантами: сама не продаётся и остатков не имеет
+	KindParent ProductKind = "parent"
+	// KindBundle — набор из других товаров: резервируются его компоненты
+	KindBundle ProductKind = "bundle"
+)
+
+func (k ProductKind) IsValid() bool {
+	switch k {
+	case KindSimple, KindParent, KindBundle:
+		return true
+	}
+	return false
+}
+
 type Product struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	SKU         string    `json:"sku"`
-	Price       float64   `json:"price"`
-	Available   int       `json:"available"`
-	Reserved    int       `json:"reserved"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	SKU       string      `json:"sku"`
+	Kind      ProductKind `json:"kind"`
+	Price     float64     `json:"price"`
+	Available int         `json:"available"`
+	Reserved  int         `json:"reserved"`
+	// ParentID и Attributes заполнены у вариантов: {"size": "M", "color": "black"}
+	ParentID   string            `json:"parent_id,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Components — состав набора, только у KindBundle
+	Components []BundleComponent `json:"components,omitempty"`
+	// ReorderPoint — точка заказа: при свободном остатке не выше неё товар считается
+	// заканчивающимся; 0 — порог не задан
+	ReorderPoint int `json:"reorder_point"`
+	// TaxClass — налоговый класс товара, ключ таблицы ставок вместе с юрисдикцией
+	TaxClass string `json:"tax_class"`
+	// Weight — вес единицы товара в килограммах, по нему считается стоимость доставки
+	Weight    float64   `json:"weight"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt — товар снят с продажи: не резервируется, но остаётся для заказов в работе
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type Reservation struct {
-	ID         string    `json:"id"`
-	OrderID    string    `json:"order_id"`
-	ProductID  string    `json:"product_id"`
-	Quantity   int       `json:"quantity"`
-	ExpiresAt  time.Time `json:"expires_at"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID          string    `json:"id"`
+	OrderID     string    `json:"order_id"`
+	ProductID   string    `json:"product_id"`
+	WarehouseID string    `json:"warehouse_id"`
+	Quantity    int       `json:"quantity"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type CheckRequest struct {
-	OrderID string      `json:"order_id"`
-	Items   []CheckItem `json:"items"`
+	OrderID     string      `json:"order_id"`
+	Items       []CheckItem `json:"items"`
+	Destination *GeoPoint   `json:"destination,omitempty"`
 }
 
 type CheckItem struct {
@@ -36,21 +80,28 @@ type CheckResponse struct {
 	Available        bool                `json:"available"`
 	UnavailableItems []UnavailableItem   `json:"unavailable_items,omitempty"`
 	ReservationID    string              `json:"reservation_id,omitempty"`
+	// Allocation — склады, с которых заказ будет собран при резервировании сейчас
+	Allocation *Allocation `json:"allocation,omitempty"`
 }
 
+// UnavailableItem — у позиции из набора BundleID — набор, а ProductID — компонент.
 type UnavailableItem struct {
-	ProductID        string `json:"product_id"`
-	RequestedQuantity int   `json:"requested_quantity"`
-	AvailableQuantity int   `json:"available_quantity"`
+	ProductID         string `json:"product_id"`
+	BundleID          string `json:"bundle_id,omitempty"`
+	RequestedQuantity int    `json:"requested_quantity"`
+	AvailableQuantity int    `json:"available_quantity"`
 }
 
 type ReserveRequest struct {
-	OrderID string        `json:"order_id"`
-	Items   []ReserveItem `json:"items"`
+	OrderID     string        `json:"order_id"`
+	Items       []ReserveItem `json:"items"`
+	Destination *GeoPoint     `json:"destination,omitempty"`
 }
 
+// ReserveItem — BundleID заполняется, когда позиция получена раскрытием набора.
 type ReserveItem struct {
 	ProductID string `json:"product_id"`
+	BundleID  string `json:"bundle_id,omitempty"`
 	Quantity  int    `json:"quantity"`
 }
 
@@ -92,6 +143,45 @@ func (p *Product) Sell(quantity int) error {
 	return nil
 }
 
+// IsLowStock сообщает, что свободный остаток опустился до точки заказа.
+func (p *Product) IsLowStock() bool {
+	return p.ReorderPoint > 0 && !p.IsDeleted() && p.Available-p.Reserved <= p.ReorderPoint
+}
+
+// HasStock сообщает, что у товара есть собственные остатки. Остатки набора — это
+// остатки его компонентов, у карточки с вариантами — остатки вариантов.
+func (p *Product) HasStock() bool {
+	return p.Kind != KindParent && p.Kind != KindBundle
+}
+
+func (p *Product) IsVariant() bool {
+	return p.ParentID != ""
+}
+
+func (p *Product) IsBundle() bool {
+	return p.Kind == KindBundle
+}
+
+func (p *Product) IsDeleted() bool {
+	return p.DeletedAt != nil
+}
+
+// AdjustStock меняет остаток на delta. Остаток не может стать меньше зарезервированного.
+func (p *Product) AdjustStock(delta int) error {
+	if p.Available+delta < p.Reserved {
+		return NewInsufficientStockError(p.ID, -delta, p.Available-p.Reserved)
+	}
+	p.Available += delta
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+func (p *Product) Delete() {
+	now := time.Now()
+	p.DeletedAt = &now
+	p.UpdatedAt = now
+}
+
 func (r *Reservation) IsExpired() bool {
 	return time.Now().After(r.ExpiresAt)
 }
\ No newline at end of file
@@ -6,14 +6,50 @@ import (
 
 type ReservationID string
 
+// Repository меняет остатки складов и итоговые счётчики товара только вместе с записью
+// движения в журнал stock_movements в одной транзакции. Когда товар опускается до точки
+// заказа, в той же транзакции в outbox пишется событие product.stock_low.
 type Repository interface {
+	// CreateProduct записывает начальный остаток движением receipt на DefaultWarehouseID
+	// и состав набора и возвращает DuplicateSKUError, если SKU занят другим товаром.
 	CreateProduct(ctx context.Context, product *Product) error
+	// GetProduct возвращает и удалённые товары: они нужны заказам, созданным до удаления.
+	// GetProduct, GetProductBySKU и GetProducts заполняют состав наборов.
 	GetProduct(ctx context.Context, productID string) (*Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (*Product, error)
+	// UpdateProduct сохраняет карточку товара (название, SKU, цену, точку заказа, атрибуты,
+	// состав набора, удаление); счётчики не меняет. Занятый SKU — DuplicateSKUError.
 	UpdateProduct(ctx context.Context, product *Product) error
-	GetProducts(ctx context.Context) ([]*Product, error)
-	
-	CreateReservation(ctx context.Context, reservation *Reservation) error
-	GetReservationByOrderID(ctx context.Context, orderID string) (*Reservation, error)
-	DeleteReservation(ctx context.Context, orderID string) error
+	GetProducts(ctx context.Context, includeDeleted bool) ([]*Product, error)
+	// GetVariants возвращает варианты карточки в продаже, упорядоченные по SKU.
+	GetVariants(ctx context.Context, parentID string) ([]*Product, error)
+	// GetLowStockProducts возвращает товары в продаже, чей свободный остаток не выше точки
+	// заказа, начиная с самых дефицитных.
+	GetLowStockProducts(ctx context.Context) ([]*Product, error)
+	// AdjustStock применяет StockLevel.AdjustStock на movement.AvailableDelta к складу
+	// movement.WarehouseID и дописывает movement.
+	AdjustStock(ctx context.Context, movement *StockMovement) (*Product, error)
+	// GetStockLevels возвращает остатки товаров по всем складам, где они когда-либо были.
+	GetStockLevels(ctx context.Context, productIDs []string) ([]*StockLevel, error)
+
+	// CreateWarehouse возвращает DuplicateWarehouseError, если склад с таким ID уже есть.
+	CreateWarehouse(ctx context.Context, warehouse *Warehouse) error
+	GetWarehouse(ctx context.Context, warehouseID string) (*Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouse *Warehouse) error
+	GetWarehouses(ctx context.Context, activeOnly bool) ([]*Warehouse, error)
+
+	// ReserveStock резервирует товары на складах резервов и создаёт резервы атомарно:
+	// при нехватке любого товара не резервируется ничего.
+	ReserveStock(ctx context.Context, reservations []*Reservation) error
+	// ReleaseReservations снимает все резервы заказа; ReservationNotFoundError, если их нет.
+	ReleaseReservations(ctx context.Context, orderID string) ([]*Reservation, error)
+	// ConfirmReservations списывает зарезервированные товары заказа как продажу.
+	ConfirmReservations(ctx context.Context, orderID string) ([]*Reservation, error)
 	GetExpiredReservations(ctx context.Context) ([]*Reservation, error)
+	ReleaseExpiredReservations(ctx context.Context, limit int) ([]*Reservation, error)
+
+	GetMovements(ctx context.Context, productID string, limit int) ([]*StockMovement, error)
+	// Reconcile сравнивает остатки складов и итоговые счётчики товаров с суммой движений;
+	// при apply заменяет расходящиеся значения значениями из журнала.
+	Reconcile(ctx context.Context, apply bool) (*ReconcileReport, error)
 }
@@ -4,14 +4,57 @@ import "context"
 
 type Service interface {
 	CheckAvailability(ctx context.Context, req *CheckRequest) (*CheckResponse, error)
-	
-	ReserveItems(ctx context.Context, req *ReserveRequest) error
-	
+
+	// ReserveItems распределяет позиции по складам стратегией сервиса и резервирует их.
+	ReserveItems(ctx context.Context, req *ReserveRequest) (*Allocation, error)
+
 	ReleaseReservation(ctx context.Context, orderID string) error
-	
+
 	ConfirmReservation(ctx context.Context, orderID string) error
-	
+
 	GetProduct(ctx context.Context, productID string) (*Product, error)
-	
+
 	UpdateStock(ctx context.Context, productID string, quantity int) error
-}
\ No newline at end of file
+
+	CleanupExpiredReservations(ctx context.Context, batchSize int) ([]*Reservation, error)
+}
+
+// CatalogService — управление каталогом товаров из admin API и CLI.
+type CatalogService interface {
+	ListProducts(ctx context.Context, includeDeleted bool) ([]*Product, error)
+
+	GetProduct(ctx context.Context, productID string) (*Product, error)
+
+	CreateProduct(ctx context.Context, req *CreateProductRequest) (*Product, error)
+
+	UpdateProduct(ctx context.Context, productID string, req *UpdateProductRequest) (*Product, error)
+
+	// ListVariants возвращает варианты карточки товара в продаже.
+	ListVariants(ctx context.Context, productID string) ([]*Product, error)
+
+	// DeleteProduct снимает товар с продажи; повторное удаление не ошибка.
+	DeleteProduct(ctx context.Context, productID string) error
+
+	AdjustStock(ctx context.Context, productID string, req *AdjustStockRequest) (*Product, error)
+
+	// LowStockReport возвращает товары в продаже, чей свободный остаток не выше точки заказа.
+	LowStockReport(ctx context.Context) ([]*StockLow, error)
+
+	// GetStockLevels возвращает остатки товара по складам.
+	GetStockLevels(ctx context.Context, productID string) ([]*StockLevel, error)
+
+	ListWarehouses(ctx context.Context) ([]*Warehouse, error)
+
+	CreateWarehouse(ctx context.Context, req *CreateWarehouseRequest) (*Warehouse, error)
+
+	UpdateWarehouse(ctx context.Context, warehouseID string, req *UpdateWarehouseRequest) (*Warehouse, error)
+
+	// GetMovements возвращает последние движения товара, новые первыми.
+	GetMovements(ctx context.Context, productID string, limit int) ([]*StockMovement, error)
+
+	// Reconcile сверяет счётчики остатков с журналом движений.
+	Reconcile(ctx context.Context, apply bool) (*ReconcileReport, error)
+
+	// ImportCatalog создаёт или обновляет товары по SKU; удалённый товар с тем же SKU восстанавливается.
+	ImportCatalog(ctx context.Context, rows []CatalogRow) (*ImportResult, error)
+}
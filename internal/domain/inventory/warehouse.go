@@ -0,0 +1,143 @@
+package inventory
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultWarehouseID — склад, на который попадает остаток без явного склада: начальный
+// остаток товара, импорт каталога и корректировки без warehouse_id.
+const DefaultWarehouseID = "main"
+
+// GeoPoint — координаты склада или адреса доставки в градусах.
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm — расстояние по большому кругу (формула гаверсинусов).
+func (p GeoPoint) DistanceKm(other GeoPoint) float64 {
+	lat1, lat2 := p.Latitude*math.Pi/180, other.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (other.Longitude - p.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func (p GeoPoint) Validate() error {
+	if p.Latitude < -90 || p.Latitude > 90 {
+		return NewValidationError("latitude must be between -90 and 90")
+	}
+	if p.Longitude < -180 || p.Longitude > 180 {
+		return NewValidationError("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// Warehouse — склад с собственными остатками. ShippingCost — стоимость отправки единицы
+// товара со склада, Priority — порядок выбора при равенстве (меньше — раньше).
+// Неактивный склад не участвует в распределении, но его остатки можно корректировать.
+type Warehouse struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Location     GeoPoint  `json:"location"`
+	ShippingCost float64   `json:"shipping_cost"`
+	Priority     int       `json:"priority"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// StockLevel — остаток товара на складе. Счётчики товара Product.Available и
+// Product.Reserved равны сумме остатков по всем складам.
+type StockLevel struct {
+	ProductID   string    `json:"product_id"`
+	WarehouseID string    `json:"warehouse_id"`
+	Available   int       `json:"available"`
+	Reserved    int       `json:"reserved"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Free — остаток, который ещё можно зарезервировать.
+func (l *StockLevel) Free() int {
+	if free := l.Available - l.Reserved; free > 0 {
+		return free
+	}
+	return 0
+}
+
+func (l *StockLevel) Reserve(quantity int) error {
+	if l.Free() < quantity {
+		return NewInsufficientStockError(l.ProductID, quantity, l.Free())
+	}
+	l.Reserved += quantity
+	l.UpdatedAt = time.Now()
+	return nil
+}
+
+func (l *StockLevel) ReleaseReservation(quantity int) {
+	if l.Reserved >= quantity {
+		l.Reserved -= quantity
+	} else {
+		l.Reserved = 0
+	}
+	l.UpdatedAt = time.Now()
+}
+
+func (l *StockLevel) Sell(quantity int) error {
+	if l.Available < quantity {
+		return NewInsufficientStockError(l.ProductID, quantity, l.Available)
+	}
+	l.Available -= quantity
+	if l.Reserved >= quantity {
+		l.Reserved -= quantity
+	}
+	l.UpdatedAt = time.Now()
+	return nil
+}
+
+// AdjustStock меняет остаток на складе на delta; он не может стать меньше зарезервированного.
+func (l *StockLevel) AdjustStock(delta int) error {
+	if l.Available+delta < l.Reserved {
+		return NewInsufficientStockError(l.ProductID, -delta, l.Available-l.Reserved)
+	}
+	l.Available += delta
+	l.UpdatedAt = time.Now()
+	return nil
+}
+
+type CreateWarehouseRequest struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Location     GeoPoint `json:"location"`
+	ShippingCost float64  `json:"shipping_cost"`
+	Priority     int      `json:"priority"`
+	Active       *bool    `json:"active,omitempty"`
+}
+
+// UpdateWarehouseRequest меняет только переданные поля.
+type UpdateWarehouseRequest struct {
+	Name         *string   `json:"name,omitempty"`
+	Location     *GeoPoint `json:"location,omitempty"`
+	ShippingCost *float64  `json:"shipping_cost,omitempty"`
+	Priority     *int      `json:"priority,omitempty"`
+	Active       *bool     `json:"active,omitempty"`
+}
+
+// ValidateWarehouse проверяет склад перед сохранением.
+func ValidateWarehouse(w *Warehouse) error {
+	if strings.TrimSpace(w.ID) == "" {
+		return NewValidationError("id is required")
+	}
+	if strings.TrimSpace(w.Name) == "" {
+		return NewValidationError("name is required")
+	}
+	if w.ShippingCost < 0 {
+		return NewValidationError("shipping_cost must not be negative")
+	}
+	return w.Location.Validate()
+}
@@ -0,0 +1,41 @@
+package notification
+
+import "time"
+
+const DefaultDigestWindow = 15 * time.Minute
+
+// LowPriorityTypes — типы, которые по умолчанию можно отложить до сводки: они не требуют
+// действий клиента. Ошибки заказа и оплаты отправляются сразу.
+var LowPriorityTypes = []Type{TypeOrderConfirmed, TypeOrderCancelled, TypeOrderTimeout}
+
+// DigestPolicy включает режим сводок: уведомления типов Types копятся по клиенту и каналу
+// и уходят одним сообщением через Window после первого из них.
+type DigestPolicy struct {
+	Enabled bool
+	Window  time.Duration
+	Types   []Type
+}
+
+// WithDefaults заменяет незаданные поля значениями по умолчанию.
+func (p DigestPolicy) WithDefaults() DigestPolicy {
+	if p.Window <= 0 {
+		p.Window = DefaultDigestWindow
+	}
+	if len(p.Types) == 0 {
+		p.Types = LowPriorityTypes
+	}
+	return p
+}
+
+// Applies сообщает, откладывается ли уведомление типа notificationType до сводки.
+func (p DigestPolicy) Applies(notificationType Type) bool {
+	if !p.Enabled {
+		return false
+	}
+	for _, t := range p.Types {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
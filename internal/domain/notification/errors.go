@@ -29,6 +29,8 @@ func NewNotFoundError(notificationID string) *NotFoundError {
 type SendError struct {
 	Channel Channel
 	Reason  string
+	// Permanent — получатель или провайдер окончательно отклонил сообщение, повтор не поможет
+	Permanent bool
 }
 
 func (e *SendError) Error() string {
@@ -39,6 +41,10 @@ func NewSendError(channel Channel, reason string) *SendError {
 	return &SendError{Channel: channel, Reason: reason}
 }
 
+func NewPermanentSendError(channel Channel, reason string) *SendError {
+	return &SendError{Channel: channel, Reason: reason, Permanent: true}
+}
+
 type UnsupportedChannelError struct {
 	Channel Channel
 }
@@ -62,4 +68,61 @@ func (e *TemplateError) Error() string {
 
 func NewTemplateError(notificationType Type, message string) *TemplateError {
 	return &TemplateError{Type: notificationType, Message: message}
-}
\ No newline at end of file
+}
+
+type RecipientNotFoundError struct {
+	CustomerID string
+	Channel    Channel
+}
+
+func (e *RecipientNotFoundError) Error() string {
+	if e.Channel == "" {
+		return fmt.Sprintf("no contact address for customer %s", e.CustomerID)
+	}
+	return fmt.Sprintf("no %s address for customer %s", e.Channel, e.CustomerID)
+}
+
+func NewRecipientNotFoundError(customerID string, channel Channel) *RecipientNotFoundError {
+	return &RecipientNotFoundError{CustomerID: customerID, Channel: channel}
+}
+
+type OptedOutError struct {
+	CustomerID string
+	Type       Type
+}
+
+func (e *OptedOutError) Error() string {
+	return fmt.Sprintf("customer %s opted out of %s notifications", e.CustomerID, e.Type)
+}
+
+func NewOptedOutError(customerID string, notificationType Type) *OptedOutError {
+	return &OptedOutError{CustomerID: customerID, Type: notificationType}
+}
+
+// StatusError — операция недоступна в текущем статусе уведомления.
+type StatusError struct {
+	NotificationID string
+	Status         Status
+	Expected       Status
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("notification %s is %s, expected %s", e.NotificationID, e.Status, e.Expected)
+}
+
+func NewStatusError(notificationID string, status, expected Status) *StatusError {
+	return &StatusError{NotificationID: notificationID, Status: status, Expected: expected}
+}
+
+// DuplicateError — уведомление с таким IdempotencyKey уже создано.
+type DuplicateError struct {
+	IdempotencyKey string
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("notification with idempotency key %s already exists", e.IdempotencyKey)
+}
+
+func NewDuplicateError(idempotencyKey string) *DuplicateError {
+	return &DuplicateError{IdempotencyKey: idempotencyKey}
+}
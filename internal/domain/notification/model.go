@@ -9,6 +9,11 @@ const (
 	TypeOrderFailed    Type = "order_failed"
 	TypeOrderCancelled Type = "order_cancelled"
 	TypePaymentFailed  Type = "payment_failed"
+	TypeOrderTimeout   Type = "order_timeout"
+	// TypeStockLow — товар опустился до точки заказа; уведомление мерчанту, не связано с заказом
+	TypeStockLow Type = "stock_low"
+	// TypeDigest — сводка нескольких отложенных уведомлений клиенту; не подписка, а форма доставки
+	TypeDigest Type = "digest"
 )
 
 type Channel string
@@ -19,24 +24,82 @@ const (
 	ChannelPush  Channel = "push"
 )
 
+// Channels — порядок каналов по умолчанию, если клиент не указал предпочтения
+var Channels = []Channel{ChannelEmail, ChannelSMS, ChannelPush}
+
+func (c Channel) IsValid() bool {
+	switch c {
+	case ChannelEmail, ChannelSMS, ChannelPush:
+		return true
+	}
+	return false
+}
+
+// Types — все типы уведомлений
+var Types = []Type{TypeOrderConfirmed, TypeOrderFailed, TypeOrderCancelled, TypePaymentFailed, TypeOrderTimeout, TypeStockLow}
+
+func (t Type) IsValid() bool {
+	switch t {
+	case TypeOrderConfirmed, TypeOrderFailed, TypeOrderCancelled, TypePaymentFailed, TypeOrderTimeout, TypeStockLow:
+		return true
+	}
+	return false
+}
+
+// RequiresOrder сообщает, что уведомление этого типа относится к заказу и без order_id невалидно
+func (t Type) RequiresOrder() bool {
+	return t != TypeStockLow
+}
+
+// TemplateTypes — типы, для которых нужны шаблоны: Types и TypeDigest
+var TemplateTypes = append(append([]Type{}, Types...), TypeDigest)
+
+func (t Type) HasTemplate() bool {
+	return t.IsValid() || t == TypeDigest
+}
+
 type Status string
 
 const (
 	StatusPending Status = "pending"
 	StatusSent    Status = "sent"
-	StatusFailed  Status = "failed"
+	// StatusFailed — попытка не удалась, следующая запланирована на NextAttemptAt
+	StatusFailed Status = "failed"
+	// StatusDead — попытки исчерпаны или отказ окончательный; вернуть в очередь можно через admin API
+	StatusDead Status = "dead"
+	// StatusDiscarded — dead-уведомление отброшено администратором
+	StatusDiscarded Status = "discarded"
+	// StatusBatched — уведомление отложено до отправки сводкой в NextAttemptAt
+	StatusBatched Status = "batched"
+	// StatusDigested — уведомление доставлено в составе сводки, её ID в Metadata[MetadataDigestID]
+	StatusDigested Status = "digested"
 )
 
+// MetadataDigestID — ключ метаданных со ссылкой на сводку, в которую вошло уведомление
+const MetadataDigestID = "digest_id"
+
 type Notification struct {
 	ID         string            `json:"id"`
 	CustomerID string            `json:"customer_id"`
 	OrderID    string            `json:"order_id"`
 	Type       Type              `json:"type"`
 	Channel    Channel           `json:"channel"`
+	// Recipient — адрес в канале (email, телефон, токен устройства); пусто — адрес ищет отправщик
+	Recipient  string            `json:"recipient,omitempty"`
+	Locale     string            `json:"locale,omitempty"`
 	Status     Status            `json:"status"`
 	Subject    string            `json:"subject"`
 	Message    string            `json:"message"`
+	// HTML — HTML-версия сообщения для email; пусто — HTML строится из Message
+	HTML       string            `json:"html,omitempty"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
+	// IdempotencyKey — уникальный ключ запроса; повторный Send с тем же ключом не создаёт новое уведомление
+	IdempotencyKey string        `json:"idempotency_key,omitempty"`
+	// Attempts — сколько раз отправщик пытался доставить уведомление
+	Attempts   int               `json:"attempts"`
+	// NextAttemptAt — когда очередь повторов снова попробует отправить уведомление
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty"`
+	LastError  string            `json:"last_error,omitempty"`
 	SentAt     *time.Time        `json:"sent_at,omitempty"`
 	CreatedAt  time.Time         `json:"created_at"`
 	UpdatedAt  time.Time         `json:"updated_at"`
@@ -46,10 +109,21 @@ type Request struct {
 	CustomerID string            `json:"customer_id"`
 	OrderID    string            `json:"order_id"`
 	Type       Type              `json:"type"`
-	Channel    Channel           `json:"channel"`
+	// Channel можно не указывать — канал выбирается по предпочтениям клиента
+	Channel    Channel           `json:"channel,omitempty"`
 	Subject    string            `json:"subject,omitempty"`
+	// Message задаёт текст явно; пусто — текст рендерится из шаблона по Data
 	Message    string            `json:"message"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
+	Data       *TemplateData     `json:"data,omitempty"`
+	// IdempotencyKey можно не указывать; см. NewIdempotencyKey
+	IdempotencyKey string        `json:"idempotency_key,omitempty"`
+}
+
+// NewIdempotencyKey строит ключ уведомления о заказе из запуска workflow: повтор activity
+// в том же запуске получает тот же ключ, а новый запуск (повторный заказ) — другой.
+func NewIdempotencyKey(orderID string, notificationType Type, runID string) string {
+	return orderID + ":" + string(notificationType) + ":" + runID
 }
 
 func NewNotification(req *Request) *Notification {
@@ -61,6 +135,7 @@ func NewNotification(req *Request) *Notification {
 		Subject:    req.Subject,
 		Message:    req.Message,
 		Metadata:   req.Metadata,
+		IdempotencyKey: req.IdempotencyKey,
 		Status:     StatusPending,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
@@ -75,15 +150,70 @@ func (n *Notification) IsFailed() bool {
 	return n.Status == StatusFailed
 }
 
+func (n *Notification) IsDead() bool {
+	return n.Status == StatusDead
+}
+
+func (n *Notification) IsBatched() bool {
+	return n.Status == StatusBatched
+}
+
 func (n *Notification) MarkAsSent() {
 	n.Status = StatusSent
 	now := time.Now()
 	n.SentAt = &now
+	n.NextAttemptAt = nil
+	n.LastError = ""
 	n.UpdatedAt = now
 }
 
-func (n *Notification) MarkAsFailed() {
+// ScheduleRetry отмечает неудачную попытку и планирует следующую.
+func (n *Notification) ScheduleRetry(reason string, nextAttemptAt time.Time) {
+	n.Status = StatusFailed
+	n.LastError = reason
+	n.NextAttemptAt = &nextAttemptAt
+	n.UpdatedAt = time.Now()
+}
+
+// MarkAsDead выводит уведомление из очереди повторов.
+func (n *Notification) MarkAsDead(reason string) {
+	n.Status = StatusDead
+	n.LastError = reason
+	n.NextAttemptAt = nil
+	n.UpdatedAt = time.Now()
+}
+
+// Requeue возвращает dead-уведомление в очередь с новым счётчиком попыток;
+// LastError сохраняется до следующей попытки.
+func (n *Notification) Requeue() {
+	now := time.Now()
 	n.Status = StatusFailed
+	n.Attempts = 0
+	n.NextAttemptAt = &now
+	n.UpdatedAt = now
+}
+
+// Batch откладывает уведомление до отправки сводкой в dueAt.
+func (n *Notification) Batch(dueAt time.Time) {
+	n.Status = StatusBatched
+	n.NextAttemptAt = &dueAt
+	n.UpdatedAt = time.Now()
+}
+
+// MarkAsDigested отмечает, что уведомление доставляется в составе сводки digestID.
+func (n *Notification) MarkAsDigested(digestID string) {
+	n.Status = StatusDigested
+	n.NextAttemptAt = nil
+	if n.Metadata == nil {
+		n.Metadata = make(map[string]string)
+	}
+	n.Metadata[MetadataDigestID] = digestID
+	n.UpdatedAt = time.Now()
+}
+
+func (n *Notification) Discard() {
+	n.Status = StatusDiscarded
+	n.NextAttemptAt = nil
 	n.UpdatedAt = time.Now()
 }
 
@@ -91,7 +221,7 @@ func (n *Notification) Validate() error {
 	if n.CustomerID == "" {
 		return NewValidationError("customer_id is required")
 	}
-	if n.OrderID == "" {
+	if n.OrderID == "" && n.Type.RequiresOrder() {
 		return NewValidationError("order_id is required")
 	}
 	if n.Type == "" {
@@ -104,4 +234,4 @@ func (n *Notification) Validate() error {
 		return NewValidationError("message is required")
 	}
 	return nil
-}
\ No newline at end of file
+}
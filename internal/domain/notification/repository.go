@@ -2,13 +2,27 @@ package notification
 
 import (
 	"context"
+	"time"
 )
 
 type Repository interface {
+	// CreateNotification возвращает DuplicateError, если уведомление с тем же IdempotencyKey уже есть.
 	CreateNotification(ctx context.Context, notification *Notification) error
 	GetNotification(ctx context.Context, id string) (*Notification, error)
 	GetNotificationsByOrderID(ctx context.Context, orderID string) ([]*Notification, error)
 	UpdateNotification(ctx context.Context, notification *Notification) error
 	GetNotifications(ctx context.Context) ([]*Notification, error)
-	GetFailedNotifications(ctx context.Context) ([]*Notification, error)
+	// ClaimDueNotifications забирает failed-уведомления с наступившим NextAttemptAt
+	// (FOR UPDATE SKIP LOCKED) и сдвигает их NextAttemptAt на lease, чтобы параллельные
+	// воркеры не взяли их повторно.
+	ClaimDueNotifications(ctx context.Context, limit int, lease time.Duration) ([]*Notification, error)
+	GetNotificationsByStatus(ctx context.Context, status Status, limit int) ([]*Notification, error)
+	GetNotificationByIdempotencyKey(ctx context.Context, key string) (*Notification, error)
+	// GetDigestDueAt возвращает ближайшее время отправки сводки клиенту в канале
+	// среди отложенных уведомлений или nil, если их нет.
+	GetDigestDueAt(ctx context.Context, customerID string, channel Channel) (*time.Time, error)
+	// ClaimDueDigests забирает отложенные уведомления с наступившим временем сводки
+	// так же, как ClaimDueNotifications, но limit считает получателей (клиент и канал):
+	// все готовые уведомления получателя забираются вместе, чтобы сводка не делилась.
+	ClaimDueDigests(ctx context.Context, limit int, lease time.Duration) ([]*Notification, error)
 }
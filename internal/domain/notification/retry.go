@@ -0,0 +1,70 @@
+package notification
+
+import (
+	"math"
+	"time"
+)
+
+// ClaimLease — на это время заявленное воркером уведомление скрыто от других воркеров.
+// Если воркер упал, не записав результат, уведомление снова попадёт в очередь после истечения.
+const ClaimLease = 5 * time.Minute
+
+// DefaultRetryBatchSize — сколько уведомлений забирает один проход очереди повторов.
+// Проход должен закончить все отправки до истечения ClaimLease, поэтому пачка небольшая.
+const DefaultRetryBatchSize = 5
+
+// RetryPolicy задаёт экспоненциальную задержку между попытками отправки.
+// После MaxAttempts попыток уведомление переходит в StatusDead.
+type RetryPolicy struct {
+	MaxAttempts        int
+	InitialInterval    time.Duration
+	MaxInterval        time.Duration
+	BackoffCoefficient float64
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:        5,
+	InitialInterval:    time.Minute,
+	MaxInterval:        time.Hour,
+	BackoffCoefficient: 2,
+}
+
+// WithDefaults заменяет незаданные поля значениями DefaultRetryPolicy.
+func (p RetryPolicy) WithDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = DefaultRetryPolicy.InitialInterval
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = DefaultRetryPolicy.MaxInterval
+	}
+	if p.BackoffCoefficient < 1 {
+		p.BackoffCoefficient = DefaultRetryPolicy.BackoffCoefficient
+	}
+	return p
+}
+
+// Delay возвращает задержку перед попыткой, следующей за attempt-й неудачной:
+// InitialInterval * BackoffCoefficient^(attempt-1), но не больше MaxInterval.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(p.InitialInterval) * math.Pow(p.BackoffCoefficient, float64(attempt-1))
+	if delay > float64(p.MaxInterval) {
+		return p.MaxInterval
+	}
+	return time.Duration(delay)
+}
+
+// RetryBatchResult — итог одного прохода очереди повторов.
+type RetryBatchResult struct {
+	Claimed     int `json:"claimed"`
+	Sent        int `json:"sent"`
+	Rescheduled int `json:"rescheduled"`
+	Dead        int `json:"dead"`
+	// Digested — сколько отложенных уведомлений объединено в сводки
+	Digested int `json:"digested"`
+}
@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:        5,
+		InitialInterval:    time.Minute,
+		MaxInterval:        10 * time.Minute,
+		BackoffCoefficient: 2,
+	}
+
+	tests := []struct {
+		name    string
+		attempt int
+		want    time.Duration
+	}{
+		{"zero attempts treated as first", 0, time.Minute},
+		{"first attempt", 1, time.Minute},
+		{"second attempt doubles", 2, 2 * time.Minute},
+		{"third attempt", 3, 4 * time.Minute},
+		{"fourth attempt", 4, 8 * time.Minute},
+		{"capped at max interval", 5, 10 * time.Minute},
+		{"far beyond max stays capped", 60, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyWithDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   RetryPolicy
+	}{
+		{"empty policy", RetryPolicy{}, DefaultRetryPolicy},
+		{"coefficient below one", RetryPolicy{MaxAttempts: 3, BackoffCoefficient: 0.5},
+			RetryPolicy{MaxAttempts: 3, InitialInterval: time.Minute, MaxInterval: time.Hour, BackoffCoefficient: 2}},
+		{"explicit values kept", RetryPolicy{MaxAttempts: 2, InitialInterval: time.Second, MaxInterval: time.Minute, BackoffCoefficient: 3},
+			RetryPolicy{MaxAttempts: 2, InitialInterval: time.Second, MaxInterval: time.Minute, BackoffCoefficient: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.WithDefaults(); got != tt.want {
+				t.Errorf("WithDefaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,18 @@ type Service interface {
 	GetByOrderID(ctx context.Context, orderID string) ([]*Notification, error)
 	
 	Retry(ctx context.Context, id string) error
+
+	// ProcessRetryQueue отправляет до batchSize уведомлений, у которых наступило время повтора.
+	// progress, если задан, получает промежуточный итог после каждой отправки.
+	ProcessRetryQueue(ctx context.Context, batchSize int, progress func(*RetryBatchResult)) (*RetryBatchResult, error)
+
+	ListDead(ctx context.Context, limit int) ([]*Notification, error)
+
+	// Requeue возвращает dead-уведомление в очередь повторов со сброшенным счётчиком попыток.
+	Requeue(ctx context.Context, id string) (*Notification, error)
+
+	// Discard окончательно отбрасывает dead-уведомление.
+	Discard(ctx context.Context, id string) (*Notification, error)
 }
 
 type Sender interface {
@@ -18,10 +30,30 @@ type Sender interface {
 	SupportedChannels() []Channel
 }
 
+// Template рендерит уведомление для типа, канала и локали. Если шаблона для локали нет,
+// используется цепочка запасных вариантов вплоть до DefaultLocale; ошибка — TemplateError.
 type Template interface {
-	Render(ctx context.Context, notificationType Type, data map[string]interface{}) (string, string, error)
-	
-	GetSubject(ctx context.Context, notificationType Type, data map[string]interface{}) (string, error)
-	
-	GetMessage(ctx context.Context, notificationType Type, data map[string]interface{}) (string, error)
-}
\ No newline at end of file
+	Render(ctx context.Context, notificationType Type, channel Channel, locale string, data *TemplateData) (*Content, error)
+}
+
+// AddressBook возвращает адрес клиента для канала: email, телефон или токен устройства.
+// Если адреса нет, возвращается RecipientNotFoundError.
+type AddressBook interface {
+	Address(ctx context.Context, customerID string, channel Channel) (string, error)
+}
+
+// Recipient — куда доставить уведомление конкретному клиенту.
+type Recipient struct {
+	Channel Channel
+	Address string
+	Locale  string
+	Name    string
+}
+
+// RecipientResolver выбирает канал и адрес по контактам и предпочтениям клиента.
+// Пустой channel — первый доступный канал из предпочтений. Если клиент отказался
+// от уведомлений этого типа, возвращается OptedOutError, если подходящего контакта нет —
+// RecipientNotFoundError.
+type RecipientResolver interface {
+	ResolveRecipient(ctx context.Context, customerID string, notificationType Type, channel Channel) (*Recipient, error)
+}
@@ -0,0 +1,64 @@
+package notification
+
+// DefaultLocale — последняя ступень цепочки локалей при выборе шаблона
+const DefaultLocale = "en"
+
+// TemplateData — данные для шаблонов уведомлений.
+type TemplateData struct {
+	OrderID       string           `json:"order_id"`
+	CustomerID    string           `json:"customer_id"`
+	CustomerName  string           `json:"customer_name,omitempty"`
+	Items         []TemplateItem   `json:"items,omitempty"`
+	TotalAmount   float64          `json:"total_amount"`
+	Currency      string           `json:"currency,omitempty"`
+	FailureReason string           `json:"failure_reason,omitempty"`
+	Payment       *TemplatePayment `json:"payment,omitempty"`
+	// Digest — уведомления, вошедшие в сводку; заполняется только для TypeDigest
+	Digest []TemplateDigestEntry `json:"digest,omitempty"`
+	// Stock — заканчивающийся товар; заполняется только для TypeStockLow
+	Stock *TemplateStock `json:"stock,omitempty"`
+}
+
+type TemplateItem struct {
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price"`
+	Total     float64 `json:"total"`
+}
+
+type TemplatePayment struct {
+	ID            string  `json:"id"`
+	Method        string  `json:"method"`
+	Status        string  `json:"status"`
+	TransactionID string  `json:"transaction_id,omitempty"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+}
+
+// TemplateStock — остаток товара, опустившийся до точки заказа.
+type TemplateStock struct {
+	ProductID    string `json:"product_id"`
+	SKU          string `json:"sku"`
+	Name         string `json:"name"`
+	Available    int    `json:"available"`
+	Reserved     int    `json:"reserved"`
+	Free         int    `json:"free"`
+	ReorderPoint int    `json:"reorder_point"`
+	WarehouseID  string `json:"warehouse_id,omitempty"`
+}
+
+// TemplateDigestEntry — одно уведомление в сводке с уже отрендеренными темой и текстом.
+type TemplateDigestEntry struct {
+	Type    Type   `json:"type"`
+	OrderID string `json:"order_id"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+}
+
+// Content — результат рендеринга: HTML заполняется только для каналов, у которых есть HTML-шаблон.
+type Content struct {
+	Subject string
+	Text    string
+	HTML    string
+}
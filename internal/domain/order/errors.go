@@ -26,6 +26,20 @@ func NewNotFoundError(orderID string) *NotFoundError {
 	return &NotFoundError{OrderID: orderID}
 }
 
+// DuplicateWorkflowError — заказ этого workflow уже создан: CreateOrderActivity повторена
+// после того, как её транзакция зафиксирована.
+type DuplicateWorkflowError struct {
+	WorkflowID string
+}
+
+func (e *DuplicateWorkflowError) Error() string {
+	return fmt.Sprintf("order for workflow %s already exists", e.WorkflowID)
+}
+
+func NewDuplicateWorkflowError(workflowID string) *DuplicateWorkflowError {
+	return &DuplicateWorkflowError{WorkflowID: workflowID}
+}
+
 type CannotCancelError struct {
 	Status Status
 }
@@ -1,6 +1,8 @@
 package order
 
 import (
+	"math"
+	"strings"
 	"time"
 )
 
@@ -13,6 +15,8 @@ const (
 	StatusCompleted  Status = "completed"
 	StatusFailed     Status = "failed"
 	StatusCancelled  Status = "cancelled"
+	// StatusTimedOut используется только в состоянии workflow: в БД такой заказ компенсируется и отменяется
+	StatusTimedOut Status = "timed_out"
 )
 
 type Order struct {
@@ -27,6 +31,41 @@ type Order struct {
 	PaymentID     string     `json:"payment_id,omitempty"`
 	FailureReason string     `json:"failure_reason,omitempty"`
 	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	WorkflowID    string     `json:"workflow_id,omitempty"`
+
+	// Allocation — склады, с которых собирается заказ; заполняется при резервировании
+	Allocation []AllocationLine `json:"allocation,omitempty"`
+
+	// Subtotal — сумма позиций до скидок;
+	// TotalAmount = Subtotal - DiscountAmount + ShippingAmount + TaxAmount
+	Subtotal       float64      `json:"subtotal"`
+	DiscountAmount float64      `json:"discount_amount,omitempty"`
+	CouponCode     string       `json:"coupon_code,omitempty"`
+	Adjustments    []Adjustment `json:"adjustments,omitempty"`
+
+	// TaxJurisdiction — юрисдикция из запроса; пустая — юрисдикция по умолчанию из конфигурации
+	TaxJurisdiction string    `json:"tax_jurisdiction,omitempty"`
+	TaxAmount       float64   `json:"tax_amount,omitempty"`
+	TaxLines        []TaxLine `json:"tax_lines,omitempty"`
+
+	// ShippingAddress пуст у заказов без доставки; BillingAddress по умолчанию совпадает с ним
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	BillingAddress  *Address `json:"billing_address,omitempty"`
+	ShippingMethod  string   `json:"shipping_method,omitempty"`
+	ShippingAmount  float64  `json:"shipping_amount,omitempty"`
+}
+
+// Address — адрес доставки или плательщика. Country — код страны ISO 3166-1 alpha-2,
+// Region — код штата или области внутри страны ("CA" для "US-CA").
+type Address struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone,omitempty"`
 }
 
 type Item struct {
@@ -34,11 +73,57 @@ type Item struct {
 	Name      string  `json:"name"`
 	Quantity  int     `json:"quantity"`
 	Price     float64 `json:"price"`
+	// Components — состав набора на момент резервирования; пусто у обычных товаров
+	Components []ItemComponent `json:"components,omitempty"`
+}
+
+// ItemComponent — товар в составе набора и его количество в одном наборе.
+type ItemComponent struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+	Quantity  int    `json:"quantity"`
+}
+
+// AllocationLine — сколько единиц товара отгружается со склада.
+type AllocationLine struct {
+	ProductID   string `json:"product_id"`
+	WarehouseID string `json:"warehouse_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+// Adjustment — скидка, применённая при создании заказа. У скидки на позицию
+// заполнен ProductID, у скидки на весь заказ он пуст.
+type Adjustment struct {
+	PromotionID string  `json:"promotion_id"`
+	Code        string  `json:"code,omitempty"`
+	Type        string  `json:"type"`
+	ProductID   string  `json:"product_id,omitempty"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
 }
 
+// TaxLine — налог на позицию заказа, посчитанный перед оплатой.
+type TaxLine struct {
+	ProductID     string  `json:"product_id"`
+	TaxClass      string  `json:"tax_class"`
+	Jurisdiction  string  `json:"jurisdiction"`
+	Name          string  `json:"name"`
+	Rate          float64 `json:"rate"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	Amount        float64 `json:"amount"`
+}
+
+// CreateRequest: пустая TaxJurisdiction берётся из адреса доставки, пустой ShippingMethod —
+// самый дешёвый из доступных способов.
 type CreateRequest struct {
-	CustomerID string `json:"customer_id"`
-	Items      []Item `json:"items"`
+	CustomerID      string   `json:"customer_id"`
+	Items           []Item   `json:"items"`
+	WorkflowID      string   `json:"workflow_id,omitempty"`
+	CouponCode      string   `json:"coupon_code,omitempty"`
+	TaxJurisdiction string   `json:"tax_jurisdiction,omitempty"`
+	ShippingAddress *Address `json:"shipping_address,omitempty"`
+	BillingAddress  *Address `json:"billing_address,omitempty"`
+	ShippingMethod  string   `json:"shipping_method,omitempty"`
 }
 
 func NewOrder(customerID string, items []Item) *Order {
@@ -97,12 +182,80 @@ func (o *Order) Cancel() error {
 }
 
 func (o *Order) CalculateTotal() float64 {
-	total := 0.0
+	subtotal := 0.0
 	for _, item := range o.Items {
-		total += item.Price * float64(item.Quantity)
+		subtotal += item.Price * float64(item.Quantity)
+	}
+	o.Subtotal = subtotal
+	// Скидки, доставка и налог округлены до копеек, итог тоже
+	o.TotalAmount = math.Round((subtotal-o.DiscountAmount+o.ShippingAmount+o.TaxAmount)*100) / 100
+	return o.TotalAmount
+}
+
+// ApplyAdjustments заменяет скидки заказа и пересчитывает итог.
+func (o *Order) ApplyAdjustments(adjustments []Adjustment) float64 {
+	o.Adjustments = adjustments
+	o.DiscountAmount = 0
+	for _, adjustment := range adjustments {
+		o.DiscountAmount += adjustment.Amount
+	}
+	return o.CalculateTotal()
+}
+
+// ApplyShipping записывает выбранный способ доставки и пересчитывает итог.
+func (o *Order) ApplyShipping(method string, amount float64) float64 {
+	o.ShippingMethod = method
+	o.ShippingAmount = amount
+	return o.CalculateTotal()
+}
+
+// ApplyTax заменяет налог заказа и пересчитывает итог.
+func (o *Order) ApplyTax(lines []TaxLine) float64 {
+	o.TaxLines = lines
+	o.TaxAmount = 0
+	for _, line := range lines {
+		o.TaxAmount += line.Amount
 	}
-	o.TotalAmount = total
-	return total
+	o.TaxAmount = math.Round(o.TaxAmount*100) / 100
+	return o.CalculateTotal()
+}
+
+// TaxableAmounts возвращает облагаемую сумму каждой позиции: цену за вычетом скидок.
+// Скидка на товар делится между его позициями, скидка на заказ — между всеми позициями
+// пропорционально их сумме.
+func (o *Order) TaxableAmounts() []float64 {
+	amounts := make([]float64, len(o.Items))
+	productTotals := make(map[string]float64)
+	for i, item := range o.Items {
+		amounts[i] = item.Price * float64(item.Quantity)
+		productTotals[item.ProductID] += amounts[i]
+	}
+
+	orderDiscount := 0.0
+	productDiscounts := make(map[string]float64)
+	for _, adjustment := range o.Adjustments {
+		if adjustment.ProductID == "" {
+			orderDiscount += adjustment.Amount
+		} else {
+			productDiscounts[adjustment.ProductID] += adjustment.Amount
+		}
+	}
+
+	afterLineDiscounts := 0.0
+	for i, item := range o.Items {
+		if total := productTotals[item.ProductID]; total > 0 {
+			amounts[i] -= productDiscounts[item.ProductID] * amounts[i] / total
+		}
+		afterLineDiscounts += amounts[i]
+	}
+
+	for i := range amounts {
+		if orderDiscount > 0 && afterLineDiscounts > 0 {
+			amounts[i] -= orderDiscount * amounts[i] / afterLineDiscounts
+		}
+		amounts[i] = math.Max(0, math.Round(amounts[i]*100)/100)
+	}
+	return amounts
 }
 
 func (o *Order) Validate() error {
@@ -128,3 +281,42 @@ func (o *Order) Validate() error {
 
 	return nil
 }
+
+// Normalize убирает пробелы по краям и приводит коды страны и региона к верхнему регистру.
+func (a *Address) Normalize() {
+	a.Name = strings.TrimSpace(a.Name)
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.Line2 = strings.TrimSpace(a.Line2)
+	a.City = strings.TrimSpace(a.City)
+	a.Region = strings.ToUpper(strings.TrimSpace(a.Region))
+	a.PostalCode = strings.TrimSpace(a.PostalCode)
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.Phone = strings.TrimSpace(a.Phone)
+}
+
+func (a *Address) Validate() error {
+	if a.Name == "" {
+		return NewValidationError("address name is required")
+	}
+	if a.Line1 == "" {
+		return NewValidationError("address line1 is required")
+	}
+	if a.City == "" {
+		return NewValidationError("address city is required")
+	}
+	if a.PostalCode == "" {
+		return NewValidationError("address postal_code is required")
+	}
+	if len(a.Country) != 2 || strings.Trim(a.Country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return NewValidationError("address country must be an ISO 3166-1 alpha-2 code")
+	}
+	return nil
+}
+
+// Jurisdiction возвращает налоговую юрисдикцию адреса: "US-CA" или "DE".
+func (a *Address) Jurisdiction() string {
+	if a.Region == "" {
+		return a.Country
+	}
+	return a.Country + "-" + a.Region
+}
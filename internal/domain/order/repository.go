@@ -3,16 +3,30 @@ package order
 import "context"
 
 type Repository interface {
+	// Create сохраняет заказ со скидками и в той же транзакции резервирует погашение купонов
+	// из скидок; исчерпанный лимит купона — promotion.CouponError. Второй заказ того же
+	// workflow не создаётся — DuplicateWorkflowError.
 	Create(ctx context.Context, order *Order) error
 
 	GetByID(ctx context.Context, id string) (*Order, error)
 
+	// GetByWorkflowID возвращает заказ, созданный workflow; нет такого — NotFoundError.
+	GetByWorkflowID(ctx context.Context, workflowID string) (*Order, error)
+
 	Update(ctx context.Context, order *Order) error
 
 	UpdateStatus(ctx context.Context, id string, status Status) error
 
 	SetFailure(ctx context.Context, id string, reason string) error
 
+	SetAllocation(ctx context.Context, id string, lines []AllocationLine) error
+
+	// SetItemComponents заменяет состав наборов в заказе; ключ — ID товара-набора.
+	SetItemComponents(ctx context.Context, id string, components map[string][]ItemComponent) error
+
+	// SetTax заменяет налоговые строки заказа и записывает его TaxAmount и TotalAmount.
+	SetTax(ctx context.Context, order *Order) error
+
 	GetByCustomerID(ctx context.Context, customerID string) ([]*Order, error)
 
 	List(ctx context.Context, offset, limit int) ([]*Order, error)
@@ -7,6 +7,8 @@ type Service interface {
 
 	GetByID(ctx context.Context, id string) (*Order, error)
 
+	// Cancel, UpdateStatus и SetFailure освобождают погашения купонов заказа,
+	// когда он переходит в cancelled или failed.
 	Cancel(ctx context.Context, id string) error
 
 	UpdateStatus(ctx context.Context, id string, status Status) error
@@ -14,4 +16,13 @@ type Service interface {
 	SetFailure(ctx context.Context, id string, reason string) error
 
 	Complete(ctx context.Context, id string, paymentID string) error
+
+	// SetAllocation заменяет распределение заказа по складам.
+	SetAllocation(ctx context.Context, id string, lines []AllocationLine) error
+
+	// SetItemComponents записывает в позиции-наборы их состав; ключ — ID товара-набора.
+	SetItemComponents(ctx context.Context, id string, components map[string][]ItemComponent) error
+
+	// ApplyTax записывает налог заказа и возвращает заказ с пересчитанным итогом.
+	ApplyTax(ctx context.Context, id string, lines []TaxLine) (*Order, error)
 }
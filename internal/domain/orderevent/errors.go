@@ -0,0 +1,15 @@
+package orderevent
+
+import "fmt"
+
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("order event validation error: %s", e.Message)
+}
+
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
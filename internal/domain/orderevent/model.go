@@ -0,0 +1,51 @@
+package orderevent
+
+import (
+	"time"
+
+	"orderflow/internal/domain/order"
+)
+
+// Channel — канал Postgres LISTEN/NOTIFY, в который триггер публикует новые события.
+const Channel = "order_step_events"
+
+// DefaultListLimit ограничивает число событий, отдаваемых за один запрос к БД.
+const DefaultListLimit = 100
+
+// Event — переход шага из workflow.State.StepHistory. ID монотонно растёт и используется
+// как id события SSE (Last-Event-ID), Seq — порядковый номер события внутри workflow
+// и ключ дедупликации при повторе local activity.
+type Event struct {
+	ID          int64        `json:"id"`
+	WorkflowID  string       `json:"workflow_id"`
+	Seq         int          `json:"seq"`
+	OrderID     string       `json:"order_id,omitempty"`
+	Step        string       `json:"step"`
+	StepStatus  string       `json:"step_status"`
+	OrderStatus order.Status `json:"order_status"`
+	ErrorCode   string       `json:"error_code,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	OccurredAt  time.Time    `json:"occurred_at"`
+}
+
+// IsTerminal сообщает, что после события заказ больше не меняется и поток можно закрыть.
+func (e *Event) IsTerminal() bool {
+	switch e.OrderStatus {
+	case order.StatusCompleted, order.StatusFailed, order.StatusCancelled, order.StatusTimedOut:
+		return true
+	}
+	return false
+}
+
+func (e *Event) Validate() error {
+	if e.WorkflowID == "" {
+		return NewValidationError("workflow_id is required")
+	}
+	if e.Step == "" {
+		return NewValidationError("step is required")
+	}
+	if e.StepStatus == "" {
+		return NewValidationError("step_status is required")
+	}
+	return nil
+}
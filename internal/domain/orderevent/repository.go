@@ -0,0 +1,13 @@
+package orderevent
+
+import "context"
+
+type Repository interface {
+	// Append сохраняет событие; повтор с тем же (WorkflowID, Seq) игнорируется
+	Append(ctx context.Context, event *Event) error
+
+	ListAfter(ctx context.Context, workflowID string, afterID int64, limit int) ([]*Event, error)
+
+	// Listen блокируется и вызывает notify для каждого нового события, пока ctx не отменён
+	Listen(ctx context.Context, notify func(workflowID string, id int64)) error
+}
@@ -0,0 +1,13 @@
+package orderevent
+
+import "context"
+
+type Service interface {
+	Record(ctx context.Context, event *Event) error
+
+	ListAfter(ctx context.Context, workflowID string, afterID int64) ([]*Event, error)
+
+	// Subscribe возвращает канал, который получает сигнал при появлении новых событий
+	// workflow. Сами события читаются через ListAfter. unsubscribe обязателен.
+	Subscribe(workflowID string) (updates <-chan struct{}, unsubscribe func())
+}
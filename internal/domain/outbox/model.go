@@ -0,0 +1,102 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/notification"
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/payment"
+)
+
+const (
+	AggregateOrder        = "order"
+	AggregatePayment      = "payment"
+	AggregateNotification = "notification"
+	AggregateProduct      = "product"
+)
+
+// EventTypeStockLow — товар опустился до точки заказа, payload — inventory.StockLow
+const EventTypeStockLow = AggregateProduct + "." + inventory.EventStockLow
+
+const (
+	DefaultRelayBatchSize    = 100
+	DefaultRelayPollInterval = time.Second
+	// MaxRetryDelay ограничивает экспоненциальную задержку между попытками публикации
+	MaxRetryDelay = 10 * time.Minute
+	// ClaimLease — на сколько релей забирает пачку. Если релей упал во время публикации,
+	// события снова становятся доступны по истечении аренды.
+	ClaimLease = 5 * time.Minute
+)
+
+// Event — запись outbox, сохранённая в одной транзакции с изменением состояния.
+// Тип события — "<aggregate>.<status>", например order.completed или payment.refunded.
+// DedupKey уникален для перехода агрегата в статус и включает момент перехода: повторная запись
+// того же перехода (ретрай activity) не создаёт второго события, а повторный переход в статус,
+// в котором агрегат уже бывал (failed → pending → failed), — новое событие. Получатели используют
+// ключ для идемпотентности.
+type Event struct {
+	ID            int64           `json:"id"`
+	DedupKey      string          `json:"dedup_key"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+
+	Attempts  int    `json:"-"`
+	LastError string `json:"-"`
+}
+
+// AggregateKey — ключ, в пределах которого релей сохраняет порядок публикации.
+func (e *Event) AggregateKey() string {
+	return e.AggregateType + ":" + e.AggregateID
+}
+
+// NewEvent создаёт событие перехода агрегата в status в момент occurredAt.
+func NewEvent(aggregateType, aggregateID, status string, occurredAt time.Time, payload interface{}) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event payload: %w", aggregateType, err)
+	}
+
+	return &Event{
+		DedupKey:      aggregateType + ":" + aggregateID + ":" + status + ":" + strconv.FormatInt(occurredAt.UnixNano(), 10),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Type:          aggregateType + "." + status,
+		Payload:       data,
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+type OrderStatusPayload struct {
+	OrderID        string       `json:"order_id"`
+	CustomerID     string       `json:"customer_id"`
+	Status         order.Status `json:"status"`
+	PreviousStatus order.Status `json:"previous_status"`
+	TotalAmount    float64      `json:"total_amount"`
+	PaymentID      string       `json:"payment_id,omitempty"`
+	FailureReason  string       `json:"failure_reason,omitempty"`
+}
+
+// NewOrderStatusEvent — updatedAt — значение orders.updated_at, записанное вместе со сменой статуса.
+func NewOrderStatusEvent(payload OrderStatusPayload, updatedAt time.Time) (*Event, error) {
+	return NewEvent(AggregateOrder, payload.OrderID, string(payload.Status), updatedAt, payload)
+}
+
+func NewPaymentEvent(p *payment.Payment) (*Event, error) {
+	return NewEvent(AggregatePayment, p.ID, string(p.Status), p.UpdatedAt, p)
+}
+
+func NewNotificationEvent(n *notification.Notification) (*Event, error) {
+	return NewEvent(AggregateNotification, n.ID, string(n.Status), n.UpdatedAt, n)
+}
+
+// NewStockLowEvent — товар может пересекать порог многократно, моментом перехода служит момент обнаружения.
+func NewStockLowEvent(alert *inventory.StockLow) (*Event, error) {
+	return NewEvent(AggregateProduct, alert.ProductID, inventory.EventStockLow, alert.DetectedAt, alert)
+}
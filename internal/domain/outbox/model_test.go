@@ -0,0 +1,48 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+
+	"orderflow/internal/domain/notification"
+)
+
+func TestNotificationEventDedupKey(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	n := &notification.Notification{ID: "n-1"}
+
+	// Уведомление проходит failed → pending → failed: оба перехода в failed — разные события
+	transitions := []struct {
+		status notification.Status
+		at     time.Time
+	}{
+		{notification.StatusFailed, start},
+		{notification.StatusPending, start.Add(time.Minute)},
+		{notification.StatusFailed, start.Add(2 * time.Minute)},
+	}
+
+	seen := make(map[string]bool)
+	for _, tr := range transitions {
+		n.Status = tr.status
+		n.UpdatedAt = tr.at
+		event, err := NewNotificationEvent(n)
+		if err != nil {
+			t.Fatalf("NewNotificationEvent: %v", err)
+		}
+		if seen[event.DedupKey] {
+			t.Fatalf("duplicate dedup key %q for repeated transition", event.DedupKey)
+		}
+		seen[event.DedupKey] = true
+	}
+
+	// Ретрай уже записанного перехода даёт тот же ключ
+	n.Status = notification.StatusFailed
+	n.UpdatedAt = start.Add(2 * time.Minute)
+	retry, err := NewNotificationEvent(n)
+	if err != nil {
+		t.Fatalf("NewNotificationEvent: %v", err)
+	}
+	if !seen[retry.DedupKey] {
+		t.Errorf("retry of the same transition produced new dedup key %q", retry.DedupKey)
+	}
+}
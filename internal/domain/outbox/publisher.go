@@ -0,0 +1,10 @@
+package outbox
+
+import "context"
+
+// EventPublisher доставляет события outbox во внешнюю систему. Доставка «как минимум
+// один раз»: при ошибке событие будет отправлено повторно, получатель дедуплицирует
+// по Event.DedupKey.
+type EventPublisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
@@ -0,0 +1,15 @@
+package outbox
+
+import "context"
+
+// PublishFunc публикует пачку событий и возвращает результат для каждой попытки:
+// nil — событие опубликовано, ошибка — попытка будет повторена позже.
+// События, которых нет в результате, остаются в очереди без изменений.
+type PublishFunc func(ctx context.Context, events []*Event) map[int64]error
+
+type Repository interface {
+	// ProcessPending забирает готовые к публикации события в порядке id под аренду ClaimLease,
+	// вызывает publish вне транзакции и фиксирует результаты. Пачку одновременно забирает только
+	// один релей; если блокировку держит другой, возвращается 0 без ошибки.
+	ProcessPending(ctx context.Context, limit int, publish PublishFunc) (int, error)
+}
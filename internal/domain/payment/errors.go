@@ -100,4 +100,29 @@ func (e *RefundFailedError) Error() string {
 
 func NewRefundFailedError(paymentID, reason string) *RefundFailedError {
 	return &RefundFailedError{PaymentID: paymentID, Reason: reason}
-}
\ No newline at end of file
+}
+
+type SignatureError struct {
+	Provider string
+	Reason   string
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("invalid %s webhook signature: %s", e.Provider, e.Reason)
+}
+
+func NewSignatureError(provider, reason string) *SignatureError {
+	return &SignatureError{Provider: provider, Reason: reason}
+}
+
+type UnknownProviderError struct {
+	Provider string
+}
+
+func (e *UnknownProviderError) Error() string {
+	return fmt.Sprintf("unknown payment provider: %s", e.Provider)
+}
+
+func NewUnknownProviderError(provider string) *UnknownProviderError {
+	return &UnknownProviderError{Provider: provider}
+}
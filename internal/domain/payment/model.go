@@ -9,6 +9,8 @@ const (
 	StatusCompleted Status = "completed"
 	StatusFailed    Status = "failed"
 	StatusRefunded  Status = "refunded"
+	// StatusDisputed — по платежу открыт chargeback у провайдера
+	StatusDisputed Status = "disputed"
 )
 
 type Payment struct {
@@ -102,6 +104,12 @@ func (p *Payment) Refund() error {
 	return nil
 }
 
+func (p *Payment) Dispute(reason string) {
+	p.Status = StatusDisputed
+	p.FailureReason = reason
+	p.UpdatedAt = time.Now()
+}
+
 func (p *Payment) Validate() error {
 	if p.OrderID == "" {
 		return NewValidationError("order_id is required")
@@ -0,0 +1,110 @@
+package payment
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type ProviderEventType string
+
+const (
+	// ProviderEventChargeSucceeded — поздний capture платежа, который был в pending
+	ProviderEventChargeSucceeded ProviderEventType = "charge.succeeded"
+	ProviderEventChargeFailed    ProviderEventType = "charge.failed"
+	ProviderEventChargeRefunded  ProviderEventType = "charge.refunded"
+	// ProviderEventChargeDisputed — chargeback, открытый держателем карты
+	ProviderEventChargeDisputed ProviderEventType = "charge.disputed"
+)
+
+// ProviderEvent — асинхронное событие платёжного провайдера, пришедшее callback-ом.
+// Платёж ищется по PaymentID, если провайдер передаёт наш ID в метаданных, иначе по TransactionID.
+type ProviderEvent struct {
+	Provider      string            `json:"provider"`
+	ID            string            `json:"id"`
+	Type          ProviderEventType `json:"type"`
+	PaymentID     string            `json:"payment_id,omitempty"`
+	TransactionID string            `json:"transaction_id,omitempty"`
+	Amount        float64           `json:"amount,omitempty"`
+	Reason        string            `json:"reason,omitempty"`
+	OccurredAt    time.Time         `json:"occurred_at"`
+	Payload       json.RawMessage   `json:"payload,omitempty"`
+}
+
+func (e *ProviderEvent) Validate() error {
+	if e.Provider == "" {
+		return NewValidationError("provider is required")
+	}
+	if e.ID == "" {
+		return NewValidationError("event id is required")
+	}
+	if e.PaymentID == "" && e.TransactionID == "" {
+		return NewValidationError("payment_id or transaction_id is required")
+	}
+	switch e.Type {
+	case ProviderEventChargeSucceeded, ProviderEventChargeFailed, ProviderEventChargeRefunded, ProviderEventChargeDisputed:
+		return nil
+	}
+	return NewValidationError("unsupported event type: " + string(e.Type))
+}
+
+// Apply переводит платёж в состояние, о котором сообщил провайдер. Провайдеры не гарантируют
+// порядок callback-ов, поэтому переходы, невозможные из текущего статуса, игнорируются:
+// Apply возвращает false, и платёж не меняется.
+func (e *ProviderEvent) Apply(p *Payment) bool {
+	switch e.Type {
+	case ProviderEventChargeSucceeded:
+		if p.Status != StatusPending {
+			return false
+		}
+		transactionID := e.TransactionID
+		if transactionID == "" {
+			transactionID = p.TransactionID
+		}
+		p.Complete(transactionID)
+	case ProviderEventChargeFailed:
+		if p.Status != StatusPending {
+			return false
+		}
+		p.Fail(e.reason("charge failed"))
+	case ProviderEventChargeRefunded:
+		if p.Status != StatusCompleted && p.Status != StatusDisputed {
+			return false
+		}
+		p.Status = StatusRefunded
+		p.UpdatedAt = time.Now()
+	case ProviderEventChargeDisputed:
+		if p.Status != StatusCompleted && p.Status != StatusRefunded {
+			return false
+		}
+		p.Dispute(e.reason("charge disputed"))
+	default:
+		return false
+	}
+	return true
+}
+
+func (e *ProviderEvent) reason(fallback string) string {
+	if e.Reason != "" {
+		return e.Reason
+	}
+	return fallback
+}
+
+// ProviderEventResult — итог обработки события провайдера.
+type ProviderEventResult struct {
+	Payment        *Payment `json:"payment"`
+	PreviousStatus Status   `json:"previous_status"`
+	// Duplicate — событие с этим ID уже было обработано, платёж не менялся
+	Duplicate bool `json:"duplicate"`
+	// Changed — событие изменило статус платежа
+	Changed bool `json:"changed"`
+}
+
+// WebhookParser проверяет подпись callback-а провайдера и разбирает его тело.
+// Ошибка подписи возвращается как SignatureError.
+type WebhookParser interface {
+	Provider() string
+
+	ParseWebhook(headers http.Header, body []byte) (*ProviderEvent, error)
+}
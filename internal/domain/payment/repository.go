@@ -11,4 +11,9 @@ type Repository interface {
 	UpdatePayment(ctx context.Context, payment *Payment) error
 	GetPayments(ctx context.Context) ([]*Payment, error)
 	GetPaymentsByCustomerID(ctx context.Context, customerID string) ([]*Payment, error)
+
+	// ApplyProviderEvent в одной транзакции записывает событие провайдера в журнал
+	// (повторное событие с тем же ID возвращается с Duplicate=true), блокирует платёж
+	// и сохраняет изменения, если event.Apply их внёс.
+	ApplyProviderEvent(ctx context.Context, event *ProviderEvent) (*ProviderEventResult, error)
 }
@@ -15,6 +15,9 @@ type Service interface {
 	RefundPayment(ctx context.Context, req *RefundRequest) error
 
 	CancelPayment(ctx context.Context, paymentID string) error
+
+	// HandleProviderEvent применяет асинхронное событие провайдера к платежу
+	HandleProviderEvent(ctx context.Context, event *ProviderEvent) (*ProviderEventResult, error)
 }
 
 type Gateway interface {
@@ -0,0 +1,148 @@
+package promotion
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Line — позиция заказа для расчёта скидок.
+type Line struct {
+	ProductID string  `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price"`
+}
+
+// Adjustment — применённая скидка. У скидки на позицию заполнен ProductID,
+// у скидки на весь заказ он пуст. Amount — положительная сумма скидки.
+type Adjustment struct {
+	PromotionID string  `json:"promotion_id"`
+	Code        string  `json:"code,omitempty"`
+	Type        Type    `json:"type"`
+	ProductID   string  `json:"product_id,omitempty"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+}
+
+// Quote — итог расчёта: сумма до скидок, сумма скидок и к оплате.
+type Quote struct {
+	Subtotal    float64      `json:"subtotal"`
+	Discount    float64      `json:"discount"`
+	Total       float64      `json:"total"`
+	Adjustments []Adjustment `json:"adjustments"`
+}
+
+// Applied — дала ли промоакция хотя бы одну скидку.
+func (q *Quote) Applied(promotionID string) bool {
+	for _, adjustment := range q.Adjustments {
+		if adjustment.PromotionID == promotionID {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply применяет промоакции к позициям заказа. Сначала применяются скидки на позиции,
+// затем на весь заказ, внутри каждой группы — в переданном порядке. Каждая скидка
+// считается от остатка после предыдущих и не уводит позицию или заказ в минус.
+// Условие MinSubtotal проверяется по сумме до скидок.
+func Apply(lines []Line, promotions []*Promotion) *Quote {
+	remaining := make([]float64, len(lines))
+	quote := &Quote{Adjustments: []Adjustment{}}
+	for i, line := range lines {
+		remaining[i] = roundAmount(line.Price * float64(line.Quantity))
+		quote.Subtotal += remaining[i]
+	}
+	quote.Subtotal = roundAmount(quote.Subtotal)
+
+	ordered := make([]*Promotion, len(promotions))
+	copy(ordered, promotions)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return !ordered[i].IsOrderLevel() && ordered[j].IsOrderLevel()
+	})
+
+	for _, promotion := range ordered {
+		if quote.Subtotal < promotion.MinSubtotal {
+			continue
+		}
+		if promotion.IsOrderLevel() {
+			left := 0.0
+			for _, amount := range remaining {
+				left += amount
+			}
+			left = roundAmount(left - quote.Discount)
+			amount := math.Min(orderDiscount(promotion, left), left)
+			if amount > 0 {
+				quote.add(promotion, "", amount)
+			}
+			continue
+		}
+
+		for i, line := range lines {
+			if !promotion.AppliesTo(line.ProductID) {
+				continue
+			}
+			amount := math.Min(lineDiscount(promotion, line, remaining[i]), remaining[i])
+			if amount > 0 {
+				remaining[i] = roundAmount(remaining[i] - amount)
+				quote.add(promotion, line.ProductID, amount)
+			}
+		}
+	}
+
+	quote.Total = roundAmount(quote.Subtotal - quote.Discount)
+	return quote
+}
+
+func (q *Quote) add(promotion *Promotion, productID string, amount float64) {
+	amount = roundAmount(amount)
+	q.Discount = roundAmount(q.Discount + amount)
+	q.Adjustments = append(q.Adjustments, Adjustment{
+		PromotionID: promotion.ID,
+		Code:        promotion.Code,
+		Type:        promotion.Type,
+		ProductID:   productID,
+		Amount:      amount,
+		Description: describe(promotion),
+	})
+}
+
+func lineDiscount(promotion *Promotion, line Line, remaining float64) float64 {
+	switch promotion.Type {
+	case TypePercentage:
+		return roundAmount(remaining * promotion.Value / 100)
+	case TypeFixed:
+		return roundAmount(promotion.Value * float64(line.Quantity))
+	case TypeBuyXGetY:
+		free := line.Quantity / (promotion.BuyQuantity + promotion.GetQuantity) * promotion.GetQuantity
+		return roundAmount(line.Price * float64(free))
+	}
+	return 0
+}
+
+func orderDiscount(promotion *Promotion, remaining float64) float64 {
+	switch promotion.Type {
+	case TypePercentage:
+		return roundAmount(remaining * promotion.Value / 100)
+	case TypeFixed:
+		return promotion.Value
+	}
+	return 0
+}
+
+func describe(promotion *Promotion) string {
+	switch promotion.Type {
+	case TypePercentage:
+		return fmt.Sprintf("%s: %g%% off", promotion.Name, promotion.Value)
+	case TypeFixed:
+		return fmt.Sprintf("%s: %.2f off", promotion.Name, promotion.Value)
+	case TypeBuyXGetY:
+		return fmt.Sprintf("%s: buy %d get %d free", promotion.Name, promotion.BuyQuantity, promotion.GetQuantity)
+	}
+	return promotion.Name
+}
+
+// roundAmount округляет сумму до копеек, как она хранится в NUMERIC(12,2).
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,75 @@
+package promotion
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testLines = []Line{
+	{ProductID: "phone", Quantity: 1, Price: 800},
+	{ProductID: "case", Quantity: 5, Price: 20},
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		promotions []*Promotion
+		want       []Adjustment
+		total      float64
+	}{
+		{"percentage on order", []*Promotion{
+			{ID: "p1", Name: "Sale", Type: TypePercentage, Value: 10},
+		}, []Adjustment{
+			{PromotionID: "p1", Type: TypePercentage, Amount: 90, Description: "Sale: 10% off"},
+		}, 810},
+		{"fixed per unit of product", []*Promotion{
+			{ID: "p1", Code: "CASE5", Name: "Cases", Type: TypeFixed, Value: 5, ProductIDs: []string{"case"}},
+		}, []Adjustment{
+			{PromotionID: "p1", Code: "CASE5", Type: TypeFixed, ProductID: "case", Amount: 25, Description: "Cases: 5.00 off"},
+		}, 875},
+		// Из пяти чехлов по правилу 2+1 бесплатен один
+		{"buy 2 get 1", []*Promotion{
+			{ID: "p1", Name: "3 for 2", Type: TypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1, ProductIDs: []string{"case"}},
+		}, []Adjustment{
+			{PromotionID: "p1", Type: TypeBuyXGetY, ProductID: "case", Amount: 20, Description: "3 for 2: buy 2 get 1 free"},
+		}, 880},
+		// Скидка на заказ считается после скидки на позицию, хотя передана первой
+		{"line before order", []*Promotion{
+			{ID: "p1", Name: "Minus 50", Type: TypeFixed, Value: 50},
+			{ID: "p2", Name: "Phones", Type: TypePercentage, Value: 50, ProductIDs: []string{"phone"}},
+		}, []Adjustment{
+			{PromotionID: "p2", Type: TypePercentage, ProductID: "phone", Amount: 400, Description: "Phones: 50% off"},
+			{PromotionID: "p1", Type: TypeFixed, Amount: 50, Description: "Minus 50: 50.00 off"},
+		}, 450},
+		{"min subtotal not reached", []*Promotion{
+			{ID: "p1", Name: "Big order", Type: TypeFixed, Value: 100, MinSubtotal: 1000},
+		}, []Adjustment{}, 900},
+		{"discount capped by total", []*Promotion{
+			{ID: "p1", Name: "Gift card", Type: TypeFixed, Value: 1000},
+		}, []Adjustment{
+			{PromotionID: "p1", Type: TypeFixed, Amount: 900, Description: "Gift card: 1000.00 off"},
+		}, 0},
+	}
+
+	for _, tt := range tests {
+		quote := Apply(testLines, tt.promotions)
+		if !reflect.DeepEqual(quote.Adjustments, tt.want) {
+			t.Errorf("%s: adjustments = %+v, want %+v", tt.name, quote.Adjustments, tt.want)
+		}
+		if quote.Subtotal != 900 || quote.Total != tt.total {
+			t.Errorf("%s: subtotal = %v, total = %v, want 900 and %v", tt.name, quote.Subtotal, quote.Total, tt.total)
+		}
+	}
+}
+
+func TestValidateUsageLimitRequiresCode(t *testing.T) {
+	promotion := &Promotion{Name: "Sale", Type: TypePercentage, Value: 10, PerCustomerLimit: 1}
+	if _, ok := promotion.Validate().(*ValidationError); !ok {
+		t.Errorf("Validate() = %v, want ValidationError", promotion.Validate())
+	}
+
+	promotion.Code = "WELCOME"
+	if err := promotion.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
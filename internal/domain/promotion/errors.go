@@ -0,0 +1,54 @@
+package promotion
+
+import "fmt"
+
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("promotion validation error: %s", e.Message)
+}
+
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
+type NotFoundError struct {
+	PromotionID string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("promotion not found: %s", e.PromotionID)
+}
+
+func NewNotFoundError(promotionID string) *NotFoundError {
+	return &NotFoundError{PromotionID: promotionID}
+}
+
+type DuplicateCodeError struct {
+	Code string
+}
+
+func (e *DuplicateCodeError) Error() string {
+	return fmt.Sprintf("coupon code already exists: %s", e.Code)
+}
+
+func NewDuplicateCodeError(code string) *DuplicateCodeError {
+	return &DuplicateCodeError{Code: code}
+}
+
+// CouponError — купон из заказа нельзя применить: его нет, он не действует,
+// исчерпан лимит или заказ не подходит под условия.
+type CouponError struct {
+	Code   string
+	Reason string
+}
+
+func (e *CouponError) Error() string {
+	return fmt.Sprintf("coupon %s cannot be applied: %s", e.Code, e.Reason)
+}
+
+func NewCouponError(code, reason string) *CouponError {
+	return &CouponError{Code: code, Reason: reason}
+}
@@ -0,0 +1,183 @@
+package promotion
+
+import (
+	"strings"
+	"time"
+)
+
+type Type string
+
+const (
+	// TypePercentage — процент от суммы позиций или заказа
+	TypePercentage Type = "percentage"
+	// TypeFixed — фиксированная сумма с каждой единицы товара или со всего заказа
+	TypeFixed Type = "fixed"
+	// TypeBuyXGetY — из каждых BuyQuantity+GetQuantity единиц товара GetQuantity бесплатно
+	TypeBuyXGetY Type = "buy_x_get_y"
+)
+
+func (t Type) IsValid() bool {
+	switch t {
+	case TypePercentage, TypeFixed, TypeBuyXGetY:
+		return true
+	}
+	return false
+}
+
+// Promotion — правило скидки. Промоакция без кода применяется ко всем подходящим заказам,
+// с кодом — только к заказам с этим купоном. Лимиты использований есть только у купонов.
+type Promotion struct {
+	ID   string `json:"id"`
+	Code string `json:"code,omitempty"`
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+	// Value — процент для percentage и сумма для fixed; buy_x_get_y его не использует
+	Value float64 `json:"value,omitempty"`
+	// ProductIDs — товары, на позиции которых действует скидка; пусто — скидка на весь заказ
+	ProductIDs  []string `json:"product_ids,omitempty"`
+	BuyQuantity int      `json:"buy_quantity,omitempty"`
+	GetQuantity int      `json:"get_quantity,omitempty"`
+	// MinSubtotal — минимальная сумма заказа до скидок
+	MinSubtotal float64 `json:"min_subtotal,omitempty"`
+	// UsageLimit и PerCustomerLimit — сколько раз купон можно использовать всего и одному
+	// клиенту; 0 — без ограничения
+	UsageLimit       int        `json:"usage_limit,omitempty"`
+	PerCustomerLimit int        `json:"per_customer_limit,omitempty"`
+	Active           bool       `json:"active"`
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Usage — неосвобождённые погашения купона: всего и одним клиентом.
+type Usage struct {
+	Total    int `json:"total"`
+	Customer int `json:"customer"`
+}
+
+// RedemptionStatus — состояние погашения купона заказом. Погашение резервируется при
+// создании заказа, подтверждается после оплаты и освобождается при отказе или отмене.
+type RedemptionStatus string
+
+const (
+	RedemptionReserved RedemptionStatus = "reserved"
+	RedemptionRedeemed RedemptionStatus = "redeemed"
+	RedemptionReleased RedemptionStatus = "released"
+)
+
+type CreateRequest struct {
+	Code             string     `json:"code,omitempty"`
+	Name             string     `json:"name"`
+	Type             Type       `json:"type"`
+	Value            float64    `json:"value,omitempty"`
+	ProductIDs       []string   `json:"product_ids,omitempty"`
+	BuyQuantity      int        `json:"buy_quantity,omitempty"`
+	GetQuantity      int        `json:"get_quantity,omitempty"`
+	MinSubtotal      float64    `json:"min_subtotal,omitempty"`
+	UsageLimit       int        `json:"usage_limit,omitempty"`
+	PerCustomerLimit int        `json:"per_customer_limit,omitempty"`
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+}
+
+// UpdateRequest меняет только переданные поля. Правила скидки не меняются: применённые
+// скидки хранятся в заказах, новая скидка — новая промоакция.
+type UpdateRequest struct {
+	Name             *string    `json:"name,omitempty"`
+	Active           *bool      `json:"active,omitempty"`
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+	UsageLimit       *int       `json:"usage_limit,omitempty"`
+	PerCustomerLimit *int       `json:"per_customer_limit,omitempty"`
+}
+
+// QuoteRequest — позиции заказа и купон, к которым подбираются скидки.
+type QuoteRequest struct {
+	CustomerID string `json:"customer_id"`
+	CouponCode string `json:"coupon_code,omitempty"`
+	Lines      []Line `json:"lines"`
+}
+
+// NormalizeCode приводит код купона к виду, в котором он хранится.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (p *Promotion) IsCoupon() bool {
+	return p.Code != ""
+}
+
+// IsOrderLevel — скидка на весь заказ, а не на отдельные позиции.
+func (p *Promotion) IsOrderLevel() bool {
+	return len(p.ProductIDs) == 0
+}
+
+func (p *Promotion) AppliesTo(productID string) bool {
+	for _, id := range p.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAvailableAt — промоакция включена и действует в момент at.
+func (p *Promotion) IsAvailableAt(at time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && at.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || at.Before(*p.EndsAt)
+}
+
+func (p *Promotion) Validate() error {
+	if p.Name == "" {
+		return NewValidationError("name is required")
+	}
+	if !p.Type.IsValid() {
+		return NewValidationError("unsupported promotion type: " + string(p.Type))
+	}
+
+	switch p.Type {
+	case TypePercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return NewValidationError("percentage value must be in (0, 100]")
+		}
+	case TypeFixed:
+		if p.Value <= 0 {
+			return NewValidationError("fixed value must be positive")
+		}
+	case TypeBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return NewValidationError("buy_quantity and get_quantity must be positive")
+		}
+		if len(p.ProductIDs) == 0 {
+			return NewValidationError("buy_x_get_y requires product_ids")
+		}
+	}
+	if p.Type != TypeBuyXGetY && (p.BuyQuantity != 0 || p.GetQuantity != 0) {
+		return NewValidationError("buy_quantity and get_quantity are only used by buy_x_get_y")
+	}
+
+	for _, id := range p.ProductIDs {
+		if id == "" {
+			return NewValidationError("product_ids must not contain empty values")
+		}
+	}
+	if p.MinSubtotal < 0 {
+		return NewValidationError("min_subtotal cannot be negative")
+	}
+	if p.UsageLimit < 0 || p.PerCustomerLimit < 0 {
+		return NewValidationError("usage limits cannot be negative")
+	}
+	if !p.IsCoupon() && (p.UsageLimit > 0 || p.PerCustomerLimit > 0) {
+		return NewValidationError("usage limits require a coupon code")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return NewValidationError("ends_at must be after starts_at")
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package promotion
+
+import (
+	"context"
+	"time"
+)
+
+// Repository хранит промоакции и погашения купонов. Погашение резервируется репозиторием
+// заказов в одной транзакции с созданием заказа.
+type Repository interface {
+	// Create возвращает DuplicateCodeError, если купон с таким кодом уже есть.
+	Create(ctx context.Context, promotion *Promotion) error
+	Get(ctx context.Context, id string) (*Promotion, error)
+	GetByCode(ctx context.Context, code string) (*Promotion, error)
+	Update(ctx context.Context, promotion *Promotion) error
+	List(ctx context.Context) ([]*Promotion, error)
+	// ListAutomatic возвращает промоакции без кода, действующие в момент at, в порядке создания.
+	ListAutomatic(ctx context.Context, at time.Time) ([]*Promotion, error)
+	// GetUsage считает зарезервированные и подтверждённые погашения купона.
+	GetUsage(ctx context.Context, promotionID, customerID string) (*Usage, error)
+	// ConfirmRedemptions подтверждает зарезервированные погашения купонов заказа.
+	ConfirmRedemptions(ctx context.Context, orderID string) error
+	// ReleaseRedemptions освобождает погашения купонов заказа; повторный вызов ничего не меняет.
+	ReleaseRedemptions(ctx context.Context, orderID string) error
+}
@@ -0,0 +1,23 @@
+package promotion
+
+import "context"
+
+type Service interface {
+	CreatePromotion(ctx context.Context, req *CreateRequest) (*Promotion, error)
+
+	GetPromotion(ctx context.Context, id string) (*Promotion, error)
+
+	ListPromotions(ctx context.Context) ([]*Promotion, error)
+
+	UpdatePromotion(ctx context.Context, id string, req *UpdateRequest) (*Promotion, error)
+
+	// Quote подбирает скидки к заказу: действующие промоакции без кода и купон из запроса.
+	// Неприменимый купон — CouponError.
+	Quote(ctx context.Context, req *QuoteRequest) (*Quote, error)
+
+	// ConfirmRedemptions подтверждает погашения купонов заказа после оплаты.
+	ConfirmRedemptions(ctx context.Context, orderID string) error
+
+	// ReleaseRedemptions возвращает клиенту купоны заказа, который отказан или отменён.
+	ReleaseRedemptions(ctx context.Context, orderID string) error
+}
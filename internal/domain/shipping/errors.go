@@ -0,0 +1,33 @@
+package shipping
+
+import "fmt"
+
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("shipping validation error: %s", e.Message)
+}
+
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
+// UnavailableError — в страну нет доставки, выбранный способ в ней недоступен
+// или заказ тяжелее допустимого веса. Пустой Method — нет ни одного способа.
+type UnavailableError struct {
+	Method  string
+	Country string
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("no shipping methods available to %s", e.Country)
+	}
+	return fmt.Sprintf("shipping method %s is not available to %s", e.Method, e.Country)
+}
+
+func NewUnavailableError(method, country string) *UnavailableError {
+	return &UnavailableError{Method: method, Country: country}
+}
@@ -0,0 +1,98 @@
+package shipping
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	// MethodStandard — способ доставки по умолчанию, если правила не заданы в конфигурации
+	MethodStandard = "standard"
+	// AnyCountry — зона с этой страной подходит для адресов, не попавших в другие зоны
+	AnyCountry = "*"
+)
+
+// Zone — зона доставки. Countries — коды стран ISO 3166-1 ("DE") или регионов ("US-AK").
+type Zone struct {
+	Name      string   `json:"name" mapstructure:"name"`
+	Countries []string `json:"countries" mapstructure:"countries"`
+}
+
+// MethodRule — способ доставки в зоне. Стоимость — BaseAmount плюс PerKg за килограмм веса;
+// при сумме товаров от FreeOver доставка бесплатна. MaxWeight и FreeOver: 0 — без ограничения.
+type MethodRule struct {
+	Method        string  `json:"method" mapstructure:"method"`
+	Name          string  `json:"name" mapstructure:"name"`
+	Zone          string  `json:"zone" mapstructure:"zone"`
+	BaseAmount    float64 `json:"base_amount" mapstructure:"base_amount"`
+	PerKg         float64 `json:"per_kg" mapstructure:"per_kg"`
+	MaxWeight     float64 `json:"max_weight" mapstructure:"max_weight"`
+	FreeOver      float64 `json:"free_over" mapstructure:"free_over"`
+	EstimatedDays int     `json:"estimated_days" mapstructure:"estimated_days"`
+}
+
+// Destination — часть адреса, от которой зависит стоимость доставки.
+type Destination struct {
+	Country    string `json:"country"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+}
+
+// RateRequest — Weight в килограммах, Subtotal — сумма товаров после скидок.
+type RateRequest struct {
+	Destination Destination `json:"destination"`
+	Weight      float64     `json:"weight"`
+	Subtotal    float64     `json:"subtotal"`
+}
+
+type Rate struct {
+	Method        string  `json:"method"`
+	Name          string  `json:"name"`
+	Zone          string  `json:"zone"`
+	Amount        float64 `json:"amount"`
+	EstimatedDays int     `json:"estimated_days,omitempty"`
+}
+
+// RateProvider возвращает способы доставки, доступные для запроса. Правила из конфигурации —
+// RuleProvider; тарифы перевозчика подключаются другой реализацией этого интерфейса.
+type RateProvider interface {
+	Rates(ctx context.Context, req *RateRequest) ([]Rate, error)
+}
+
+type Item struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// QuoteRequest — корзина для расчёта доставки: вес считается по товарам каталога.
+type QuoteRequest struct {
+	Destination Destination `json:"destination"`
+	Items       []Item      `json:"items"`
+	Subtotal    float64     `json:"subtotal"`
+}
+
+// SelectRate выбирает способ доставки: пустой method — самый дешёвый из доступных.
+func SelectRate(rates []Rate, destination Destination, method string) (*Rate, error) {
+	var selected *Rate
+	for i := range rates {
+		rate := &rates[i]
+		if method != "" {
+			if rate.Method == method {
+				return rate, nil
+			}
+			continue
+		}
+		if selected == nil || rate.Amount < selected.Amount {
+			selected = rate
+		}
+	}
+	if selected == nil {
+		return nil, NewUnavailableError(method, destination.Country)
+	}
+	return selected, nil
+}
+
+// NormalizeCode приводит код страны или региона к верхнему регистру.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,100 @@
+package shipping
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RuleProvider считает доставку по зонам и правилам из конфигурации.
+type RuleProvider struct {
+	// zones — страна или регион -> имя зоны
+	zones   map[string]string
+	methods []MethodRule
+}
+
+// NewRuleProvider проверяет правила. Без правил доставка бесплатна способом standard
+// в любую страну.
+func NewRuleProvider(zones []Zone, methods []MethodRule) (*RuleProvider, error) {
+	if len(methods) == 0 {
+		zones = []Zone{{Name: "world", Countries: []string{AnyCountry}}}
+		methods = []MethodRule{{Method: MethodStandard, Name: "Standard", Zone: "world"}}
+	}
+
+	p := &RuleProvider{zones: make(map[string]string)}
+	names := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		if zone.Name == "" || names[zone.Name] {
+			return nil, NewValidationError(fmt.Sprintf("zone name %q is empty or duplicated", zone.Name))
+		}
+		names[zone.Name] = true
+		for _, country := range zone.Countries {
+			country = NormalizeCode(country)
+			if other, exists := p.zones[country]; exists {
+				return nil, NewValidationError(fmt.Sprintf("%s belongs to zones %s and %s", country, other, zone.Name))
+			}
+			p.zones[country] = zone.Name
+		}
+	}
+
+	seen := make(map[string]bool, len(methods))
+	for _, rule := range methods {
+		if rule.Method == "" || !names[rule.Zone] {
+			return nil, NewValidationError(fmt.Sprintf("method %q requires a known zone, got %q", rule.Method, rule.Zone))
+		}
+		if rule.BaseAmount < 0 || rule.PerKg < 0 || rule.MaxWeight < 0 || rule.FreeOver < 0 {
+			return nil, NewValidationError(fmt.Sprintf("method %s in zone %s has negative amounts", rule.Method, rule.Zone))
+		}
+		key := rule.Zone + "/" + rule.Method
+		if seen[key] {
+			return nil, NewValidationError("duplicate method " + key)
+		}
+		seen[key] = true
+		p.methods = append(p.methods, rule)
+	}
+	return p, nil
+}
+
+// Rates возвращает способы зоны адреса, отсортированные по стоимости.
+func (p *RuleProvider) Rates(ctx context.Context, req *RateRequest) ([]Rate, error) {
+	zone, ok := p.zoneOf(req.Destination)
+	if !ok {
+		return []Rate{}, nil
+	}
+
+	rates := []Rate{}
+	for _, rule := range p.methods {
+		if rule.Zone != zone || (rule.MaxWeight > 0 && req.Weight > rule.MaxWeight) {
+			continue
+		}
+		amount := math.Round((rule.BaseAmount+rule.PerKg*req.Weight)*100) / 100
+		if rule.FreeOver > 0 && req.Subtotal >= rule.FreeOver {
+			amount = 0
+		}
+		rates = append(rates, Rate{
+			Method:        rule.Method,
+			Name:          rule.Name,
+			Zone:          zone,
+			Amount:        amount,
+			EstimatedDays: rule.EstimatedDays,
+		})
+	}
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].Amount < rates[j].Amount })
+	return rates, nil
+}
+
+// zoneOf ищет зону сначала по региону ("US-AK"), затем по стране, затем зону AnyCountry.
+func (p *RuleProvider) zoneOf(destination Destination) (string, bool) {
+	country := NormalizeCode(destination.Country)
+	candidates := []string{country, AnyCountry}
+	if region := NormalizeCode(destination.Region); region != "" {
+		candidates = append([]string{country + "-" + region}, candidates...)
+	}
+	for _, candidate := range candidates {
+		if zone, ok := p.zones[candidate]; ok {
+			return zone, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,96 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var (
+	testZones = []Zone{
+		{Name: "domestic", Countries: []string{"US"}},
+		{Name: "remote", Countries: []string{"us-ak", "US-HI"}},
+		{Name: "eu", Countries: []string{"DE", "FR"}},
+	}
+	testMethods = []MethodRule{
+		{Method: "ground", Name: "Ground", Zone: "domestic", BaseAmount: 5, PerKg: 0.5, FreeOver: 100},
+		{Method: "express", Name: "Express", Zone: "domestic", BaseAmount: 20, MaxWeight: 10},
+		{Method: "ground", Name: "Ground", Zone: "remote", BaseAmount: 15, PerKg: 2},
+		{Method: "ground", Name: "Ground", Zone: "eu", BaseAmount: 12},
+	}
+)
+
+func TestRuleProviderRates(t *testing.T) {
+	provider, err := NewRuleProvider(testZones, testMethods)
+	if err != nil {
+		t.Fatalf("NewRuleProvider() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  RateRequest
+		want []Rate
+	}{
+		{"by weight", RateRequest{Destination: Destination{Country: "US"}, Weight: 3, Subtotal: 50}, []Rate{
+			{Method: "ground", Name: "Ground", Zone: "domestic", Amount: 6.5},
+			{Method: "express", Name: "Express", Zone: "domestic", Amount: 20},
+		}},
+		{"free over threshold", RateRequest{Destination: Destination{Country: "us"}, Weight: 3, Subtotal: 100}, []Rate{
+			{Method: "ground", Name: "Ground", Zone: "domestic", Amount: 0},
+			{Method: "express", Name: "Express", Zone: "domestic", Amount: 20},
+		}},
+		// Тяжелее max_weight экспресса: остаётся только наземная доставка
+		{"over max weight", RateRequest{Destination: Destination{Country: "US"}, Weight: 12, Subtotal: 50}, []Rate{
+			{Method: "ground", Name: "Ground", Zone: "domestic", Amount: 11},
+		}},
+		// Регион из отдельной зоны важнее страны
+		{"region zone", RateRequest{Destination: Destination{Country: "US", Region: "AK"}, Weight: 1, Subtotal: 500}, []Rate{
+			{Method: "ground", Name: "Ground", Zone: "remote", Amount: 17},
+		}},
+		{"unknown country", RateRequest{Destination: Destination{Country: "JP"}, Weight: 1}, []Rate{}},
+	}
+
+	for _, tt := range tests {
+		rates, err := provider.Rates(context.Background(), &tt.req)
+		if err != nil {
+			t.Errorf("%s: Rates() error = %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rates, tt.want) {
+			t.Errorf("%s: rates = %+v, want %+v", tt.name, rates, tt.want)
+		}
+	}
+}
+
+func TestSelectRate(t *testing.T) {
+	rates := []Rate{{Method: "express", Amount: 20}, {Method: "ground", Amount: 6.5}}
+	destination := Destination{Country: "US"}
+
+	if rate, err := SelectRate(rates, destination, ""); err != nil || rate.Method != "ground" {
+		t.Errorf("SelectRate(\"\") = %+v, %v, want ground", rate, err)
+	}
+	if rate, err := SelectRate(rates, destination, "express"); err != nil || rate.Amount != 20 {
+		t.Errorf("SelectRate(express) = %+v, %v, want express", rate, err)
+	}
+
+	var unavailable *UnavailableError
+	if _, err := SelectRate(rates, destination, "drone"); !errors.As(err, &unavailable) {
+		t.Errorf("SelectRate(drone) error = %v, want UnavailableError", err)
+	}
+}
+
+func TestNewRuleProviderDefaults(t *testing.T) {
+	provider, err := NewRuleProvider(nil, nil)
+	if err != nil {
+		t.Fatalf("NewRuleProvider() error = %v", err)
+	}
+	rates, _ := provider.Rates(context.Background(), &RateRequest{Destination: Destination{Country: "JP"}, Weight: 5})
+	if len(rates) != 1 || rates[0].Method != MethodStandard || rates[0].Amount != 0 {
+		t.Errorf("default rates = %+v, want free standard", rates)
+	}
+
+	if _, err := NewRuleProvider(testZones, []MethodRule{{Method: "ground", Zone: "mars"}}); err == nil {
+		t.Error("NewRuleProvider() with unknown zone error = nil, want ValidationError")
+	}
+}
@@ -0,0 +1,8 @@
+package shipping
+
+import "context"
+
+type Service interface {
+	// Quote возвращает способы доставки корзины с их стоимостью.
+	Quote(ctx context.Context, req *QuoteRequest) ([]Rate, error)
+}
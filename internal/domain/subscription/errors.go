@@ -0,0 +1,27 @@
+package subscription
+
+import "fmt"
+
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("subscription validation error: %s", e.Message)
+}
+
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
+type NotFoundError struct {
+	SubscriptionID string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("subscription not found: %s", e.SubscriptionID)
+}
+
+func NewNotFoundError(subscriptionID string) *NotFoundError {
+	return &NotFoundError{SubscriptionID: subscriptionID}
+}
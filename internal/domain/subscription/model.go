@@ -0,0 +1,69 @@
+package subscription
+
+import (
+	"time"
+
+	"orderflow/internal/domain/order"
+)
+
+const MinInterval = time.Minute
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusPaused    Status = "paused"
+	StatusCancelled Status = "cancelled"
+)
+
+type Subscription struct {
+	ID                  string        `json:"id"`
+	CustomerID          string        `json:"customer_id"`
+	Items               []order.Item  `json:"items"`
+	Interval            time.Duration `json:"interval"`
+	Status              Status        `json:"status"`
+	CyclesCompleted     int           `json:"cycles_completed"`
+	CyclesSkipped       int           `json:"cycles_skipped"`
+	SkipNext            bool          `json:"skip_next"`
+	NextRunAt           time.Time     `json:"next_run_at"`
+	LastOrderWorkflowID string        `json:"last_order_workflow_id,omitempty"`
+	WorkflowID          string        `json:"workflow_id"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+}
+
+func (s *Subscription) IsActive() bool {
+	return s.Status == StatusActive
+}
+
+func (s *Subscription) IsPaused() bool {
+	return s.Status == StatusPaused
+}
+
+func (s *Subscription) IsCancelled() bool {
+	return s.Status == StatusCancelled
+}
+
+func (s *Subscription) Validate() error {
+	if s.CustomerID == "" {
+		return NewValidationError("customer_id is required")
+	}
+	if len(s.Items) == 0 {
+		return NewValidationError("subscription must have at least one item")
+	}
+	for _, item := range s.Items {
+		if item.ProductID == "" {
+			return NewValidationError("product_id is required for all items")
+		}
+		if item.Quantity <= 0 {
+			return NewValidationError("quantity must be positive")
+		}
+		if item.Price < 0 {
+			return NewValidationError("price cannot be negative")
+		}
+	}
+	if s.Interval < MinInterval {
+		return NewValidationError("interval must be at least " + MinInterval.String())
+	}
+	return nil
+}
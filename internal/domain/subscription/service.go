@@ -0,0 +1,11 @@
+package subscription
+
+import "context"
+
+type Service interface {
+	Save(ctx context.Context, subscription *Subscription) error
+
+	GetByID(ctx context.Context, id string) (*Subscription, error)
+
+	GetByCustomerID(ctx context.Context, customerID string) ([]*Subscription, error)
+}
@@ -0,0 +1,106 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Calculator считает налог на позиции заказа. Табличная реализация — TableCalculator;
+// внешний налоговый сервис подключается другой реализацией этого интерфейса.
+type Calculator interface {
+	Calculate(ctx context.Context, req *Request) (*Result, error)
+}
+
+type rateKey struct {
+	jurisdiction string
+	taxClass     string
+}
+
+// TableCalculator берёт ставки из таблицы, заданной в конфигурации.
+type TableCalculator struct {
+	defaultJurisdiction string
+	rates               map[rateKey]Rate
+}
+
+func NewTableCalculator(defaultJurisdiction string, rates []Rate) (*TableCalculator, error) {
+	c := &TableCalculator{
+		defaultJurisdiction: NormalizeJurisdiction(defaultJurisdiction),
+		rates:               make(map[rateKey]Rate, len(rates)),
+	}
+	for _, rate := range rates {
+		rate.Jurisdiction = NormalizeJurisdiction(rate.Jurisdiction)
+		if rate.Jurisdiction == "" || rate.TaxClass == "" {
+			return nil, NewValidationError("rate requires jurisdiction and tax_class")
+		}
+		if rate.Rate < 0 || rate.Rate >= 1 {
+			return nil, NewValidationError(fmt.Sprintf("rate for %s/%s must be in [0, 1)", rate.Jurisdiction, rate.TaxClass))
+		}
+		key := rateKey{jurisdiction: rate.Jurisdiction, taxClass: rate.TaxClass}
+		if _, exists := c.rates[key]; exists {
+			return nil, NewValidationError(fmt.Sprintf("duplicate rate for %s/%s", rate.Jurisdiction, rate.TaxClass))
+		}
+		c.rates[key] = rate
+	}
+	return c, nil
+}
+
+// Calculate начисляет налог по каждой позиции отдельно и округляет его до копеек.
+// Без юрисдикции в запросе и без юрисдикции по умолчанию налог не начисляется.
+func (c *TableCalculator) Calculate(ctx context.Context, req *Request) (*Result, error) {
+	jurisdiction := NormalizeJurisdiction(req.Jurisdiction)
+	if jurisdiction == "" {
+		jurisdiction = c.defaultJurisdiction
+	}
+
+	result := &Result{Jurisdiction: jurisdiction, Lines: []TaxLine{}}
+	if jurisdiction == "" {
+		return result, nil
+	}
+
+	for _, line := range req.Lines {
+		taxClass := line.TaxClass
+		if taxClass == "" {
+			taxClass = ClassStandard
+		}
+		if taxClass == ClassExempt || line.Amount <= 0 {
+			continue
+		}
+
+		rate, ok := c.lookup(jurisdiction, taxClass)
+		if !ok {
+			return nil, NewNoRateError(jurisdiction, taxClass)
+		}
+
+		amount := math.Round(line.Amount*rate.Rate*100) / 100
+		result.Lines = append(result.Lines, TaxLine{
+			ProductID:     line.ProductID,
+			TaxClass:      taxClass,
+			Jurisdiction:  rate.Jurisdiction,
+			Name:          rate.Name,
+			Rate:          rate.Rate,
+			TaxableAmount: line.Amount,
+			Amount:        amount,
+		})
+		result.Total += amount
+	}
+	result.Total = math.Round(result.Total*100) / 100
+	return result, nil
+}
+
+// lookup ищет ставку класса от юрисдикции к её родителям ("US-CA", затем "US"),
+// а если класс нигде не задан — так же ставку класса standard.
+func (c *TableCalculator) lookup(jurisdiction, taxClass string) (Rate, bool) {
+	classes := []string{taxClass}
+	if taxClass != ClassStandard {
+		classes = append(classes, ClassStandard)
+	}
+	for _, class := range classes {
+		for j := jurisdiction; j != ""; j = parentJurisdiction(j) {
+			if rate, ok := c.rates[rateKey{jurisdiction: j, taxClass: class}]; ok {
+				return rate, true
+			}
+		}
+	}
+	return Rate{}, false
+}
@@ -0,0 +1,82 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var testRates = []Rate{
+	{Jurisdiction: "US", TaxClass: ClassStandard, Rate: 0.05, Name: "US tax"},
+	{Jurisdiction: "US-CA", TaxClass: ClassStandard, Rate: 0.0725, Name: "CA sales tax"},
+	{Jurisdiction: "DE", TaxClass: ClassStandard, Rate: 0.19, Name: "MwSt"},
+	{Jurisdiction: "DE", TaxClass: "reduced", Rate: 0.07, Name: "MwSt reduced"},
+}
+
+func TestTableCalculatorCalculate(t *testing.T) {
+	calculator, err := NewTableCalculator("de", testRates)
+	if err != nil {
+		t.Fatalf("NewTableCalculator() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		jurisdiction string
+		line         Line
+		wantName     string
+		wantAmount   float64
+	}{
+		{"class in jurisdiction", "DE", Line{TaxClass: "reduced", Amount: 100}, "MwSt reduced", 7},
+		{"default jurisdiction", "", Line{TaxClass: ClassStandard, Amount: 100}, "MwSt", 19},
+		{"exact region", "us-ca", Line{TaxClass: ClassStandard, Amount: 19.99}, "CA sales tax", 1.45},
+		// Для штата без своей ставки действует ставка страны
+		{"parent jurisdiction", "US-NY", Line{TaxClass: ClassStandard, Amount: 100}, "US tax", 5},
+		// Класса reduced в США нет — берётся standard
+		{"standard fallback", "US-CA", Line{TaxClass: "reduced", Amount: 100}, "CA sales tax", 7.25},
+	}
+
+	for _, tt := range tests {
+		result, err := calculator.Calculate(context.Background(), &Request{Jurisdiction: tt.jurisdiction, Lines: []Line{tt.line}})
+		if err != nil {
+			t.Errorf("%s: Calculate() error = %v", tt.name, err)
+			continue
+		}
+		if len(result.Lines) != 1 || result.Lines[0].Name != tt.wantName || result.Total != tt.wantAmount {
+			t.Errorf("%s: result = %+v, want %s %v", tt.name, result, tt.wantName, tt.wantAmount)
+		}
+	}
+}
+
+func TestTableCalculatorExemptAndMissingRate(t *testing.T) {
+	calculator, err := NewTableCalculator("", testRates)
+	if err != nil {
+		t.Fatalf("NewTableCalculator() error = %v", err)
+	}
+
+	result, err := calculator.Calculate(context.Background(), &Request{
+		Jurisdiction: "DE",
+		Lines:        []Line{{TaxClass: ClassExempt, Amount: 100}},
+	})
+	if err != nil || len(result.Lines) != 0 || result.Total != 0 {
+		t.Errorf("exempt: result = %+v, err = %v, want no tax", result, err)
+	}
+
+	_, err = calculator.Calculate(context.Background(), &Request{
+		Jurisdiction: "FR",
+		Lines:        []Line{{TaxClass: ClassStandard, Amount: 100}},
+	})
+	var noRate *NoRateError
+	if !errors.As(err, &noRate) || noRate.Jurisdiction != "FR" {
+		t.Errorf("missing rate: err = %v, want NoRateError for FR", err)
+	}
+}
+
+func TestNewTableCalculatorRejectsDuplicates(t *testing.T) {
+	rates := []Rate{
+		{Jurisdiction: "DE", TaxClass: ClassStandard, Rate: 0.19},
+		{Jurisdiction: "de", TaxClass: ClassStandard, Rate: 0.16},
+	}
+	if _, err := NewTableCalculator("DE", rates); err == nil {
+		t.Error("NewTableCalculator() error = nil, want duplicate rate error")
+	}
+}
@@ -0,0 +1,30 @@
+package tax
+
+import "fmt"
+
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("tax validation error: %s", e.Message)
+}
+
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
+// NoRateError — в таблице нет ставки ни для юрисдикции и её родителей,
+// ни для класса standard.
+type NoRateError struct {
+	Jurisdiction string
+	TaxClass     string
+}
+
+func (e *NoRateError) Error() string {
+	return fmt.Sprintf("no tax rate for jurisdiction %s and tax class %s", e.Jurisdiction, e.TaxClass)
+}
+
+func NewNoRateError(jurisdiction, taxClass string) *NoRateError {
+	return &NoRateError{Jurisdiction: jurisdiction, TaxClass: taxClass}
+}
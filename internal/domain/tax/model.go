@@ -0,0 +1,61 @@
+package tax
+
+import "strings"
+
+const (
+	// ClassStandard — класс товара по умолчанию и запасной класс при поиске ставки
+	ClassStandard = "standard"
+	// ClassExempt — товар не облагается налогом, ставка для него не ищется
+	ClassExempt = "exempt"
+)
+
+// Rate — строка таблицы ставок. Rate — доля: 0.2 означает 20%.
+type Rate struct {
+	Jurisdiction string  `json:"jurisdiction" mapstructure:"jurisdiction"`
+	TaxClass     string  `json:"tax_class" mapstructure:"tax_class"`
+	Rate         float64 `json:"rate" mapstructure:"rate"`
+	Name         string  `json:"name" mapstructure:"name"`
+}
+
+// Line — облагаемая позиция заказа. Amount — сумма позиции после скидок.
+type Line struct {
+	ProductID string  `json:"product_id"`
+	TaxClass  string  `json:"tax_class"`
+	Amount    float64 `json:"amount"`
+}
+
+type Request struct {
+	OrderID      string `json:"order_id"`
+	Jurisdiction string `json:"jurisdiction"`
+	Lines        []Line `json:"lines"`
+}
+
+// TaxLine — налог, начисленный на одну позицию.
+type TaxLine struct {
+	ProductID     string  `json:"product_id"`
+	TaxClass      string  `json:"tax_class"`
+	Jurisdiction  string  `json:"jurisdiction"`
+	Name          string  `json:"name"`
+	Rate          float64 `json:"rate"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	Amount        float64 `json:"amount"`
+}
+
+type Result struct {
+	Jurisdiction string    `json:"jurisdiction"`
+	Lines        []TaxLine `json:"lines"`
+	Total        float64   `json:"total"`
+}
+
+// NormalizeJurisdiction приводит код юрисдикции к виду "US-CA".
+func NormalizeJurisdiction(jurisdiction string) string {
+	return strings.ToUpper(strings.TrimSpace(jurisdiction))
+}
+
+// parentJurisdiction возвращает юрисдикцию уровнем выше: "US-CA" -> "US".
+func parentJurisdiction(jurisdiction string) string {
+	if i := strings.LastIndex(jurisdiction, "-"); i > 0 {
+		return jurisdiction[:i]
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import "fmt"
+
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("webhook validation error: %s", e.Message)
+}
+
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
+type NotFoundError struct {
+	SubscriptionID string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("webhook subscription not found: %s", e.SubscriptionID)
+}
+
+func NewNotFoundError(subscriptionID string) *NotFoundError {
+	return &NotFoundError{SubscriptionID: subscriptionID}
+}
+
+type DeliveryNotFoundError struct {
+	DeliveryID string
+}
+
+func (e *DeliveryNotFoundError) Error() string {
+	return fmt.Sprintf("webhook delivery not found: %s", e.DeliveryID)
+}
+
+func NewDeliveryNotFoundError(deliveryID string) *DeliveryNotFoundError {
+	return &DeliveryNotFoundError{DeliveryID: deliveryID}
+}
+
+type DisabledError struct {
+	SubscriptionID string
+}
+
+func (e *DisabledError) Error() string {
+	return fmt.Sprintf("webhook subscription %s is disabled", e.SubscriptionID)
+}
+
+func NewDisabledError(subscriptionID string) *DisabledError {
+	return &DisabledError{SubscriptionID: subscriptionID}
+}
+
+// DeliveryError — неуспешная попытка доставки: ошибка сети или ответ вне 2xx.
+type DeliveryError struct {
+	StatusCode int
+	Message    string
+	Retryable  bool
+}
+
+func (e *DeliveryError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("webhook delivery failed with status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("webhook delivery failed: %s", e.Message)
+}
+
+func NewDeliveryError(statusCode int, message string, retryable bool) *DeliveryError {
+	return &DeliveryError{StatusCode: statusCode, Message: message, Retryable: retryable}
+}
+
+// RedeliveryInProgressError — по доставке уже выполняется workflow доставки.
+type RedeliveryInProgressError struct {
+	DeliveryID string
+}
+
+func (e *RedeliveryInProgressError) Error() string {
+	return fmt.Sprintf("webhook delivery %s is already in progress", e.DeliveryID)
+}
+
+func NewRedeliveryInProgressError(deliveryID string) *RedeliveryInProgressError {
+	return &RedeliveryInProgressError{DeliveryID: deliveryID}
+}
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusDisabled Status = "disabled"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+const (
+	// SignatureHeader содержит "t=<unix>,v1=<hex>", где v1 = HMAC-SHA256(secret, "<unix>.<body>")
+	SignatureHeader  = "X-Orderflow-Signature"
+	EventTypeHeader  = "X-Orderflow-Event-Type"
+	DeliveryIDHeader = "X-Orderflow-Delivery"
+	// IdempotencyHeader — dedup_key события, одинаковый для всех попыток и повторных доставок
+	IdempotencyHeader = "Idempotency-Key"
+
+	// После стольких доставок подряд, не прошедших все ретраи, подписка отключается
+	DefaultMaxConsecutiveFailures = 5
+	// Ответ получателя сохраняется в журнал попыток с обрезкой до этого размера
+	MaxStoredResponseBytes  = 2048
+	DefaultDeliveryLogLimit = 50
+)
+
+// deliveryNamespace задаёт пространство детерминированных ID доставок
+var deliveryNamespace = uuid.MustParse("5b0c1f8e-3f4a-4b7e-9d2c-6a1e8f0b7c31")
+
+type Subscription struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"event_types"`
+	Secret              string     `json:"-"`
+	Status              Status     `json:"status"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type CreateRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// Secret можно не указывать — тогда он генерируется
+	Secret string `json:"secret,omitempty"`
+}
+
+func (s *Subscription) IsActive() bool {
+	return s.Status == StatusActive
+}
+
+// Matches проверяет тип события по фильтру подписки: "*" — все события,
+// "order.*" — все события агрегата, иначе точное совпадение.
+func (s *Subscription) Matches(eventType string) bool {
+	for _, filter := range s.EventTypes {
+		switch {
+		case filter == "*", filter == eventType:
+			return true
+		case strings.HasSuffix(filter, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(filter, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewValidationError("url must be an absolute http(s) URL")
+	}
+	if len(s.EventTypes) == 0 {
+		return NewValidationError("event_types is required")
+	}
+	for _, filter := range s.EventTypes {
+		if filter == "" {
+			return NewValidationError("event_types must not contain empty values")
+		}
+	}
+	if s.Secret == "" {
+		return NewValidationError("secret is required")
+	}
+	return nil
+}
+
+type Delivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	DedupKey       string          `json:"dedup_key"`
+	EventType      string          `json:"event_type"`
+	Body           json.RawMessage `json:"body"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+
+	AttemptLog []*Attempt `json:"attempt_log,omitempty"`
+}
+
+// Attempt — одна HTTP-попытка доставки в журнале.
+type Attempt struct {
+	ID           int64     `json:"id"`
+	DeliveryID   string    `json:"delivery_id"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Duration     int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (a *Attempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// DeliveryID детерминирован для пары подписка/событие: повторная публикация события
+// релеем outbox попадает в ту же доставку.
+func DeliveryID(subscriptionID, dedupKey string) string {
+	return uuid.NewSHA1(deliveryNamespace, []byte(subscriptionID+"|"+dedupKey)).String()
+}
+
+// Sign возвращает значение заголовка SignatureHeader.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), hex.EncodeToString(mac.Sum(nil)))
+}
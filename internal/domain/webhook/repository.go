@@ -0,0 +1,37 @@
+package webhook
+
+import "context"
+
+type Repository interface {
+	CreateSubscription(ctx context.Context, subscription *Subscription) error
+
+	GetSubscription(ctx context.Context, id string) (*Subscription, error)
+
+	ListSubscriptions(ctx context.Context) ([]*Subscription, error)
+
+	ListActiveSubscriptions(ctx context.Context) ([]*Subscription, error)
+
+	DeleteSubscription(ctx context.Context, id string) error
+
+	// Enable включает подписку и сбрасывает счётчик неудачных доставок
+	Enable(ctx context.Context, id string) error
+
+	// RecordDeliveryOutcome сбрасывает или увеличивает счётчик неудачных доставок подряд
+	// и отключает подписку, когда счётчик достигает maxFailures. Возвращает true, если
+	// подписка была отключена этим вызовом.
+	RecordDeliveryOutcome(ctx context.Context, subscriptionID string, delivered bool, maxFailures int) (bool, error)
+
+	// CreateDelivery создаёт доставку; существующая доставка с тем же ID не изменяется
+	CreateDelivery(ctx context.Context, delivery *Delivery) error
+
+	GetDelivery(ctx context.Context, id string) (*Delivery, error)
+
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*Delivery, error)
+
+	ListAttempts(ctx context.Context, deliveryID string) ([]*Attempt, error)
+
+	// AddAttempt пишет попытку в журнал и обновляет счётчики доставки
+	AddAttempt(ctx context.Context, attempt *Attempt) error
+
+	SetDeliveryStatus(ctx context.Context, id string, status DeliveryStatus) error
+}
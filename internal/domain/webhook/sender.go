@@ -0,0 +1,23 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+)
+
+type SendRequest struct {
+	URL     string
+	Headers http.Header
+	Body    []byte
+}
+
+type SendResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Sender выполняет HTTP-запрос к получателю. Ошибка возвращается только при сбое
+// транспорта; ответ с любым статусом — это SendResponse.
+type Sender interface {
+	Send(ctx context.Context, req *SendRequest) (*SendResponse, error)
+}
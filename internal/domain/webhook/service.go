@@ -0,0 +1,36 @@
+package webhook
+
+import (
+	"context"
+
+	"orderflow/internal/domain/outbox"
+)
+
+type Service interface {
+	CreateSubscription(ctx context.Context, req *CreateRequest) (*Subscription, error)
+
+	GetSubscription(ctx context.Context, id string) (*Subscription, error)
+
+	ListSubscriptions(ctx context.Context) ([]*Subscription, error)
+
+	DeleteSubscription(ctx context.Context, id string) error
+
+	EnableSubscription(ctx context.Context, id string) (*Subscription, error)
+
+	// CreateDeliveries создаёт доставки события для всех активных подписок с подходящим фильтром
+	CreateDeliveries(ctx context.Context, event *outbox.Event) ([]*Delivery, error)
+
+	// GetDelivery возвращает доставку подписки вместе с журналом попыток
+	GetDelivery(ctx context.Context, subscriptionID, deliveryID string) (*Delivery, error)
+
+	ListDeliveries(ctx context.Context, subscriptionID string) ([]*Delivery, error)
+
+	// Deliver выполняет одну подписанную попытку доставки и пишет её в журнал
+	Deliver(ctx context.Context, deliveryID string) error
+
+	// CompleteDelivery фиксирует итог доставки после всех ретраев; true — подписка отключена
+	CompleteDelivery(ctx context.Context, deliveryID string, delivered bool) (bool, error)
+
+	// PrepareRedelivery проверяет, что доставку можно отправить заново, и переводит её в pending
+	PrepareRedelivery(ctx context.Context, subscriptionID, deliveryID string) (*Delivery, error)
+}
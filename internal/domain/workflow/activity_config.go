@@ -0,0 +1,139 @@
+package workflow
+
+import "time"
+
+// ActivityConfig описывает ActivityOptions одной activity. Нулевые поля означают "не задано".
+type ActivityConfig struct {
+	StartToCloseTimeout    time.Duration `mapstructure:"start_to_close_timeout"`
+	ScheduleToCloseTimeout time.Duration `mapstructure:"schedule_to_close_timeout"`
+	HeartbeatTimeout       time.Duration `mapstructure:"heartbeat_timeout"`
+	MaximumAttempts        int32         `mapstructure:"maximum_attempts"`
+	InitialInterval        time.Duration `mapstructure:"initial_interval"`
+	MaximumInterval        time.Duration `mapstructure:"maximum_interval"`
+	BackoffCoefficient     float64       `mapstructure:"backoff_coefficient"`
+	NonRetryableErrorTypes []string      `mapstructure:"non_retryable_error_types"`
+}
+
+// Merge возвращает копию конфигурации, в которой заданные в override поля заменены.
+func (c ActivityConfig) Merge(override ActivityConfig) ActivityConfig {
+	if override.StartToCloseTimeout > 0 {
+		c.StartToCloseTimeout = override.StartToCloseTimeout
+	}
+	if override.ScheduleToCloseTimeout > 0 {
+		c.ScheduleToCloseTimeout = override.ScheduleToCloseTimeout
+	}
+	if override.HeartbeatTimeout > 0 {
+		c.HeartbeatTimeout = override.HeartbeatTimeout
+	}
+	if override.MaximumAttempts > 0 {
+		c.MaximumAttempts = override.MaximumAttempts
+	}
+	if override.InitialInterval > 0 {
+		c.InitialInterval = override.InitialInterval
+	}
+	if override.MaximumInterval > 0 {
+		c.MaximumInterval = override.MaximumInterval
+	}
+	if override.BackoffCoefficient > 0 {
+		c.BackoffCoefficient = override.BackoffCoefficient
+	}
+	if len(override.NonRetryableErrorTypes) > 0 {
+		c.NonRetryableErrorTypes = override.NonRetryableErrorTypes
+	}
+	return c
+}
+
+// DefaultActivityConfig возвращает общие настройки, от которых отталкиваются настройки отдельных activities.
+func DefaultActivityConfig() ActivityConfig {
+	return ActivityConfig{
+		StartToCloseTimeout:    30 * time.Second,
+		MaximumAttempts:        DefaultMaximumAttempts,
+		InitialInterval:        DefaultInitialInterval,
+		MaximumInterval:        DefaultMaximumInterval,
+		BackoffCoefficient:     DefaultBackoffCoefficient,
+		NonRetryableErrorTypes: []string{ErrorCodeValidation},
+	}
+}
+
+// DefaultActivityConfigs возвращает настройки по умолчанию для всех activities.
+func DefaultActivityConfigs() map[string]ActivityConfig {
+	base := DefaultActivityConfig()
+
+	return map[string]ActivityConfig{
+		CreateOrderActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    10 * time.Second,
+			ScheduleToCloseTimeout: time.Minute,
+		}),
+		CheckInventoryActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    10 * time.Second,
+			ScheduleToCloseTimeout: time.Minute,
+		}),
+		CalculateTaxActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    10 * time.Second,
+			ScheduleToCloseTimeout: time.Minute,
+		}),
+		// Heartbeat шлётся по таймеру, пока ждём провайдера: по HeartbeatTimeout обнаруживается
+		// упавший воркер, и через heartbeat до activity доходит отмена. Зависший вызов
+		// провайдера при этом продолжает слать heartbeat — его ограничивает только StartToClose
+		ProcessPaymentActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    30 * time.Second,
+			ScheduleToCloseTimeout: 3 * time.Minute,
+			HeartbeatTimeout:       10 * time.Second,
+		}),
+		SendNotificationActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    10 * time.Second,
+			ScheduleToCloseTimeout: 5 * time.Minute,
+			MaximumAttempts:        5,
+		}),
+		CancelOrderActivity: base.Merge(ActivityConfig{
+			ScheduleToCloseTimeout: 10 * time.Minute,
+			MaximumAttempts:        10,
+		}),
+		FailOrderActivity: base.Merge(ActivityConfig{
+			ScheduleToCloseTimeout: 10 * time.Minute,
+			MaximumAttempts:        10,
+		}),
+		CompleteOrderActivity: base.Merge(ActivityConfig{
+			ScheduleToCloseTimeout: 10 * time.Minute,
+			MaximumAttempts:        10,
+		}),
+		CleanupReservationsActivity: base.Merge(ActivityConfig{
+			ScheduleToCloseTimeout: 2 * time.Minute,
+		}),
+		// StartToClose меньше notification.ClaimLease: пока attempt жив, заявленные
+		// им уведомления не достанутся другому воркеру. Пачка делает не больше batch_size
+		// отправок, поэтому batch_size * таймаут отправки должен укладываться в StartToClose:
+		// по умолчанию 5 * 30s. Heartbeat шлётся после каждого уведомления, HeartbeatTimeout
+		// покрывает одну отправку с запасом
+		RetryNotificationsActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    3 * time.Minute,
+			ScheduleToCloseTimeout: 4 * time.Minute,
+			HeartbeatTimeout:       time.Minute,
+		}),
+		SaveSubscriptionActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    10 * time.Second,
+			ScheduleToCloseTimeout: 5 * time.Minute,
+			MaximumAttempts:        10,
+		}),
+		// Выполняется как local activity: короткие таймауты, чтобы не задерживать workflow task
+		RecordStepEventsActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    2 * time.Second,
+			ScheduleToCloseTimeout: 5 * time.Second,
+			MaximumAttempts:        3,
+			MaximumInterval:        time.Second,
+		}),
+		// Ретраи доставки вебхука и есть расписание повторов: с 10s до 1h по экспоненте в пределах суток
+		DeliverWebhookActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    30 * time.Second,
+			ScheduleToCloseTimeout: 24 * time.Hour,
+			MaximumAttempts:        15,
+			InitialInterval:        10 * time.Second,
+			MaximumInterval:        time.Hour,
+		}),
+		FinalizeWebhookDeliveryActivity: base.Merge(ActivityConfig{
+			StartToCloseTimeout:    10 * time.Second,
+			ScheduleToCloseTimeout: 10 * time.Minute,
+			MaximumAttempts:        10,
+		}),
+	}
+}
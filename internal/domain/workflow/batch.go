@@ -0,0 +1,85 @@
+package workflow
+
+type BatchLineStatus string
+
+const (
+	BatchLineStatusPending   BatchLineStatus = "pending"
+	BatchLineStatusRunning   BatchLineStatus = "running"
+	BatchLineStatusCompleted BatchLineStatus = "completed"
+	BatchLineStatusFailed    BatchLineStatus = "failed"
+	BatchLineStatusCancelled BatchLineStatus = "cancelled"
+	BatchLineStatusInvalid   BatchLineStatus = "invalid"
+)
+
+type BatchLineResult struct {
+	Line       int             `json:"line"`
+	Reference  string          `json:"reference,omitempty"`
+	CustomerID string          `json:"customer_id,omitempty"`
+	Status     BatchLineStatus `json:"status"`
+	WorkflowID string          `json:"workflow_id,omitempty"`
+	OrderID    string          `json:"order_id,omitempty"`
+	ErrorCode  string          `json:"error_code,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+type BatchImportReport struct {
+	BatchID   string `json:"batch_id"`
+	Total     int    `json:"total"`
+	Pending   int    `json:"pending"`
+	Running   int    `json:"running"`
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"`
+	Cancelled int    `json:"cancelled"`
+	Invalid   int    `json:"invalid"`
+	Done      bool   `json:"done"`
+
+	Lines []BatchLineResult `json:"lines"`
+}
+
+func NewBatchImportReport(input *BatchImportInput) *BatchImportReport {
+	report := &BatchImportReport{
+		BatchID: input.BatchID,
+		Lines:   make([]BatchLineResult, len(input.Lines)),
+	}
+
+	for i, line := range input.Lines {
+		result := BatchLineResult{
+			Line:       line.Line,
+			Reference:  line.Reference,
+			CustomerID: line.CustomerID,
+			Status:     BatchLineStatusPending,
+		}
+		if line.Error != "" {
+			result.Status = BatchLineStatusInvalid
+			result.ErrorCode = ErrorCodeValidation
+			result.Error = line.Error
+		}
+		report.Lines[i] = result
+	}
+
+	report.Recount()
+	return report
+}
+
+// Recount пересчитывает счётчики по статусам строк.
+func (r *BatchImportReport) Recount() {
+	r.Total = len(r.Lines)
+	r.Pending, r.Running, r.Completed, r.Failed, r.Cancelled, r.Invalid = 0, 0, 0, 0, 0, 0
+
+	for _, line := range r.Lines {
+		switch line.Status {
+		case BatchLineStatusPending:
+			r.Pending++
+		case BatchLineStatusRunning:
+			r.Running++
+		case BatchLineStatusCompleted:
+			r.Completed++
+		case BatchLineStatusFailed:
+			r.Failed++
+		case BatchLineStatusCancelled:
+			r.Cancelled++
+		case BatchLineStatusInvalid:
+			r.Invalid++
+		}
+	}
+}
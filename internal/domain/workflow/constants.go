@@ -3,24 +3,70 @@ package workflow
 import "time"
 
 const (
-	OrderProcessingWorkflow = "OrderProcessingWorkflow"
+	OrderProcessingWorkflow      = "OrderProcessingWorkflow"
+	ReservationCleanupWorkflow   = "ReservationCleanupWorkflow"
+	CustomerSubscriptionWorkflow = "CustomerSubscriptionWorkflow"
+	BatchOrderImportWorkflow     = "BatchOrderImportWorkflow"
+	WebhookDeliveryWorkflow      = "WebhookDeliveryWorkflow"
+	NotificationRetryWorkflow    = "NotificationRetryWorkflow"
 	
-	CreateOrderActivity      = "CreateOrderActivity"
-	CheckInventoryActivity   = "CheckInventoryActivity"
-	ProcessPaymentActivity   = "ProcessPaymentActivity"
-	SendNotificationActivity = "SendNotificationActivity"
-	CancelOrderActivity      = "CancelOrderActivity"
+	CreateOrderActivity         = "CreateOrderActivity"
+	CheckInventoryActivity      = "CheckInventoryActivity"
+	CalculateTaxActivity        = "CalculateTaxActivity"
+	ProcessPaymentActivity      = "ProcessPaymentActivity"
+	SendNotificationActivity    = "SendNotificationActivity"
+	CancelOrderActivity         = "CancelOrderActivity"
+	FailOrderActivity           = "FailOrderActivity"
+	CompleteOrderActivity       = "CompleteOrderActivity"
+	CleanupReservationsActivity = "CleanupReservationsActivity"
+	SaveSubscriptionActivity    = "SaveSubscriptionActivity"
+	RecordStepEventsActivity    = "RecordStepEventsActivity"
+	RetryNotificationsActivity  = "RetryNotificationsActivity"
+
+	DeliverWebhookActivity          = "DeliverWebhookActivity"
+	FinalizeWebhookDeliveryActivity = "FinalizeWebhookDeliveryActivity"
 	
 	OrderProcessingTaskQueue = "order-processing"
 )
 
 const (
-	CancelOrderSignal = "cancel-order"
+	ReservationCleanupScheduleID = "reservation-cleanup"
+	NotificationRetryScheduleID  = "notification-retry"
+
+	SubscriptionWorkflowIDPrefix = "subscription-"
+	// После стольких циклов подписка продолжает работу через continue-as-new,
+	// чтобы история workflow оставалась ограниченной
+	SubscriptionCyclesPerRun = 12
+
+	BatchImportWorkflowIDPrefix   = "order-batch-"
+	DefaultBatchImportConcurrency = 10
+	MaxBatchImportConcurrency     = 50
+	// Все строки пакета передаются во входе workflow, поэтому размер пакета ограничен
+	MaxBatchImportLines = 1000
+	// Вход workflow пишется в историю одним payload, а Temporal отклоняет payload больше 2 МБ
+	MaxBatchImportPayloadBytes = 1536 << 10
+
+	WebhookDeliveryWorkflowIDPrefix = "webhook-delivery-"
+)
+
+const (
+	CancelOrderSignal        = "cancel-order"
+	ReservationExpiredSignal = "reservation-expired"
+	// PaymentProviderEventSignal — платёжный провайдер сообщил об изменении платежа заказа
+	PaymentProviderEventSignal = "payment-provider-event"
+
+	PauseSubscriptionSignal  = "subscription-pause"
+	ResumeSubscriptionSignal = "subscription-resume"
+	SkipSubscriptionSignal   = "subscription-skip"
+	CancelSubscriptionSignal = "subscription-cancel"
 )
 
 const (
 	OrderStatusQuery   = "order-status"
 	WorkflowStateQuery = "workflow-state"
+
+	SubscriptionStateQuery   = "subscription-state"
+	BatchImportProgressQuery = "batch-import-progress"
 )
 
 const (
@@ -30,29 +76,48 @@ const (
 	DefaultBackoffCoefficient = 2.0
 )
 
-const (
-	OrderCreationDuration     = 1 * time.Second
-	InventoryCheckDuration    = 2 * time.Second
-	PaymentProcessDuration    = 3 * time.Second
-	NotificationDuration      = 1 * time.Second
-)
-
 const (
 	StepCreateOrder      = "create_order"
 	StepCheckInventory   = "check_inventory"
+	StepCalculateTax     = "calculate_tax"
 	StepProcessPayment   = "process_payment"
 	StepSendNotification = "send_notification"
 	StepComplete         = "complete"
 	StepFailed           = "failed"
 	StepCancelled        = "cancelled"
+	StepTimedOut         = "timed_out"
+
+	StepSubscriptionCycle = "subscription_cycle"
+	StepWebhookDelivery   = "webhook_delivery"
 )
 
 const (
 	ErrorCodeValidation           = "VALIDATION_ERROR"
 	ErrorCodeInventoryUnavailable = "INVENTORY_UNAVAILABLE"
+	ErrorCodeReservationExpired   = "RESERVATION_EXPIRED"
 	ErrorCodePaymentFailed        = "PAYMENT_FAILED"
 	ErrorCodeNotificationFailed   = "NOTIFICATION_FAILED"
 	ErrorCodeOrderCancelled       = "ORDER_CANCELLED"
 	ErrorCodeOrderNotFound        = "ORDER_NOT_FOUND"
+	ErrorCodeOrderTimeout         = "ORDER_TIMEOUT"
+	ErrorCodePaymentReversed      = "PAYMENT_REVERSED"
 	ErrorCodeInternalError        = "INTERNAL_ERROR"
+
+	ErrorCodeProductNotFound     = "PRODUCT_NOT_FOUND"
+	ErrorCodeInsufficientStock   = "INSUFFICIENT_STOCK"
+	ErrorCodeReservationNotFound = "RESERVATION_NOT_FOUND"
+	ErrorCodeInsufficientFunds   = "INSUFFICIENT_FUNDS"
+	ErrorCodePaymentDeclined     = "PAYMENT_DECLINED"
+	ErrorCodeDuplicatePayment    = "DUPLICATE_PAYMENT"
+	ErrorCodeInvalidOrderStatus  = "INVALID_ORDER_STATUS"
+	ErrorCodeUnsupportedChannel  = "UNSUPPORTED_CHANNEL"
+	ErrorCodeTemplateError       = "TEMPLATE_ERROR"
+	ErrorCodeRecipientNotFound   = "RECIPIENT_NOT_FOUND"
+	ErrorCodeCouponRejected      = "COUPON_REJECTED"
+	ErrorCodeTaxRateNotFound     = "TAX_RATE_NOT_FOUND"
+	ErrorCodeShippingUnavailable = "SHIPPING_UNAVAILABLE"
+
+	ErrorCodeWebhookDeliveryFailed = "WEBHOOK_DELIVERY_FAILED"
+	ErrorCodeWebhookDisabled       = "WEBHOOK_DISABLED"
+	ErrorCodeWebhookNotFound       = "WEBHOOK_NOT_FOUND"
 )
\ No newline at end of file
@@ -41,6 +41,47 @@ func NewActivityError(activityName, step, code, message string, retryable bool)
 	}
 }
 
+// ErrorDetails передаётся в details temporal.ApplicationError вместе с типом ошибки (кодом).
+type ErrorDetails struct {
+	Activity   string            `json:"activity"`
+	Step       string            `json:"step"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+var errorCodes = map[string]struct{}{
+	ErrorCodeValidation:           {},
+	ErrorCodeInventoryUnavailable: {},
+	ErrorCodeReservationExpired:   {},
+	ErrorCodePaymentFailed:        {},
+	ErrorCodeNotificationFailed:   {},
+	ErrorCodeOrderCancelled:       {},
+	ErrorCodeOrderNotFound:        {},
+	ErrorCodeOrderTimeout:         {},
+	ErrorCodePaymentReversed:      {},
+	ErrorCodeInternalError:        {},
+	ErrorCodeProductNotFound:      {},
+	ErrorCodeInsufficientStock:    {},
+	ErrorCodeReservationNotFound:  {},
+	ErrorCodeInsufficientFunds:    {},
+	ErrorCodePaymentDeclined:      {},
+	ErrorCodeDuplicatePayment:     {},
+	ErrorCodeInvalidOrderStatus:   {},
+	ErrorCodeUnsupportedChannel:   {},
+	ErrorCodeTemplateError:        {},
+	ErrorCodeRecipientNotFound:    {},
+
+	ErrorCodeWebhookDeliveryFailed: {},
+	ErrorCodeWebhookDisabled:       {},
+	ErrorCodeWebhookNotFound:       {},
+}
+
+// IsErrorCode сообщает, является ли строка одним из стабильных кодов ошибок,
+// которые используются как тип temporal.ApplicationError.
+func IsErrorCode(code string) bool {
+	_, ok := errorCodes[code]
+	return ok
+}
+
 type CancellationError struct {
 	OrderID string
 	Reason  string
@@ -15,7 +15,10 @@ type State struct {
 	ErrorCode     string       `json:"error_code,omitempty"`
 	RetryCount    int          `json:"retry_count"`
 	IsCancelled   bool         `json:"is_cancelled"`
+	IsTimedOut    bool         `json:"is_timed_out"`
+	TimedOutStep  string       `json:"timed_out_step,omitempty"`
 	PaymentID     string       `json:"payment_id,omitempty"`
+	Allocation    []order.AllocationLine `json:"allocation,omitempty"`
 	StartedAt     time.Time    `json:"started_at"`
 	CompletedAt   *time.Time   `json:"completed_at,omitempty"`
 	
@@ -24,7 +27,7 @@ type State struct {
 
 type StepExecution struct {
 	Step        string    `json:"step"`
-	Status      string    `json:"status"` // "started", "completed", "failed"
+	Status      string    `json:"status"` // "started", "completed", "failed", "timed_out"
 	StartedAt   time.Time `json:"started_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 	Error       string    `json:"error,omitempty"`
@@ -50,14 +53,18 @@ func (s *State) IsFailed() bool {
 	return s.Status == order.StatusFailed
 }
 
+// UpdateStep завершает текущий шаг, если он ещё выполняется, и начинает следующий.
 func (s *State) UpdateStep(step string) {
+	if current := s.GetCurrentStepExecution(); current != nil && current.Status == "started" {
+		s.completeCurrentStep(true, "")
+	}
 	s.CurrentStep = step
 	s.startStepExecution(step)
 }
 
 func (s *State) UpdateStatus(status order.Status) {
 	s.Status = status
-	if status == order.StatusCompleted || status == order.StatusFailed || status == order.StatusCancelled {
+	if status == order.StatusCompleted || status == order.StatusFailed || status == order.StatusCancelled || status == order.StatusTimedOut {
 		now := time.Now()
 		s.CompletedAt = &now
 		s.completeCurrentStep(status == order.StatusCompleted, "")
@@ -73,6 +80,30 @@ func (s *State) SetError(code, message string) {
 	s.completeCurrentStep(false, message)
 }
 
+func (s *State) IsTimeout() bool {
+	return s.Status == order.StatusTimedOut
+}
+
+// SetTimeout фиксирует нарушение дедлайна заказа или SLA шага.
+func (s *State) SetTimeout(step string, timeout time.Duration) {
+	timeoutErr := NewTimeoutError(step, timeout.String())
+
+	s.IsTimedOut = true
+	s.TimedOutStep = step
+	s.ErrorCode = ErrorCodeOrderTimeout
+	s.ErrorMessage = timeoutErr.Error()
+	s.Status = order.StatusTimedOut
+	now := time.Now()
+	s.CompletedAt = &now
+
+	if len(s.StepHistory) > 0 {
+		currentStep := &s.StepHistory[len(s.StepHistory)-1]
+		currentStep.CompletedAt = &now
+		currentStep.Status = StepTimedOut
+		currentStep.Error = timeoutErr.Error()
+	}
+}
+
 func (s *State) Cancel() {
 	s.IsCancelled = true
 	s.Status = order.StatusCancelled
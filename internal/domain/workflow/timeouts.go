@@ -0,0 +1,63 @@
+package workflow
+
+import "time"
+
+const (
+	DefaultOrderDeadline = 30 * time.Minute
+	// DefaultOrderExecutionTimeout — жёсткий предел Temporal на весь запуск: оставляет запас после
+	// дедлайна на компенсацию, повторное резервирование и уведомления.
+	DefaultOrderExecutionTimeout = 2 * time.Hour
+)
+
+// OrderTimeouts — общий дедлайн OrderProcessingWorkflow, SLA отдельных шагов и WorkflowExecutionTimeout,
+// с которым workflow запускается. Нулевое значение отключает соответствующий таймер. В override для Merge
+// ноль означает «не задано», поэтому в конфиге таймер отключается отрицательным значением (например, -1s).
+type OrderTimeouts struct {
+	Deadline         time.Duration            `json:"deadline" mapstructure:"deadline"`
+	ExecutionTimeout time.Duration            `json:"execution_timeout" mapstructure:"execution_timeout"`
+	StepSLAs         map[string]time.Duration `json:"step_sla" mapstructure:"step_sla"`
+}
+
+func DefaultOrderTimeouts() OrderTimeouts {
+	return OrderTimeouts{
+		Deadline:         DefaultOrderDeadline,
+		ExecutionTimeout: DefaultOrderExecutionTimeout,
+		StepSLAs: map[string]time.Duration{
+			StepCreateOrder:    2 * time.Minute,
+			StepCheckInventory: 5 * time.Minute,
+			StepCalculateTax:   2 * time.Minute,
+			StepProcessPayment: 10 * time.Minute,
+		},
+	}
+}
+
+// Merge возвращает копию, в которой заданные в override значения заменены.
+// Отрицательное значение в override отключает таймер (в результате он равен нулю).
+func (t OrderTimeouts) Merge(override OrderTimeouts) OrderTimeouts {
+	merged := OrderTimeouts{
+		Deadline:         t.Deadline,
+		ExecutionTimeout: t.ExecutionTimeout,
+		StepSLAs:         make(map[string]time.Duration, len(t.StepSLAs)),
+	}
+	for step, sla := range t.StepSLAs {
+		merged.StepSLAs[step] = sla
+	}
+
+	merged.Deadline = mergeTimeout(merged.Deadline, override.Deadline)
+	merged.ExecutionTimeout = mergeTimeout(merged.ExecutionTimeout, override.ExecutionTimeout)
+	for step, sla := range override.StepSLAs {
+		merged.StepSLAs[step] = max(sla, 0)
+	}
+	return merged
+}
+
+func mergeTimeout(base, override time.Duration) time.Duration {
+	switch {
+	case override < 0:
+		return 0
+	case override > 0:
+		return override
+	default:
+		return base
+	}
+}
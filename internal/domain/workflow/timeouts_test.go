@@ -0,0 +1,52 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrderTimeoutsMerge(t *testing.T) {
+	tests := []struct {
+		name          string
+		override      OrderTimeouts
+		wantDeadline  time.Duration
+		wantExecution time.Duration
+		wantPayment   time.Duration
+	}{
+		{
+			name:          "empty override keeps defaults",
+			wantDeadline:  DefaultOrderDeadline,
+			wantExecution: DefaultOrderExecutionTimeout,
+			wantPayment:   10 * time.Minute,
+		},
+		{
+			name:          "positive values replace defaults",
+			override:      OrderTimeouts{Deadline: time.Hour, ExecutionTimeout: 3 * time.Hour, StepSLAs: map[string]time.Duration{StepProcessPayment: time.Minute}},
+			wantDeadline:  time.Hour,
+			wantExecution: 3 * time.Hour,
+			wantPayment:   time.Minute,
+		},
+		{
+			name:          "negative values disable timers",
+			override:      OrderTimeouts{Deadline: -1, ExecutionTimeout: -time.Second, StepSLAs: map[string]time.Duration{StepProcessPayment: -1}},
+			wantDeadline:  0,
+			wantExecution: 0,
+			wantPayment:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := DefaultOrderTimeouts().Merge(tt.override)
+			if merged.Deadline != tt.wantDeadline {
+				t.Errorf("Deadline = %s, want %s", merged.Deadline, tt.wantDeadline)
+			}
+			if merged.ExecutionTimeout != tt.wantExecution {
+				t.Errorf("ExecutionTimeout = %s, want %s", merged.ExecutionTimeout, tt.wantExecution)
+			}
+			if got := merged.StepSLAs[StepProcessPayment]; got != tt.wantPayment {
+				t.Errorf("StepSLAs[%s] = %s, want %s", StepProcessPayment, got, tt.wantPayment)
+			}
+		})
+	}
+}
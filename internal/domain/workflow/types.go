@@ -4,11 +4,18 @@ import (
 	"orderflow/internal/domain/inventory"
 	"orderflow/internal/domain/notification"
 	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/payment"
+	"orderflow/internal/domain/subscription"
 )
 
 type OrderProcessingInput struct {
-	CustomerID string       `json:"customer_id"`
-	Items      []order.Item `json:"items"`
+	CustomerID      string         `json:"customer_id"`
+	Items           []order.Item   `json:"items"`
+	CouponCode      string         `json:"coupon_code,omitempty"`
+	TaxJurisdiction string         `json:"tax_jurisdiction,omitempty"`
+	ShippingAddress *order.Address `json:"shipping_address,omitempty"`
+	BillingAddress  *order.Address `json:"billing_address,omitempty"`
+	ShippingMethod  string         `json:"shipping_method,omitempty"`
 }
 
 type ActivityInput interface {
@@ -16,8 +23,13 @@ type ActivityInput interface {
 }
 
 type CreateOrderActivityInput struct {
-	CustomerID string       `json:"customer_id"`
-	Items      []order.Item `json:"items"`
+	CustomerID      string         `json:"customer_id"`
+	Items           []order.Item   `json:"items"`
+	CouponCode      string         `json:"coupon_code,omitempty"`
+	TaxJurisdiction string         `json:"tax_jurisdiction,omitempty"`
+	ShippingAddress *order.Address `json:"shipping_address,omitempty"`
+	BillingAddress  *order.Address `json:"billing_address,omitempty"`
+	ShippingMethod  string         `json:"shipping_method,omitempty"`
 }
 
 func (i *CreateOrderActivityInput) Validate() error {
@@ -32,6 +44,8 @@ func (i *CreateOrderActivityInput) Validate() error {
 
 type CreateOrderActivityOutput struct {
 	OrderID string `json:"order_id"`
+	// TotalAmount — сумма к оплате с учётом скидок и доставки
+	TotalAmount float64 `json:"total_amount"`
 }
 
 type CheckInventoryActivityInput struct {
@@ -49,6 +63,53 @@ func (i *CheckInventoryActivityInput) Validate() error {
 type CheckInventoryActivityOutput struct {
 	Available        bool                            `json:"available"`
 	UnavailableItems []inventory.UnavailableItem     `json:"unavailable_items,omitempty"`
+	Allocation       []order.AllocationLine          `json:"allocation,omitempty"`
+}
+
+type CalculateTaxActivityInput struct {
+	OrderID string `json:"order_id"`
+}
+
+func (i *CalculateTaxActivityInput) Validate() error {
+	if i.OrderID == "" {
+		return NewValidationError("order_id is required")
+	}
+	return nil
+}
+
+type CalculateTaxActivityOutput struct {
+	TaxAmount float64 `json:"tax_amount"`
+	// TotalAmount — сумма к оплате с учётом скидок, доставки и налога
+	TotalAmount float64 `json:"total_amount"`
+}
+
+// FailOrderActivityInput — заказ, который workflow завершает ошибкой, и причина для заказа.
+type FailOrderActivityInput struct {
+	OrderID string `json:"order_id"`
+	Reason  string `json:"reason"`
+}
+
+func (i *FailOrderActivityInput) Validate() error {
+	if i.OrderID == "" {
+		return NewValidationError("order_id is required")
+	}
+	return nil
+}
+
+// CompleteOrderActivityInput — оплаченный заказ и платёж, который записывается в заказ.
+type CompleteOrderActivityInput struct {
+	OrderID   string `json:"order_id"`
+	PaymentID string `json:"payment_id"`
+}
+
+func (i *CompleteOrderActivityInput) Validate() error {
+	if i.OrderID == "" {
+		return NewValidationError("order_id is required")
+	}
+	if i.PaymentID == "" {
+		return NewValidationError("payment_id is required")
+	}
+	return nil
 }
 
 type ProcessPaymentActivityInput struct {
@@ -76,12 +137,15 @@ type ProcessPaymentActivityOutput struct {
 	TransactionID string `json:"transaction_id"`
 }
 
+// SendNotificationActivityInput: пустой Channel — канал выбирается по предпочтениям клиента,
+// пустой Message — текст рендерится из шаблона, Reason попадает в шаблон как причина ошибки.
 type SendNotificationActivityInput struct {
 	CustomerID string               `json:"customer_id"`
 	OrderID    string               `json:"order_id"`
 	Type       notification.Type    `json:"type"`
-	Channel    notification.Channel `json:"channel"`
+	Channel    notification.Channel `json:"channel,omitempty"`
 	Message    string               `json:"message"`
+	Reason     string               `json:"reason,omitempty"`
 }
 
 func (i *SendNotificationActivityInput) Validate() error {
@@ -94,6 +158,147 @@ func (i *SendNotificationActivityInput) Validate() error {
 	return nil
 }
 
+type ReservationCleanupInput struct {
+	BatchSize  int `json:"batch_size"`
+	MaxBatches int `json:"max_batches"`
+}
+
+type ReservationCleanupResult struct {
+	Released          int `json:"released"`
+	NotifiedWorkflows int `json:"notified_workflows"`
+}
+
+type CleanupReservationsActivityInput struct {
+	BatchSize int `json:"batch_size"`
+}
+
+type CleanupReservationsActivityOutput struct {
+	Released []ExpiredReservation `json:"released"`
+}
+
+type ExpiredReservation struct {
+	ReservationID string `json:"reservation_id"`
+	OrderID       string `json:"order_id"`
+	WorkflowID    string `json:"workflow_id,omitempty"`
+	ProductID     string `json:"product_id"`
+	Quantity      int    `json:"quantity"`
+}
+
+type NotificationRetryInput struct {
+	BatchSize  int `json:"batch_size"`
+	MaxBatches int `json:"max_batches"`
+}
+
+type NotificationRetryResult struct {
+	Claimed     int `json:"claimed"`
+	Sent        int `json:"sent"`
+	Rescheduled int `json:"rescheduled"`
+	Dead        int `json:"dead"`
+	Digested    int `json:"digested"`
+}
+
+type RetryNotificationsActivityInput struct {
+	BatchSize int `json:"batch_size"`
+}
+
+type RetryNotificationsActivityOutput struct {
+	Claimed     int `json:"claimed"`
+	Sent        int `json:"sent"`
+	Rescheduled int `json:"rescheduled"`
+	Dead        int `json:"dead"`
+	Digested    int `json:"digested"`
+}
+
+type ReservationExpiredSignalInput struct {
+	OrderID        string   `json:"order_id"`
+	ReservationIDs []string `json:"reservation_ids"`
+}
+
+type PaymentProviderEventSignalInput struct {
+	PaymentID     string                    `json:"payment_id"`
+	EventID       string                    `json:"event_id"`
+	EventType     payment.ProviderEventType `json:"event_type"`
+	PaymentStatus payment.Status            `json:"payment_status"`
+	Reason        string                    `json:"reason,omitempty"`
+}
+
+// ReversesPayment сообщает, что деньги по заказу больше не получены: платёж отклонён,
+// возвращён или оспорен на стороне провайдера.
+func (i *PaymentProviderEventSignalInput) ReversesPayment() bool {
+	switch i.PaymentStatus {
+	case payment.StatusFailed, payment.StatusRefunded, payment.StatusDisputed:
+		return true
+	}
+	return false
+}
+
+type CustomerSubscriptionInput struct {
+	Subscription subscription.Subscription `json:"subscription"`
+	SkipNext     bool                      `json:"skip_next"`
+	Persisted    bool                      `json:"persisted"`
+}
+
+type SubscriptionSignalInput struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type BatchOrderLine struct {
+	Line            int            `json:"line"`
+	Reference       string         `json:"reference,omitempty"`
+	CustomerID      string         `json:"customer_id"`
+	Items           []order.Item   `json:"items"`
+	CouponCode      string         `json:"coupon_code,omitempty"`
+	TaxJurisdiction string         `json:"tax_jurisdiction,omitempty"`
+	ShippingAddress *order.Address `json:"shipping_address,omitempty"`
+	BillingAddress  *order.Address `json:"billing_address,omitempty"`
+	ShippingMethod  string         `json:"shipping_method,omitempty"`
+	// Error заполняется при разборе, если строка невалидна: такой заказ не запускается
+	Error string `json:"error,omitempty"`
+}
+
+type BatchImportInput struct {
+	BatchID     string           `json:"batch_id"`
+	Lines       []BatchOrderLine `json:"lines"`
+	Concurrency int              `json:"concurrency"`
+}
+
+type WebhookDeliveryInput struct {
+	DeliveryID     string `json:"delivery_id"`
+	SubscriptionID string `json:"subscription_id"`
+	EventType      string `json:"event_type"`
+}
+
+func (i *WebhookDeliveryInput) Validate() error {
+	if i.DeliveryID == "" {
+		return NewValidationError("delivery_id is required")
+	}
+	return nil
+}
+
+type FinalizeWebhookDeliveryActivityInput struct {
+	DeliveryID string `json:"delivery_id"`
+	Delivered  bool   `json:"delivered"`
+}
+
+func (i *FinalizeWebhookDeliveryActivityInput) Validate() error {
+	if i.DeliveryID == "" {
+		return NewValidationError("delivery_id is required")
+	}
+	return nil
+}
+
+type FinalizeWebhookDeliveryActivityOutput struct {
+	SubscriptionDisabled bool `json:"subscription_disabled"`
+}
+
+type WebhookDeliveryResult struct {
+	DeliveryID           string `json:"delivery_id"`
+	Delivered            bool   `json:"delivered"`
+	ErrorCode            string `json:"error_code,omitempty"`
+	Error                string `json:"error,omitempty"`
+	SubscriptionDisabled bool   `json:"subscription_disabled"`
+}
+
 type ActivityResult struct {
 	Success bool        `json:"success"`
 	Message string      `json:"message,omitempty"`
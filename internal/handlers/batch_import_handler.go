@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go.temporal.io/sdk/client"
+
+	"orderflow/internal/domain/workflow"
+	"orderflow/internal/usecase/batchimport"
+	"orderflow/pkg/logger"
+)
+
+// Ограничение на тело запроса: разобранный пакет должен поместиться во вход workflow
+// (MaxBatchImportPayloadBytes), а JSON строк обычно не меньше исходного файла
+const maxBatchImportBodyBytes = 1 << 20
+
+type BatchImportHandler struct {
+	temporalClient client.Client
+}
+
+func NewBatchImportHandler(temporalClient client.Client) *BatchImportHandler {
+	return &BatchImportHandler{
+		temporalClient: temporalClient,
+	}
+}
+
+type BatchImportResponse struct {
+	BatchID    string `json:"batch_id"`
+	WorkflowID string `json:"workflow_id"`
+	Total      int    `json:"total"`
+	Invalid    int    `json:"invalid"`
+	Message    string `json:"message"`
+}
+
+func (h *BatchImportHandler) ImportOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format, err := batchimport.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format == "" {
+		format = batchimport.DetectFormat(r.Header.Get("Content-Type"), "")
+	}
+
+	concurrency := 0
+	if value := r.URL.Query().Get("concurrency"); value != "" {
+		concurrency, err = strconv.Atoi(value)
+		if err != nil || concurrency <= 0 {
+			http.Error(w, "concurrency must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	lines, err := batchimport.Parse(http.MaxBytesReader(w, r.Body, maxBatchImportBodyBytes), format)
+	if err != nil {
+		logger.Error("Failed to parse batch", "error", err, "format", format)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workflowRun, input, err := batchimport.Start(r.Context(), h.temporalClient, lines, concurrency)
+	if errors.Is(err, batchimport.ErrBatchPayloadTooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to start batch import workflow", "error", err)
+		http.Error(w, "Failed to start batch import", http.StatusInternalServerError)
+		return
+	}
+
+	report := workflow.NewBatchImportReport(input)
+
+	response := BatchImportResponse{
+		BatchID:    input.BatchID,
+		WorkflowID: workflowRun.GetID(),
+		Total:      report.Total,
+		Invalid:    report.Invalid,
+		Message:    "Batch import started successfully",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BatchImportHandler) GetBatchProgress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	batchID := r.URL.Query().Get("batch_id")
+	if batchID == "" {
+		http.Error(w, "batch_id is required", http.StatusBadRequest)
+		return
+	}
+
+	report, err := batchimport.Progress(r.Context(), h.temporalClient, batchID)
+	if err != nil {
+		logger.Error("Failed to query batch progress", "error", err, "batch_id", batchID)
+		http.Error(w, "Failed to get batch progress", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
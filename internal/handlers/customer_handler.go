@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"orderflow/internal/domain/customer"
+	"orderflow/internal/domain/notification"
+	"orderflow/pkg/logger"
+)
+
+type CustomerHandler struct {
+	customerService customer.Service
+}
+
+func NewCustomerHandler(customerService customer.Service) *CustomerHandler {
+	return &CustomerHandler{customerService: customerService}
+}
+
+type ConsentRequest struct {
+	OptedIn bool `json:"opted_in"`
+}
+
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	customerEntity, err := h.customerService.GetCustomer(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeCustomerError(w, err, "Failed to get customer")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customerEntity)
+}
+
+// SaveCustomer создаёт или полностью заменяет контакты и настройки клиента.
+func (h *CustomerHandler) SaveCustomer(w http.ResponseWriter, r *http.Request) {
+	var req customer.SaveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	customerEntity, err := h.customerService.SaveCustomer(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		writeCustomerError(w, err, "Failed to save customer")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customerEntity)
+}
+
+func (h *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	if err := h.customerService.DeleteCustomer(r.Context(), r.PathValue("id")); err != nil {
+		writeCustomerError(w, err, "Failed to delete customer")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CustomerHandler) SetConsent(w http.ResponseWriter, r *http.Request) {
+	var req ConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	notificationType := notification.Type(r.PathValue("type"))
+	customerEntity, err := h.customerService.SetConsent(r.Context(), r.PathValue("id"), notificationType, req.OptedIn)
+	if err != nil {
+		writeCustomerError(w, err, "Failed to update consent")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customerEntity)
+}
+
+func writeCustomerError(w http.ResponseWriter, err error, message string) {
+	var (
+		validationErr *customer.ValidationError
+		notFoundErr   *customer.NotFoundError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &notFoundErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		logger.Error(message, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"orderflow/internal/domain/notification"
+	"orderflow/pkg/logger"
+)
+
+// NotificationHandler — админский API очереди повторов: просмотр dead-уведомлений,
+// возврат в очередь и отбрасывание.
+type NotificationHandler struct {
+	notificationService notification.Service
+}
+
+func NewNotificationHandler(notificationService notification.Service) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+func (h *NotificationHandler) GetNotification(w http.ResponseWriter, r *http.Request) {
+	notificationEntity, err := h.notificationService.GetByID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeNotificationError(w, err, "Failed to get notification")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notificationEntity)
+}
+
+func (h *NotificationHandler) ListDeadNotifications(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	notifications, err := h.notificationService.ListDead(r.Context(), limit)
+	if err != nil {
+		writeNotificationError(w, err, "Failed to list dead notifications")
+		return
+	}
+	if notifications == nil {
+		notifications = []*notification.Notification{}
+	}
+
+	writeJSON(w, http.StatusOK, notifications)
+}
+
+func (h *NotificationHandler) RequeueNotification(w http.ResponseWriter, r *http.Request) {
+	notificationEntity, err := h.notificationService.Requeue(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeNotificationError(w, err, "Failed to requeue notification")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notificationEntity)
+}
+
+func (h *NotificationHandler) DiscardNotification(w http.ResponseWriter, r *http.Request) {
+	notificationEntity, err := h.notificationService.Discard(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeNotificationError(w, err, "Failed to discard notification")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notificationEntity)
+}
+
+func writeNotificationError(w http.ResponseWriter, err error, message string) {
+	var (
+		validationErr *notification.ValidationError
+		notFoundErr   *notification.NotFoundError
+		statusErr     *notification.StatusError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &notFoundErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &statusErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logger.Error(message, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+
+	"orderflow/internal/domain/orderevent"
+	"orderflow/pkg/logger"
+)
+
+const (
+	sseKeepAliveInterval = 15 * time.Second
+	sseRetryMillis       = 3000
+)
+
+// OrderEventsHandler отдаёт переходы шагов заказа как Server-Sent Events.
+// События читаются из order_step_events, о новых сообщает LISTEN/NOTIFY,
+// поэтому Temporal не опрашивается на каждое изменение.
+type OrderEventsHandler struct {
+	temporalClient client.Client
+	orderEvents    orderevent.Service
+}
+
+func NewOrderEventsHandler(temporalClient client.Client, orderEvents orderevent.Service) *OrderEventsHandler {
+	return &OrderEventsHandler{
+		temporalClient: temporalClient,
+		orderEvents:    orderEvents,
+	}
+}
+
+// StreamOrderEvents — GET /api/orders/{id}/events, где id — workflow_id заказа.
+// Для продолжения потока после обрыва клиент передаёт Last-Event-ID (заголовок
+// или параметр last_event_id). Поток закрывается после финального события заказа.
+func (h *OrderEventsHandler) StreamOrderEvents(w http.ResponseWriter, r *http.Request) {
+	workflowID := r.PathValue("id")
+	if workflowID == "" {
+		http.Error(w, "order id is required", http.StatusBadRequest)
+		return
+	}
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+
+	description, err := h.temporalClient.DescribeWorkflowExecution(r.Context(), workflowID, "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			http.Error(w, "Order not found", http.StatusNotFound)
+			return
+		}
+		logger.Error("Failed to describe order workflow", "error", err, "workflow_id", workflowID)
+		http.Error(w, "Failed to get order", http.StatusInternalServerError)
+		return
+	}
+	running := description.GetWorkflowExecutionInfo().GetStatus() == enums.WORKFLOW_EXECUTION_STATUS_RUNNING
+
+	// Подписываемся до чтения истории, чтобы не потерять события между запросом и подпиской
+	updates, unsubscribe := h.orderEvents.Subscribe(workflowID)
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	// WriteTimeout сервера рассчитан на обычные запросы, поток должен жить дольше
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("Failed to disable write deadline for SSE", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillis)
+	if err := rc.Flush(); err != nil {
+		logger.Error("Streaming is not supported", "error", err)
+		return
+	}
+
+	// sendPending отправляет все события после lastEventID; true — отправлено финальное событие
+	sendPending := func() (bool, error) {
+		for {
+			events, err := h.orderEvents.ListAfter(r.Context(), workflowID, lastEventID)
+			if err != nil {
+				return false, err
+			}
+
+			for _, event := range events {
+				if err := writeSSEEvent(w, event); err != nil {
+					return false, err
+				}
+				lastEventID = event.ID
+				if event.IsTerminal() {
+					return true, rc.Flush()
+				}
+			}
+
+			if len(events) < orderevent.DefaultListLimit {
+				return false, rc.Flush()
+			}
+		}
+	}
+
+	done, err := sendPending()
+	if err != nil {
+		logger.Error("Failed to stream order events", "error", err, "workflow_id", workflowID)
+		return
+	}
+	// Workflow, завершённые до включения событий, финального события не имеют
+	if done || !running {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates:
+			done, err := sendPending()
+			if err != nil {
+				logger.Error("Failed to stream order events", "error", err, "workflow_id", workflowID)
+				return
+			}
+			if done {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeSSEEvent(w io.Writer, event *orderevent.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: step\ndata: %s\n\n", event.ID, data)
+	return err
+}
+
+func parseLastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
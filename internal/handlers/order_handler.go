@@ -10,6 +10,7 @@ import (
 
 	"orderflow/internal/domain/order"
 	"orderflow/internal/domain/workflow"
+	usecaseWorkflow "orderflow/internal/usecase/workflow"
 	"orderflow/pkg/logger"
 )
 
@@ -26,6 +27,14 @@ func NewOrderHandler(temporalClient client.Client) *OrderHandler {
 type CreateOrderRequest struct {
 	CustomerID string      `json:"customer_id"`
 	Items      []order.Item `json:"items"`
+	CouponCode string      `json:"coupon_code,omitempty"`
+	// TaxJurisdiction — например "US-CA"; пустая — юрисдикция по умолчанию из конфигурации
+	TaxJurisdiction string `json:"tax_jurisdiction,omitempty"`
+	// ShippingAddress обязателен для заказов с доставкой; ShippingMethod — код способа из
+	// POST /api/shipping/rates, пустой — самый дешёвый
+	ShippingAddress *order.Address `json:"shipping_address,omitempty"`
+	BillingAddress  *order.Address `json:"billing_address,omitempty"`
+	ShippingMethod  string         `json:"shipping_method,omitempty"`
 }
 
 type CreateOrderResponse struct {
@@ -61,14 +70,32 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Адреса проверяются до запуска workflow, чтобы ошибка в них не стоила клиенту заказа
+	for _, address := range []*order.Address{req.ShippingAddress, req.BillingAddress} {
+		if address == nil {
+			continue
+		}
+		address.Normalize()
+		if err := address.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	input := &workflow.OrderProcessingInput{
-		CustomerID: req.CustomerID,
-		Items:      req.Items,
+		CustomerID:      req.CustomerID,
+		Items:           req.Items,
+		CouponCode:      req.CouponCode,
+		TaxJurisdiction: req.TaxJurisdiction,
+		ShippingAddress: req.ShippingAddress,
+		BillingAddress:  req.BillingAddress,
+		ShippingMethod:  req.ShippingMethod,
 	}
 
 	workflowOptions := client.StartWorkflowOptions{
-		ID:        "order-processing-" + req.CustomerID + "-" + strconv.FormatInt(time.Now().Unix(), 10),
-		TaskQueue: workflow.OrderProcessingTaskQueue,
+		ID:                       "order-processing-" + req.CustomerID + "-" + strconv.FormatInt(time.Now().Unix(), 10),
+		TaskQueue:                workflow.OrderProcessingTaskQueue,
+		WorkflowExecutionTimeout: usecaseWorkflow.OrderExecutionTimeout(),
 	}
 
 	workflowRun, err := h.temporalClient.ExecuteWorkflow(r.Context(), workflowOptions, workflow.OrderProcessingWorkflow, input)
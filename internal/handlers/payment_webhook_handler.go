@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"orderflow/internal/domain/payment"
+	"orderflow/internal/usecase/paymentevents"
+	"orderflow/pkg/logger"
+)
+
+// Callback провайдера больше этого размера отклоняется без разбора
+const maxPaymentWebhookBody = 1 << 20
+
+type PaymentWebhookHandler struct {
+	processor *paymentevents.Processor
+}
+
+func NewPaymentWebhookHandler(processor *paymentevents.Processor) *PaymentWebhookHandler {
+	return &PaymentWebhookHandler{processor: processor}
+}
+
+func (h *PaymentWebhookHandler) HandleProviderWebhook(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPaymentWebhookBody))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	result, err := h.processor.Process(r.Context(), provider, r.Header, body)
+	if err != nil {
+		var (
+			unknownProvider *payment.UnknownProviderError
+			signatureErr    *payment.SignatureError
+			validationErr   *payment.ValidationError
+			notFoundErr     *payment.NotFoundError
+		)
+
+		switch {
+		case errors.As(err, &unknownProvider), errors.As(err, &notFoundErr):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.As(err, &signatureErr):
+			logger.Warn("Rejected payment provider webhook", "provider", provider, "error", err)
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		case errors.As(err, &validationErr):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			logger.Error("Failed to process payment provider webhook", "error", err, "provider", provider)
+			http.Error(w, "Failed to process webhook", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := map[string]interface{}{
+		"payment_id":      result.Payment.ID,
+		"payment_status":  result.Payment.Status,
+		"previous_status": result.PreviousStatus,
+		"duplicate":       result.Duplicate,
+		"changed":         result.Changed,
+	}
+	writeJSON(w, http.StatusOK, response)
+}
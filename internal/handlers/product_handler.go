@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/usecase/catalogimport"
+	"orderflow/pkg/logger"
+)
+
+// Ограничение на тело CSV-импорта: MaxRows строк с запасом на длинные названия
+const maxCatalogImportBodyBytes = 4 << 20
+
+// ProductHandler — админский API каталога товаров.
+type ProductHandler struct {
+	catalogService inventory.CatalogService
+}
+
+func NewProductHandler(catalogService inventory.CatalogService) *ProductHandler {
+	return &ProductHandler{catalogService: catalogService}
+}
+
+func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
+
+	products, err := h.catalogService.ListProducts(r.Context(), includeDeleted)
+	if err != nil {
+		writeProductError(w, err, "Failed to list products")
+		return
+	}
+	if products == nil {
+		products = []*inventory.Product{}
+	}
+
+	writeJSON(w, http.StatusOK, products)
+}
+
+func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	product, err := h.catalogService.GetProduct(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeProductError(w, err, "Failed to get product")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
+
+func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var req inventory.CreateProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.catalogService.CreateProduct(r.Context(), &req)
+	if err != nil {
+		writeProductError(w, err, "Failed to create product")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, product)
+}
+
+func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	var req inventory.UpdateProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.catalogService.UpdateProduct(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		writeProductError(w, err, "Failed to update product")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
+
+func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	if err := h.catalogService.DeleteProduct(r.Context(), r.PathValue("id")); err != nil {
+		writeProductError(w, err, "Failed to delete product")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ProductHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	var req inventory.AdjustStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.catalogService.AdjustStock(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		writeProductError(w, err, "Failed to adjust stock")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
+
+// ListMovements возвращает журнал движений остатка товара, новые записи первыми.
+func (h *ProductHandler) ListMovements(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	movements, err := h.catalogService.GetMovements(r.Context(), r.PathValue("id"), limit)
+	if err != nil {
+		writeProductError(w, err, "Failed to list stock movements")
+		return
+	}
+	if movements == nil {
+		movements = []*inventory.StockMovement{}
+	}
+
+	writeJSON(w, http.StatusOK, movements)
+}
+
+// ListVariants возвращает варианты карточки товара в продаже.
+func (h *ProductHandler) ListVariants(w http.ResponseWriter, r *http.Request) {
+	variants, err := h.catalogService.ListVariants(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeProductError(w, err, "Failed to list variants")
+		return
+	}
+	if variants == nil {
+		variants = []*inventory.Product{}
+	}
+
+	writeJSON(w, http.StatusOK, variants)
+}
+
+// GetStockLevels возвращает остатки товара по складам.
+func (h *ProductHandler) GetStockLevels(w http.ResponseWriter, r *http.Request) {
+	levels, err := h.catalogService.GetStockLevels(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeProductError(w, err, "Failed to get stock levels")
+		return
+	}
+	if levels == nil {
+		levels = []*inventory.StockLevel{}
+	}
+
+	writeJSON(w, http.StatusOK, levels)
+}
+
+// ListLowStock — отчёт по товарам, чей свободный остаток опустился до точки заказа.
+func (h *ProductHandler) ListLowStock(w http.ResponseWriter, r *http.Request) {
+	report, err := h.catalogService.LowStockReport(r.Context())
+	if err != nil {
+		writeProductError(w, err, "Failed to build low stock report")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *ProductHandler) ListWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := h.catalogService.ListWarehouses(r.Context())
+	if err != nil {
+		writeProductError(w, err, "Failed to list warehouses")
+		return
+	}
+	if warehouses == nil {
+		warehouses = []*inventory.Warehouse{}
+	}
+
+	writeJSON(w, http.StatusOK, warehouses)
+}
+
+func (h *ProductHandler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req inventory.CreateWarehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	warehouse, err := h.catalogService.CreateWarehouse(r.Context(), &req)
+	if err != nil {
+		writeProductError(w, err, "Failed to create warehouse")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, warehouse)
+}
+
+func (h *ProductHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req inventory.UpdateWarehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	warehouse, err := h.catalogService.UpdateWarehouse(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		writeProductError(w, err, "Failed to update warehouse")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, warehouse)
+}
+
+// ImportCatalog принимает CSV-каталог в теле запроса и возвращает отчёт по строкам.
+func (h *ProductHandler) ImportCatalog(w http.ResponseWriter, r *http.Request) {
+	rows, err := catalogimport.ParseCSV(http.MaxBytesReader(w, r.Body, maxCatalogImportBodyBytes))
+	if err != nil {
+		logger.Error("Failed to parse catalog", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.catalogService.ImportCatalog(r.Context(), rows)
+	if err != nil {
+		writeProductError(w, err, "Failed to import catalog")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeProductError(w http.ResponseWriter, err error, message string) {
+	var (
+		validationErr *inventory.ValidationError
+		notFoundErr   *inventory.ProductNotFoundError
+		warehouseErr  *inventory.WarehouseNotFoundError
+		duplicateErr  *inventory.DuplicateSKUError
+		existsErr     *inventory.DuplicateWarehouseError
+		stockErr      *inventory.InsufficientStockError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &notFoundErr), errors.As(err, &warehouseErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &duplicateErr), errors.As(err, &existsErr), errors.As(err, &stockErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logger.Error(message, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
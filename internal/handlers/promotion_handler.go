@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"orderflow/internal/domain/promotion"
+	"orderflow/pkg/logger"
+)
+
+// PromotionHandler — админский API промоакций и купонов.
+type PromotionHandler struct {
+	promotionService promotion.Service
+}
+
+func NewPromotionHandler(promotionService promotion.Service) *PromotionHandler {
+	return &PromotionHandler{promotionService: promotionService}
+}
+
+func (h *PromotionHandler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.promotionService.ListPromotions(r.Context())
+	if err != nil {
+		writePromotionError(w, err, "Failed to list promotions")
+		return
+	}
+	if promotions == nil {
+		promotions = []*promotion.Promotion{}
+	}
+
+	writeJSON(w, http.StatusOK, promotions)
+}
+
+func (h *PromotionHandler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	p, err := h.promotionService.GetPromotion(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writePromotionError(w, err, "Failed to get promotion")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req promotion.CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	p, err := h.promotionService.CreatePromotion(r.Context(), &req)
+	if err != nil {
+		writePromotionError(w, err, "Failed to create promotion")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (h *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req promotion.UpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	p, err := h.promotionService.UpdatePromotion(r.Context(), r.PathValue("id"), &req)
+	if err != nil {
+		writePromotionError(w, err, "Failed to update promotion")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+// QuotePromotions показывает, какие скидки получит корзина, не резервируя купон.
+func (h *PromotionHandler) QuotePromotions(w http.ResponseWriter, r *http.Request) {
+	var req promotion.QuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	quote, err := h.promotionService.Quote(r.Context(), &req)
+	if err != nil {
+		writePromotionError(w, err, "Failed to quote promotions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, quote)
+}
+
+func writePromotionError(w http.ResponseWriter, err error, message string) {
+	var (
+		validationErr *promotion.ValidationError
+		notFoundErr   *promotion.NotFoundError
+		duplicateErr  *promotion.DuplicateCodeError
+		couponErr     *promotion.CouponError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &notFoundErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &duplicateErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.As(err, &couponErr):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		logger.Error(message, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/shipping"
+	"orderflow/pkg/logger"
+)
+
+// ShippingHandler показывает способы доставки корзины до создания заказа.
+type ShippingHandler struct {
+	shippingService shipping.Service
+}
+
+func NewShippingHandler(shippingService shipping.Service) *ShippingHandler {
+	return &ShippingHandler{shippingService: shippingService}
+}
+
+func (h *ShippingHandler) QuoteRates(w http.ResponseWriter, r *http.Request) {
+	var req shipping.QuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rates, err := h.shippingService.Quote(r.Context(), &req)
+	if err != nil {
+		writeShippingError(w, err, "Failed to quote shipping rates")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rates)
+}
+
+func writeShippingError(w http.ResponseWriter, err error, message string) {
+	var (
+		validationErr  *shipping.ValidationError
+		unavailableErr *shipping.UnavailableError
+		notFoundErr    *inventory.ProductNotFoundError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &notFoundErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &unavailableErr):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		logger.Error(message, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/client"
+
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/subscription"
+	"orderflow/internal/domain/workflow"
+	"orderflow/pkg/logger"
+)
+
+type SubscriptionHandler struct {
+	temporalClient client.Client
+}
+
+func NewSubscriptionHandler(temporalClient client.Client) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		temporalClient: temporalClient,
+	}
+}
+
+type CreateSubscriptionRequest struct {
+	CustomerID string       `json:"customer_id"`
+	Items      []order.Item `json:"items"`
+	Interval   string       `json:"interval"` // например "720h"
+	StartAt    *time.Time   `json:"start_at,omitempty"`
+}
+
+type CreateSubscriptionResponse struct {
+	SubscriptionID string `json:"subscription_id"`
+	WorkflowID     string `json:"workflow_id"`
+	Message        string `json:"message"`
+}
+
+func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CreateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	interval, err := time.ParseDuration(req.Interval)
+	if err != nil {
+		http.Error(w, "interval must be a duration, e.g. 720h", http.StatusBadRequest)
+		return
+	}
+
+	sub := subscription.Subscription{
+		ID:         uuid.New().String(),
+		CustomerID: req.CustomerID,
+		Items:      req.Items,
+		Interval:   interval,
+		Status:     subscription.StatusActive,
+	}
+	if req.StartAt != nil {
+		sub.NextRunAt = *req.StartAt
+	}
+
+	if err := sub.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        workflow.SubscriptionWorkflowIDPrefix + sub.ID,
+		TaskQueue: workflow.OrderProcessingTaskQueue,
+	}
+
+	workflowRun, err := h.temporalClient.ExecuteWorkflow(r.Context(), workflowOptions, workflow.CustomerSubscriptionWorkflow,
+		&workflow.CustomerSubscriptionInput{Subscription: sub})
+	if err != nil {
+		logger.Error("Failed to start subscription workflow", "error", err)
+		http.Error(w, "Failed to start subscription", http.StatusInternalServerError)
+		return
+	}
+
+	response := CreateSubscriptionResponse{
+		SubscriptionID: sub.ID,
+		WorkflowID:     workflowRun.GetID(),
+		Message:        "Subscription started successfully",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *SubscriptionHandler) PauseSubscription(w http.ResponseWriter, r *http.Request) {
+	h.signalSubscription(w, r, workflow.PauseSubscriptionSignal, "Subscription pause signal sent successfully")
+}
+
+func (h *SubscriptionHandler) ResumeSubscription(w http.ResponseWriter, r *http.Request) {
+	h.signalSubscription(w, r, workflow.ResumeSubscriptionSignal, "Subscription resume signal sent successfully")
+}
+
+func (h *SubscriptionHandler) SkipSubscriptionCycle(w http.ResponseWriter, r *http.Request) {
+	h.signalSubscription(w, r, workflow.SkipSubscriptionSignal, "Subscription skip signal sent successfully")
+}
+
+func (h *SubscriptionHandler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	h.signalSubscription(w, r, workflow.CancelSubscriptionSignal, "Subscription cancellation signal sent successfully")
+}
+
+func (h *SubscriptionHandler) GetSubscriptionState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	subscriptionID := r.URL.Query().Get("subscription_id")
+	if subscriptionID == "" {
+		http.Error(w, "subscription_id is required", http.StatusBadRequest)
+		return
+	}
+
+	workflowID := workflow.SubscriptionWorkflowIDPrefix + subscriptionID
+
+	encoded, err := h.temporalClient.QueryWorkflow(r.Context(), workflowID, "", workflow.SubscriptionStateQuery)
+	if err != nil {
+		logger.Error("Failed to query subscription state", "error", err, "workflow_id", workflowID)
+		http.Error(w, "Failed to get subscription state", http.StatusInternalServerError)
+		return
+	}
+
+	var state workflow.CustomerSubscriptionInput
+	if err := encoded.Get(&state); err != nil {
+		logger.Error("Failed to decode subscription state", "error", err, "workflow_id", workflowID)
+		http.Error(w, "Failed to get subscription state", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+func (h *SubscriptionHandler) signalSubscription(w http.ResponseWriter, r *http.Request, signalName, message string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	subscriptionID := r.URL.Query().Get("subscription_id")
+	if subscriptionID == "" {
+		http.Error(w, "subscription_id is required", http.StatusBadRequest)
+		return
+	}
+
+	workflowID := workflow.SubscriptionWorkflowIDPrefix + subscriptionID
+	signal := &workflow.SubscriptionSignalInput{Reason: r.URL.Query().Get("reason")}
+
+	err := h.temporalClient.SignalWorkflow(r.Context(), workflowID, "", signalName, signal)
+	if err != nil {
+		logger.Error("Failed to send subscription signal", "error", err, "workflow_id", workflowID, "signal", signalName)
+		http.Error(w, "Failed to signal subscription", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{
+		"subscription_id": subscriptionID,
+		"workflow_id":     workflowID,
+		"message":         message,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
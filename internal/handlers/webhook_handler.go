@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.temporal.io/sdk/client"
+
+	"orderflow/internal/domain/webhook"
+	"orderflow/internal/usecase/webhooks"
+	"orderflow/pkg/logger"
+)
+
+type WebhookHandler struct {
+	webhookService webhook.Service
+	dispatcher     *webhooks.Dispatcher
+}
+
+func NewWebhookHandler(temporalClient client.Client, webhookService webhook.Service) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		dispatcher:     webhooks.NewDispatcher(temporalClient, webhookService),
+	}
+}
+
+// CreateWebhookResponse — единственный ответ, в котором возвращается секрет подписки.
+type CreateWebhookResponse struct {
+	*webhook.Subscription
+	Secret string `json:"secret"`
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhook.CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode request", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := h.webhookService.CreateSubscription(r.Context(), &req)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to create webhook")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, CreateWebhookResponse{Subscription: subscription, Secret: subscription.Secret})
+}
+
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.webhookService.ListSubscriptions(r.Context())
+	if err != nil {
+		writeWebhookError(w, err, "Failed to list webhooks")
+		return
+	}
+	if subscriptions == nil {
+		subscriptions = []*webhook.Subscription{}
+	}
+
+	writeJSON(w, http.StatusOK, subscriptions)
+}
+
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, err := h.webhookService.GetSubscription(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeWebhookError(w, err, "Failed to get webhook")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subscription)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.webhookService.DeleteSubscription(r.Context(), r.PathValue("id")); err != nil {
+		writeWebhookError(w, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) EnableWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, err := h.webhookService.EnableSubscription(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeWebhookError(w, err, "Failed to enable webhook")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subscription)
+}
+
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeWebhookError(w, err, "Failed to list webhook deliveries")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*webhook.Delivery{}
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.webhookService.GetDelivery(r.Context(), r.PathValue("id"), r.PathValue("delivery"))
+	if err != nil {
+		writeWebhookError(w, err, "Failed to get webhook delivery")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+func (h *WebhookHandler) RedeliverDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.dispatcher.Redeliver(r.Context(), r.PathValue("id"), r.PathValue("delivery"))
+	if err != nil {
+		writeWebhookError(w, err, "Failed to redeliver webhook")
+		return
+	}
+
+	response := map[string]string{
+		"delivery_id": delivery.ID,
+		"workflow_id": webhooks.WorkflowID(delivery.ID),
+		"message":     "Webhook redelivery started",
+	}
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+func writeWebhookError(w http.ResponseWriter, err error, message string) {
+	var (
+		validationErr *webhook.ValidationError
+		notFoundErr   *webhook.NotFoundError
+		deliveryErr   *webhook.DeliveryNotFoundError
+		disabledErr   *webhook.DisabledError
+		inProgressErr *webhook.RedeliveryInProgressError
+	)
+
+	switch {
+	case errors.As(err, &validationErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &notFoundErr), errors.As(err, &deliveryErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &disabledErr), errors.As(err, &inProgressErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logger.Error(message, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
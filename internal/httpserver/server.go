@@ -8,26 +8,120 @@ import (
 
 	"go.temporal.io/sdk/client"
 
+	"orderflow/internal/domain/customer"
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/notification"
+	"orderflow/internal/domain/orderevent"
+	"orderflow/internal/domain/promotion"
+	"orderflow/internal/domain/shipping"
+	"orderflow/internal/domain/webhook"
 	"orderflow/internal/handlers"
+	"orderflow/internal/usecase/paymentevents"
 	"orderflow/pkg/logger"
 )
 
 type Server struct {
-	server          *http.Server
-	temporalClient  client.Client
-	orderHandler    *handlers.OrderHandler
+	server              *http.Server
+	temporalClient      client.Client
+	orderHandler        *handlers.OrderHandler
+	subscriptionHandler *handlers.SubscriptionHandler
+	batchImportHandler  *handlers.BatchImportHandler
+	orderEventsHandler  *handlers.OrderEventsHandler
+	webhookHandler      *handlers.WebhookHandler
+	paymentWebhooks     *handlers.PaymentWebhookHandler
+	customerHandler     *handlers.CustomerHandler
+	notificationHandler *handlers.NotificationHandler
+	productHandler      *handlers.ProductHandler
+	promotionHandler    *handlers.PromotionHandler
+	shippingHandler     *handlers.ShippingHandler
 }
 
-func NewServer(port int, temporalClient client.Client) *Server {
-	orderHandler := handlers.NewOrderHandler(temporalClient)
-	
+// Deps — клиент Temporal и сервисы, которые вызывают HTTP-обработчики.
+type Deps struct {
+	TemporalClient client.Client
+	OrderEvents    orderevent.Service
+	Webhooks       webhook.Service
+	PaymentEvents  *paymentevents.Processor
+	Customers      customer.Service
+	Notifications  notification.Service
+	Catalog        inventory.CatalogService
+	Promotions     promotion.Service
+	Shipping       shipping.Service
+}
+
+func NewServer(port int, deps Deps) *Server {
+	orderHandler := handlers.NewOrderHandler(deps.TemporalClient)
+	subscriptionHandler := handlers.NewSubscriptionHandler(deps.TemporalClient)
+	batchImportHandler := handlers.NewBatchImportHandler(deps.TemporalClient)
+	orderEventsHandler := handlers.NewOrderEventsHandler(deps.TemporalClient, deps.OrderEvents)
+	webhookHandler := handlers.NewWebhookHandler(deps.TemporalClient, deps.Webhooks)
+	paymentWebhooks := handlers.NewPaymentWebhookHandler(deps.PaymentEvents)
+	customerHandler := handlers.NewCustomerHandler(deps.Customers)
+	notificationHandler := handlers.NewNotificationHandler(deps.Notifications)
+	productHandler := handlers.NewProductHandler(deps.Catalog)
+	promotionHandler := handlers.NewPromotionHandler(deps.Promotions)
+	shippingHandler := handlers.NewShippingHandler(deps.Shipping)
+
 	mux := http.NewServeMux()
-	
+
 	mux.HandleFunc("/api/orders", orderHandler.CreateOrder)
 	mux.HandleFunc("/api/orders/status", orderHandler.GetOrderStatus)
 	mux.HandleFunc("/api/orders/cancel", orderHandler.CancelOrder)
 	mux.HandleFunc("/api/orders/state", orderHandler.GetWorkflowState)
-	
+	mux.HandleFunc("GET /api/orders/{id}/events", orderEventsHandler.StreamOrderEvents)
+	mux.HandleFunc("/api/orders/batch", batchImportHandler.ImportOrders)
+	mux.HandleFunc("/api/orders/batch/status", batchImportHandler.GetBatchProgress)
+	mux.HandleFunc("POST /api/shipping/rates", shippingHandler.QuoteRates)
+
+	mux.HandleFunc("/api/subscriptions", subscriptionHandler.CreateSubscription)
+	mux.HandleFunc("/api/subscriptions/state", subscriptionHandler.GetSubscriptionState)
+	mux.HandleFunc("/api/subscriptions/pause", subscriptionHandler.PauseSubscription)
+	mux.HandleFunc("/api/subscriptions/resume", subscriptionHandler.ResumeSubscription)
+	mux.HandleFunc("/api/subscriptions/skip", subscriptionHandler.SkipSubscriptionCycle)
+	mux.HandleFunc("/api/subscriptions/cancel", subscriptionHandler.CancelSubscription)
+
+	mux.HandleFunc("POST /api/payments/webhooks/{provider}", paymentWebhooks.HandleProviderWebhook)
+
+	mux.HandleFunc("GET /api/customers/{id}", customerHandler.GetCustomer)
+	mux.HandleFunc("PUT /api/customers/{id}", customerHandler.SaveCustomer)
+	mux.HandleFunc("DELETE /api/customers/{id}", customerHandler.DeleteCustomer)
+	mux.HandleFunc("PUT /api/customers/{id}/consents/{type}", customerHandler.SetConsent)
+
+	mux.HandleFunc("GET /api/notifications/dead", notificationHandler.ListDeadNotifications)
+	mux.HandleFunc("GET /api/notifications/{id}", notificationHandler.GetNotification)
+	mux.HandleFunc("POST /api/notifications/{id}/requeue", notificationHandler.RequeueNotification)
+	mux.HandleFunc("POST /api/notifications/{id}/discard", notificationHandler.DiscardNotification)
+
+	mux.HandleFunc("GET /api/admin/products", productHandler.ListProducts)
+	mux.HandleFunc("POST /api/admin/products", productHandler.CreateProduct)
+	mux.HandleFunc("POST /api/admin/products/import", productHandler.ImportCatalog)
+	mux.HandleFunc("GET /api/admin/products/low-stock", productHandler.ListLowStock)
+	mux.HandleFunc("GET /api/admin/products/{id}", productHandler.GetProduct)
+	mux.HandleFunc("PATCH /api/admin/products/{id}", productHandler.UpdateProduct)
+	mux.HandleFunc("DELETE /api/admin/products/{id}", productHandler.DeleteProduct)
+	mux.HandleFunc("POST /api/admin/products/{id}/stock-adjustments", productHandler.AdjustStock)
+	mux.HandleFunc("GET /api/admin/products/{id}/movements", productHandler.ListMovements)
+	mux.HandleFunc("GET /api/admin/products/{id}/stock", productHandler.GetStockLevels)
+	mux.HandleFunc("GET /api/admin/products/{id}/variants", productHandler.ListVariants)
+	mux.HandleFunc("GET /api/admin/warehouses", productHandler.ListWarehouses)
+	mux.HandleFunc("POST /api/admin/warehouses", productHandler.CreateWarehouse)
+	mux.HandleFunc("PATCH /api/admin/warehouses/{id}", productHandler.UpdateWarehouse)
+
+	mux.HandleFunc("GET /api/admin/promotions", promotionHandler.ListPromotions)
+	mux.HandleFunc("POST /api/admin/promotions", promotionHandler.CreatePromotion)
+	mux.HandleFunc("POST /api/admin/promotions/quote", promotionHandler.QuotePromotions)
+	mux.HandleFunc("GET /api/admin/promotions/{id}", promotionHandler.GetPromotion)
+	mux.HandleFunc("PATCH /api/admin/promotions/{id}", promotionHandler.UpdatePromotion)
+
+	mux.HandleFunc("POST /api/webhooks", webhookHandler.CreateWebhook)
+	mux.HandleFunc("GET /api/webhooks", webhookHandler.ListWebhooks)
+	mux.HandleFunc("GET /api/webhooks/{id}", webhookHandler.GetWebhook)
+	mux.HandleFunc("DELETE /api/webhooks/{id}", webhookHandler.DeleteWebhook)
+	mux.HandleFunc("POST /api/webhooks/{id}/enable", webhookHandler.EnableWebhook)
+	mux.HandleFunc("GET /api/webhooks/{id}/deliveries", webhookHandler.ListDeliveries)
+	mux.HandleFunc("GET /api/webhooks/{id}/deliveries/{delivery}", webhookHandler.GetDelivery)
+	mux.HandleFunc("POST /api/webhooks/{id}/deliveries/{delivery}/redeliver", webhookHandler.RedeliverDelivery)
+
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -43,9 +137,19 @@ func NewServer(port int, temporalClient client.Client) *Server {
 	}
 
 	return &Server{
-		server:         server,
-		temporalClient: temporalClient,
-		orderHandler:   orderHandler,
+		server:              server,
+		temporalClient:      deps.TemporalClient,
+		orderHandler:        orderHandler,
+		subscriptionHandler: subscriptionHandler,
+		batchImportHandler:  batchImportHandler,
+		orderEventsHandler:  orderEventsHandler,
+		webhookHandler:      webhookHandler,
+		paymentWebhooks:     paymentWebhooks,
+		customerHandler:     customerHandler,
+		notificationHandler: notificationHandler,
+		productHandler:      productHandler,
+		promotionHandler:    promotionHandler,
+		shippingHandler:     shippingHandler,
 	}
 }
 
@@ -0,0 +1,92 @@
+package activity
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/tax"
+	wf "orderflow/internal/domain/workflow"
+)
+
+// CalculateTaxActivity считает налог заказа после резервирования и записывает его в заказ.
+// Источник ставок — tax.Calculator: таблица из конфигурации или внешний сервис.
+type CalculateTaxActivity struct {
+	orderService     order.Service
+	inventoryService inventory.Service
+	calculator       tax.Calculator
+}
+
+func NewCalculateTaxActivity(orderService order.Service, inventoryService inventory.Service, calculator tax.Calculator) *CalculateTaxActivity {
+	return &CalculateTaxActivity{
+		orderService:     orderService,
+		inventoryService: inventoryService,
+		calculator:       calculator,
+	}
+}
+
+func (a *CalculateTaxActivity) Execute(ctx context.Context, input *wf.CalculateTaxActivityInput) (*wf.CalculateTaxActivityOutput, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Starting CalculateTaxActivity", "order_id", input.OrderID)
+
+	if err := input.Validate(); err != nil {
+		logger.Error("Validation failed", "error", err)
+		return nil, activityError(wf.CalculateTaxActivity, wf.StepCalculateTax, wf.ErrorCodeValidation, err)
+	}
+
+	o, err := a.orderService.GetByID(ctx, input.OrderID)
+	if err != nil {
+		return nil, activityError(wf.CalculateTaxActivity, wf.StepCalculateTax, wf.ErrorCodeInternalError, err)
+	}
+
+	req := &tax.Request{OrderID: o.ID, Jurisdiction: o.TaxJurisdiction}
+	amounts := o.TaxableAmounts()
+	for i, item := range o.Items {
+		product, err := a.inventoryService.GetProduct(ctx, item.ProductID)
+		if err != nil {
+			return nil, activityError(wf.CalculateTaxActivity, wf.StepCalculateTax, wf.ErrorCodeInternalError, err)
+		}
+		req.Lines = append(req.Lines, tax.Line{ProductID: item.ProductID, TaxClass: product.TaxClass, Amount: amounts[i]})
+	}
+
+	// Резерв освобождает и заказ завершает workflow, когда activity окончательно не удалась:
+	// здесь не известно, последняя ли это попытка
+	result, err := a.calculator.Calculate(ctx, req)
+	if err != nil {
+		logger.Error("Failed to calculate tax", "error", err)
+		return nil, activityError(wf.CalculateTaxActivity, wf.StepCalculateTax, wf.ErrorCodeInternalError, err)
+	}
+
+	lines := make([]order.TaxLine, len(result.Lines))
+	for i, line := range result.Lines {
+		lines[i] = order.TaxLine{
+			ProductID:     line.ProductID,
+			TaxClass:      line.TaxClass,
+			Jurisdiction:  line.Jurisdiction,
+			Name:          line.Name,
+			Rate:          line.Rate,
+			TaxableAmount: line.TaxableAmount,
+			Amount:        line.Amount,
+		}
+	}
+
+	o, err = a.orderService.ApplyTax(ctx, input.OrderID, lines)
+	if err != nil {
+		logger.Error("Failed to save order tax", "error", err)
+		return nil, activityError(wf.CalculateTaxActivity, wf.StepCalculateTax, wf.ErrorCodeInternalError, err)
+	}
+
+	logger.Info("Tax calculated", "order_id", o.ID, "jurisdiction", result.Jurisdiction,
+		"tax_amount", o.TaxAmount, "total_amount", o.TotalAmount)
+
+	return &wf.CalculateTaxActivityOutput{
+		TaxAmount:   o.TaxAmount,
+		TotalAmount: o.TotalAmount,
+	}, nil
+}
+
+func (a *CalculateTaxActivity) GetActivityName() (string, error) {
+	return wf.CalculateTaxActivity, nil
+}
@@ -2,6 +2,7 @@ package activity
 
 import (
 	"context"
+	"errors"
 
 	"go.temporal.io/sdk/activity"
 
@@ -43,56 +44,25 @@ func (a *CancelOrderActivity) Execute(ctx context.Context, input *CancelOrderAct
 		"reason", input.Reason)
 
 	if input.OrderID == "" {
-		return wf.NewActivityError(
-			wf.CancelOrderActivity,
-			wf.StepCancelled,
-			wf.ErrorCodeValidation,
-			"order_id is required",
-			false,
-		)
+		return activityFailure(wf.CancelOrderActivity, wf.StepCancelled, wf.ErrorCodeValidation, "order_id is required", false)
 	}
 
 	orderEntity, err := a.orderService.GetByID(ctx, input.OrderID)
 	if err != nil {
 		logger.Error("Failed to get order", "error", err)
-		
-		errorCode := wf.ErrorCodeInternalError
-		if _, ok := err.(*order.NotFoundError); ok {
-			errorCode = wf.ErrorCodeOrderNotFound
-		}
-		
-		return wf.NewActivityError(
-			wf.CancelOrderActivity,
-			wf.StepCancelled,
-			errorCode,
-			err.Error(),
-			false,
-		)
+		return activityError(wf.CancelOrderActivity, wf.StepCancelled, wf.ErrorCodeInternalError, err)
 	}
 
 	if !orderEntity.CanBeCancelled() {
 		logger.Warn("Cannot cancel order", "order_status", orderEntity.Status)
-		return wf.NewActivityError(
-			wf.CancelOrderActivity,
-			wf.StepCancelled,
-			wf.ErrorCodeOrderCancelled,
-			"Order cannot be cancelled in current status: "+string(orderEntity.Status),
-			false,
-		)
+		return activityError(wf.CancelOrderActivity, wf.StepCancelled, wf.ErrorCodeInvalidOrderStatus, order.NewCannotCancelError(orderEntity.Status))
 	}
 
-	
-	if orderEntity.PaymentID != "" {
-		logger.Info("Refunding payment", "payment_id", orderEntity.PaymentID)
-		
-		refundReq := &payment.RefundRequest{
-			PaymentID: orderEntity.PaymentID,
-			Reason:    "Order cancelled: " + input.Reason,
-		}
-		
-		if err := a.paymentService.RefundPayment(ctx, refundReq); err != nil {
-			logger.Error("Failed to refund payment", "error", err, "payment_id", orderEntity.PaymentID)
-		}
+	// payment_id в заказе есть не всегда: если отмена пришла во время списания,
+	// платёж ищется по заказу. Неудавшийся возврат ретраится до отмены заказа
+	if err := refundOrderPayment(ctx, a.paymentService, input.OrderID, orderEntity.PaymentID, "Order cancelled: "+input.Reason); err != nil {
+		logger.Error("Failed to refund payment", "error", err, "order_id", input.OrderID)
+		return activityError(wf.CancelOrderActivity, wf.StepCancelled, wf.ErrorCodeInternalError, err)
 	}
 
 	logger.Info("Releasing inventory reservation", "order_id", input.OrderID)
@@ -105,19 +75,48 @@ func (a *CancelOrderActivity) Execute(ctx context.Context, input *CancelOrderAct
 	
 	if err := a.orderService.Cancel(ctx, input.OrderID); err != nil {
 		logger.Error("Failed to cancel order", "error", err)
-		return wf.NewActivityError(
-			wf.CancelOrderActivity,
-			wf.StepCancelled,
-			wf.ErrorCodeInternalError,
-			"Failed to update order status: "+err.Error(),
-			true,
-		)
+		return activityError(wf.CancelOrderActivity, wf.StepCancelled, wf.ErrorCodeInternalError, err)
 	}
 
 	logger.Info("Order cancelled successfully", "order_id", input.OrderID)
 	return nil
 }
 
+// refundOrderPayment возвращает деньги по заказу, если платёж по нему завершён.
+// Без paymentID платёж ищется по orderID; отсутствие платежа — не ошибка.
+func refundOrderPayment(ctx context.Context, paymentService payment.Service, orderID, paymentID, reason string) error {
+	logger := activity.GetLogger(ctx)
+
+	var (
+		paymentEntity *payment.Payment
+		err           error
+	)
+	if paymentID != "" {
+		paymentEntity, err = paymentService.GetPayment(ctx, paymentID)
+	} else {
+		paymentEntity, err = paymentService.GetPaymentByOrderID(ctx, orderID)
+	}
+
+	var notFound *payment.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !paymentEntity.CanBeRefunded() {
+		logger.Info("Payment does not need a refund", "payment_id", paymentEntity.ID, "payment_status", paymentEntity.Status)
+		return nil
+	}
+
+	logger.Info("Refunding payment", "payment_id", paymentEntity.ID)
+	return paymentService.RefundPayment(ctx, &payment.RefundRequest{
+		PaymentID: paymentEntity.ID,
+		Reason:    reason,
+	})
+}
+
 func (a *CancelOrderActivity) GetActivityName() (string, error) {
 	return wf.CancelOrderActivity, nil
 }
\ No newline at end of file
@@ -0,0 +1,108 @@
+package activity
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.temporal.io/sdk/testsuite"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/payment"
+)
+
+// cancellableOrders отдаёт заказ в заданном статусе и запоминает отменённые.
+type cancellableOrders struct {
+	order.Service
+	order     *order.Order
+	cancelled []string
+}
+
+func (s *cancellableOrders) GetByID(ctx context.Context, id string) (*order.Order, error) {
+	copied := *s.order
+	return &copied, nil
+}
+
+func (s *cancellableOrders) Cancel(ctx context.Context, id string) error {
+	s.cancelled = append(s.cancelled, id)
+	return nil
+}
+
+// orderPayments хранит платежи по заказам и запоминает возвраты.
+type orderPayments struct {
+	payment.Service
+	byOrder  map[string]*payment.Payment
+	refunded []string
+}
+
+func (s *orderPayments) GetPayment(ctx context.Context, paymentID string) (*payment.Payment, error) {
+	for _, p := range s.byOrder {
+		if p.ID == paymentID {
+			return p, nil
+		}
+	}
+	return nil, payment.NewNotFoundError(paymentID)
+}
+
+func (s *orderPayments) GetPaymentByOrderID(ctx context.Context, orderID string) (*payment.Payment, error) {
+	p, ok := s.byOrder[orderID]
+	if !ok {
+		return nil, payment.NewNotFoundError("for order " + orderID)
+	}
+	return p, nil
+}
+
+func (s *orderPayments) RefundPayment(ctx context.Context, req *payment.RefundRequest) error {
+	s.refunded = append(s.refunded, req.PaymentID)
+	return nil
+}
+
+type releasingInventory struct {
+	inventory.Service
+}
+
+func (releasingInventory) ReleaseReservation(ctx context.Context, orderID string) error {
+	return nil
+}
+
+func TestCancelOrderActivityRefundsPaymentByOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		paymentID    string
+		payment      *payment.Payment
+		wantRefunded []string
+	}{
+		{"payment id not written to order", "", &payment.Payment{ID: "pay-1", Status: payment.StatusCompleted}, []string{"pay-1"}},
+		{"payment id on order", "pay-1", &payment.Payment{ID: "pay-1", Status: payment.StatusCompleted}, []string{"pay-1"}},
+		{"failed payment", "", &payment.Payment{ID: "pay-1", Status: payment.StatusFailed}, nil},
+		{"already refunded", "", &payment.Payment{ID: "pay-1", Status: payment.StatusRefunded}, nil},
+		{"no payment yet", "", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &cancellableOrders{order: &order.Order{ID: "order-1", Status: order.StatusPayment, PaymentID: tt.paymentID}}
+			payments := &orderPayments{byOrder: map[string]*payment.Payment{}}
+			if tt.payment != nil {
+				payments.byOrder["order-1"] = tt.payment
+			}
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestActivityEnvironment()
+			act := NewCancelOrderActivity(orders, payments, releasingInventory{})
+			env.RegisterActivity(act.Execute)
+
+			if _, err := env.ExecuteActivity(act.Execute, &CancelOrderActivityInput{OrderID: "order-1", Reason: "reservation expired"}); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(payments.refunded, tt.wantRefunded) {
+				t.Errorf("refunded = %v, want %v", payments.refunded, tt.wantRefunded)
+			}
+			if len(orders.cancelled) != 1 {
+				t.Errorf("cancelled = %v, want order-1 cancelled once", orders.cancelled)
+			}
+		})
+	}
+}
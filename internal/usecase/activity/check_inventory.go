@@ -2,7 +2,6 @@ package activity
 
 import (
 	"context"
-	"time"
 
 	"go.temporal.io/sdk/activity"
 
@@ -29,25 +28,13 @@ func (a *CheckInventoryActivity) Execute(ctx context.Context, input *wf.CheckInv
 
 	if err := input.Validate(); err != nil {
 		logger.Error("Validation failed", "error", err)
-		return nil, wf.NewActivityError(
-			wf.CheckInventoryActivity,
-			wf.StepCheckInventory,
-			wf.ErrorCodeValidation,
-			err.Error(),
-			false,
-		)
+		return nil, activityError(wf.CheckInventoryActivity, wf.StepCheckInventory, wf.ErrorCodeValidation, err)
 	}
 
 	if err := a.orderService.UpdateStatus(ctx, input.OrderID, order.StatusValidating); err != nil {
 		logger.Error("Failed to update order status", "error", err)
 	}
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-time.After(wf.InventoryCheckDuration):
-	}
-
 	checkItems := make([]inventory.CheckItem, len(input.Items))
 	for i, item := range input.Items {
 		checkItems[i] = inventory.CheckItem{
@@ -56,6 +43,8 @@ func (a *CheckInventoryActivity) Execute(ctx context.Context, input *wf.CheckInv
 		}
 	}
 
+	// Адрес доставки заказа хранится без координат, поэтому Destination не задаётся
+	// и склады ранжируются по priority (см. inventory.AllocationRequest)
 	checkReq := &inventory.CheckRequest{
 		OrderID: input.OrderID,
 		Items:   checkItems,
@@ -64,31 +53,12 @@ func (a *CheckInventoryActivity) Execute(ctx context.Context, input *wf.CheckInv
 	checkResp, err := a.inventoryService.CheckAvailability(ctx, checkReq)
 	if err != nil {
 		logger.Error("Failed to check inventory", "error", err)
-		
-		a.orderService.SetFailure(ctx, input.OrderID, "Failed to check inventory: "+err.Error())
-		
-		retryable := true
-		errorCode := wf.ErrorCodeInternalError
-		
-		switch err.(type) {
-		case *inventory.ProductNotFoundError:
-			retryable = false
-		}
-		
-		return nil, wf.NewActivityError(
-			wf.CheckInventoryActivity,
-			wf.StepCheckInventory,
-			errorCode,
-			err.Error(),
-			retryable,
-		)
+		return nil, activityError(wf.CheckInventoryActivity, wf.StepCheckInventory, wf.ErrorCodeInternalError, err)
 	}
 
 	if !checkResp.Available {
+		// Заказ переводит в failed workflow через FailOrderActivity
 		logger.Warn("Inventory not available", "unavailable_items", checkResp.UnavailableItems)
-		
-		a.orderService.SetFailure(ctx, input.OrderID, "Some items are not available")
-		
 		return &wf.CheckInventoryActivityOutput{
 			Available:        false,
 			UnavailableItems: checkResp.UnavailableItems,
@@ -103,33 +73,59 @@ func (a *CheckInventoryActivity) Execute(ctx context.Context, input *wf.CheckInv
 		}
 	}
 
+	// Распределение должно совпасть с проверкой выше, поэтому Destination тоже не задаётся
 	reserveReq := &inventory.ReserveRequest{
 		OrderID: input.OrderID,
 		Items:   reserveItems,
 	}
 
-	if err := a.inventoryService.ReserveItems(ctx, reserveReq); err != nil {
+	allocation, err := a.inventoryService.ReserveItems(ctx, reserveReq)
+	if err != nil {
 		logger.Error("Failed to reserve items", "error", err)
-		
-		a.orderService.SetFailure(ctx, input.OrderID, "Failed to reserve items: "+err.Error())
-		
-		return nil, wf.NewActivityError(
-			wf.CheckInventoryActivity,
-			wf.StepCheckInventory,
-			wf.ErrorCodeInventoryUnavailable,
-			err.Error(),
-			true,
-		)
+		return nil, activityError(wf.CheckInventoryActivity, wf.StepCheckInventory, wf.ErrorCodeInventoryUnavailable, err)
+	}
+
+	lines := make([]order.AllocationLine, len(allocation.Lines))
+	for i, line := range allocation.Lines {
+		lines[i] = order.AllocationLine{
+			ProductID:   line.ProductID,
+			WarehouseID: line.WarehouseID,
+			Quantity:    line.Quantity,
+		}
+	}
+	// Резерв уже создан: ошибка записи распределения не должна приводить к повтору активности
+	if err := a.orderService.SetAllocation(ctx, input.OrderID, lines); err != nil {
+		logger.Error("Failed to save order allocation", "error", err)
+	}
+	if len(allocation.Bundles) > 0 {
+		if err := a.orderService.SetItemComponents(ctx, input.OrderID, itemComponents(allocation.Bundles)); err != nil {
+			logger.Error("Failed to save bundle composition", "error", err)
+		}
 	}
 
-	logger.Info("Inventory checked and items reserved successfully", "order_id", input.OrderID)
+	logger.Info("Inventory checked and items reserved successfully", "order_id", input.OrderID, "warehouses", allocation.Warehouses())
 
 	return &wf.CheckInventoryActivityOutput{
 		Available:        true,
 		UnavailableItems: nil,
+		Allocation:       lines,
 	}, nil
 }
 
+func itemComponents(bundles []inventory.BundleComposition) map[string][]order.ItemComponent {
+	components := make(map[string][]order.ItemComponent, len(bundles))
+	for _, bundle := range bundles {
+		for _, component := range bundle.Components {
+			components[bundle.BundleID] = append(components[bundle.BundleID], order.ItemComponent{
+				ProductID: component.ProductID,
+				SKU:       component.SKU,
+				Quantity:  component.Quantity,
+			})
+		}
+	}
+	return components
+}
+
 func (a *CheckInventoryActivity) GetActivityName() (string, error) {
 	return wf.CheckInventoryActivity, nil
 }
\ No newline at end of file
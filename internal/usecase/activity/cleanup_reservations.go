@@ -0,0 +1,69 @@
+package activity
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/order"
+	wf "orderflow/internal/domain/workflow"
+)
+
+type CleanupReservationsActivity struct {
+	inventoryService inventory.Service
+	orderService     order.Service
+}
+
+func NewCleanupReservationsActivity(inventoryService inventory.Service, orderService order.Service) *CleanupReservationsActivity {
+	return &CleanupReservationsActivity{
+		inventoryService: inventoryService,
+		orderService:     orderService,
+	}
+}
+
+func (a *CleanupReservationsActivity) Execute(ctx context.Context, input *wf.CleanupReservationsActivityInput) (*wf.CleanupReservationsActivityOutput, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Starting CleanupReservationsActivity", "batch_size", input.BatchSize)
+
+	released, err := a.inventoryService.CleanupExpiredReservations(ctx, input.BatchSize)
+	if err != nil {
+		logger.Error("Failed to cleanup expired reservations", "error", err)
+		return nil, activityError(wf.CleanupReservationsActivity, wf.StepCheckInventory, wf.ErrorCodeInternalError, err)
+	}
+
+	output := &wf.CleanupReservationsActivityOutput{
+		Released: make([]wf.ExpiredReservation, 0, len(released)),
+	}
+
+	workflowIDs := make(map[string]string)
+	for _, reservation := range released {
+		workflowID, ok := workflowIDs[reservation.OrderID]
+		if !ok {
+			orderEntity, err := a.orderService.GetByID(ctx, reservation.OrderID)
+			if err != nil {
+				logger.Warn("Failed to resolve workflow for expired reservation",
+					"error", err,
+					"order_id", reservation.OrderID)
+			} else {
+				workflowID = orderEntity.WorkflowID
+			}
+			workflowIDs[reservation.OrderID] = workflowID
+		}
+
+		output.Released = append(output.Released, wf.ExpiredReservation{
+			ReservationID: reservation.ID,
+			OrderID:       reservation.OrderID,
+			WorkflowID:    workflowID,
+			ProductID:     reservation.ProductID,
+			Quantity:      reservation.Quantity,
+		})
+	}
+
+	logger.Info("Expired reservations released", "count", len(output.Released))
+	return output, nil
+}
+
+func (a *CleanupReservationsActivity) GetActivityName() (string, error) {
+	return wf.CleanupReservationsActivity, nil
+}
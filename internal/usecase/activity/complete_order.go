@@ -0,0 +1,43 @@
+package activity
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"orderflow/internal/domain/order"
+	wf "orderflow/internal/domain/workflow"
+)
+
+// CompleteOrderActivity записывает в заказ платёж и статус completed. Смена статуса идёт
+// через outbox, поэтому из неё получаются событие order.completed и вебхуки мерчантов.
+// Повтор для уже завершённого заказа статус не меняет и второго события не создаёт.
+type CompleteOrderActivity struct {
+	orderService order.Service
+}
+
+func NewCompleteOrderActivity(orderService order.Service) *CompleteOrderActivity {
+	return &CompleteOrderActivity{orderService: orderService}
+}
+
+func (a *CompleteOrderActivity) Execute(ctx context.Context, input *wf.CompleteOrderActivityInput) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Starting CompleteOrderActivity", "order_id", input.OrderID, "payment_id", input.PaymentID)
+
+	if err := input.Validate(); err != nil {
+		logger.Error("Validation failed", "error", err)
+		return activityError(wf.CompleteOrderActivity, wf.StepComplete, wf.ErrorCodeValidation, err)
+	}
+
+	if err := a.orderService.Complete(ctx, input.OrderID, input.PaymentID); err != nil {
+		logger.Error("Failed to complete order", "error", err)
+		return activityError(wf.CompleteOrderActivity, wf.StepComplete, wf.ErrorCodeInternalError, err)
+	}
+
+	logger.Info("Order completed", "order_id", input.OrderID)
+	return nil
+}
+
+func (a *CompleteOrderActivity) GetActivityName() (string, error) {
+	return wf.CompleteOrderActivity, nil
+}
@@ -2,9 +2,8 @@ package activity
 
 import (
 	"context"
-	"time"
 
-	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/activity"
 
 	"orderflow/internal/domain/order"
 	wf "orderflow/internal/domain/workflow"
@@ -21,40 +20,32 @@ func NewCreateOrderActivity(svc order.Service) *CreateOrderActivity {
 
 func (a *CreateOrderActivity) Execute(ctx context.Context, in *wf.CreateOrderActivityInput) (*wf.CreateOrderActivityOutput, error) {
 	if in == nil {
-		return nil, temporal.NewNonRetryableApplicationError("nil input", wf.ErrorCodeValidation, nil)
+		return nil, activityFailure(wf.CreateOrderActivity, wf.StepCreateOrder, wf.ErrorCodeValidation, "nil input", false)
 	}
 
 	logger.Info("CreateOrderActivity: start", "customer_id", in.CustomerID)
-	if v, ok := any(in).(interface{ Validate() error }); ok {
-		if err := v.Validate(); err != nil {
-			return nil, temporal.NewNonRetryableApplicationError(err.Error(), wf.ErrorCodeValidation, nil)
-		}
-	} else {
-		if in.CustomerID == "" || len(in.Items) == 0 {
-			return nil, temporal.NewNonRetryableApplicationError("customer_id and items are required", wf.ErrorCodeValidation, nil)
-		}
+	if err := in.Validate(); err != nil {
+		return nil, activityError(wf.CreateOrderActivity, wf.StepCreateOrder, wf.ErrorCodeValidation, err)
 	}
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-time.After(wf.OrderCreationDuration):
-	}
-
 	req := &order.CreateRequest{
-		CustomerID: in.CustomerID,
-		Items:      in.Items, // <- должен быть []order.Item
+		CustomerID:      in.CustomerID,
+		Items:           in.Items, // <- должен быть []order.Item
+		WorkflowID:      activity.GetInfo(ctx).WorkflowExecution.ID,
+		CouponCode:      in.CouponCode,
+		TaxJurisdiction: in.TaxJurisdiction,
+		ShippingAddress: in.ShippingAddress,
+		BillingAddress:  in.BillingAddress,
+		ShippingMethod:  in.ShippingMethod,
 	}
 
 	o, err := a.orderService.Create(ctx, req)
 	if err != nil {
-		if _, ok := err.(*order.ValidationError); ok {
-			return nil, temporal.NewNonRetryableApplicationError(err.Error(), wf.ErrorCodeValidation, nil)
-		}
-		return nil, temporal.NewApplicationError(err.Error(), wf.ErrorCodeInternalError)
+		return nil, activityError(wf.CreateOrderActivity, wf.StepCreateOrder, wf.ErrorCodeInternalError, err)
 	}
 
-	logger.Info("CreateOrderActivity: success", "order_id", o.ID)
-	return &wf.CreateOrderActivityOutput{OrderID: o.ID}, nil
+	logger.Info("CreateOrderActivity: success", "order_id", o.ID, "total_amount", o.TotalAmount, "discount", o.DiscountAmount,
+		"shipping_method", o.ShippingMethod, "shipping", o.ShippingAmount)
+	return &wf.CreateOrderActivityOutput{OrderID: o.ID, TotalAmount: o.TotalAmount}, nil
 }
 
 func (a *CreateOrderActivity) GetActivityName() (string, error) {
@@ -0,0 +1,44 @@
+package activity
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"orderflow/internal/domain/webhook"
+	wf "orderflow/internal/domain/workflow"
+)
+
+// DeliverWebhookActivity выполняет одну подписанную попытку доставки вебхука.
+// Повторы с экспоненциальной задержкой задаёт retry policy activity; 4xx (кроме 408 и 429)
+// и отключённая подписка возвращаются как NonRetryable.
+type DeliverWebhookActivity struct {
+	webhookService webhook.Service
+}
+
+func NewDeliverWebhookActivity(webhookService webhook.Service) *DeliverWebhookActivity {
+	return &DeliverWebhookActivity{webhookService: webhookService}
+}
+
+func (a *DeliverWebhookActivity) Execute(ctx context.Context, input *wf.WebhookDeliveryInput) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Starting DeliverWebhookActivity",
+		"delivery_id", input.DeliveryID,
+		"subscription_id", input.SubscriptionID,
+		"attempt", activity.GetInfo(ctx).Attempt)
+
+	if err := input.Validate(); err != nil {
+		return activityError(wf.DeliverWebhookActivity, wf.StepWebhookDelivery, wf.ErrorCodeValidation, err)
+	}
+
+	if err := a.webhookService.Deliver(ctx, input.DeliveryID); err != nil {
+		logger.Warn("Webhook delivery attempt failed", "delivery_id", input.DeliveryID, "error", err)
+		return activityError(wf.DeliverWebhookActivity, wf.StepWebhookDelivery, wf.ErrorCodeWebhookDeliveryFailed, err)
+	}
+
+	return nil
+}
+
+func (a *DeliverWebhookActivity) GetActivityName() (string, error) {
+	return wf.DeliverWebhookActivity, nil
+}
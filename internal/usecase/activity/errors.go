@@ -0,0 +1,195 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go.temporal.io/sdk/temporal"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/notification"
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/orderevent"
+	"orderflow/internal/domain/payment"
+	"orderflow/internal/domain/promotion"
+	"orderflow/internal/domain/shipping"
+	"orderflow/internal/domain/subscription"
+	"orderflow/internal/domain/tax"
+	"orderflow/internal/domain/webhook"
+	wf "orderflow/internal/domain/workflow"
+)
+
+// activityError — единая точка перевода ошибок activities в temporal.ApplicationError.
+// Тип ошибки — стабильный код из wf.ErrorCode*, по нему workflow выставляет State.ErrorCode,
+// а флаг NonRetryable Temporal учитывает при ретраях. fallbackCode используется для
+// ошибок, которые не распознаны как доменные.
+func activityError(activityName, step, fallbackCode string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	code, retryable, attributes := classifyDomainError(err, fallbackCode)
+
+	message := err.Error()
+	var activityErr *wf.ActivityError
+	if errors.As(err, &activityErr) {
+		message = activityErr.Message
+	}
+
+	details := wf.ErrorDetails{
+		Activity:   activityName,
+		Step:       step,
+		Attributes: attributes,
+	}
+
+	return temporal.NewApplicationErrorWithOptions(message, code, temporal.ApplicationErrorOptions{
+		NonRetryable: !retryable,
+		Cause:        err,
+		Details:      []interface{}{details},
+	})
+}
+
+// activityFailure — ошибка без доменной причины, код и возможность ретрая задаются явно.
+func activityFailure(activityName, step, code, message string, retryable bool) error {
+	return activityError(activityName, step, code, wf.NewActivityError(activityName, step, code, message, retryable))
+}
+
+func classifyDomainError(err error, fallbackCode string) (code string, retryable bool, attributes map[string]string) {
+	var (
+		activityErr        *wf.ActivityError
+		workflowValidation *wf.ValidationError
+
+		orderValidation        *order.ValidationError
+		orderNotFound          *order.NotFoundError
+		orderCannotCancel      *order.CannotCancelError
+		orderStatusTransition  *order.StatusTransitionError
+		inventoryValidation    *inventory.ValidationError
+		productNotFound        *inventory.ProductNotFoundError
+		insufficientStock      *inventory.InsufficientStockError
+		reservationNotFound    *inventory.ReservationNotFoundError
+		reservationExpired     *inventory.ReservationExpiredError
+		paymentValidation      *payment.ValidationError
+		insufficientFunds      *payment.InsufficientFundsError
+		paymentProcessing      *payment.ProcessingError
+		duplicatePayment       *payment.DuplicatePaymentError
+		notificationValidation *notification.ValidationError
+		unsupportedChannel     *notification.UnsupportedChannelError
+		templateErr            *notification.TemplateError
+		notificationSend       *notification.SendError
+		recipientNotFound      *notification.RecipientNotFoundError
+		subscriptionValidation *subscription.ValidationError
+		orderEventValidation   *orderevent.ValidationError
+		webhookValidation      *webhook.ValidationError
+		webhookNotFound        *webhook.NotFoundError
+		webhookDeliveryMissing *webhook.DeliveryNotFoundError
+		webhookDisabled        *webhook.DisabledError
+		webhookDelivery        *webhook.DeliveryError
+		promotionValidation    *promotion.ValidationError
+		couponRejected         *promotion.CouponError
+		taxValidation          *tax.ValidationError
+		taxRateNotFound        *tax.NoRateError
+		shippingValidation     *shipping.ValidationError
+		shippingUnavailable    *shipping.UnavailableError
+	)
+
+	switch {
+	case errors.As(err, &activityErr):
+		return activityErr.Code, activityErr.Retryable, nil
+
+	case errors.As(err, &workflowValidation),
+		errors.As(err, &orderValidation),
+		errors.As(err, &inventoryValidation),
+		errors.As(err, &paymentValidation),
+		errors.As(err, &notificationValidation),
+		errors.As(err, &subscriptionValidation),
+		errors.As(err, &orderEventValidation),
+		errors.As(err, &webhookValidation),
+		errors.As(err, &promotionValidation),
+		errors.As(err, &taxValidation),
+		errors.As(err, &shippingValidation):
+		return wf.ErrorCodeValidation, false, nil
+
+	case errors.As(err, &orderNotFound):
+		return wf.ErrorCodeOrderNotFound, false, map[string]string{"order_id": orderNotFound.OrderID}
+	case errors.As(err, &orderCannotCancel):
+		return wf.ErrorCodeInvalidOrderStatus, false, map[string]string{"status": string(orderCannotCancel.Status)}
+	case errors.As(err, &orderStatusTransition):
+		return wf.ErrorCodeInvalidOrderStatus, false, map[string]string{
+			"from": string(orderStatusTransition.FromStatus),
+			"to":   string(orderStatusTransition.ToStatus),
+		}
+
+	case errors.As(err, &productNotFound):
+		return wf.ErrorCodeProductNotFound, false, map[string]string{"product_id": productNotFound.ProductID}
+	case errors.As(err, &insufficientStock):
+		return wf.ErrorCodeInsufficientStock, false, map[string]string{
+			"product_id": insufficientStock.ProductID,
+			"requested":  strconv.Itoa(insufficientStock.RequestedQuantity),
+			"available":  strconv.Itoa(insufficientStock.AvailableQuantity),
+		}
+	case errors.As(err, &reservationNotFound):
+		return wf.ErrorCodeReservationNotFound, false, map[string]string{"order_id": reservationNotFound.OrderID}
+	case errors.As(err, &reservationExpired):
+		return wf.ErrorCodeReservationExpired, false, map[string]string{"reservation_id": reservationExpired.ReservationID}
+
+	case errors.As(err, &insufficientFunds):
+		return wf.ErrorCodeInsufficientFunds, false, map[string]string{"amount": fmt.Sprintf("%.2f", insufficientFunds.Amount)}
+	case errors.As(err, &paymentProcessing):
+		return wf.ErrorCodePaymentDeclined, false, map[string]string{"provider_code": paymentProcessing.Code}
+	case errors.As(err, &duplicatePayment):
+		return wf.ErrorCodeDuplicatePayment, false, map[string]string{"order_id": duplicatePayment.OrderID}
+
+	case errors.As(err, &couponRejected):
+		return wf.ErrorCodeCouponRejected, false, map[string]string{
+			"code":   couponRejected.Code,
+			"reason": couponRejected.Reason,
+		}
+
+	case errors.As(err, &taxRateNotFound):
+		return wf.ErrorCodeTaxRateNotFound, false, map[string]string{
+			"jurisdiction": taxRateNotFound.Jurisdiction,
+			"tax_class":    taxRateNotFound.TaxClass,
+		}
+
+	case errors.As(err, &shippingUnavailable):
+		return wf.ErrorCodeShippingUnavailable, false, map[string]string{
+			"method":  shippingUnavailable.Method,
+			"country": shippingUnavailable.Country,
+		}
+
+	case errors.As(err, &unsupportedChannel):
+		return wf.ErrorCodeUnsupportedChannel, false, map[string]string{"channel": string(unsupportedChannel.Channel)}
+	case errors.As(err, &templateErr):
+		return wf.ErrorCodeTemplateError, false, nil
+	case errors.As(err, &recipientNotFound):
+		return wf.ErrorCodeRecipientNotFound, false, map[string]string{
+			"customer_id": recipientNotFound.CustomerID,
+			"channel":     string(recipientNotFound.Channel),
+		}
+	case errors.As(err, &notificationSend):
+		return wf.ErrorCodeNotificationFailed, !notificationSend.Permanent, nil
+
+	case errors.As(err, &webhookNotFound):
+		return wf.ErrorCodeWebhookNotFound, false, map[string]string{"subscription_id": webhookNotFound.SubscriptionID}
+	case errors.As(err, &webhookDeliveryMissing):
+		return wf.ErrorCodeWebhookNotFound, false, map[string]string{"delivery_id": webhookDeliveryMissing.DeliveryID}
+	case errors.As(err, &webhookDisabled):
+		return wf.ErrorCodeWebhookDisabled, false, map[string]string{"subscription_id": webhookDisabled.SubscriptionID}
+	case errors.As(err, &webhookDelivery):
+		return wf.ErrorCodeWebhookDeliveryFailed, webhookDelivery.Retryable, map[string]string{
+			"status_code": strconv.Itoa(webhookDelivery.StatusCode),
+		}
+	}
+
+	return fallbackCode, true, nil
+}
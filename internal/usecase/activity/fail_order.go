@@ -0,0 +1,53 @@
+package activity
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/order"
+	wf "orderflow/internal/domain/workflow"
+)
+
+// FailOrderActivity компенсирует заказ, который workflow завершает ошибкой после
+// резервирования: освобождает резерв и переводит заказ в failed. Оба шага идемпотентны,
+// поэтому при сбое activity повторяется целиком.
+type FailOrderActivity struct {
+	orderService     order.Service
+	inventoryService inventory.Service
+}
+
+func NewFailOrderActivity(orderService order.Service, inventoryService inventory.Service) *FailOrderActivity {
+	return &FailOrderActivity{
+		orderService:     orderService,
+		inventoryService: inventoryService,
+	}
+}
+
+func (a *FailOrderActivity) Execute(ctx context.Context, input *wf.FailOrderActivityInput) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Starting FailOrderActivity", "order_id", input.OrderID, "reason", input.Reason)
+
+	if err := input.Validate(); err != nil {
+		logger.Error("Validation failed", "error", err)
+		return activityError(wf.FailOrderActivity, wf.StepFailed, wf.ErrorCodeValidation, err)
+	}
+
+	if err := a.inventoryService.ReleaseReservation(ctx, input.OrderID); err != nil {
+		logger.Error("Failed to release inventory reservation", "error", err)
+		return activityError(wf.FailOrderActivity, wf.StepFailed, wf.ErrorCodeInternalError, err)
+	}
+
+	if err := a.orderService.SetFailure(ctx, input.OrderID, input.Reason); err != nil {
+		logger.Error("Failed to set order failure", "error", err)
+		return activityError(wf.FailOrderActivity, wf.StepFailed, wf.ErrorCodeInternalError, err)
+	}
+
+	logger.Info("Order failed and reservation released", "order_id", input.OrderID)
+	return nil
+}
+
+func (a *FailOrderActivity) GetActivityName() (string, error) {
+	return wf.FailOrderActivity, nil
+}
@@ -0,0 +1,45 @@
+package activity
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"orderflow/internal/domain/webhook"
+	wf "orderflow/internal/domain/workflow"
+)
+
+// FinalizeWebhookDeliveryActivity фиксирует итог доставки после всех попыток
+// и при необходимости отключает подписку.
+type FinalizeWebhookDeliveryActivity struct {
+	webhookService webhook.Service
+}
+
+func NewFinalizeWebhookDeliveryActivity(webhookService webhook.Service) *FinalizeWebhookDeliveryActivity {
+	return &FinalizeWebhookDeliveryActivity{webhookService: webhookService}
+}
+
+func (a *FinalizeWebhookDeliveryActivity) Execute(ctx context.Context, input *wf.FinalizeWebhookDeliveryActivityInput) (*wf.FinalizeWebhookDeliveryActivityOutput, error) {
+	logger := activity.GetLogger(ctx)
+
+	if err := input.Validate(); err != nil {
+		return nil, activityError(wf.FinalizeWebhookDeliveryActivity, wf.StepWebhookDelivery, wf.ErrorCodeValidation, err)
+	}
+
+	disabled, err := a.webhookService.CompleteDelivery(ctx, input.DeliveryID, input.Delivered)
+	if err != nil {
+		logger.Error("Failed to finalize webhook delivery", "delivery_id", input.DeliveryID, "error", err)
+		return nil, activityError(wf.FinalizeWebhookDeliveryActivity, wf.StepWebhookDelivery, wf.ErrorCodeInternalError, err)
+	}
+
+	logger.Info("Webhook delivery finalized",
+		"delivery_id", input.DeliveryID,
+		"delivered", input.Delivered,
+		"subscription_disabled", disabled)
+
+	return &wf.FinalizeWebhookDeliveryActivityOutput{SubscriptionDisabled: disabled}, nil
+}
+
+func (a *FinalizeWebhookDeliveryActivity) GetActivityName() (string, error) {
+	return wf.FinalizeWebhookDeliveryActivity, nil
+}
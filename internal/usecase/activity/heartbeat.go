@@ -0,0 +1,54 @@
+package activity
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// withHeartbeat выполняет fn и, пока она работает, периодически отправляет heartbeat.
+// Heartbeat подтверждает, что воркер жив, и доставляет в ctx отмену activity, поэтому fn
+// должна передавать ctx в свои вызовы. О прогрессе fn он ничего не говорит: зависший внутри
+// fn вызов ограничивает StartToCloseTimeout. Если у activity не задан HeartbeatTimeout,
+// fn вызывается как есть.
+func withHeartbeat[T any](ctx context.Context, details string, fn func() (T, error)) (T, error) {
+	timeout := activity.GetInfo(ctx).HeartbeatTimeout
+	if timeout <= 0 {
+		return fn()
+	}
+
+	return heartbeatWhile(ctx, timeout/3, func() { activity.RecordHeartbeat(ctx, details) }, fn)
+}
+
+// heartbeatWhile вызывает record сразу и затем раз в interval, пока работает fn
+// и не отменён ctx.
+func heartbeatWhile[T any](ctx context.Context, interval time.Duration, record func(), fn func() (T, error)) (T, error) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	defer func() {
+		close(done)
+		<-stopped
+	}()
+
+	record()
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				record()
+			}
+		}
+	}()
+
+	return fn()
+}
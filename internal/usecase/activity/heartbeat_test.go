@@ -0,0 +1,59 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatWhilePropagatesCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var heartbeats atomic.Int32
+	record := func() {
+		if heartbeats.Add(1) == 3 {
+			// Temporal отменяет activity в ответ на heartbeat
+			cancel()
+		}
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := heartbeatWhile(ctx, time.Millisecond, record, func() (struct{}, error) {
+			<-ctx.Done()
+			return struct{}{}, ctx.Err()
+		})
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("heartbeatWhile() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("heartbeatWhile() did not return after cancellation")
+	}
+
+	// После выхода из heartbeatWhile heartbeat больше не отправляется
+	sent := heartbeats.Load()
+	time.Sleep(10 * time.Millisecond)
+	if got := heartbeats.Load(); got != sent {
+		t.Errorf("heartbeats after return = %d, want %d", got, sent)
+	}
+}
+
+func TestHeartbeatWhileStopsWhenFnReturns(t *testing.T) {
+	var heartbeats atomic.Int32
+	value, err := heartbeatWhile(context.Background(), time.Hour, func() { heartbeats.Add(1) }, func() (int, error) {
+		return 42, nil
+	})
+	if err != nil || value != 42 {
+		t.Fatalf("heartbeatWhile() = %v, %v, want 42, nil", value, err)
+	}
+	if got := heartbeats.Load(); got != 1 {
+		t.Errorf("heartbeats = %d, want 1 (initial)", got)
+	}
+}
@@ -0,0 +1,56 @@
+package activity
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// LatencyInjector — перехватчик воркера для локальной разработки: перед выполнением
+// activity ждёт заданное время, имитируя медленные внешние системы. Ожидание сопровождается
+// heartbeat, поэтому не срабатывает HeartbeatTimeout.
+type LatencyInjector struct {
+	interceptor.WorkerInterceptorBase
+	delays map[string]time.Duration
+}
+
+func NewLatencyInjector(delays map[string]time.Duration) *LatencyInjector {
+	normalized := make(map[string]time.Duration, len(delays))
+	for name, delay := range delays {
+		normalized[strings.ToLower(name)] = delay
+	}
+	return &LatencyInjector{delays: normalized}
+}
+
+func (l *LatencyInjector) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	i := &latencyActivityInbound{delays: l.delays}
+	i.Next = next
+	return i
+}
+
+type latencyActivityInbound struct {
+	interceptor.ActivityInboundInterceptorBase
+	delays map[string]time.Duration
+}
+
+func (i *latencyActivityInbound) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	delay := i.delays[strings.ToLower(activity.GetInfo(ctx).ActivityType.Name)]
+	if delay > 0 {
+		_, err := withHeartbeat(ctx, "injected latency", func() (struct{}, error) {
+			select {
+			case <-ctx.Done():
+				return struct{}{}, ctx.Err()
+			case <-time.After(delay):
+				return struct{}{}, nil
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return i.Next.ExecuteActivity(ctx, in)
+}
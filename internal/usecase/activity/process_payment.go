@@ -7,18 +7,23 @@ import (
 	"orderflow/internal/domain/inventory"
 	"orderflow/internal/domain/order"
 	"orderflow/internal/domain/payment"
+	"orderflow/internal/domain/promotion"
 	wf "orderflow/internal/domain/workflow"
 	"orderflow/pkg/logger"
 )
 
+// refundAfterCancelTimeout ограничивает возврат списания, завершившегося после отмены activity.
+const refundAfterCancelTimeout = 30 * time.Second
+
 type ProcessPaymentActivity struct {
 	paymenyService   payment.Service
 	orderService     order.Service
 	inventoryService inventory.Service
+	promotionService promotion.Service
 }
 
-func NewProcessPaymentActivity(paymenyService payment.Service, orderService order.Service, inventoryService inventory.Service) *ProcessPaymentActivity {
-	return &ProcessPaymentActivity{paymenyService: paymenyService, orderService: orderService, inventoryService: inventoryService}
+func NewProcessPaymentActivity(paymenyService payment.Service, orderService order.Service, inventoryService inventory.Service, promotionService promotion.Service) *ProcessPaymentActivity {
+	return &ProcessPaymentActivity{paymenyService: paymenyService, orderService: orderService, inventoryService: inventoryService, promotionService: promotionService}
 }
 
 func (a *ProcessPaymentActivity) Execute(ctx context.Context, input *wf.ProcessPaymentActivityInput) (*wf.ProcessPaymentActivityOutput, error) {
@@ -26,25 +31,13 @@ func (a *ProcessPaymentActivity) Execute(ctx context.Context, input *wf.ProcessP
 
 	if err := input.Validate(); err != nil {
 		logger.Error("ProcessPaymentActivity validation error", "error", err)
-		return nil, wf.NewActivityError(
-			wf.ProcessPaymentActivity,
-			wf.StepProcessPayment,
-			wf.ErrorCodeValidation,
-			err.Error(),
-			false, // не ретраить в таком случае
-		)
+		return nil, activityError(wf.ProcessPaymentActivity, wf.StepProcessPayment, wf.ErrorCodeValidation, err)
 	}
 
 	if err := a.orderService.UpdateStatus(ctx, input.OrderID, order.StatusPayment); err != nil {
 		logger.Error("Failed to update order status", "error", err)
 	}
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-time.After(wf.PaymentProcessDuration):
-	}
-
 	paymentReq := &payment.Request{
 		OrderID:   input.OrderID,
 		CustomerID: input.CustomerID,
@@ -53,63 +46,47 @@ func (a *ProcessPaymentActivity) Execute(ctx context.Context, input *wf.ProcessP
 		PaymentMethod: "card", // если нужно то расширить, пока дефолт
 	}
 
-	paymentResp, err := a.paymenyService.ProcessPayment(ctx, paymentReq)
+	// Вызов платёжного провайдера может быть долгим: heartbeat позволяет Temporal
+	// быстро заметить зависший воркер и доставить отмену в ctx
+	paymentResp, err := withHeartbeat(ctx, wf.StepProcessPayment, func() (*payment.Response, error) {
+		return a.paymenyService.ProcessPayment(ctx, paymentReq)
+	})
 	if err != nil {
+		// Резерв освобождает и заказ переводит в failed workflow через FailOrderActivity
 		logger.Error("Failed to process payment", "error", err)
-		if releaseErr := a.inventoryService.ReleaseReservation(ctx, input.OrderID); releaseErr != nil {
-			logger.Error("Failed to release reservation after payment failure", "error", releaseErr)
-		}
-
-		a.orderService.SetFailure(ctx, input.OrderID, "Payment failed"+ err.Error())
-
-		retryable := true
-		errorCode := wf.ErrorCodePaymentFailed
-
-		switch err.(type) {
-		case *payment.ValidationError:
-			errorCode = wf.ErrorCodeValidation
-			retryable = false
-		case *payment.InsufficientFundsError: 
-		retryable = false
-		}
-
-		return nil, wf.NewActivityError(
-			wf.ProcessPaymentActivity,
-			wf.StepProcessPayment,
-			errorCode,
-			err.Error(),
-			retryable,
-		)
+		return nil, activityError(wf.ProcessPaymentActivity, wf.StepProcessPayment, wf.ErrorCodePaymentFailed, err)
 	}
 
 	if !paymentResp.Success {
 		logger.Error("Payment was not successful", "error_code", paymentResp.ErrorCode, "error_message", paymentResp.ErrorMessage)
-		
-		if releaseErr := a.inventoryService.ReleaseReservation(ctx, input.OrderID); releaseErr != nil {
-			logger.Error("Failed to release reservation after payment failure", "error", releaseErr)
+
+		// Отказ провайдера не ретраим: повторное списание решает клиент, а не воркер
+		return nil, activityError(wf.ProcessPaymentActivity, wf.StepProcessPayment, wf.ErrorCodePaymentDeclined,
+			paymentDeclinedError(input.Amount, paymentResp))
+	}
+
+	// Отмену и таймаут activity (истёк резерв, отмена заказа) провайдер не видит, и списание
+	// доходит до конца. Workflow к этому моменту уже не ждёт результат, поэтому деньги возвращаются здесь
+	if ctx.Err() != nil {
+		logger.Warn("Payment activity cancelled after charge, refunding", "order_id", input.OrderID, "payment_id", paymentResp.PaymentID)
+		refundCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refundAfterCancelTimeout)
+		defer cancel()
+		if err := refundOrderPayment(refundCtx, a.paymenyService, input.OrderID, paymentResp.PaymentID, "Payment activity cancelled"); err != nil {
+			logger.Error("Failed to refund payment after cancellation", "error", err, "payment_id", paymentResp.PaymentID)
 		}
-		
-		a.orderService.SetFailure(ctx, input.OrderID, paymentResp.ErrorMessage)
-		
-		return nil, wf.NewActivityError(
-			wf.ProcessPaymentActivity,
-			wf.StepProcessPayment,
-			wf.ErrorCodePaymentFailed,
-			paymentResp.ErrorMessage,
-			false, // обычно ошибки платежа не ретраим автоматически
-		)
+		return nil, ctx.Err()
 	}
 
 	if err := a.inventoryService.ConfirmReservation(ctx, input.OrderID); err != nil {
 		logger.Error("Failed to confirm reservation", "error", err)
 		
-		return nil, wf.NewActivityError(
-			wf.ProcessPaymentActivity,
-			wf.StepProcessPayment,
-			wf.ErrorCodeInternalError,
-			"Failed to confirm reservation after successful payment: "+err.Error(),
-			true,
-		)
+		return nil, activityFailure(wf.ProcessPaymentActivity, wf.StepProcessPayment, wf.ErrorCodeInternalError,
+			"Failed to confirm reservation after successful payment: "+err.Error(), true)
+	}
+
+	// Деньги уже списаны: неподтверждённое погашение купона всё равно занимает лимит
+	if err := a.promotionService.ConfirmRedemptions(ctx, input.OrderID); err != nil {
+		logger.Error("Failed to confirm coupon redemptions", "error", err)
 	}
 
 	logger.Info("Payment processed successfully", 
@@ -124,6 +101,14 @@ func (a *ProcessPaymentActivity) Execute(ctx context.Context, input *wf.ProcessP
 }
 
 
+// paymentDeclinedError превращает неуспешный ответ провайдера в доменную ошибку платежа.
+func paymentDeclinedError(amount float64, resp *payment.Response) error {
+	if resp.ErrorCode == "INSUFFICIENT_FUNDS" {
+		return payment.NewInsufficientFundsError(amount)
+	}
+	return payment.NewProcessingError(resp.ErrorCode, resp.ErrorMessage)
+}
+
 func (a *ProcessPaymentActivity) GetActivityName() (string, error) {
 	return wf.ProcessPaymentActivity, nil
 }
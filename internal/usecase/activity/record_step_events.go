@@ -0,0 +1,43 @@
+package activity
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"orderflow/internal/domain/orderevent"
+	wf "orderflow/internal/domain/workflow"
+)
+
+// RecordStepEventsActivity записывает переходы шагов заказа в order_step_events.
+// Вызывается из workflow как local activity; API-сервер получает события через LISTEN/NOTIFY.
+type RecordStepEventsActivity struct {
+	orderEventService orderevent.Service
+}
+
+func NewRecordStepEventsActivity(orderEventService orderevent.Service) *RecordStepEventsActivity {
+	return &RecordStepEventsActivity{orderEventService: orderEventService}
+}
+
+func (a *RecordStepEventsActivity) Execute(ctx context.Context, events []*orderevent.Event) error {
+	logger := activity.GetLogger(ctx)
+
+	for _, event := range events {
+		logger.Debug("Recording step event",
+			"workflow_id", event.WorkflowID,
+			"seq", event.Seq,
+			"step", event.Step,
+			"step_status", event.StepStatus)
+
+		if err := a.orderEventService.Record(ctx, event); err != nil {
+			logger.Error("Failed to record step event", "error", err, "seq", event.Seq)
+			return activityError(wf.RecordStepEventsActivity, event.Step, wf.ErrorCodeInternalError, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *RecordStepEventsActivity) GetActivityName() (string, error) {
+	return wf.RecordStepEventsActivity, nil
+}
@@ -0,0 +1,52 @@
+package activity
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"orderflow/internal/domain/notification"
+	wf "orderflow/internal/domain/workflow"
+)
+
+type RetryNotificationsActivity struct {
+	notificationService notification.Service
+}
+
+func NewRetryNotificationsActivity(notificationService notification.Service) *RetryNotificationsActivity {
+	return &RetryNotificationsActivity{notificationService: notificationService}
+}
+
+func (a *RetryNotificationsActivity) Execute(ctx context.Context, input *wf.RetryNotificationsActivityInput) (*wf.RetryNotificationsActivityOutput, error) {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Starting RetryNotificationsActivity", "batch_size", input.BatchSize)
+
+	// Heartbeat после каждого уведомления: пачка, которая перестала продвигаться,
+	// обрывается по HeartbeatTimeout, а не держит заявленные уведомления до StartToClose
+	result, err := a.notificationService.ProcessRetryQueue(ctx, input.BatchSize, func(progress *notification.RetryBatchResult) {
+		activity.RecordHeartbeat(ctx, *progress)
+	})
+	if err != nil {
+		logger.Error("Failed to process notification retry queue", "error", err)
+		return nil, activityError(wf.RetryNotificationsActivity, wf.StepSendNotification, wf.ErrorCodeInternalError, err)
+	}
+
+	logger.Info("Notification retry batch completed",
+		"claimed", result.Claimed,
+		"sent", result.Sent,
+		"rescheduled", result.Rescheduled,
+		"dead", result.Dead,
+		"digested", result.Digested)
+
+	return &wf.RetryNotificationsActivityOutput{
+		Claimed:     result.Claimed,
+		Sent:        result.Sent,
+		Rescheduled: result.Rescheduled,
+		Dead:        result.Dead,
+		Digested:    result.Digested,
+	}, nil
+}
+
+func (a *RetryNotificationsActivity) GetActivityName() (string, error) {
+	return wf.RetryNotificationsActivity, nil
+}
@@ -0,0 +1,37 @@
+package activity
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"orderflow/internal/domain/subscription"
+	wf "orderflow/internal/domain/workflow"
+)
+
+type SaveSubscriptionActivity struct {
+	subscriptionService subscription.Service
+}
+
+func NewSaveSubscriptionActivity(subscriptionService subscription.Service) *SaveSubscriptionActivity {
+	return &SaveSubscriptionActivity{subscriptionService: subscriptionService}
+}
+
+func (a *SaveSubscriptionActivity) Execute(ctx context.Context, input *subscription.Subscription) error {
+	logger := activity.GetLogger(ctx)
+	logger.Info("Starting SaveSubscriptionActivity",
+		"subscription_id", input.ID,
+		"status", input.Status,
+		"cycles_completed", input.CyclesCompleted)
+
+	if err := a.subscriptionService.Save(ctx, input); err != nil {
+		logger.Error("Failed to save subscription", "error", err)
+		return activityError(wf.SaveSubscriptionActivity, wf.StepSubscriptionCycle, wf.ErrorCodeInternalError, err)
+	}
+
+	return nil
+}
+
+func (a *SaveSubscriptionActivity) GetActivityName() (string, error) {
+	return wf.SaveSubscriptionActivity, nil
+}
@@ -2,24 +2,27 @@ package activity
 
 import (
 	"context"
-	"time"
+	"errors"
 
 	"go.temporal.io/sdk/activity"
 
 	"orderflow/internal/domain/notification"
 	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/payment"
 	wf "orderflow/internal/domain/workflow"
 )
 
 type SendNotificationActivity struct {
 	notificationService notification.Service
 	orderService        order.Service
+	paymentService      payment.Service
 }
 
-func NewSendNotificationActivity(notificationService notification.Service, orderService order.Service) *SendNotificationActivity {
+func NewSendNotificationActivity(notificationService notification.Service, orderService order.Service, paymentService payment.Service) *SendNotificationActivity {
 	return &SendNotificationActivity{
 		notificationService: notificationService,
 		orderService:        orderService,
+		paymentService:      paymentService,
 	}
 }
 
@@ -32,19 +35,7 @@ func (a *SendNotificationActivity) Execute(ctx context.Context, input *wf.SendNo
 
 	if err := input.Validate(); err != nil {
 		logger.Error("Validation failed", "error", err)
-		return wf.NewActivityError(
-			wf.SendNotificationActivity,
-			wf.StepSendNotification,
-			wf.ErrorCodeValidation,
-			err.Error(),
-			false,
-		)
-	}
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-time.After(wf.NotificationDuration):
+		return activityError(wf.SendNotificationActivity, wf.StepSendNotification, wf.ErrorCodeValidation, err)
 	}
 
 	notificationReq := &notification.Request{
@@ -57,32 +48,24 @@ func (a *SendNotificationActivity) Execute(ctx context.Context, input *wf.SendNo
 			"order_id": input.OrderID,
 		},
 	}
+	// Повтор activity в том же запуске workflow не создаёт второе уведомление
+	if activity.IsActivity(ctx) {
+		runID := activity.GetInfo(ctx).WorkflowExecution.RunID
+		notificationReq.IdempotencyKey = notification.NewIdempotencyKey(input.OrderID, input.Type, runID)
+	}
 
 	if notificationReq.Message == "" {
-		notificationReq.Message = a.generateDefaultMessage(input.Type, input.OrderID)
+		data, err := a.templateData(ctx, input)
+		if err != nil {
+			logger.Error("Failed to collect notification data", "error", err)
+			return activityError(wf.SendNotificationActivity, wf.StepSendNotification, wf.ErrorCodeNotificationFailed, err)
+		}
+		notificationReq.Data = data
 	}
 
 	if err := a.notificationService.Send(ctx, notificationReq); err != nil {
 		logger.Error("Failed to send notification", "error", err)
-		
-		retryable := true
-		errorCode := wf.ErrorCodeNotificationFailed
-		
-		switch err.(type) {
-		case *notification.ValidationError:
-			retryable = false
-			errorCode = wf.ErrorCodeValidation
-		case *notification.UnsupportedChannelError:
-			retryable = false
-		}
-		
-		return wf.NewActivityError(
-			wf.SendNotificationActivity,
-			wf.StepSendNotification,
-			errorCode,
-			err.Error(),
-			retryable,
-		)
+		return activityError(wf.SendNotificationActivity, wf.StepSendNotification, wf.ErrorCodeNotificationFailed, err)
 	}
 
 	logger.Info("Notification sent successfully", 
@@ -93,13 +76,63 @@ func (a *SendNotificationActivity) Execute(ctx context.Context, input *wf.SendNo
 	return nil
 }
 
+// templateData собирает данные для шаблона из заказа и платежа. Отсутствие заказа или
+// платежа не мешает отправке: шаблоны проверяют необязательные поля.
+func (a *SendNotificationActivity) templateData(ctx context.Context, input *wf.SendNotificationActivityInput) (*notification.TemplateData, error) {
+	data := &notification.TemplateData{
+		OrderID:       input.OrderID,
+		CustomerID:    input.CustomerID,
+		FailureReason: input.Reason,
+	}
+
+	orderEntity, err := a.orderService.GetByID(ctx, input.OrderID)
+	var orderNotFound *order.NotFoundError
+	switch {
+	case errors.As(err, &orderNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		data.TotalAmount = orderEntity.TotalAmount
+		if data.FailureReason == "" {
+			data.FailureReason = orderEntity.FailureReason
+		}
+		for _, item := range orderEntity.Items {
+			data.Items = append(data.Items, notification.TemplateItem{
+				ProductID: item.ProductID,
+				Name:      item.Name,
+				Quantity:  item.Quantity,
+				Price:     item.Price,
+				Total:     item.Price * float64(item.Quantity),
+			})
+		}
+	}
+
+	paymentEntity, err := a.paymentService.GetPaymentByOrderID(ctx, input.OrderID)
+	var paymentNotFound *payment.NotFoundError
+	switch {
+	case errors.As(err, &paymentNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		data.Currency = paymentEntity.Currency
+		data.Payment = &notification.TemplatePayment{
+			ID:            paymentEntity.ID,
+			Method:        paymentEntity.PaymentMethod,
+			Status:        string(paymentEntity.Status),
+			TransactionID: paymentEntity.TransactionID,
+			Amount:        paymentEntity.Amount,
+			Currency:      paymentEntity.Currency,
+		}
+	}
+
+	return data, nil
+}
+
 func (a *SendNotificationActivity) SendOrderConfirmation(ctx context.Context, orderID, customerID, paymentID string) error {
 	input := &wf.SendNotificationActivityInput{
 		CustomerID: customerID,
 		OrderID:    orderID,
 		Type:       notification.TypeOrderConfirmed,
-		Channel:    notification.ChannelEmail,
-		Message:    a.generateOrderConfirmationMessage(orderID, paymentID),
 	}
 	
 	return a.Execute(ctx, input)
@@ -110,8 +143,7 @@ func (a *SendNotificationActivity) SendOrderFailure(ctx context.Context, orderID
 		CustomerID: customerID,
 		OrderID:    orderID,
 		Type:       notification.TypeOrderFailed,
-		Channel:    notification.ChannelEmail,
-		Message:    a.generateOrderFailureMessage(orderID, reason),
+		Reason:     reason,
 	}
 	
 	return a.Execute(ctx, input)
@@ -122,48 +154,11 @@ func (a *SendNotificationActivity) SendOrderCancellation(ctx context.Context, or
 		CustomerID: customerID,
 		OrderID:    orderID,
 		Type:       notification.TypeOrderCancelled,
-		Channel:    notification.ChannelEmail,
-		Message:    a.generateOrderCancellationMessage(orderID),
 	}
 	
 	return a.Execute(ctx, input)
 }
 
-func (a *SendNotificationActivity) generateDefaultMessage(notificationType notification.Type, orderID string) string {
-	switch notificationType {
-	case notification.TypeOrderConfirmed:
-		return a.generateOrderConfirmationMessage(orderID, "")
-	case notification.TypeOrderFailed:
-		return a.generateOrderFailureMessage(orderID, "Processing failed")
-	case notification.TypeOrderCancelled:
-		return a.generateOrderCancellationMessage(orderID)
-	default:
-		return "Order update for order " + orderID
-	}
-}
-
-func (a *SendNotificationActivity) generateOrderConfirmationMessage(orderID, paymentID string) string {
-	message := "Your order " + orderID + " has been successfully processed"
-	if paymentID != "" {
-		message += " (Payment ID: " + paymentID + ")"
-	}
-	message += ". Thank you for your purchase!"
-	return message
-}
-
-func (a *SendNotificationActivity) generateOrderFailureMessage(orderID, reason string) string {
-	message := "Unfortunately, your order " + orderID + " could not be processed"
-	if reason != "" {
-		message += ": " + reason
-	}
-	message += ". Please contact our support team for assistance."
-	return message
-}
-
-func (a *SendNotificationActivity) generateOrderCancellationMessage(orderID string) string {
-	return "Your order " + orderID + " has been cancelled as requested. If you have any questions, please contact our support team."
-}
-
 func (a *SendNotificationActivity) GetActivityName() (string, error) {
 	return wf.SendNotificationActivity, nil
-}
\ No newline at end of file
+}
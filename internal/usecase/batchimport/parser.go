@@ -0,0 +1,326 @@
+package batchimport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/workflow"
+)
+
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+)
+
+var (
+	ErrEmptyBatch      = errors.New("batch contains no orders")
+	ErrBatchTooLarge   = fmt.Errorf("batch exceeds %d orders", workflow.MaxBatchImportLines)
+	ErrUnknownFormat   = errors.New("unknown batch format, expected ndjson or csv")
+	csvRequiredColumns = []string{"customer_id", "product_id", "quantity", "price"}
+	// csvOrderColumns относятся к заказу, а не к позиции: в строках одного order_ref
+	// они либо пусты, либо совпадают с первой строкой
+	csvOrderColumns = append(append([]string{"coupon_code", "tax_jurisdiction", "shipping_method"},
+		csvAddressColumns("shipping_")...), csvAddressColumns("billing_")...)
+)
+
+func csvAddressColumns(prefix string) []string {
+	fields := []string{"name", "line1", "line2", "city", "region", "postal_code", "country", "phone"}
+	columns := make([]string, len(fields))
+	for i, name := range fields {
+		columns[i] = prefix + name
+	}
+	return columns
+}
+
+// ParseFormat разбирает явно указанный формат; пустая строка означает "определить автоматически".
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return "", nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// DetectFormat определяет формат по Content-Type или расширению файла. По умолчанию NDJSON.
+func DetectFormat(contentType, filename string) Format {
+	if strings.Contains(contentType, "csv") {
+		return FormatCSV
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return FormatCSV
+	}
+	return FormatNDJSON
+}
+
+// Parse читает пакет заказов. Ошибки в отдельных строках не прерывают разбор:
+// они попадают в BatchOrderLine.Error и отражаются в отчёте пакета.
+func Parse(r io.Reader, format Format) ([]workflow.BatchOrderLine, error) {
+	var (
+		lines []workflow.BatchOrderLine
+		err   error
+	)
+
+	switch format {
+	case FormatNDJSON:
+		lines, err = parseNDJSON(r)
+	case FormatCSV:
+		lines, err = parseCSV(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(lines) > workflow.MaxBatchImportLines {
+		return nil, ErrBatchTooLarge
+	}
+
+	for i := range lines {
+		if lines[i].Error == "" {
+			lines[i].Error = validateLine(&lines[i])
+		}
+	}
+
+	return lines, nil
+}
+
+type ndjsonLine struct {
+	Reference       string         `json:"reference"`
+	CustomerID      string         `json:"customer_id"`
+	Items           []order.Item   `json:"items"`
+	CouponCode      string         `json:"coupon_code"`
+	TaxJurisdiction string         `json:"tax_jurisdiction"`
+	ShippingAddress *order.Address `json:"shipping_address"`
+	BillingAddress  *order.Address `json:"billing_address"`
+	ShippingMethod  string         `json:"shipping_method"`
+}
+
+func parseNDJSON(r io.Reader) ([]workflow.BatchOrderLine, error) {
+	var lines []workflow.BatchOrderLine
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		line := workflow.BatchOrderLine{Line: lineNumber}
+
+		var decoded ndjsonLine
+		if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+			line.Error = fmt.Sprintf("invalid JSON: %v", err)
+		} else {
+			line.Reference = decoded.Reference
+			line.CustomerID = decoded.CustomerID
+			line.Items = decoded.Items
+			line.CouponCode = decoded.CouponCode
+			line.TaxJurisdiction = decoded.TaxJurisdiction
+			line.ShippingAddress = decoded.ShippingAddress
+			line.BillingAddress = decoded.BillingAddress
+			line.ShippingMethod = decoded.ShippingMethod
+		}
+
+		lines = append(lines, line)
+		if len(lines) > workflow.MaxBatchImportLines {
+			return nil, ErrBatchTooLarge
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch: %w", err)
+	}
+
+	return lines, nil
+}
+
+// parseCSV ожидает заголовок с колонками customer_id, product_id, quantity, price
+// и необязательными name, order_ref и полями заказа из csvOrderColumns: coupon_code,
+// tax_jurisdiction, shipping_method и адресами shipping_*/billing_* (shipping_line1, billing_country...).
+// Строки с одинаковым order_ref собираются в один заказ, без order_ref каждая строка — отдельный заказ.
+func parseCSV(r io.Reader) ([]workflow.BatchOrderLine, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyBatch
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header is missing required column %q", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var lines []workflow.BatchOrderLine
+	byReference := make(map[string]int)
+	firstRecords := make(map[int][]string)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		lineNumber, _ := reader.FieldPos(0)
+		reference := field(record, "order_ref")
+		customerID := field(record, "customer_id")
+
+		item, itemErr := parseCSVItem(field(record, "product_id"), field(record, "name"), field(record, "quantity"), field(record, "price"))
+
+		if idx, ok := byReference[reference]; ok && reference != "" {
+			line := &lines[idx]
+			switch {
+			case line.Error != "":
+			case itemErr != nil:
+				line.Error = fmt.Sprintf("line %d: %v", lineNumber, itemErr)
+			case line.CustomerID != customerID:
+				line.Error = fmt.Sprintf("line %d: customer_id differs within order_ref %q", lineNumber, reference)
+			default:
+				for _, name := range csvOrderColumns {
+					if value := field(record, name); value != "" && value != field(firstRecords[idx], name) {
+						line.Error = fmt.Sprintf("line %d: %s differs within order_ref %q", lineNumber, name, reference)
+						break
+					}
+				}
+				if line.Error != "" {
+					continue
+				}
+				line.Items = append(line.Items, item)
+			}
+			continue
+		}
+
+		line := workflow.BatchOrderLine{
+			Line:            lineNumber,
+			Reference:       reference,
+			CustomerID:      customerID,
+			CouponCode:      field(record, "coupon_code"),
+			TaxJurisdiction: field(record, "tax_jurisdiction"),
+			ShippingAddress: csvAddress(record, field, "shipping_"),
+			BillingAddress:  csvAddress(record, field, "billing_"),
+			ShippingMethod:  field(record, "shipping_method"),
+		}
+		if itemErr != nil {
+			line.Error = itemErr.Error()
+		} else {
+			line.Items = []order.Item{item}
+		}
+
+		if reference != "" {
+			byReference[reference] = len(lines)
+			firstRecords[len(lines)] = record
+		}
+		lines = append(lines, line)
+		if len(lines) > workflow.MaxBatchImportLines {
+			return nil, ErrBatchTooLarge
+		}
+	}
+
+	return lines, nil
+}
+
+// csvAddress собирает адрес из колонок с префиксом prefix; nil, если все они пусты.
+func csvAddress(record []string, field func([]string, string) string, prefix string) *order.Address {
+	address := &order.Address{
+		Name:       field(record, prefix+"name"),
+		Line1:      field(record, prefix+"line1"),
+		Line2:      field(record, prefix+"line2"),
+		City:       field(record, prefix+"city"),
+		Region:     field(record, prefix+"region"),
+		PostalCode: field(record, prefix+"postal_code"),
+		Country:    field(record, prefix+"country"),
+		Phone:      field(record, prefix+"phone"),
+	}
+	if *address == (order.Address{}) {
+		return nil
+	}
+	return address
+}
+
+func parseCSVItem(productID, name, quantity, price string) (order.Item, error) {
+	qty, err := strconv.Atoi(quantity)
+	if err != nil {
+		return order.Item{}, fmt.Errorf("invalid quantity %q", quantity)
+	}
+
+	amount, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return order.Item{}, fmt.Errorf("invalid price %q", price)
+	}
+
+	return order.Item{
+		ProductID: productID,
+		Name:      name,
+		Quantity:  qty,
+		Price:     amount,
+	}, nil
+}
+
+// validateLine проверяет заказ строки и нормализует её адреса, как POST /api/orders.
+func validateLine(line *workflow.BatchOrderLine) string {
+	if err := order.NewOrder(line.CustomerID, line.Items).Validate(); err != nil {
+		return validationMessage(err)
+	}
+	for _, address := range []*order.Address{line.ShippingAddress, line.BillingAddress} {
+		if address == nil {
+			continue
+		}
+		address.Normalize()
+		if err := address.Validate(); err != nil {
+			return validationMessage(err)
+		}
+	}
+	return ""
+}
+
+func validationMessage(err error) string {
+	var validationErr *order.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Message
+	}
+	return err.Error()
+}
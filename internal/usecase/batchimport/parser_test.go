@@ -0,0 +1,235 @@
+package batchimport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"orderflow/internal/domain/workflow"
+)
+
+func ndjsonBatch(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `{"customer_id":"c-%d","items":[{"product_id":"p-1","quantity":1,"price":10}]}`+"\n", i)
+	}
+	return b.String()
+}
+
+func csvBatch(n int) string {
+	var b strings.Builder
+	b.WriteString("customer_id,product_id,quantity,price\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "c-%d,p-1,1,10\n", i)
+	}
+	return b.String()
+}
+
+func TestParseLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		format  Format
+		wantErr error
+	}{
+		{"ndjson at limit", ndjsonBatch(workflow.MaxBatchImportLines), FormatNDJSON, nil},
+		{"ndjson over limit", ndjsonBatch(workflow.MaxBatchImportLines + 1), FormatNDJSON, ErrBatchTooLarge},
+		{"csv at limit", csvBatch(workflow.MaxBatchImportLines), FormatCSV, nil},
+		{"csv over limit", csvBatch(workflow.MaxBatchImportLines + 1), FormatCSV, ErrBatchTooLarge},
+		{"ndjson empty", "", FormatNDJSON, ErrEmptyBatch},
+		{"ndjson only blank lines", "\n  \n\n", FormatNDJSON, ErrEmptyBatch},
+		{"csv empty", "", FormatCSV, ErrEmptyBatch},
+		{"csv header only", "customer_id,product_id,quantity,price\n", FormatCSV, ErrEmptyBatch},
+		{"unknown format", ndjsonBatch(1), Format("xml"), ErrUnknownFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := Parse(strings.NewReader(tt.input), tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && len(lines) != workflow.MaxBatchImportLines {
+				t.Errorf("Parse() returned %d lines, want %d", len(lines), workflow.MaxBatchImportLines)
+			}
+		})
+	}
+}
+
+func TestParseCSVMissingColumn(t *testing.T) {
+	_, err := Parse(strings.NewReader("customer_id,product_id,quantity\nc-1,p-1,1\n"), FormatCSV)
+	if err == nil || !strings.Contains(err.Error(), `"price"`) {
+		t.Fatalf("Parse() error = %v, want missing price column", err)
+	}
+}
+
+func TestParseMalformedRows(t *testing.T) {
+	type wantLine struct {
+		line  int
+		error string
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+		want   []wantLine
+	}{
+		{
+			name: "ndjson keeps physical line numbers",
+			input: `{"customer_id":"c-1","items":[{"product_id":"p-1","quantity":1,"price":10}]}` + "\n" +
+				"\n" +
+				`{"customer_id":` + "\n" +
+				`{"customer_id":"c-2","items":[{"product_id":"p-1","quantity":0,"price":10}]}` + "\n" +
+				`{"items":[{"product_id":"p-1","quantity":1,"price":10}]}` + "\n",
+			format: FormatNDJSON,
+			want: []wantLine{
+				{line: 1},
+				{line: 3, error: "invalid JSON"},
+				{line: 4, error: "quantity must be positive"},
+				{line: 5, error: "customer_id is required"},
+			},
+		},
+		{
+			name: "csv row errors",
+			input: "customer_id,product_id,quantity,price\n" +
+				"c-1,p-1,two,10\n" +
+				"c-2,p-1,1,ten\n" +
+				"c-3,,1,10\n",
+			format: FormatCSV,
+			want: []wantLine{
+				{line: 2, error: `invalid quantity "two"`},
+				{line: 3, error: `invalid price "ten"`},
+				{line: 4, error: "product_id is required for all items"},
+			},
+		},
+		{
+			name: "csv order_ref errors name the offending row",
+			input: "order_ref,customer_id,product_id,quantity,price\n" +
+				"A,c-1,p-1,1,10\n" +
+				"A,c-1,p-2,x,10\n" +
+				"B,c-2,p-1,1,10\n" +
+				"B,c-3,p-2,1,10\n",
+			format: FormatCSV,
+			want: []wantLine{
+				{line: 2, error: `line 3: invalid quantity "x"`},
+				{line: 4, error: `line 5: customer_id differs within order_ref "B"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := Parse(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if len(lines) != len(tt.want) {
+				t.Fatalf("Parse() returned %d lines, want %d: %+v", len(lines), len(tt.want), lines)
+			}
+			for i, want := range tt.want {
+				got := lines[i]
+				if got.Line != want.line {
+					t.Errorf("lines[%d].Line = %d, want %d", i, got.Line, want.line)
+				}
+				if want.error == "" && got.Error != "" {
+					t.Errorf("lines[%d].Error = %q, want none", i, got.Error)
+				}
+				if want.error != "" && !strings.Contains(got.Error, want.error) {
+					t.Errorf("lines[%d].Error = %q, want it to contain %q", i, got.Error, want.error)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOrderFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+	}{
+		{
+			name: "ndjson",
+			input: `{"customer_id":"c-1","items":[{"product_id":"p-1","quantity":1,"price":10}],` +
+				`"coupon_code":"WELCOME10","tax_jurisdiction":"US-CA","shipping_method":"ground",` +
+				`"shipping_address":{"name":"Jane Doe","line1":"1 Market St","city":"San Francisco","region":"ca","postal_code":"94105","country":"us"}}` + "\n",
+			format: FormatNDJSON,
+		},
+		{
+			name: "csv order_ref takes order fields from any row",
+			input: "order_ref,customer_id,product_id,quantity,price,coupon_code,tax_jurisdiction,shipping_method," +
+				"shipping_name,shipping_line1,shipping_city,shipping_region,shipping_postal_code,shipping_country\n" +
+				"A,c-1,p-1,1,10,WELCOME10,US-CA,ground,Jane Doe,1 Market St,San Francisco,ca,94105,us\n" +
+				"A,c-1,p-2,1,10,,,,,,,,,\n",
+			format: FormatCSV,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := Parse(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if len(lines) != 1 || lines[0].Error != "" {
+				t.Fatalf("Parse() = %+v, want one valid line", lines)
+			}
+			line := lines[0]
+			if line.CouponCode != "WELCOME10" || line.TaxJurisdiction != "US-CA" || line.ShippingMethod != "ground" {
+				t.Errorf("order fields = %q/%q/%q, want WELCOME10/US-CA/ground", line.CouponCode, line.TaxJurisdiction, line.ShippingMethod)
+			}
+			if line.ShippingAddress == nil || line.ShippingAddress.Country != "US" || line.ShippingAddress.Region != "CA" {
+				t.Errorf("shipping address = %+v, want normalized US/CA address", line.ShippingAddress)
+			}
+			if line.BillingAddress != nil {
+				t.Errorf("billing address = %+v, want none without billing columns", line.BillingAddress)
+			}
+		})
+	}
+}
+
+func TestParseOrderFieldErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+		error  string
+	}{
+		{
+			name: "ndjson invalid address",
+			input: `{"customer_id":"c-1","items":[{"product_id":"p-1","quantity":1,"price":10}],` +
+				`"shipping_address":{"name":"Jane Doe","line1":"1 Market St","city":"San Francisco","postal_code":"94105","country":"USA"}}` + "\n",
+			format: FormatNDJSON,
+			error:  "address country must be an ISO 3166-1 alpha-2 code",
+		},
+		{
+			name: "csv incomplete address",
+			input: "customer_id,product_id,quantity,price,billing_name,billing_country\n" +
+				"c-1,p-1,1,10,Jane Doe,DE\n",
+			format: FormatCSV,
+			error:  "address line1 is required",
+		},
+		{
+			name: "csv coupon differs within order_ref",
+			input: "order_ref,customer_id,product_id,quantity,price,coupon_code\n" +
+				"A,c-1,p-1,1,10,WELCOME10\n" +
+				"A,c-1,p-2,1,10,SUMMER\n",
+			format: FormatCSV,
+			error:  `line 3: coupon_code differs within order_ref "A"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := Parse(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if len(lines) != 1 || !strings.Contains(lines[0].Error, tt.error) {
+				t.Fatalf("Parse() = %+v, want one line with error %q", lines, tt.error)
+			}
+		})
+	}
+}
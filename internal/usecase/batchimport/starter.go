@@ -0,0 +1,69 @@
+package batchimport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+
+	"orderflow/internal/domain/workflow"
+)
+
+var ErrBatchPayloadTooLarge = fmt.Errorf("batch exceeds %d bytes once encoded for the workflow", workflow.MaxBatchImportPayloadBytes)
+
+// Start запускает BatchOrderImportWorkflow для уже разобранных строк. Пакет, который
+// не помещается во вход workflow, не запускается — ErrBatchPayloadTooLarge.
+func Start(ctx context.Context, temporalClient client.Client, lines []workflow.BatchOrderLine, concurrency int) (client.WorkflowRun, *workflow.BatchImportInput, error) {
+	input := &workflow.BatchImportInput{
+		BatchID:     uuid.New().String(),
+		Lines:       lines,
+		Concurrency: concurrency,
+	}
+	if err := checkPayloadSize(input); err != nil {
+		return nil, nil, err
+	}
+
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        WorkflowID(input.BatchID),
+		TaskQueue: workflow.OrderProcessingTaskQueue,
+	}
+
+	workflowRun, err := temporalClient.ExecuteWorkflow(ctx, workflowOptions, workflow.BatchOrderImportWorkflow, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return workflowRun, input, nil
+}
+
+// Progress возвращает текущий отчёт пакета через query.
+func Progress(ctx context.Context, temporalClient client.Client, batchID string) (*workflow.BatchImportReport, error) {
+	encoded, err := temporalClient.QueryWorkflow(ctx, WorkflowID(batchID), "", workflow.BatchImportProgressQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var report workflow.BatchImportReport
+	if err := encoded.Get(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func WorkflowID(batchID string) string {
+	return workflow.BatchImportWorkflowIDPrefix + batchID
+}
+
+// checkPayloadSize кодирует вход так же, как клиент Temporal при старте workflow.
+func checkPayloadSize(input *workflow.BatchImportInput) error {
+	payload, err := converter.GetDefaultDataConverter().ToPayload(input)
+	if err != nil {
+		return err
+	}
+	if len(payload.GetData()) > workflow.MaxBatchImportPayloadBytes {
+		return ErrBatchPayloadTooLarge
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package batchimport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/workflow"
+)
+
+func batchInput(lines, itemsPerLine int) *workflow.BatchImportInput {
+	input := &workflow.BatchImportInput{BatchID: "batch-1"}
+	for i := 0; i < lines; i++ {
+		line := workflow.BatchOrderLine{Line: i + 1, CustomerID: "customer-1"}
+		for j := 0; j < itemsPerLine; j++ {
+			line.Items = append(line.Items, order.Item{ProductID: "prod-1", Name: strings.Repeat("x", 40), Quantity: 1, Price: 10})
+		}
+		input.Lines = append(input.Lines, line)
+	}
+	return input
+}
+
+func TestCheckPayloadSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   *workflow.BatchImportInput
+		wantErr error
+	}{
+		{"max lines with one item", batchInput(workflow.MaxBatchImportLines, 1), nil},
+		{"max lines with many items", batchInput(workflow.MaxBatchImportLines, 20), ErrBatchPayloadTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkPayloadSize(tt.input); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkPayloadSize() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
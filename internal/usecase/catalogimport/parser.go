@@ -0,0 +1,119 @@
+package catalogimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"orderflow/internal/domain/inventory"
+)
+
+// MaxRows ограничивает размер одного импорта каталога
+const MaxRows = 10000
+
+var (
+	ErrEmptyCatalog    = errors.New("catalog contains no products")
+	ErrCatalogTooLarge = fmt.Errorf("catalog exceeds %d products", MaxRows)
+	requiredColumns    = []string{"sku", "name", "price"}
+)
+
+// ParseCSV читает каталог с заголовком sku, name, price и необязательной колонкой available.
+// Пустое значение available не меняет остаток существующего товара. Ошибки в отдельных
+// строках не прерывают разбор: они попадают в CatalogRow.Error. Повтор SKU в файле — ошибка строки.
+func ParseCSV(r io.Reader) ([]inventory.CatalogRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyCatalog
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header is missing required column %q", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []inventory.CatalogRow
+	seen := make(map[string]int)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		lineNumber, _ := reader.FieldPos(0)
+		row := inventory.CatalogRow{
+			Line: lineNumber,
+			SKU:  field(record, "sku"),
+			Name: field(record, "name"),
+		}
+		row.Error = parseRow(&row, field(record, "price"), field(record, "available"))
+		if first, ok := seen[row.SKU]; ok && row.Error == "" {
+			row.Error = fmt.Sprintf("sku %s is repeated, first seen on line %d", row.SKU, first)
+		}
+		if row.SKU != "" {
+			if _, ok := seen[row.SKU]; !ok {
+				seen[row.SKU] = lineNumber
+			}
+		}
+
+		rows = append(rows, row)
+		if len(rows) > MaxRows {
+			return nil, ErrCatalogTooLarge
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyCatalog
+	}
+	return rows, nil
+}
+
+func parseRow(row *inventory.CatalogRow, price, available string) string {
+	if row.SKU == "" {
+		return "sku is required"
+	}
+	if row.Name == "" {
+		return "name is required"
+	}
+
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil || value < 0 {
+		return fmt.Sprintf("invalid price %q", price)
+	}
+	row.Price = value
+
+	if available != "" {
+		quantity, err := strconv.Atoi(available)
+		if err != nil || quantity < 0 {
+			return fmt.Sprintf("invalid available %q", available)
+		}
+		row.Available = &quantity
+	}
+	return ""
+}
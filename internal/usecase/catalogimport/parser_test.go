@@ -0,0 +1,99 @@
+package catalogimport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func catalog(n int) string {
+	var b strings.Builder
+	b.WriteString("sku,name,price,available\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "SKU-%d,Product %d,9.99,5\n", i, i)
+	}
+	return b.String()
+}
+
+func TestParseCSVLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantErr  error
+		wantRows int
+	}{
+		{name: "at limit", input: catalog(MaxRows), wantRows: MaxRows},
+		{name: "over limit", input: catalog(MaxRows + 1), wantErr: ErrCatalogTooLarge},
+		{name: "empty input", input: "", wantErr: ErrEmptyCatalog},
+		{name: "header only", input: "sku,name,price\n", wantErr: ErrEmptyCatalog},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseCSV(strings.NewReader(tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseCSV() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(rows) != tt.wantRows {
+				t.Errorf("ParseCSV() returned %d rows, want %d", len(rows), tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestParseCSVMissingColumn(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("sku,price\nSKU-1,10\n"))
+	if err == nil || !strings.Contains(err.Error(), `"name"`) {
+		t.Fatalf("ParseCSV() error = %v, want missing name column", err)
+	}
+}
+
+func TestParseCSVMalformedRows(t *testing.T) {
+	input := "sku,name,price,available\n" +
+		"SKU-1,Mug,9.99,\n" +
+		",Cup,5,1\n" +
+		"SKU-2,,5,1\n" +
+		"SKU-3,Plate,free,1\n" +
+		"SKU-4,Bowl,-1,1\n" +
+		"SKU-5,Fork,2,-3\n" +
+		"SKU-1,Mug again,9.99,1\n" +
+		"SKU-6,Spoon,2,10\n"
+
+	want := []struct {
+		line  int
+		error string
+	}{
+		{line: 2},
+		{line: 3, error: "sku is required"},
+		{line: 4, error: "name is required"},
+		{line: 5, error: `invalid price "free"`},
+		{line: 6, error: `invalid price "-1"`},
+		{line: 7, error: `invalid available "-3"`},
+		{line: 8, error: "sku SKU-1 is repeated, first seen on line 2"},
+		{line: 9},
+	}
+
+	rows, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseCSV() unexpected error: %v", err)
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("ParseCSV() returned %d rows, want %d", len(rows), len(want))
+	}
+	for i, w := range want {
+		if rows[i].Line != w.line {
+			t.Errorf("rows[%d].Line = %d, want %d", i, rows[i].Line, w.line)
+		}
+		if rows[i].Error != w.error {
+			t.Errorf("rows[%d].Error = %q, want %q", i, rows[i].Error, w.error)
+		}
+	}
+
+	if rows[0].Available != nil {
+		t.Errorf("empty available should keep stock unchanged, got %d", *rows[0].Available)
+	}
+	if rows[7].Available == nil || *rows[7].Available != 10 || rows[7].Price != 2 {
+		t.Errorf("rows[7] = %+v, want price 2 and available 10", rows[7])
+	}
+}
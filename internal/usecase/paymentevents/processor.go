@@ -0,0 +1,108 @@
+package paymentevents
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/payment"
+	"orderflow/internal/domain/workflow"
+	"orderflow/pkg/logger"
+)
+
+// Processor обрабатывает callback-и платёжных провайдеров: проверяет подпись,
+// применяет событие к платежу и, если заказ ещё обрабатывается, сигналит его workflow.
+// Событие сохраняется до сигнала, поэтому повторный callback с тем же ID сигналит
+// workflow ещё раз: так сигнал, не дошедший в первый раз, доставляется при повторе.
+type Processor struct {
+	temporalClient client.Client
+	paymentService payment.Service
+	orderService   order.Service
+	parsers        map[string]payment.WebhookParser
+}
+
+func NewProcessor(
+	temporalClient client.Client,
+	paymentService payment.Service,
+	orderService order.Service,
+	parsers ...payment.WebhookParser,
+) *Processor {
+	byProvider := make(map[string]payment.WebhookParser, len(parsers))
+	for _, parser := range parsers {
+		byProvider[parser.Provider()] = parser
+	}
+	return &Processor{
+		temporalClient: temporalClient,
+		paymentService: paymentService,
+		orderService:   orderService,
+		parsers:        byProvider,
+	}
+}
+
+func (p *Processor) Process(ctx context.Context, provider string, headers http.Header, body []byte) (*payment.ProviderEventResult, error) {
+	parser, ok := p.parsers[provider]
+	if !ok {
+		return nil, payment.NewUnknownProviderError(provider)
+	}
+
+	event, err := parser.ParseWebhook(headers, body)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := p.paymentService.HandleProviderEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Changed || result.Duplicate {
+		if err := p.signalOrder(ctx, event, result.Payment); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// signalOrder возвращает ошибку, если workflow заказа не удалось просигналить: провайдер
+// получит 5xx и повторит callback. Завершённый workflow ошибкой не считается. Повторный
+// сигнал workflow безопасен — отзыв платежа он обрабатывает один раз.
+func (p *Processor) signalOrder(ctx context.Context, event *payment.ProviderEvent, paymentEntity *payment.Payment) error {
+	orderEntity, err := p.orderService.GetByID(ctx, paymentEntity.OrderID)
+	if err != nil {
+		logger.Error("Failed to load order for payment provider event", "error", err, "order_id", paymentEntity.OrderID)
+		return err
+	}
+	if orderEntity.WorkflowID == "" {
+		return nil
+	}
+
+	signal := &workflow.PaymentProviderEventSignalInput{
+		PaymentID:     paymentEntity.ID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		PaymentStatus: paymentEntity.Status,
+		Reason:        event.Reason,
+	}
+
+	err = p.temporalClient.SignalWorkflow(ctx, orderEntity.WorkflowID, "", workflow.PaymentProviderEventSignal, signal)
+	var notFound *serviceerror.NotFound
+	switch {
+	case errors.As(err, &notFound):
+		logger.Info("Order workflow already finished, payment provider event not signalled",
+			"workflow_id", orderEntity.WorkflowID,
+			"event_id", event.ID)
+	case err != nil:
+		logger.Error("Failed to signal order workflow", "error", err, "workflow_id", orderEntity.WorkflowID)
+		return err
+	default:
+		logger.Info("Payment provider event signalled to order workflow",
+			"workflow_id", orderEntity.WorkflowID,
+			"event_id", event.ID,
+			"payment_status", paymentEntity.Status)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package paymentevents
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+
+	"orderflow/internal/adapter/webapi"
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/payment"
+	"orderflow/internal/domain/workflow"
+	"orderflow/internal/usecase/service"
+	"orderflow/pkg/logger"
+)
+
+// journalPaymentRepo повторяет контракт ApplyProviderEvent: событие с уже записанным ID
+// возвращается с Duplicate=true и не меняет платёж.
+type journalPaymentRepo struct {
+	payment.Repository
+	payments map[string]*payment.Payment
+	journal  map[string]bool
+}
+
+func (r *journalPaymentRepo) ApplyProviderEvent(_ context.Context, event *payment.ProviderEvent) (*payment.ProviderEventResult, error) {
+	p, ok := r.payments[event.PaymentID]
+	if !ok {
+		return nil, payment.NewNotFoundError(event.PaymentID)
+	}
+
+	key := event.Provider + ":" + event.ID
+	result := &payment.ProviderEventResult{Payment: p, PreviousStatus: p.Status}
+	if r.journal[key] {
+		result.Duplicate = true
+		return result, nil
+	}
+	r.journal[key] = true
+
+	result.Changed = event.Apply(p)
+	return result, nil
+}
+
+type countingOrderService struct {
+	order.Service
+	workflowID string
+	lookups    int
+}
+
+func (s *countingOrderService) GetByID(_ context.Context, id string) (*order.Order, error) {
+	s.lookups++
+	return &order.Order{ID: id, WorkflowID: s.workflowID}, nil
+}
+
+// signalClient записывает сигналы workflow; пока failures > 0, SignalWorkflow возвращает ошибку.
+type signalClient struct {
+	client.Client
+	failures int
+	signals  []*workflow.PaymentProviderEventSignalInput
+}
+
+func (c *signalClient) SignalWorkflow(_ context.Context, _, _, _ string, arg interface{}) error {
+	if c.failures > 0 {
+		c.failures--
+		return serviceerror.NewUnavailable("frontend unavailable")
+	}
+	c.signals = append(c.signals, arg.(*workflow.PaymentProviderEventSignalInput))
+	return nil
+}
+
+func newTestProcessor(temporalClient client.Client, orders order.Service) *Processor {
+	repo := &journalPaymentRepo{
+		payments: map[string]*payment.Payment{
+			"pay_1": {ID: "pay_1", OrderID: "ord_1", Status: payment.StatusCompleted},
+		},
+		journal: make(map[string]bool),
+	}
+	provider := webapi.NewHMACPaymentProvider("acme", "whsec_test", time.Hour)
+	return NewProcessor(temporalClient, service.NewPaymentService(repo), orders, provider)
+}
+
+func TestProcessorDoesNotReapplyDuplicateEventID(t *testing.T) {
+	logger.Init("test")
+
+	orders := &countingOrderService{}
+	processor := newTestProcessor(nil, orders)
+
+	body := []byte(`{"id":"evt_1","type":"charge.disputed","created":1700000000,"data":{"payment_id":"pay_1","reason":"fraudulent"}}`)
+	headers := http.Header{}
+	headers.Set(webapi.PaymentSignatureHeader, sign("whsec_test", body))
+
+	first, err := processor.Process(context.Background(), "acme", headers, body)
+	if err != nil {
+		t.Fatalf("first Process() error: %v", err)
+	}
+	if !first.Changed || first.Duplicate || first.Payment.Status != payment.StatusDisputed {
+		t.Fatalf("first Process() = %+v, want changed to disputed", first)
+	}
+
+	second, err := processor.Process(context.Background(), "acme", headers, body)
+	if err != nil {
+		t.Fatalf("second Process() error: %v", err)
+	}
+	if !second.Duplicate || second.Changed {
+		t.Errorf("second Process() = %+v, want duplicate without changes", second)
+	}
+}
+
+func TestProcessorRedeliveryResignalsAfterSignalFailure(t *testing.T) {
+	logger.Init("test")
+
+	temporalClient := &signalClient{failures: 1}
+	processor := newTestProcessor(temporalClient, &countingOrderService{workflowID: "order-processing-1"})
+
+	body := []byte(`{"id":"evt_1","type":"charge.disputed","created":1700000000,"data":{"payment_id":"pay_1","reason":"fraudulent"}}`)
+	headers := http.Header{}
+	headers.Set(webapi.PaymentSignatureHeader, sign("whsec_test", body))
+
+	// Событие сохранено, но сигнал не ушёл: провайдер должен получить ошибку и повторить callback
+	if _, err := processor.Process(context.Background(), "acme", headers, body); err == nil {
+		t.Fatal("first Process() error = nil, want signal failure")
+	}
+
+	redelivered, err := processor.Process(context.Background(), "acme", headers, body)
+	if err != nil {
+		t.Fatalf("redelivered Process() error: %v", err)
+	}
+	if !redelivered.Duplicate {
+		t.Errorf("redelivered Process() = %+v, want duplicate", redelivered)
+	}
+	if len(temporalClient.signals) != 1 {
+		t.Fatalf("workflow signalled %d times, want 1", len(temporalClient.signals))
+	}
+	if signal := temporalClient.signals[0]; signal.EventID != "evt_1" || !signal.ReversesPayment() {
+		t.Errorf("signal = %+v, want reversal from evt_1", signal)
+	}
+}
+
+func sign(secret string, body []byte) string {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
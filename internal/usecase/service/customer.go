@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"orderflow/internal/domain/customer"
+	"orderflow/internal/domain/notification"
+	"orderflow/pkg/logger"
+)
+
+type CustomerService struct {
+	customerRepo customer.Repository
+}
+
+func NewCustomerService(customerRepo customer.Repository) *CustomerService {
+	return &CustomerService{customerRepo: customerRepo}
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, id string) (*customer.Customer, error) {
+	if id == "" {
+		return nil, customer.NewValidationError("customer_id is required")
+	}
+	return s.customerRepo.GetCustomer(ctx, id)
+}
+
+func (s *CustomerService) SaveCustomer(ctx context.Context, id string, req *customer.SaveRequest) (*customer.Customer, error) {
+	now := time.Now()
+	customerEntity := &customer.Customer{
+		ID:                id,
+		Name:              req.Name,
+		Locale:            req.Locale,
+		Contacts:          req.Contacts,
+		PreferredChannels: req.PreferredChannels,
+		Consents:          req.Consents,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if customerEntity.Locale == "" {
+		customerEntity.Locale = customer.DefaultLocale
+	}
+	if customerEntity.Contacts == nil {
+		customerEntity.Contacts = []customer.ContactPoint{}
+	}
+	if customerEntity.PreferredChannels == nil {
+		customerEntity.PreferredChannels = []notification.Channel{}
+	}
+	if customerEntity.Consents == nil {
+		customerEntity.Consents = map[notification.Type]bool{}
+	}
+
+	if err := customerEntity.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.customerRepo.SaveCustomer(ctx, customerEntity); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Customer contacts saved",
+		"customer_id", id,
+		"contacts", len(customerEntity.Contacts),
+		"preferred_channels", customerEntity.PreferredChannels)
+	return s.customerRepo.GetCustomer(ctx, id)
+}
+
+func (s *CustomerService) DeleteCustomer(ctx context.Context, id string) error {
+	if id == "" {
+		return customer.NewValidationError("customer_id is required")
+	}
+	return s.customerRepo.DeleteCustomer(ctx, id)
+}
+
+func (s *CustomerService) SetConsent(ctx context.Context, id string, notificationType notification.Type, optedIn bool) (*customer.Customer, error) {
+	if id == "" {
+		return nil, customer.NewValidationError("customer_id is required")
+	}
+	if !notificationType.IsValid() {
+		return nil, customer.NewValidationError("unknown notification type: " + string(notificationType))
+	}
+
+	if err := s.customerRepo.SetConsent(ctx, id, notificationType, optedIn); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Customer consent updated", "customer_id", id, "type", notificationType, "opted_in", optedIn)
+	return s.customerRepo.GetCustomer(ctx, id)
+}
+
+// ResolveRecipient реализует notification.RecipientResolver. Клиенты, которых нет в справочнике,
+// получают уведомления как раньше: по указанному каналу или по email, а адрес ищет отправщик.
+func (s *CustomerService) ResolveRecipient(ctx context.Context, customerID string, notificationType notification.Type, channel notification.Channel) (*notification.Recipient, error) {
+	customerEntity, err := s.customerRepo.GetCustomer(ctx, customerID)
+	var notFound *customer.NotFoundError
+	if errors.As(err, &notFound) {
+		if channel == "" {
+			channel = notification.ChannelEmail
+		}
+		return &notification.Recipient{Channel: channel, Locale: customer.DefaultLocale}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return customerEntity.Route(notificationType, channel)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"orderflow/internal/domain/customer"
+	"orderflow/internal/domain/notification"
+)
+
+// memoryCustomerRepo отдаёт клиентов из map; отсутствующий клиент — customer.NotFoundError.
+type memoryCustomerRepo struct {
+	customer.Repository
+	customers map[string]*customer.Customer
+	err       error
+}
+
+func (r *memoryCustomerRepo) GetCustomer(ctx context.Context, id string) (*customer.Customer, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	c, ok := r.customers[id]
+	if !ok {
+		return nil, customer.NewNotFoundError(id)
+	}
+	return c, nil
+}
+
+func TestCustomerServiceResolveRecipient(t *testing.T) {
+	repo := &memoryCustomerRepo{customers: map[string]*customer.Customer{
+		"customer-1": {
+			ID:       "customer-1",
+			Locale:   "ru",
+			Contacts: []customer.ContactPoint{{Channel: notification.ChannelSMS, Address: "+15555550100"}},
+			Consents: map[notification.Type]bool{notification.TypeOrderConfirmed: false},
+		},
+	}}
+	svc := NewCustomerService(repo)
+
+	tests := []struct {
+		name       string
+		customerID string
+		typ        notification.Type
+		channel    notification.Channel
+		want       *notification.Recipient
+		wantErr    error
+	}{
+		{"unknown customer falls back to email", "customer-404", notification.TypeOrderConfirmed, "",
+			&notification.Recipient{Channel: notification.ChannelEmail, Locale: customer.DefaultLocale}, nil},
+		{"unknown customer keeps explicit channel", "customer-404", notification.TypeOrderConfirmed, notification.ChannelSMS,
+			&notification.Recipient{Channel: notification.ChannelSMS, Locale: customer.DefaultLocale}, nil},
+		{"known customer routed by contacts", "customer-1", notification.TypeOrderFailed, "",
+			&notification.Recipient{Channel: notification.ChannelSMS, Address: "+15555550100", Locale: "ru"}, nil},
+		{"opted out", "customer-1", notification.TypeOrderConfirmed, "",
+			nil, notification.NewOptedOutError("customer-1", notification.TypeOrderConfirmed)},
+		{"missing recipient in channel", "customer-1", notification.TypeOrderFailed, notification.ChannelEmail,
+			nil, notification.NewRecipientNotFoundError("customer-1", notification.ChannelEmail)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.ResolveRecipient(context.Background(), tt.customerID, tt.typ, tt.channel)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("ResolveRecipient() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveRecipient() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomerServiceResolveRecipientRepositoryError(t *testing.T) {
+	repoErr := errors.New("connection refused")
+	svc := NewCustomerService(&memoryCustomerRepo{err: repoErr})
+
+	// Ошибка справочника не должна превращаться в отправку по email без адреса
+	if _, err := svc.ResolveRecipient(context.Background(), "customer-1", notification.TypeOrderFailed, ""); !errors.Is(err, repoErr) {
+		t.Errorf("ResolveRecipient() error = %v, want %v", err, repoErr)
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,13 +12,29 @@ import (
 	"orderflow/pkg/logger"
 )
 
+// Сколько раз ReserveItems пересчитывает распределение, если остаток склада успели
+// зарезервировать между чтением остатков и резервированием
+const maxAllocationAttempts = 3
+
 type InventoryService struct {
-	inventoryRepo inventory.Repository
+	inventoryRepo  inventory.Repository
+	reservationTTL time.Duration
+	allocator      inventory.AllocationStrategy
 }
 
-func NewInventoryService(inventoryRepo inventory.Repository) *InventoryService {
+// NewInventoryService — allocator == nil означает inventory.DefaultAllocationStrategy.
+func NewInventoryService(inventoryRepo inventory.Repository, reservationTTL time.Duration, allocator inventory.AllocationStrategy) *InventoryService {
+	if reservationTTL <= 0 {
+		reservationTTL = inventory.DefaultReservationTTL
+	}
+	if allocator == nil {
+		allocator, _ = inventory.NewAllocationStrategy(inventory.DefaultAllocationStrategy)
+	}
+
 	return &InventoryService{
-		inventoryRepo: inventoryRepo,
+		inventoryRepo:  inventoryRepo,
+		reservationTTL: reservationTTL,
+		allocator:      allocator,
 	}
 }
 
@@ -31,38 +49,25 @@ func (service *InventoryService) CheckAvailability(ctx context.Context, req *inv
 		return nil, inventory.NewValidationError("items are required")
 	}
 
-	response := &inventory.CheckResponse{
-		Available:        true,
-		UnavailableItems: make([]inventory.UnavailableItem, 0),
+	items := make([]inventory.ReserveItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = inventory.ReserveItem{ProductID: item.ProductID, Quantity: item.Quantity}
 	}
 
-	for _, item := range req.Items {
-		product, err := service.inventoryRepo.GetProduct(ctx, item.ProductID)
-		if err != nil {
-			if _, ok := err.(*inventory.ProductNotFoundError); ok {
-				response.Available = false
-				response.UnavailableItems = append(response.UnavailableItems, inventory.UnavailableItem{
-					ProductID:         item.ProductID,
-					RequestedQuantity: item.Quantity,
-					AvailableQuantity: 0,
-				})
-				continue
-			}
-			return nil, err
-		}
+	allocation, err := service.allocate(ctx, items, req.Destination)
+	if err != nil {
+		return nil, err
+	}
 
-		if !product.IsAvailable(item.Quantity) {
-			response.Available = false
-			response.UnavailableItems = append(response.UnavailableItems, inventory.UnavailableItem{
-				ProductID:         item.ProductID,
-				RequestedQuantity: item.Quantity,
-				AvailableQuantity: product.Available,
-			})
-		}
+	response := &inventory.CheckResponse{
+		Available:        allocation.IsComplete(),
+		UnavailableItems: make([]inventory.UnavailableItem, 0, len(allocation.Unavailable)),
 	}
+	response.UnavailableItems = append(response.UnavailableItems, allocation.Unavailable...)
 
 	if response.Available {
-		logger.Info("Inventory check passed", "order_id", req.OrderID)
+		response.Allocation = allocation
+		logger.Info("Inventory check passed", "order_id", req.OrderID, "warehouses", allocation.Warehouses())
 	} else {
 		logger.Warn("Inventory check failed", "order_id", req.OrderID, "unavailable_items", response.UnavailableItems)
 	}
@@ -70,59 +75,122 @@ func (service *InventoryService) CheckAvailability(ctx context.Context, req *inv
 	return response, nil
 }
 
-func (service *InventoryService) ReserveItems(ctx context.Context, req *inventory.ReserveRequest) error {
+func (service *InventoryService) ReserveItems(ctx context.Context, req *inventory.ReserveRequest) (*inventory.Allocation, error) {
 	logger.Info("Reserving items", "order_id", req.OrderID, "items_count", len(req.Items))
 
 	if req.OrderID == "" {
-		return inventory.NewValidationError("order_id is required")
+		return nil, inventory.NewValidationError("order_id is required")
 	}
 
 	if len(req.Items) == 0 {
-		return inventory.NewValidationError("items are required")
+		return nil, inventory.NewValidationError("items are required")
 	}
 
-	for _, item := range req.Items {
-		product, err := service.inventoryRepo.GetProduct(ctx, item.ProductID)
+	for attempt := 1; ; attempt++ {
+		allocation, err := service.allocate(ctx, req.Items, req.Destination)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if !allocation.IsComplete() {
+			item := allocation.Unavailable[0]
+			return nil, inventory.NewInsufficientStockError(item.ProductID, item.RequestedQuantity, item.AvailableQuantity)
 		}
 
-		if !product.CanReserve(item.Quantity) {
-			return inventory.NewInsufficientStockError(item.ProductID, item.Quantity, product.Available-product.Reserved)
+		now := time.Now()
+		reservations := make([]*inventory.Reservation, 0, len(allocation.Lines))
+		for _, line := range allocation.Lines {
+			reservations = append(reservations, &inventory.Reservation{
+				ID:          uuid.New().String(),
+				OrderID:     req.OrderID,
+				ProductID:   line.ProductID,
+				WarehouseID: line.WarehouseID,
+				Quantity:    line.Quantity,
+				ExpiresAt:   now.Add(service.reservationTTL),
+				CreatedAt:   now,
+			})
 		}
-	}
 
-	for _, item := range req.Items {
-		product, err := service.inventoryRepo.GetProduct(ctx, item.ProductID)
+		err = service.inventoryRepo.ReserveStock(ctx, reservations)
+		var stockErr *inventory.InsufficientStockError
+		if errors.As(err, &stockErr) && attempt < maxAllocationAttempts {
+			logger.Warn("Stock changed during allocation, retrying", "order_id", req.OrderID, "product_id", stockErr.ProductID, "attempt", attempt)
+			continue
+		}
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		if err := product.Reserve(item.Quantity); err != nil {
-			return err
+		logger.Info("Items reserved successfully",
+			"order_id", req.OrderID,
+			"strategy", allocation.Strategy,
+			"warehouses", allocation.Warehouses())
+		return allocation, nil
+	}
+}
+
+// allocate раскрывает наборы и распределяет позиции по активным складам. Отсутствующие
+// и удалённые товары не получают остатков и попадают в Allocation.Unavailable.
+func (service *InventoryService) allocate(ctx context.Context, items []inventory.ReserveItem, destination *inventory.GeoPoint) (*inventory.Allocation, error) {
+	products := make(map[string]*inventory.Product)
+	if err := service.loadProducts(ctx, items, products); err != nil {
+		return nil, err
+	}
+	expanded, bundles, err := inventory.ExpandBundles(items, products)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.loadProducts(ctx, expanded, products); err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]string, 0, len(products))
+	for _, item := range expanded {
+		product, ok := products[item.ProductID]
+		if ok && !product.IsDeleted() && product.HasStock() {
+			productIDs = append(productIDs, product.ID)
 		}
+	}
 
-		if err := service.inventoryRepo.UpdateProduct(ctx, product); err != nil {
-			return err
+	warehouses, err := service.inventoryRepo.GetWarehouses(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var stock []*inventory.StockLevel
+	if len(productIDs) > 0 {
+		stock, err = service.inventoryRepo.GetStockLevels(ctx, productIDs)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		reservation := &inventory.Reservation{
-			ID:        uuid.New().String(),
-			OrderID:   req.OrderID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			ExpiresAt: time.Now().Add(30 * time.Minute), // Резервирование на 30 минут
-			CreatedAt: time.Now(),
+	allocation := service.allocator.Allocate(&inventory.AllocationRequest{
+		Items:       expanded,
+		Destination: destination,
+		Warehouses:  warehouses,
+		Stock:       stock,
+	})
+	allocation.Bundles = bundles
+	return allocation, nil
+}
+
+// loadProducts дополняет products товарами позиций; отсутствующие товары пропускаются.
+func (service *InventoryService) loadProducts(ctx context.Context, items []inventory.ReserveItem, products map[string]*inventory.Product) error {
+	for _, item := range items {
+		if _, ok := products[item.ProductID]; ok {
+			continue
 		}
 
-		if err := service.inventoryRepo.CreateReservation(ctx, reservation); err != nil {
-			product.ReleaseReservation(item.Quantity)
-			service.inventoryRepo.UpdateProduct(ctx, product)
+		product, err := service.inventoryRepo.GetProduct(ctx, item.ProductID)
+		var notFoundErr *inventory.ProductNotFoundError
+		if errors.As(err, &notFoundErr) {
+			continue
+		}
+		if err != nil {
 			return err
 		}
+		products[product.ID] = product
 	}
-
-	logger.Info("Items reserved successfully", "order_id", req.OrderID)
 	return nil
 }
 
@@ -133,7 +201,7 @@ func (service *InventoryService) ReleaseReservation(ctx context.Context, orderID
 		return inventory.NewValidationError("order_id is required")
 	}
 
-	reservation, err := service.inventoryRepo.GetReservationByOrderID(ctx, orderID)
+	reservations, err := service.inventoryRepo.ReleaseReservations(ctx, orderID)
 	if err != nil {
 		if _, ok := err.(*inventory.ReservationNotFoundError); ok {
 			logger.Warn("Reservation not found", "order_id", orderID)
@@ -142,139 +210,532 @@ func (service *InventoryService) ReleaseReservation(ctx context.Context, orderID
 		return err
 	}
 
-	product, err := service.inventoryRepo.GetProduct(ctx, reservation.ProductID)
+	logger.Info("Reservation released", "order_id", orderID, "reservations", len(reservations))
+	return nil
+}
+
+func (service *InventoryService) ConfirmReservation(ctx context.Context, orderID string) error {
+	logger.Info("Confirming reservation", "order_id", orderID)
+
+	if orderID == "" {
+		return inventory.NewValidationError("order_id is required")
+	}
+
+	reservations, err := service.inventoryRepo.ConfirmReservations(ctx, orderID)
 	if err != nil {
 		return err
 	}
 
-	product.ReleaseReservation(reservation.Quantity)
-	if err := service.inventoryRepo.UpdateProduct(ctx, product); err != nil {
+	logger.Info("Reservation confirmed", "order_id", orderID, "reservations", len(reservations))
+	return nil
+}
+
+func (service *InventoryService) GetProduct(ctx context.Context, productID string) (*inventory.Product, error) {
+	if productID == "" {
+		return nil, inventory.NewValidationError("product_id is required")
+	}
+
+	return service.inventoryRepo.GetProduct(ctx, productID)
+}
+
+func (service *InventoryService) UpdateStock(ctx context.Context, productID string, quantity int) error {
+	logger.Info("Updating stock", "product_id", productID, "quantity", quantity)
+
+	if productID == "" {
+		return inventory.NewValidationError("product_id is required")
+	}
+
+	product, err := service.inventoryRepo.GetProduct(ctx, productID)
+	if err != nil {
 		return err
 	}
+	if quantity == product.Available {
+		return nil
+	}
 
-	if err := service.inventoryRepo.DeleteReservation(ctx, orderID); err != nil {
+	// Абсолютный остаток записывается в журнал как корректировка на разницу
+	_, err = service.AdjustStock(ctx, productID, &inventory.AdjustStockRequest{
+		Delta:  quantity - product.Available,
+		Reason: inventory.ReasonCorrection,
+	})
+	if err != nil {
 		return err
 	}
 
-	logger.Info("Reservation released", "order_id", orderID, "reservation_id", reservation.ID)
+	logger.Info("Stock updated", "product_id", productID, "new_quantity", quantity)
 	return nil
 }
 
-func (service *InventoryService) ConfirmReservation(ctx context.Context, orderID string) error {
-	logger.Info("Confirming reservation", "order_id", orderID)
+func (service *InventoryService) ListProducts(ctx context.Context, includeDeleted bool) ([]*inventory.Product, error) {
+	return service.inventoryRepo.GetProducts(ctx, includeDeleted)
+}
 
-	if orderID == "" {
-		return inventory.NewValidationError("order_id is required")
+func (service *InventoryService) CreateProduct(ctx context.Context, req *inventory.CreateProductRequest) (*inventory.Product, error) {
+	now := time.Now()
+	product := &inventory.Product{
+		ID:           strings.TrimSpace(req.ID),
+		Name:         strings.TrimSpace(req.Name),
+		SKU:          strings.TrimSpace(req.SKU),
+		Price:        req.Price,
+		Available:    req.Available,
+		ReorderPoint: req.ReorderPoint,
+		TaxClass:     strings.TrimSpace(req.TaxClass),
+		Weight:       req.Weight,
+		Kind:         req.Kind,
+		ParentID:     strings.TrimSpace(req.ParentID),
+		Attributes:   req.Attributes,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if product.ID == "" {
+		product.ID = uuid.New().String()
+	}
+	if product.Kind == "" {
+		product.Kind = inventory.KindSimple
+	}
+	if product.TaxClass == "" {
+		product.TaxClass = inventory.DefaultTaxClass
+	}
+	if len(req.Components) > 0 {
+		components, err := service.resolveComponents(ctx, req.Components)
+		if err != nil {
+			return nil, err
+		}
+		product.Components = components
+	}
+	if err := inventory.ValidateProduct(product); err != nil {
+		return nil, err
+	}
+	if err := service.checkVariant(ctx, product); err != nil {
+		return nil, err
+	}
+
+	if err := service.inventoryRepo.CreateProduct(ctx, product); err != nil {
+		return nil, err
 	}
 
-	reservation, err := service.inventoryRepo.GetReservationByOrderID(ctx, orderID)
+	logger.Info("Product created", "product_id", product.ID, "sku", product.SKU, "kind", product.Kind, "available", product.Available)
+	return product, nil
+}
+
+func (service *InventoryService) UpdateProduct(ctx context.Context, productID string, req *inventory.UpdateProductRequest) (*inventory.Product, error) {
+	product, err := service.getActiveProduct(ctx, productID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if req.Name != nil {
+		product.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.SKU != nil {
+		product.SKU = strings.TrimSpace(*req.SKU)
+	}
+	if req.Price != nil {
+		product.Price = *req.Price
+	}
+	if req.ReorderPoint != nil {
+		product.ReorderPoint = *req.ReorderPoint
+	}
+	if req.TaxClass != nil {
+		product.TaxClass = strings.TrimSpace(*req.TaxClass)
+	}
+	if req.Weight != nil {
+		product.Weight = *req.Weight
+	}
+	if req.Attributes != nil {
+		product.Attributes = req.Attributes
+	}
+	if req.Components != nil {
+		components, err := service.resolveComponents(ctx, req.Components)
+		if err != nil {
+			return nil, err
+		}
+		product.Components = components
+	}
+	if err := inventory.ValidateProduct(product); err != nil {
+		return nil, err
+	}
+	if req.Attributes != nil {
+		if err := service.checkVariant(ctx, product); err != nil {
+			return nil, err
+		}
+	}
+	product.UpdatedAt = time.Now()
+
+	if err := service.inventoryRepo.UpdateProduct(ctx, product); err != nil {
+		return nil, err
 	}
 
-	product, err := service.inventoryRepo.GetProduct(ctx, reservation.ProductID)
+	logger.Info("Product updated", "product_id", product.ID, "sku", product.SKU)
+	return product, nil
+}
+
+// ListVariants возвращает варианты карточки товара.
+func (service *InventoryService) ListVariants(ctx context.Context, productID string) ([]*inventory.Product, error) {
+	product, err := service.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Kind != inventory.KindParent {
+		return []*inventory.Product{}, nil
+	}
+	return service.inventoryRepo.GetVariants(ctx, product.ID)
+}
+
+// resolveComponents находит компоненты набора по SKU. Компонентом может быть только
+// товар в продаже со своими остатками, в том числе вариант.
+func (service *InventoryService) resolveComponents(ctx context.Context, requests []inventory.ComponentRequest) ([]inventory.BundleComponent, error) {
+	components := make([]inventory.BundleComponent, 0, len(requests))
+	for _, req := range requests {
+		sku := strings.TrimSpace(req.SKU)
+		product, err := service.inventoryRepo.GetProductBySKU(ctx, sku)
+		var notFoundErr *inventory.ProductNotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil, inventory.NewValidationError("unknown bundle component: " + sku)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if product.IsDeleted() || !product.HasStock() {
+			return nil, inventory.NewValidationError("bundle component must be an active product with own stock: " + sku)
+		}
+
+		components = append(components, inventory.BundleComponent{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Quantity:  req.Quantity,
+		})
+	}
+	return components, nil
+}
+
+// checkVariant проверяет, что родитель варианта — карточка с вариантами в продаже и что
+// у других её вариантов другие атрибуты.
+func (service *InventoryService) checkVariant(ctx context.Context, product *inventory.Product) error {
+	if !product.IsVariant() {
+		return nil
+	}
+
+	parent, err := service.inventoryRepo.GetProduct(ctx, product.ParentID)
+	var notFoundErr *inventory.ProductNotFoundError
+	if errors.As(err, &notFoundErr) {
+		return inventory.NewValidationError("unknown parent product: " + product.ParentID)
+	}
 	if err != nil {
 		return err
 	}
+	if parent.IsDeleted() || parent.Kind != inventory.KindParent {
+		return inventory.NewValidationError("parent must be an active product of kind parent: " + product.ParentID)
+	}
 
-	if err := product.Sell(reservation.Quantity); err != nil {
+	variants, err := service.inventoryRepo.GetVariants(ctx, parent.ID)
+	if err != nil {
 		return err
 	}
+	for _, variant := range variants {
+		if variant.ID != product.ID && inventory.SameAttributes(variant.Attributes, product.Attributes) {
+			return inventory.NewValidationError("variant with the same attributes already exists: " + variant.SKU)
+		}
+	}
+	return nil
+}
 
-	if err := service.inventoryRepo.UpdateProduct(ctx, product); err != nil {
+func (service *InventoryService) DeleteProduct(ctx context.Context, productID string) error {
+	product, err := service.GetProduct(ctx, productID)
+	if err != nil {
 		return err
 	}
+	if product.IsDeleted() {
+		return nil
+	}
 
-	if err := service.inventoryRepo.DeleteReservation(ctx, orderID); err != nil {
+	product.Delete()
+	if err := service.inventoryRepo.UpdateProduct(ctx, product); err != nil {
 		return err
 	}
 
-	logger.Info("Reservation confirmed", "order_id", orderID, "reservation_id", reservation.ID)
+	logger.Info("Product deleted", "product_id", product.ID, "sku", product.SKU, "reserved", product.Reserved)
 	return nil
 }
 
-func (service *InventoryService) GetProduct(ctx context.Context, productID string) (*inventory.Product, error) {
+func (service *InventoryService) AdjustStock(ctx context.Context, productID string, req *inventory.AdjustStockRequest) (*inventory.Product, error) {
 	if productID == "" {
 		return nil, inventory.NewValidationError("product_id is required")
 	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 
-	return service.inventoryRepo.GetProduct(ctx, productID)
-}
+	warehouseID := strings.TrimSpace(req.WarehouseID)
+	if warehouseID == "" {
+		warehouseID = inventory.DefaultWarehouseID
+	}
 
-func (service *InventoryService) UpdateStock(ctx context.Context, productID string, quantity int) error {
-	logger.Info("Updating stock", "product_id", productID, "quantity", quantity)
+	movement := &inventory.StockMovement{
+		ProductID:      productID,
+		WarehouseID:    warehouseID,
+		Type:           req.MovementType(),
+		AvailableDelta: req.Delta,
+		Reason:         req.Reason,
+		Note:           req.Note,
+		CreatedAt:      time.Now(),
+	}
+	product, err := service.inventoryRepo.AdjustStock(ctx, movement)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Stock adjusted",
+		"product_id", productID,
+		"warehouse_id", warehouseID,
+		"delta", req.Delta,
+		"reason", req.Reason,
+		"available", product.Available,
+		"movement_id", movement.ID)
+	return product, nil
+}
 
+func (service *InventoryService) GetMovements(ctx context.Context, productID string, limit int) ([]*inventory.StockMovement, error) {
 	if productID == "" {
-		return inventory.NewValidationError("product_id is required")
+		return nil, inventory.NewValidationError("product_id is required")
+	}
+	if limit <= 0 {
+		limit = inventory.DefaultMovementsLimit
 	}
+	return service.inventoryRepo.GetMovements(ctx, productID, limit)
+}
 
-	product, err := service.inventoryRepo.GetProduct(ctx, productID)
+func (service *InventoryService) Reconcile(ctx context.Context, apply bool) (*inventory.ReconcileReport, error) {
+	report, err := service.inventoryRepo.Reconcile(ctx, apply)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	product.Available = quantity
-	product.UpdatedAt = time.Now()
+	for _, drift := range report.Drifts {
+		logger.Warn("Stock drift detected",
+			"product_id", drift.ProductID,
+			"warehouse_id", drift.WarehouseID,
+			"sku", drift.SKU,
+			"available", drift.Available,
+			"ledger_available", drift.LedgerAvailable,
+			"reserved", drift.Reserved,
+			"ledger_reserved", drift.LedgerReserved,
+			"applied", report.Applied)
+	}
+	logger.Info("Stock reconciliation completed", "checked", report.Checked, "drifts", len(report.Drifts), "applied", report.Applied)
+	return report, nil
+}
 
-	if err := service.inventoryRepo.UpdateProduct(ctx, product); err != nil {
-		return err
+func (service *InventoryService) LowStockReport(ctx context.Context) ([]*inventory.StockLow, error) {
+	products, err := service.inventoryRepo.GetLowStockProducts(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Info("Stock updated", "product_id", productID, "new_quantity", quantity)
-	return nil
+	report := make([]*inventory.StockLow, 0, len(products))
+	for _, product := range products {
+		report = append(report, inventory.NewStockLow(product))
+	}
+	return report, nil
+}
+
+func (service *InventoryService) GetStockLevels(ctx context.Context, productID string) ([]*inventory.StockLevel, error) {
+	if _, err := service.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	return service.inventoryRepo.GetStockLevels(ctx, []string{productID})
 }
 
-func (service *InventoryService) GetProducts(ctx context.Context) ([]*inventory.Product, error) {
-	return service.inventoryRepo.GetProducts(ctx)
+func (service *InventoryService) ListWarehouses(ctx context.Context) ([]*inventory.Warehouse, error) {
+	return service.inventoryRepo.GetWarehouses(ctx, false)
 }
 
-func (service *InventoryService) CreateProduct(ctx context.Context, product *inventory.Product) error {
-	if product.ID == "" {
-		product.ID = uuid.New().String()
+func (service *InventoryService) CreateWarehouse(ctx context.Context, req *inventory.CreateWarehouseRequest) (*inventory.Warehouse, error) {
+	now := time.Now()
+	warehouse := &inventory.Warehouse{
+		ID:           strings.TrimSpace(req.ID),
+		Name:         strings.TrimSpace(req.Name),
+		Location:     req.Location,
+		ShippingCost: req.ShippingCost,
+		Priority:     req.Priority,
+		Active:       req.Active == nil || *req.Active,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
-	if product.CreatedAt.IsZero() {
-		product.CreatedAt = time.Now()
+	if err := inventory.ValidateWarehouse(warehouse); err != nil {
+		return nil, err
 	}
-	if product.UpdatedAt.IsZero() {
-		product.UpdatedAt = time.Now()
+
+	if err := service.inventoryRepo.CreateWarehouse(ctx, warehouse); err != nil {
+		return nil, err
 	}
 
-	return service.inventoryRepo.CreateProduct(ctx, product)
+	logger.Info("Warehouse created", "warehouse_id", warehouse.ID, "active", warehouse.Active)
+	return warehouse, nil
 }
 
-func (service *InventoryService) CleanupExpiredReservations(ctx context.Context) error {
-	logger.Info("Cleaning up expired reservations")
-
-	expiredReservations, err := service.inventoryRepo.GetExpiredReservations(ctx)
+func (service *InventoryService) UpdateWarehouse(ctx context.Context, warehouseID string, req *inventory.UpdateWarehouseRequest) (*inventory.Warehouse, error) {
+	warehouse, err := service.inventoryRepo.GetWarehouse(ctx, warehouseID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if req.Name != nil {
+		warehouse.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Location != nil {
+		warehouse.Location = *req.Location
+	}
+	if req.ShippingCost != nil {
+		warehouse.ShippingCost = *req.ShippingCost
 	}
+	if req.Priority != nil {
+		warehouse.Priority = *req.Priority
+	}
+	if req.Active != nil {
+		warehouse.Active = *req.Active
+	}
+	if err := inventory.ValidateWarehouse(warehouse); err != nil {
+		return nil, err
+	}
+	warehouse.UpdatedAt = time.Now()
+
+	if err := service.inventoryRepo.UpdateWarehouse(ctx, warehouse); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Warehouse updated", "warehouse_id", warehouse.ID, "active", warehouse.Active)
+	return warehouse, nil
+}
 
-	for _, reservation := range expiredReservations {
-		logger.Info("Cleaning up expired reservation", 
-			"reservation_id", reservation.ID, 
-			"order_id", reservation.OrderID)
+// ImportCatalog применяет строки по одной: ошибка строки попадает в отчёт и не прерывает
+// импорт. Новый остаток существующего товара записывается корректировкой ReasonStocktake.
+func (service *InventoryService) ImportCatalog(ctx context.Context, rows []inventory.CatalogRow) (*inventory.ImportResult, error) {
+	if len(rows) == 0 {
+		return nil, inventory.NewValidationError("catalog is empty")
+	}
 
-		product, err := service.inventoryRepo.GetProduct(ctx, reservation.ProductID)
+	result := &inventory.ImportResult{}
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		rowResult, err := service.importRow(ctx, row)
 		if err != nil {
-			logger.Error("Failed to get product for expired reservation", 
-				"error", err, "product_id", reservation.ProductID)
-			continue
+			var (
+				validationErr *inventory.ValidationError
+				duplicateErr  *inventory.DuplicateSKUError
+				stockErr      *inventory.InsufficientStockError
+			)
+			if !errors.As(err, &validationErr) && !errors.As(err, &duplicateErr) && !errors.As(err, &stockErr) {
+				return result, err
+			}
+			rowResult.Action = inventory.ImportFailed
+			rowResult.Error = err.Error()
 		}
+		result.Add(rowResult)
+	}
 
-		product.ReleaseReservation(reservation.Quantity)
-		if err := service.inventoryRepo.UpdateProduct(ctx, product); err != nil {
-			logger.Error("Failed to update product for expired reservation", 
-				"error", err, "product_id", reservation.ProductID)
-			continue
+	logger.Info("Catalog imported",
+		"total", result.Total,
+		"created", result.Created,
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"failed", result.Failed)
+	return result, nil
+}
+
+func (service *InventoryService) importRow(ctx context.Context, row inventory.CatalogRow) (inventory.ImportRowResult, error) {
+	rowResult := inventory.ImportRowResult{Line: row.Line, SKU: row.SKU}
+	if row.Error != "" {
+		return rowResult, inventory.NewValidationError(row.Error)
+	}
+
+	existing, err := service.inventoryRepo.GetProductBySKU(ctx, row.SKU)
+	var notFound *inventory.ProductNotFoundError
+	if errors.As(err, &notFound) {
+		req := &inventory.CreateProductRequest{Name: row.Name, SKU: row.SKU, Price: row.Price}
+		if row.Available != nil {
+			req.Available = *row.Available
+		}
+		product, err := service.CreateProduct(ctx, req)
+		if err != nil {
+			return rowResult, err
 		}
+		rowResult.ProductID = product.ID
+		rowResult.Action = inventory.ImportCreated
+		return rowResult, nil
+	}
+	if err != nil {
+		return rowResult, err
+	}
 
-		if err := service.inventoryRepo.DeleteReservation(ctx, reservation.OrderID); err != nil {
-			logger.Error("Failed to delete expired reservation", 
-				"error", err, "reservation_id", reservation.ID)
+	rowResult.ProductID = existing.ID
+	rowResult.Action = inventory.ImportUnchanged
+
+	name := strings.TrimSpace(row.Name)
+	if existing.Name != name || existing.Price != row.Price || existing.IsDeleted() {
+		existing.Name = name
+		existing.Price = row.Price
+		existing.DeletedAt = nil
+		if err := inventory.ValidateProduct(existing); err != nil {
+			return rowResult, err
+		}
+		existing.UpdatedAt = time.Now()
+		if err := service.inventoryRepo.UpdateProduct(ctx, existing); err != nil {
+			return rowResult, err
 		}
+		rowResult.Action = inventory.ImportUpdated
 	}
 
-	logger.Info("Expired reservations cleanup completed", "count", len(expiredReservations))
-	return nil
+	if row.Available != nil && *row.Available != existing.Available {
+		_, err := service.AdjustStock(ctx, existing.ID, &inventory.AdjustStockRequest{
+			Delta:  *row.Available - existing.Available,
+			Reason: inventory.ReasonStocktake,
+			Note:   "catalog import",
+		})
+		if err != nil {
+			return rowResult, err
+		}
+		rowResult.Action = inventory.ImportUpdated
+	}
+
+	return rowResult, nil
+}
+
+// getActiveProduct возвращает товар, не снятый с продажи.
+func (service *InventoryService) getActiveProduct(ctx context.Context, productID string) (*inventory.Product, error) {
+	product, err := service.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.IsDeleted() {
+		return nil, inventory.NewProductNotFoundError(productID)
+	}
+	return product, nil
+}
+
+func (service *InventoryService) CleanupExpiredReservations(ctx context.Context, batchSize int) ([]*inventory.Reservation, error) {
+	if batchSize <= 0 {
+		batchSize = inventory.DefaultCleanupBatchSize
+	}
+
+	logger.Info("Cleaning up expired reservations", "batch_size", batchSize)
+
+	released, err := service.inventoryRepo.ReleaseExpiredReservations(ctx, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reservation := range released {
+		logger.Info("Expired reservation released",
+			"reservation_id", reservation.ID,
+			"order_id", reservation.OrderID,
+			"product_id", reservation.ProductID,
+			"quantity", reservation.Quantity)
+	}
+
+	logger.Info("Expired reservations cleanup completed", "count", len(released))
+	return released, nil
 }
\ No newline at end of file
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,33 +13,46 @@ import (
 	"orderflow/pkg/logger"
 )
 
+const maxDeadNotificationsLimit = 500
+
 type NotificationService struct {
 	notificationRepo notification.Repository
-	senders map[notification.Channel]notification.Sender
-	template notification.Template
+	recipients       notification.RecipientResolver
+	senders          map[notification.Channel]notification.Sender
+	template         notification.Template
+	retryPolicy      notification.RetryPolicy
+	digest           notification.DigestPolicy
 }
 
-func NewNotificationService(notificationRepo notification.Repository) *NotificationService {
+// NewNotificationService регистрирует отправщики по каналам из SupportedChannels.
+// Канал без отправщика не поддерживается: уведомление в нём сразу становится dead
+// с UnsupportedChannelError, а не считается отправленным.
+// recipients выбирает канал и адрес по предпочтениям клиента; nil — канал из запроса или email.
+// template рендерит уведомления, для которых в запросе нет готового текста.
+// retryPolicy задаёт задержки очереди повторов; незаданные поля берутся из DefaultRetryPolicy.
+// digest включает отложенную отправку сводками для низкоприоритетных типов.
+func NewNotificationService(notificationRepo notification.Repository, recipients notification.RecipientResolver, template notification.Template, retryPolicy notification.RetryPolicy, digest notification.DigestPolicy, senders ...notification.Sender) *NotificationService {
 	service := &NotificationService{
 		notificationRepo: notificationRepo,
+		recipients:       recipients,
 		senders:          make(map[notification.Channel]notification.Sender),
-		template:         NewNotificationTemplate(),
+		template:         template,
+		retryPolicy:      retryPolicy.WithDefaults(),
+		digest:           digest.WithDefaults(),
+	}
+
+	for _, sender := range senders {
+		for _, channel := range sender.SupportedChannels() {
+			service.senders[channel] = sender
+		}
 	}
-	
-	service.initializeSenders()
-	
-	return service
-}
 
-func (service *NotificationService) initializeSenders() {
-	service.senders[notification.ChannelEmail] = NewEmailSender()
-	service.senders[notification.ChannelSMS] = NewSMSSender()
-	service.senders[notification.ChannelPush] = NewPushSender()
+	return service
 }
 
 func (service *NotificationService) Send(ctx context.Context, req *notification.Request) error {
-	logger.Info("Sending notification", 
-		"order_id", req.OrderID, 
+	logger.Info("Sending notification",
+		"order_id", req.OrderID,
 		"customer_id", req.CustomerID,
 		"type", req.Type,
 		"channel", req.Channel)
@@ -45,84 +60,267 @@ func (service *NotificationService) Send(ctx context.Context, req *notification.
 	if req.CustomerID == "" {
 		return notification.NewValidationError("customer_id is required")
 	}
-	if req.OrderID == "" {
+	if req.OrderID == "" && req.Type.RequiresOrder() {
 		return notification.NewValidationError("order_id is required")
 	}
 	if req.Type == "" {
 		return notification.NewValidationError("type is required")
 	}
-	if req.Channel == "" {
-		return notification.NewValidationError("channel is required")
+	if req.Channel != "" && !req.Channel.IsValid() {
+		return notification.NewUnsupportedChannelError(req.Channel)
+	}
+
+	if req.IdempotencyKey != "" {
+		existing, err := service.notificationRepo.GetNotificationByIdempotencyKey(ctx, req.IdempotencyKey)
+		var notFound *notification.NotFoundError
+		switch {
+		case err == nil:
+			return service.resume(ctx, existing)
+		case !errors.As(err, &notFound):
+			return err
+		}
+	}
+
+	recipient, err := service.resolveRecipient(ctx, req)
+	var optedOut *notification.OptedOutError
+	if errors.As(err, &optedOut) {
+		logger.Info("Notification skipped, customer opted out",
+			"order_id", req.OrderID,
+			"customer_id", req.CustomerID,
+			"type", req.Type)
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
 	notificationEntity := notification.NewNotification(req)
 	notificationEntity.ID = uuid.New().String()
+	notificationEntity.Channel = recipient.Channel
+	notificationEntity.Recipient = recipient.Address
+	notificationEntity.Locale = recipient.Locale
 
 	if notificationEntity.Message == "" {
-		subject, message, err := service.template.Render(ctx, req.Type, map[string]interface{}{
-			"order_id":    req.OrderID,
-			"customer_id": req.CustomerID,
-		})
-		if err != nil {
+		if err := service.render(ctx, req, recipient, notificationEntity); err != nil {
 			logger.Error("Failed to render notification template", "error", err)
-			notificationEntity.MarkAsFailed()
-		} else {
-			notificationEntity.Subject = subject
-			notificationEntity.Message = message
+			notificationEntity.MarkAsDead(err.Error())
+			existing, created, createErr := service.create(ctx, notificationEntity)
+			if createErr != nil {
+				return createErr
+			}
+			if !created {
+				return service.resume(ctx, existing)
+			}
+			return err
 		}
 	}
 
-	if err := service.notificationRepo.CreateNotification(ctx, notificationEntity); err != nil {
+	if service.digest.Applies(notificationEntity.Type) {
+		return service.batch(ctx, notificationEntity)
+	}
+
+	existing, created, err := service.create(ctx, notificationEntity)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return service.resume(ctx, existing)
+	}
+
+	return service.dispatch(ctx, notificationEntity)
+}
+
+// create сохраняет новое уведомление. Если уведомление с тем же IdempotencyKey уже
+// сохранил параллельный вызов, возвращается оно и false.
+func (service *NotificationService) create(ctx context.Context, notificationEntity *notification.Notification) (*notification.Notification, bool, error) {
+	err := service.notificationRepo.CreateNotification(ctx, notificationEntity)
+	var duplicate *notification.DuplicateError
+	if !errors.As(err, &duplicate) {
+		return notificationEntity, err == nil, err
+	}
+
+	existing, err := service.notificationRepo.GetNotificationByIdempotencyKey(ctx, notificationEntity.IdempotencyKey)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+// resume завершает повторный Send с уже использованным ключом идемпотентности. Уведомление,
+// сохранённое до сбоя, но не отправленное (StatusPending), доставляется; для dead-уведомления
+// возвращается исходная ошибка. В остальных статусах уведомление уже отправлено или
+// принадлежит очереди повторов либо сводке, и второе сообщение не нужно.
+func (service *NotificationService) resume(ctx context.Context, notificationEntity *notification.Notification) error {
+	logger.Info("Duplicate notification request",
+		"notification_id", notificationEntity.ID,
+		"idempotency_key", notificationEntity.IdempotencyKey,
+		"status", notificationEntity.Status)
+
+	switch notificationEntity.Status {
+	case notification.StatusPending:
+		return service.dispatch(ctx, notificationEntity)
+	case notification.StatusDead:
+		return notification.NewPermanentSendError(notificationEntity.Channel, notificationEntity.LastError)
+	}
+	return nil
+}
+
+// batch откладывает уведомление до сводки. Новое уведомление присоединяется к уже
+// ожидающей сводке клиента в этом канале, иначе сводка уйдёт через окно digest.Window.
+func (service *NotificationService) batch(ctx context.Context, notificationEntity *notification.Notification) error {
+	dueAt, err := service.notificationRepo.GetDigestDueAt(ctx, notificationEntity.CustomerID, notificationEntity.Channel)
+	if err != nil {
 		return err
 	}
+	if dueAt == nil {
+		next := time.Now().Add(service.digest.Window)
+		dueAt = &next
+	}
+
+	notificationEntity.Batch(*dueAt)
+	existing, created, err := service.create(ctx, notificationEntity)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return service.resume(ctx, existing)
+	}
 
-	_, exists := service.senders[req.Channel]
+	logger.Info("Notification batched for digest",
+		"notification_id", notificationEntity.ID,
+		"order_id", notificationEntity.OrderID,
+		"customer_id", notificationEntity.CustomerID,
+		"channel", notificationEntity.Channel,
+		"digest_at", *dueAt)
+	return nil
+}
+
+// dispatch доставляет уведомление отправщиком его канала. Уведомление в канале без
+// отправщика переводится в StatusDead.
+func (service *NotificationService) dispatch(ctx context.Context, notificationEntity *notification.Notification) error {
+	sender, exists := service.senders[notificationEntity.Channel]
 	if !exists {
-		logger.Error("Unsupported notification channel", "channel", req.Channel)
-		notificationEntity.MarkAsFailed()
-		service.notificationRepo.UpdateNotification(ctx, notificationEntity)
-		return notification.NewUnsupportedChannelError(req.Channel)
+		logger.Error("Unsupported notification channel", "channel", notificationEntity.Channel)
+		unsupportedErr := notification.NewUnsupportedChannelError(notificationEntity.Channel)
+		notificationEntity.MarkAsDead(unsupportedErr.Error())
+		if err := service.notificationRepo.UpdateNotification(ctx, notificationEntity); err != nil {
+			return err
+		}
+		return unsupportedErr
+	}
+
+	return service.deliver(ctx, sender, notificationEntity)
+}
+
+// render заполняет тему, текст и HTML уведомления из шаблона для канала и локали получателя.
+// Тема из запроса, если задана, сохраняется.
+func (service *NotificationService) render(ctx context.Context, req *notification.Request, recipient *notification.Recipient, notificationEntity *notification.Notification) error {
+	data := &notification.TemplateData{}
+	if req.Data != nil {
+		copied := *req.Data
+		data = &copied
+	}
+	if data.OrderID == "" {
+		data.OrderID = req.OrderID
+	}
+	if data.CustomerID == "" {
+		data.CustomerID = req.CustomerID
+	}
+	if data.CustomerName == "" {
+		data.CustomerName = recipient.Name
 	}
 
-	success := service.simulateNotificationSending(req.Channel)
-	if success {
+	content, err := service.template.Render(ctx, req.Type, notificationEntity.Channel, notificationEntity.Locale, data)
+	if err != nil {
+		return err
+	}
+
+	if notificationEntity.Subject == "" {
+		notificationEntity.Subject = content.Subject
+	}
+	notificationEntity.Message = content.Text
+	notificationEntity.HTML = content.HTML
+	return nil
+}
+
+func (service *NotificationService) resolveRecipient(ctx context.Context, req *notification.Request) (*notification.Recipient, error) {
+	if service.recipients == nil {
+		channel := req.Channel
+		if channel == "" {
+			channel = notification.ChannelEmail
+		}
+		return &notification.Recipient{Channel: channel}, nil
+	}
+	return service.recipients.ResolveRecipient(ctx, req.CustomerID, req.Type, req.Channel)
+}
+
+// deliver делает одну попытку отправки и сохраняет результат. После временной ошибки
+// следующая попытка планируется по retryPolicy, и ошибка не возвращается: уведомление
+// доставит очередь повторов. Окончательный отказ или исчерпанные попытки переводят
+// уведомление в StatusDead, тогда возвращается RecipientNotFoundError или SendError с Permanent.
+func (service *NotificationService) deliver(ctx context.Context, sender notification.Sender, notificationEntity *notification.Notification) error {
+	notificationEntity.Attempts++
+
+	var deadErr error
+	sendErr := sender.Send(ctx, notificationEntity)
+	if sendErr == nil {
 		notificationEntity.MarkAsSent()
-		logger.Info("Notification sent successfully", 
+		logger.Info("Notification sent successfully",
 			"notification_id", notificationEntity.ID,
-			"order_id", req.OrderID,
-			"channel", req.Channel)
+			"order_id", notificationEntity.OrderID,
+			"channel", notificationEntity.Channel,
+			"attempt", notificationEntity.Attempts)
 	} else {
-		notificationEntity.MarkAsFailed()
-		logger.Error("Failed to send notification", 
-			"notification_id", notificationEntity.ID,
-			"order_id", req.OrderID,
-			"channel", req.Channel)
+		deadErr = service.recordFailure(notificationEntity, sendErr)
 	}
 
 	if err := service.notificationRepo.UpdateNotification(ctx, notificationEntity); err != nil {
 		return err
 	}
+	return deadErr
+}
 
-	if !success {
-		return notification.NewSendError(req.Channel, "Failed to send notification")
+// recordFailure планирует повтор или переводит уведомление в StatusDead.
+// Возвращает ошибку только для dead-уведомлений.
+func (service *NotificationService) recordFailure(notificationEntity *notification.Notification, sendErr error) error {
+	var sendError *notification.SendError
+	var recipientErr *notification.RecipientNotFoundError
+	switch {
+	case errors.As(sendErr, &recipientErr):
+	case errors.As(sendErr, &sendError):
+	default:
+		sendError = notification.NewSendError(notificationEntity.Channel, sendErr.Error())
+		sendErr = sendError
 	}
 
-	return nil
-}
-
-func (service *NotificationService) simulateNotificationSending(channel notification.Channel) bool {
-	successRates := map[notification.Channel]int{
-		notification.ChannelEmail: 95, // 95% успешных email
-		notification.ChannelSMS:   90, // 90% успешных SMS
-		notification.ChannelPush:  85, // 85% успешных push
+	permanent := recipientErr != nil || sendError.Permanent
+	if !permanent && notificationEntity.Attempts >= service.retryPolicy.MaxAttempts {
+		sendErr = notification.NewPermanentSendError(notificationEntity.Channel,
+			fmt.Sprintf("gave up after %d attempts: %s", notificationEntity.Attempts, sendError.Reason))
+		permanent = true
 	}
 
-	rate, exists := successRates[channel]
-	if !exists {
-		rate = 80 // по умолчанию 80%
+	if permanent {
+		notificationEntity.MarkAsDead(sendErr.Error())
+		logger.Error("Notification delivery failed permanently",
+			"notification_id", notificationEntity.ID,
+			"order_id", notificationEntity.OrderID,
+			"channel", notificationEntity.Channel,
+			"attempt", notificationEntity.Attempts,
+			"error", sendErr)
+		return sendErr
 	}
 
-	return time.Now().UnixNano()%100 < int64(rate)
+	nextAttemptAt := time.Now().Add(service.retryPolicy.Delay(notificationEntity.Attempts))
+	notificationEntity.ScheduleRetry(sendErr.Error(), nextAttemptAt)
+	logger.Warn("Notification delivery failed, retry scheduled",
+		"notification_id", notificationEntity.ID,
+		"order_id", notificationEntity.OrderID,
+		"channel", notificationEntity.Channel,
+		"attempt", notificationEntity.Attempts,
+		"next_attempt_at", nextAttemptAt,
+		"error", sendErr)
+	return nil
 }
 
 func (service *NotificationService) GetByID(ctx context.Context, id string) (*notification.Notification, error) {
@@ -157,26 +355,12 @@ func (service *NotificationService) Retry(ctx context.Context, id string) error
 		return notification.NewValidationError("Notification is not in failed status")
 	}
 
-	_, exists := service.senders[notificationEntity.Channel]
+	sender, exists := service.senders[notificationEntity.Channel]
 	if !exists {
 		return notification.NewUnsupportedChannelError(notificationEntity.Channel)
 	}
 
-	success := service.simulateNotificationSending(notificationEntity.Channel)
-	if success {
-		notificationEntity.MarkAsSent()
-		logger.Info("Notification retry successful", "notification_id", id)
-	} else {
-		notificationEntity.MarkAsFailed()
-		logger.Error("Notification retry failed", "notification_id", id)
-		return notification.NewSendError(notificationEntity.Channel, "Retry failed")
-	}
-
-	if err := service.notificationRepo.UpdateNotification(ctx, notificationEntity); err != nil {
-		return err
-	}
-
-	return nil
+	return service.deliver(ctx, sender, notificationEntity)
 }
 
 func (service *NotificationService) GetNotifications(ctx context.Context) ([]*notification.Notification, error) {
@@ -190,11 +374,11 @@ func (service *NotificationService) GetNotificationStatistics(ctx context.Contex
 	}
 
 	stats := &NotificationStatistics{
-		TotalNotifications: 0,
-		SentNotifications:  0,
-		FailedNotifications: 0,
+		TotalNotifications:   0,
+		SentNotifications:    0,
+		FailedNotifications:  0,
 		PendingNotifications: 0,
-		ChannelStats:        make(map[notification.Channel]ChannelStats),
+		ChannelStats:         make(map[notification.Channel]ChannelStats),
 	}
 
 	for _, notificationEntity := range notifications {
@@ -207,6 +391,8 @@ func (service *NotificationService) GetNotificationStatistics(ctx context.Contex
 			stats.FailedNotifications++
 		case notification.StatusPending:
 			stats.PendingNotifications++
+		case notification.StatusDead:
+			stats.DeadNotifications++
 		}
 
 		channelStats := stats.ChannelStats[notificationEntity.Channel]
@@ -218,6 +404,8 @@ func (service *NotificationService) GetNotificationStatistics(ctx context.Contex
 			channelStats.Failed++
 		case notification.StatusPending:
 			channelStats.Pending++
+		case notification.StatusDead:
+			channelStats.Dead++
 		}
 		stats.ChannelStats[notificationEntity.Channel] = channelStats
 	}
@@ -229,149 +417,275 @@ func (service *NotificationService) GetNotificationStatistics(ctx context.Contex
 	return stats, nil
 }
 
-func (service *NotificationService) RetryFailedNotifications(ctx context.Context) error {
-	logger.Info("Retrying all failed notifications")
+// ProcessRetryQueue отправляет сводки, у которых наступило время, затем забирает уведомления,
+// у которых наступило время повтора, и делает по одной попытке для каждого. Всего за проход
+// делается не больше batchSize отправок; сводка получателя считается одной отправкой
+// и забирается целиком, сколько бы уведомлений в ней ни было.
+// progress, если задан, вызывается после каждой отправки. Ошибка возвращается только
+// при сбое репозитория.
+func (service *NotificationService) ProcessRetryQueue(ctx context.Context, batchSize int, progress func(*notification.RetryBatchResult)) (*notification.RetryBatchResult, error) {
+	if batchSize <= 0 {
+		batchSize = notification.DefaultRetryBatchSize
+	}
+	if progress == nil {
+		progress = func(*notification.RetryBatchResult) {}
+	}
 
-	failedNotifications, err := service.notificationRepo.GetFailedNotifications(ctx)
+	result := &notification.RetryBatchResult{}
+	sends, err := service.flushDigests(ctx, batchSize, result, progress)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	successCount := 0
-	for _, notificationEntity := range failedNotifications {
-		if err := service.Retry(ctx, notificationEntity.ID); err != nil {
-			logger.Error("Failed to retry notification", 
-				"notification_id", notificationEntity.ID, 
-				"error", err)
-		} else {
-			successCount++
+	// Пачку ограничивают отправки, а не строки: сводка из многих уведомлений — одно письмо
+	remaining := batchSize - sends
+	if remaining <= 0 {
+		return result, nil
+	}
+	claimed, err := service.notificationRepo.ClaimDueNotifications(ctx, remaining, notification.ClaimLease)
+	if err != nil {
+		return result, err
+	}
+
+	result.Claimed += len(claimed)
+	for _, notificationEntity := range claimed {
+		if err := ctx.Err(); err != nil {
+			// Незавершённые уведомления вернутся в очередь по истечении аренды
+			return result, err
+		}
+
+		if err := tally(result, notificationEntity, service.dispatch(ctx, notificationEntity)); err != nil {
+			return result, err
 		}
+		progress(result)
 	}
 
-	logger.Info("Failed notifications retry completed", 
-		"total", len(failedNotifications), 
-		"successful", successCount)
-	return nil
+	if result.Claimed > 0 {
+		logger.Info("Notification retry batch processed",
+			"claimed", result.Claimed,
+			"sent", result.Sent,
+			"rescheduled", result.Rescheduled,
+			"dead", result.Dead,
+			"digested", result.Digested)
+	}
+	return result, nil
 }
 
-type NotificationStatistics struct {
-	TotalNotifications  int                                    `json:"total_notifications"`
-	SentNotifications   int                                    `json:"sent_notifications"`
-	FailedNotifications int                                    `json:"failed_notifications"`
-	PendingNotifications int                                   `json:"pending_notifications"`
-	SuccessRate         float64                                `json:"success_rate"`
-	ChannelStats        map[notification.Channel]ChannelStats `json:"channel_stats"`
+// tally учитывает исход попытки доставки в result. Ошибка доставки dead-уведомления
+// ожидаема, наружу возвращается только сбой сохранения результата.
+func tally(result *notification.RetryBatchResult, notificationEntity *notification.Notification, err error) error {
+	switch {
+	case notificationEntity.IsDead():
+		result.Dead++
+	case err != nil:
+		return err
+	case notificationEntity.IsSent():
+		result.Sent++
+	default:
+		result.Rescheduled++
+	}
+	return nil
 }
 
-type ChannelStats struct {
-	Total   int `json:"total"`
-	Sent    int `json:"sent"`
-	Failed  int `json:"failed"`
-	Pending int `json:"pending"`
-}
+// flushDigests забирает отложенные уведомления с наступившим временем сводки и группирует
+// их по получателю: одиночное уведомление уходит как есть, несколько — одной сводкой.
+// Возвращает число отправок, которое проход потратил из batchSize.
+func (service *NotificationService) flushDigests(ctx context.Context, batchSize int, result *notification.RetryBatchResult, progress func(*notification.RetryBatchResult)) (int, error) {
+	claimed, err := service.notificationRepo.ClaimDueDigests(ctx, batchSize, notification.ClaimLease)
+	if err != nil {
+		return 0, err
+	}
+	result.Claimed += len(claimed)
 
-type EmailSender struct{}
+	groups := groupByRecipient(claimed)
+	for _, group := range groups {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
 
-func NewEmailSender() *EmailSender {
-	return &EmailSender{}
+		if len(group) == 1 {
+			err = tally(result, group[0], service.dispatch(ctx, group[0]))
+		} else {
+			err = service.sendDigest(ctx, group, result)
+		}
+		if err != nil {
+			return 0, err
+		}
+		progress(result)
+	}
+	return len(groups), nil
 }
 
-func (s *EmailSender) Send(ctx context.Context, notification *notification.Notification) error {
-	logger.Info("Sending email notification", 
-		"to", notification.CustomerID,
-		"subject", notification.Subject)
-	return nil
-}
+// sendDigest объединяет группу отложенных уведомлений в одно уведомление TypeDigest.
+// Ключ идемпотентности сводки строится от первого уведомления группы: если воркер упал
+// после создания сводки, повторный проход не отправит вторую.
+func (service *NotificationService) sendDigest(ctx context.Context, group []*notification.Notification, result *notification.RetryBatchResult) error {
+	first, latest := group[0], group[len(group)-1]
+
+	data := &notification.TemplateData{OrderID: latest.OrderID, CustomerID: latest.CustomerID}
+	for _, notificationEntity := range group {
+		data.Digest = append(data.Digest, notification.TemplateDigestEntry{
+			Type:    notificationEntity.Type,
+			OrderID: notificationEntity.OrderID,
+			Subject: notificationEntity.Subject,
+			Text:    notificationEntity.Message,
+		})
+	}
 
-func (s *EmailSender) SupportedChannels() []notification.Channel {
-	return []notification.Channel{notification.ChannelEmail}
-}
+	content, err := service.template.Render(ctx, notification.TypeDigest, latest.Channel, latest.Locale, data)
+	if err != nil {
+		logger.Error("Failed to render notification digest, sending notifications separately",
+			"customer_id", latest.CustomerID,
+			"channel", latest.Channel,
+			"error", err)
+		for _, notificationEntity := range group {
+			if err := tally(result, notificationEntity, service.dispatch(ctx, notificationEntity)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 
-type SMSSender struct{}
+	now := time.Now()
+	digest := &notification.Notification{
+		ID:             uuid.New().String(),
+		CustomerID:     latest.CustomerID,
+		OrderID:        latest.OrderID,
+		Type:           notification.TypeDigest,
+		Channel:        latest.Channel,
+		Recipient:      latest.Recipient,
+		Locale:         latest.Locale,
+		Status:         notification.StatusPending,
+		Subject:        content.Subject,
+		Message:        content.Text,
+		HTML:           content.HTML,
+		Metadata:       map[string]string{"order_id": latest.OrderID},
+		IdempotencyKey: "digest:" + first.ID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	digest, _, err = service.create(ctx, digest)
+	if err != nil {
+		return err
+	}
 
-func NewSMSSender() *SMSSender {
-	return &SMSSender{}
-}
+	for _, notificationEntity := range group {
+		notificationEntity.MarkAsDigested(digest.ID)
+		if err := service.notificationRepo.UpdateNotification(ctx, notificationEntity); err != nil {
+			return err
+		}
+	}
+	result.Digested += len(group)
 
-func (s *SMSSender) Send(ctx context.Context, notification *notification.Notification) error {
-	logger.Info("Sending SMS notification", 
-		"to", notification.CustomerID,
-		"message", notification.Message)
-	return nil
-}
+	logger.Info("Notification digest created",
+		"notification_id", digest.ID,
+		"customer_id", digest.CustomerID,
+		"channel", digest.Channel,
+		"notifications", len(group))
 
-func (s *SMSSender) SupportedChannels() []notification.Channel {
-	return []notification.Channel{notification.ChannelSMS}
+	if digest.Status != notification.StatusPending {
+		return nil
+	}
+	return tally(result, digest, service.dispatch(ctx, digest))
 }
 
-type PushSender struct{}
+// groupByRecipient группирует уведомления по клиенту, каналу, адресу и локали
+// в порядке первого появления; внутри группы — по времени создания.
+func groupByRecipient(notifications []*notification.Notification) [][]*notification.Notification {
+	type recipientKey struct {
+		customerID string
+		channel    notification.Channel
+		recipient  string
+		locale     string
+	}
 
-func NewPushSender() *PushSender {
-	return &PushSender{}
-}
+	index := make(map[recipientKey]int)
+	var groups [][]*notification.Notification
+	for _, notificationEntity := range notifications {
+		key := recipientKey{notificationEntity.CustomerID, notificationEntity.Channel, notificationEntity.Recipient, notificationEntity.Locale}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], notificationEntity)
+	}
 
-func (s *PushSender) Send(ctx context.Context, notification *notification.Notification) error {
-	logger.Info("Sending push notification", 
-		"to", notification.CustomerID,
-		"title", notification.Subject,
-		"body", notification.Message)
-	return nil
+	for _, group := range groups {
+		sort.SliceStable(group, func(a, b int) bool {
+			return group[a].CreatedAt.Before(group[b].CreatedAt)
+		})
+	}
+	return groups
 }
 
-func (s *PushSender) SupportedChannels() []notification.Channel {
-	return []notification.Channel{notification.ChannelPush}
+func (service *NotificationService) ListDead(ctx context.Context, limit int) ([]*notification.Notification, error) {
+	if limit <= 0 || limit > maxDeadNotificationsLimit {
+		limit = maxDeadNotificationsLimit
+	}
+	return service.notificationRepo.GetNotificationsByStatus(ctx, notification.StatusDead, limit)
 }
 
-type NotificationTemplate struct{}
+func (service *NotificationService) Requeue(ctx context.Context, id string) (*notification.Notification, error) {
+	notificationEntity, err := service.getDead(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-func NewNotificationTemplate() *NotificationTemplate {
-	return &NotificationTemplate{}
+	notificationEntity.Requeue()
+	if err := service.notificationRepo.UpdateNotification(ctx, notificationEntity); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Dead notification requeued", "notification_id", id)
+	return notificationEntity, nil
 }
 
-func (t *NotificationTemplate) Render(ctx context.Context, notificationType notification.Type, data map[string]interface{}) (string, string, error) {
-	subject, err := t.GetSubject(ctx, notificationType, data)
+func (service *NotificationService) Discard(ctx context.Context, id string) (*notification.Notification, error) {
+	notificationEntity, err := service.getDead(ctx, id)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	message, err := t.GetMessage(ctx, notificationType, data)
-	if err != nil {
-		return "", "", err
+	notificationEntity.Discard()
+	if err := service.notificationRepo.UpdateNotification(ctx, notificationEntity); err != nil {
+		return nil, err
 	}
 
-	return subject, message, nil
+	logger.Info("Dead notification discarded", "notification_id", id)
+	return notificationEntity, nil
 }
 
-func (t *NotificationTemplate) GetSubject(ctx context.Context, notificationType notification.Type, data map[string]interface{}) (string, error) {
-	orderID, _ := data["order_id"].(string)
-	
-	switch notificationType {
-	case notification.TypeOrderConfirmed:
-		return fmt.Sprintf("Order Confirmed - %s", orderID), nil
-	case notification.TypeOrderFailed:
-		return fmt.Sprintf("Order Failed - %s", orderID), nil
-	case notification.TypeOrderCancelled:
-		return fmt.Sprintf("Order Cancelled - %s", orderID), nil
-	case notification.TypePaymentFailed:
-		return fmt.Sprintf("Payment Failed - %s", orderID), nil
-	default:
-		return "Order Update", nil
+func (service *NotificationService) getDead(ctx context.Context, id string) (*notification.Notification, error) {
+	if id == "" {
+		return nil, notification.NewValidationError("notification_id is required")
 	}
-}
 
-func (t *NotificationTemplate) GetMessage(ctx context.Context, notificationType notification.Type, data map[string]interface{}) (string, error) {
-	orderID, _ := data["order_id"].(string)
-	
-	switch notificationType {
-	case notification.TypeOrderConfirmed:
-		return fmt.Sprintf("Your order %s has been successfully processed and confirmed. Thank you for your purchase!", orderID), nil
-	case notification.TypeOrderFailed:
-		return fmt.Sprintf("Unfortunately, your order %s could not be processed. Please contact our support team for assistance.", orderID), nil
-	case notification.TypeOrderCancelled:
-		return fmt.Sprintf("Your order %s has been cancelled as requested. If you have any questions, please contact our support team.", orderID), nil
-	case notification.TypePaymentFailed:
-		return fmt.Sprintf("Payment for your order %s has failed. Please check your payment method and try again.", orderID), nil
-	default:
-		return fmt.Sprintf("There has been an update to your order %s.", orderID), nil
+	notificationEntity, err := service.notificationRepo.GetNotification(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !notificationEntity.IsDead() {
+		return nil, notification.NewStatusError(id, notificationEntity.Status, notification.StatusDead)
 	}
+	return notificationEntity, nil
+}
+
+type NotificationStatistics struct {
+	TotalNotifications   int                                   `json:"total_notifications"`
+	SentNotifications    int                                   `json:"sent_notifications"`
+	FailedNotifications  int                                   `json:"failed_notifications"`
+	PendingNotifications int                                   `json:"pending_notifications"`
+	DeadNotifications    int                                   `json:"dead_notifications"`
+	SuccessRate          float64                               `json:"success_rate"`
+	ChannelStats         map[notification.Channel]ChannelStats `json:"channel_stats"`
+}
+
+type ChannelStats struct {
+	Total   int `json:"total"`
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	Pending int `json:"pending"`
+	Dead    int `json:"dead"`
 }
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"orderflow/internal/domain/notification"
+	"orderflow/pkg/logger"
+)
+
+// memoryNotificationRepo хранит уведомления в map; Claim* отдают не больше limit
+// уведомлений из due и digests в порядке добавления.
+type memoryNotificationRepo struct {
+	notification.Repository
+	notifications map[string]*notification.Notification
+	due           []*notification.Notification
+	digests       []*notification.Notification
+	claimLimits   []int
+}
+
+func newMemoryNotificationRepo() *memoryNotificationRepo {
+	return &memoryNotificationRepo{notifications: make(map[string]*notification.Notification)}
+}
+
+func (r *memoryNotificationRepo) CreateNotification(ctx context.Context, n *notification.Notification) error {
+	if n.IdempotencyKey != "" {
+		for _, existing := range r.notifications {
+			if existing.IdempotencyKey == n.IdempotencyKey {
+				return notification.NewDuplicateError(n.IdempotencyKey)
+			}
+		}
+	}
+	r.notifications[n.ID] = n
+	return nil
+}
+
+func (r *memoryNotificationRepo) UpdateNotification(ctx context.Context, n *notification.Notification) error {
+	r.notifications[n.ID] = n
+	return nil
+}
+
+func (r *memoryNotificationRepo) GetNotificationByIdempotencyKey(ctx context.Context, key string) (*notification.Notification, error) {
+	for _, n := range r.notifications {
+		if n.IdempotencyKey == key {
+			return n, nil
+		}
+	}
+	return nil, notification.NewNotFoundError(key)
+}
+
+func (r *memoryNotificationRepo) GetDigestDueAt(ctx context.Context, customerID string, channel notification.Channel) (*time.Time, error) {
+	return nil, nil
+}
+
+func (r *memoryNotificationRepo) ClaimDueNotifications(ctx context.Context, limit int, lease time.Duration) ([]*notification.Notification, error) {
+	r.claimLimits = append(r.claimLimits, limit)
+	claimed := r.due[:min(limit, len(r.due))]
+	r.due = r.due[len(claimed):]
+	return claimed, nil
+}
+
+// ClaimDueDigests, как NotificationPG, считает limit по получателям (клиент и канал)
+// и забирает все их отложенные уведомления.
+func (r *memoryNotificationRepo) ClaimDueDigests(ctx context.Context, limit int, lease time.Duration) ([]*notification.Notification, error) {
+	type recipientKey struct {
+		customerID string
+		channel    notification.Channel
+	}
+	selected := make(map[recipientKey]bool)
+	var claimed, rest []*notification.Notification
+	for _, n := range r.digests {
+		key := recipientKey{n.CustomerID, n.Channel}
+		if !selected[key] && len(selected) < limit {
+			selected[key] = true
+		}
+		if selected[key] {
+			claimed = append(claimed, n)
+		} else {
+			rest = append(rest, n)
+		}
+	}
+	r.digests = rest
+	return claimed, nil
+}
+
+// scriptedSender возвращает err на каждую отправку и запоминает отправленные уведомления.
+type scriptedSender struct {
+	err  error
+	sent []*notification.Notification
+}
+
+func (s *scriptedSender) Send(ctx context.Context, n *notification.Notification) error {
+	s.sent = append(s.sent, n)
+	return s.err
+}
+
+func (s *scriptedSender) SupportedChannels() []notification.Channel {
+	return []notification.Channel{notification.ChannelEmail}
+}
+
+func TestNotificationServiceRecordFailure(t *testing.T) {
+	logger.Init("test")
+	svc := NewNotificationService(newMemoryNotificationRepo(), nil, nil,
+		notification.RetryPolicy{MaxAttempts: 3, InitialInterval: time.Minute, MaxInterval: time.Hour, BackoffCoefficient: 2},
+		notification.DigestPolicy{})
+
+	tests := []struct {
+		name      string
+		attempts  int
+		sendErr   error
+		wantDead  bool
+		wantDelay time.Duration
+	}{
+		{"transient error schedules retry", 1, errors.New("connection reset"), false, time.Minute},
+		{"retry delay grows with attempts", 2, notification.NewSendError(notification.ChannelEmail, "timeout"), false, 2 * time.Minute},
+		{"max attempts reached", 3, errors.New("connection reset"), true, 0},
+		{"permanent send error", 1, notification.NewPermanentSendError(notification.ChannelEmail, "mailbox does not exist"), true, 0},
+		{"recipient not found", 1, notification.NewRecipientNotFoundError("customer-1", notification.ChannelEmail), true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &notification.Notification{ID: "notification-1", Channel: notification.ChannelEmail, Attempts: tt.attempts}
+			before := time.Now()
+
+			err := svc.recordFailure(n, tt.sendErr)
+
+			if n.IsDead() != tt.wantDead {
+				t.Fatalf("status = %s, want dead = %v", n.Status, tt.wantDead)
+			}
+			if !tt.wantDead {
+				if err != nil {
+					t.Fatalf("recordFailure() error = %v, want nil for a scheduled retry", err)
+				}
+				if n.NextAttemptAt == nil || n.NextAttemptAt.Sub(before) < tt.wantDelay || n.NextAttemptAt.Sub(before) > tt.wantDelay+time.Second {
+					t.Errorf("NextAttemptAt = %v, want about now + %v", n.NextAttemptAt, tt.wantDelay)
+				}
+				return
+			}
+
+			if n.NextAttemptAt != nil {
+				t.Errorf("NextAttemptAt = %v, want nil for a dead notification", n.NextAttemptAt)
+			}
+			var sendError *notification.SendError
+			var recipientErr *notification.RecipientNotFoundError
+			switch {
+			case errors.As(err, &recipientErr):
+			case errors.As(err, &sendError) && sendError.Permanent:
+			default:
+				t.Fatalf("recordFailure() error = %v, want RecipientNotFoundError or permanent SendError", err)
+			}
+			if n.LastError != err.Error() {
+				t.Errorf("LastError = %q, want %q", n.LastError, err.Error())
+			}
+		})
+	}
+}
+
+func TestNotificationServiceProcessRetryQueueBatchBound(t *testing.T) {
+	logger.Init("test")
+	repo := newMemoryNotificationRepo()
+	for _, id := range []string{"digest-1", "digest-2"} {
+		repo.digests = append(repo.digests, &notification.Notification{
+			ID: id, CustomerID: id, Channel: notification.ChannelEmail, Status: notification.StatusBatched, Message: id,
+		})
+	}
+	for _, id := range []string{"due-1", "due-2", "due-3", "due-4"} {
+		repo.due = append(repo.due, &notification.Notification{
+			ID: id, CustomerID: "customer-1", Channel: notification.ChannelEmail, Status: notification.StatusFailed, Message: id,
+		})
+	}
+	sender := &scriptedSender{}
+	svc := NewNotificationService(repo, nil, nil, notification.RetryPolicy{}, notification.DigestPolicy{}, sender)
+
+	var progressCalls int
+	result, err := svc.ProcessRetryQueue(context.Background(), 3, func(*notification.RetryBatchResult) {
+		progressCalls++
+	})
+	if err != nil {
+		t.Fatalf("ProcessRetryQueue() error = %v", err)
+	}
+
+	if result.Claimed != 3 || result.Sent != 3 {
+		t.Errorf("result = %+v, want 3 claimed and sent", result)
+	}
+	if len(repo.claimLimits) != 1 || repo.claimLimits[0] != 1 {
+		t.Errorf("ClaimDueNotifications limits = %v, want [1] after two digests", repo.claimLimits)
+	}
+	if progressCalls != len(sender.sent) {
+		t.Errorf("progress called %d times, want once per send (%d)", progressCalls, len(sender.sent))
+	}
+}
+
+// stubTemplate рендерит тему из типа уведомления, а текст сводки — из числа её записей.
+type stubTemplate struct{}
+
+func (stubTemplate) Render(ctx context.Context, notificationType notification.Type, channel notification.Channel, locale string, data *notification.TemplateData) (*notification.Content, error) {
+	return &notification.Content{Subject: string(notificationType), Text: fmt.Sprintf("%d entries", len(data.Digest))}, nil
+}
+
+func TestGroupByRecipient(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	n := func(id, customerID string, channel notification.Channel, recipient, locale string, createdAt time.Duration) *notification.Notification {
+		return &notification.Notification{ID: id, CustomerID: customerID, Channel: channel, Recipient: recipient, Locale: locale, CreatedAt: base.Add(createdAt)}
+	}
+
+	tests := []struct {
+		name          string
+		notifications []*notification.Notification
+		want          [][]string
+	}{
+		{"empty", nil, nil},
+		{"same recipient grouped and sorted by creation",
+			[]*notification.Notification{
+				n("b", "customer-1", notification.ChannelEmail, "a@example.com", "en", time.Minute),
+				n("a", "customer-1", notification.ChannelEmail, "a@example.com", "en", 0),
+			},
+			[][]string{{"a", "b"}}},
+		{"groups kept in first-seen order",
+			[]*notification.Notification{
+				n("x", "customer-2", notification.ChannelEmail, "b@example.com", "en", 0),
+				n("y", "customer-1", notification.ChannelEmail, "a@example.com", "en", 0),
+				n("z", "customer-2", notification.ChannelEmail, "b@example.com", "en", time.Minute),
+			},
+			[][]string{{"x", "z"}, {"y"}}},
+		{"channel, address and locale split groups",
+			[]*notification.Notification{
+				n("email", "customer-1", notification.ChannelEmail, "a@example.com", "en", 0),
+				n("sms", "customer-1", notification.ChannelSMS, "+15555550100", "en", 0),
+				n("other-address", "customer-1", notification.ChannelEmail, "c@example.com", "en", 0),
+				n("other-locale", "customer-1", notification.ChannelEmail, "a@example.com", "ru", 0),
+			},
+			[][]string{{"email"}, {"sms"}, {"other-address"}, {"other-locale"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+			for _, group := range groupByRecipient(tt.notifications) {
+				var ids []string
+				for _, notificationEntity := range group {
+					ids = append(ids, notificationEntity.ID)
+				}
+				got = append(got, ids)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupByRecipient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationServiceSendIdempotencyAcrossRetries(t *testing.T) {
+	logger.Init("test")
+	key := notification.NewIdempotencyKey("order-1", notification.TypeOrderConfirmed, "run-1")
+
+	tests := []struct {
+		name      string
+		firstErr  error
+		stored    notification.Status
+		retryKey  string
+		wantSends int
+		wantErr   bool
+	}{
+		{"sent notification not sent again", nil, "", key, 1, false},
+		{"failed notification left to the retry queue", errors.New("connection reset"), "", key, 1, false},
+		{"notification saved before a crash is delivered", nil, notification.StatusPending, key, 2, false},
+		{"dead notification returns its error", notification.NewPermanentSendError(notification.ChannelEmail, "rejected"), "", key, 1, true},
+		{"new workflow run sends a new notification", nil, "",
+			notification.NewIdempotencyKey("order-1", notification.TypeOrderConfirmed, "run-2"), 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryNotificationRepo()
+			sender := &scriptedSender{err: tt.firstErr}
+			svc := NewNotificationService(repo, nil, nil, notification.RetryPolicy{}, notification.DigestPolicy{}, sender)
+			req := &notification.Request{
+				CustomerID:     "customer-1",
+				OrderID:        "order-1",
+				Type:           notification.TypeOrderConfirmed,
+				Message:        "Order confirmed",
+				IdempotencyKey: key,
+			}
+
+			_ = svc.Send(context.Background(), req)
+			if tt.stored != "" {
+				for _, n := range repo.notifications {
+					n.Status = tt.stored
+				}
+			}
+
+			sender.err = nil
+			retry := *req
+			retry.IdempotencyKey = tt.retryKey
+			err := svc.Send(context.Background(), &retry)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("retried Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(sender.sent) != tt.wantSends {
+				t.Errorf("sends = %d, want %d", len(sender.sent), tt.wantSends)
+			}
+			wantStored := 1
+			if tt.retryKey != key {
+				wantStored = 2
+			}
+			if len(repo.notifications) != wantStored {
+				t.Errorf("stored notifications = %d, want %d", len(repo.notifications), wantStored)
+			}
+		})
+	}
+}
+
+func TestNotificationServiceChannelWithoutSender(t *testing.T) {
+	logger.Init("test")
+	repo := newMemoryNotificationRepo()
+	sender := &scriptedSender{}
+	svc := NewNotificationService(repo, nil, nil, notification.RetryPolicy{}, notification.DigestPolicy{}, sender)
+
+	err := svc.Send(context.Background(), &notification.Request{
+		CustomerID: "customer-1",
+		OrderID:    "order-1",
+		Type:       notification.TypeOrderConfirmed,
+		Channel:    notification.ChannelSMS,
+		Message:    "Order confirmed",
+	})
+
+	var unsupported *notification.UnsupportedChannelError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Send() error = %v, want UnsupportedChannelError", err)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("email sender got %d sends for an sms notification", len(sender.sent))
+	}
+	if len(repo.notifications) != 1 {
+		t.Fatalf("stored notifications = %d, want 1", len(repo.notifications))
+	}
+	for _, n := range repo.notifications {
+		if !n.IsDead() {
+			t.Errorf("status = %s, want dead instead of sent", n.Status)
+		}
+	}
+}
+
+func TestNotificationServiceDigestNotResentAfterRetry(t *testing.T) {
+	logger.Init("test")
+	repo := newMemoryNotificationRepo()
+	sender := &scriptedSender{}
+	svc := NewNotificationService(repo, nil, stubTemplate{}, notification.RetryPolicy{},
+		notification.DigestPolicy{Enabled: true}, sender)
+
+	created := time.Now()
+	group := []*notification.Notification{
+		{ID: "first", CustomerID: "customer-1", OrderID: "order-1", Type: notification.TypeOrderConfirmed,
+			Channel: notification.ChannelEmail, Status: notification.StatusBatched, CreatedAt: created},
+		{ID: "second", CustomerID: "customer-1", OrderID: "order-2", Type: notification.TypeOrderCancelled,
+			Channel: notification.ChannelEmail, Status: notification.StatusBatched, CreatedAt: created.Add(time.Minute)},
+	}
+
+	// Второй проход повторяет первый, как после падения воркера до истечения аренды
+	for pass := 0; pass < 2; pass++ {
+		for _, n := range group {
+			n.Status = notification.StatusBatched
+		}
+		repo.digests = append(repo.digests, group...)
+
+		result, err := svc.ProcessRetryQueue(context.Background(), 10, nil)
+		if err != nil {
+			t.Fatalf("pass %d: ProcessRetryQueue() error = %v", pass, err)
+		}
+		if result.Digested != 2 {
+			t.Errorf("pass %d: digested = %d, want 2", pass, result.Digested)
+		}
+	}
+
+	if len(sender.sent) != 1 {
+		t.Fatalf("sends = %d, want a single digest", len(sender.sent))
+	}
+	digest := sender.sent[0]
+	if digest.Type != notification.TypeDigest || digest.IdempotencyKey != "digest:first" || digest.Message != "2 entries" {
+		t.Errorf("digest = %+v, want TypeDigest keyed by the first notification with 2 entries", digest)
+	}
+	for _, n := range group {
+		if n.Status != notification.StatusDigested || n.Metadata[notification.MetadataDigestID] != digest.ID {
+			t.Errorf("notification %s: status %s, digest id %q, want digested into %s",
+				n.ID, n.Status, n.Metadata[notification.MetadataDigestID], digest.ID)
+		}
+	}
+}
+
+func TestNotificationServiceDigestClaimedWholeAcrossBatch(t *testing.T) {
+	logger.Init("test")
+	repo := newMemoryNotificationRepo()
+	for _, id := range []string{"a-1", "a-2", "a-3"} {
+		repo.digests = append(repo.digests, &notification.Notification{ID: id, CustomerID: "customer-a",
+			Type: notification.TypeOrderConfirmed, Channel: notification.ChannelEmail, Status: notification.StatusBatched})
+	}
+	repo.digests = append(repo.digests, &notification.Notification{ID: "b-1", CustomerID: "customer-b",
+		Type: notification.TypeOrderConfirmed, Channel: notification.ChannelEmail, Status: notification.StatusBatched})
+	repo.due = append(repo.due, &notification.Notification{ID: "due-1", CustomerID: "customer-c",
+		Channel: notification.ChannelEmail, Status: notification.StatusFailed})
+	sender := &scriptedSender{}
+	svc := NewNotificationService(repo, nil, stubTemplate{}, notification.RetryPolicy{},
+		notification.DigestPolicy{Enabled: true}, sender)
+
+	// Пачка из двух отправок: сводка из трёх уведомлений клиента a занимает одну из них
+	result, err := svc.ProcessRetryQueue(context.Background(), 2, nil)
+	if err != nil {
+		t.Fatalf("ProcessRetryQueue() error = %v", err)
+	}
+
+	if len(sender.sent) != 2 {
+		t.Fatalf("sends = %d, want one digest and one single notification", len(sender.sent))
+	}
+	if sender.sent[0].Type != notification.TypeDigest || sender.sent[0].Message != "3 entries" {
+		t.Errorf("first send = %s %q, want a digest of all 3 notifications", sender.sent[0].Type, sender.sent[0].Message)
+	}
+	if sender.sent[1].ID != "b-1" {
+		t.Errorf("second send = %s, want b-1", sender.sent[1].ID)
+	}
+	if result.Claimed != 4 || result.Digested != 3 {
+		t.Errorf("result = %+v, want 4 claimed and 3 digested", result)
+	}
+	if len(repo.claimLimits) != 0 || len(repo.due) != 1 {
+		t.Errorf("ClaimDueNotifications limits = %v, want no claim after two sends", repo.claimLimits)
+	}
+}
@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/promotion"
+	"orderflow/internal/domain/shipping"
+	"orderflow/internal/domain/tax"
 )
 
 type OrderStatistics struct {
@@ -19,12 +24,16 @@ type OrderStatistics struct {
 }
 
 type OrderService struct {
-	orderRepo order.Repository
+	orderRepo  order.Repository
+	promotions promotion.Service
+	shipping   shipping.Service
 }
 
-func NewOrderService(orderRepo order.Repository) *OrderService {
+func NewOrderService(orderRepo order.Repository, promotions promotion.Service, shippingService shipping.Service) *OrderService {
 	return &OrderService{
-		orderRepo: orderRepo,
+		orderRepo:  orderRepo,
+		promotions: promotions,
+		shipping:   shippingService,
 	}
 }
 
@@ -49,20 +58,160 @@ func (s *OrderService) Create(ctx context.Context, req *order.CreateRequest) (*o
 		}
 	}
 
+	// Повтор CreateOrderActivity после зафиксированной транзакции возвращает уже созданный заказ
+	if existing, err := s.getByWorkflowID(ctx, req.WorkflowID); existing != nil || err != nil {
+		return existing, err
+	}
+
 	newOrder := order.NewOrder(req.CustomerID, req.Items)
 	newOrder.ID = uuid.New().String()
+	newOrder.WorkflowID = req.WorkflowID
 
 	if err := newOrder.Validate(); err != nil {
 		return nil, err
 	}
 
+	if err := setAddresses(newOrder, req); err != nil {
+		return nil, err
+	}
+	newOrder.TaxJurisdiction = tax.NormalizeJurisdiction(req.TaxJurisdiction)
+	if newOrder.TaxJurisdiction == "" && newOrder.ShippingAddress != nil {
+		newOrder.TaxJurisdiction = newOrder.ShippingAddress.Jurisdiction()
+	}
+
+	if err := s.applyPromotions(ctx, newOrder, req.CouponCode); err != nil {
+		return nil, err
+	}
+
+	if err := s.applyShipping(ctx, newOrder, req.ShippingMethod); err != nil {
+		return nil, err
+	}
+
 	if err := s.orderRepo.Create(ctx, newOrder); err != nil {
+		var duplicate *order.DuplicateWorkflowError
+		if errors.As(err, &duplicate) {
+			return s.getByWorkflowID(ctx, req.WorkflowID)
+		}
 		return nil, err
 	}
 
 	return newOrder, nil
 }
 
+// getByWorkflowID возвращает nil без ошибки, если заказа workflow ещё нет.
+func (s *OrderService) getByWorkflowID(ctx context.Context, workflowID string) (*order.Order, error) {
+	if workflowID == "" {
+		return nil, nil
+	}
+	existing, err := s.orderRepo.GetByWorkflowID(ctx, workflowID)
+	var notFound *order.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	return existing, err
+}
+
+// applyPromotions применяет к заказу действующие промоакции и купон. Погашение купона
+// резервирует репозиторий при сохранении заказа.
+func (s *OrderService) applyPromotions(ctx context.Context, o *order.Order, couponCode string) error {
+	lines := make([]promotion.Line, len(o.Items))
+	for i, item := range o.Items {
+		lines[i] = promotion.Line{ProductID: item.ProductID, Quantity: item.Quantity, Price: item.Price}
+	}
+
+	quote, err := s.promotions.Quote(ctx, &promotion.QuoteRequest{
+		CustomerID: o.CustomerID,
+		CouponCode: couponCode,
+		Lines:      lines,
+	})
+	if err != nil {
+		return err
+	}
+
+	adjustments := make([]order.Adjustment, len(quote.Adjustments))
+	for i, adjustment := range quote.Adjustments {
+		adjustments[i] = order.Adjustment{
+			PromotionID: adjustment.PromotionID,
+			Code:        adjustment.Code,
+			Type:        string(adjustment.Type),
+			ProductID:   adjustment.ProductID,
+			Amount:      adjustment.Amount,
+			Description: adjustment.Description,
+		}
+	}
+	o.CouponCode = promotion.NormalizeCode(couponCode)
+
+	// Бесплатный заказ провести нельзя: оплата требует положительной суммы
+	if o.ApplyAdjustments(adjustments) <= 0 {
+		return order.NewValidationError("order total after discounts must be positive")
+	}
+	return nil
+}
+
+// setAddresses проверяет адреса заказа. Без адреса доставки заказ не доставляется
+// и способ доставки указывать нельзя; без адреса плательщика им считается адрес доставки.
+func setAddresses(o *order.Order, req *order.CreateRequest) error {
+	if req.ShippingAddress == nil {
+		if req.ShippingMethod != "" {
+			return order.NewValidationError("shipping_method requires shipping_address")
+		}
+	} else {
+		address := *req.ShippingAddress
+		address.Normalize()
+		if err := address.Validate(); err != nil {
+			return err
+		}
+		o.ShippingAddress = &address
+	}
+
+	if req.BillingAddress != nil {
+		address := *req.BillingAddress
+		address.Normalize()
+		if err := address.Validate(); err != nil {
+			return err
+		}
+		o.BillingAddress = &address
+	} else if o.ShippingAddress != nil {
+		address := *o.ShippingAddress
+		o.BillingAddress = &address
+	}
+	return nil
+}
+
+// applyShipping добавляет к итогу стоимость доставки. Порог бесплатной доставки
+// сравнивается с суммой товаров после скидок.
+func (s *OrderService) applyShipping(ctx context.Context, o *order.Order, method string) error {
+	if o.ShippingAddress == nil {
+		return nil
+	}
+
+	items := make([]shipping.Item, len(o.Items))
+	for i, item := range o.Items {
+		items[i] = shipping.Item{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+	destination := shipping.Destination{
+		Country:    o.ShippingAddress.Country,
+		Region:     o.ShippingAddress.Region,
+		PostalCode: o.ShippingAddress.PostalCode,
+	}
+
+	rates, err := s.shipping.Quote(ctx, &shipping.QuoteRequest{
+		Destination: destination,
+		Items:       items,
+		Subtotal:    o.TotalAmount,
+	})
+	if err != nil {
+		return err
+	}
+
+	rate, err := shipping.SelectRate(rates, destination, strings.TrimSpace(method))
+	if err != nil {
+		return err
+	}
+	o.ApplyShipping(rate.Method, rate.Amount)
+	return nil
+}
+
 func (s *OrderService) GetByID(ctx context.Context, id string) (*order.Order, error) {
 	if id == "" {
 		return nil, order.NewValidationError("order_id is required")
@@ -97,6 +246,11 @@ func (s *OrderService) Cancel(ctx context.Context, id string) error {
 	if err := orderEntity.Cancel(); err != nil {
 		return err
 	}
+	// Купоны освобождаются до смены статуса: после записи cancelled повторная отмена
+	// не пройдёт проверку и до освобождения уже не дойдёт
+	if err := s.promotions.ReleaseRedemptions(ctx, id); err != nil {
+		return err
+	}
 
 	return s.orderRepo.Update(ctx, orderEntity)
 }
@@ -118,6 +272,11 @@ func (s *OrderService) UpdateStatus(ctx context.Context, id string, status order
 	if !s.isValidStatusTransition(orderEntity.Status, status) {
 		return order.NewStatusTransitionError(orderEntity.Status, status)
 	}
+	if status == order.StatusFailed || status == order.StatusCancelled {
+		if err := s.promotions.ReleaseRedemptions(ctx, id); err != nil {
+			return err
+		}
+	}
 
 	return s.orderRepo.UpdateStatus(ctx, id, status)
 }
@@ -130,10 +289,14 @@ func (s *OrderService) SetFailure(ctx context.Context, id string, reason string)
 	if reason == "" {
 		reason = "Unknown error"
 	}
+	if err := s.promotions.ReleaseRedemptions(ctx, id); err != nil {
+		return err
+	}
 
 	return s.orderRepo.SetFailure(ctx, id, reason)
 }
 
+
 func (s *OrderService) Complete(ctx context.Context, id string, paymentID string) error {
 	if id == "" {
 		return order.NewValidationError("order_id is required")
@@ -154,6 +317,35 @@ func (s *OrderService) Complete(ctx context.Context, id string, paymentID string
 	return s.orderRepo.Update(ctx, orderEntity)
 }
 
+func (s *OrderService) SetAllocation(ctx context.Context, id string, lines []order.AllocationLine) error {
+	if id == "" {
+		return order.NewValidationError("order_id is required")
+	}
+
+	return s.orderRepo.SetAllocation(ctx, id, lines)
+}
+
+func (s *OrderService) SetItemComponents(ctx context.Context, id string, components map[string][]order.ItemComponent) error {
+	if id == "" {
+		return order.NewValidationError("order_id is required")
+	}
+
+	return s.orderRepo.SetItemComponents(ctx, id, components)
+}
+
+func (s *OrderService) ApplyTax(ctx context.Context, id string, lines []order.TaxLine) (*order.Order, error) {
+	orderEntity, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	orderEntity.ApplyTax(lines)
+	if err := s.orderRepo.SetTax(ctx, orderEntity); err != nil {
+		return nil, err
+	}
+	return orderEntity, nil
+}
+
 func (s *OrderService) GetByCustomerID(ctx context.Context, customerID string) ([]*order.Order, error) {
 	if customerID == "" {
 		return nil, order.NewValidationError("customer_id is required")
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"orderflow/internal/domain/orderevent"
+	"orderflow/pkg/logger"
+)
+
+const orderEventsReconnectDelay = 2 * time.Second
+
+type OrderEventService struct {
+	orderEventRepo orderevent.Repository
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+func NewOrderEventService(orderEventRepo orderevent.Repository) *OrderEventService {
+	return &OrderEventService{
+		orderEventRepo: orderEventRepo,
+		subscribers:    make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+func (s *OrderEventService) Record(ctx context.Context, event *orderevent.Event) error {
+	if err := event.Validate(); err != nil {
+		return err
+	}
+
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	return s.orderEventRepo.Append(ctx, event)
+}
+
+func (s *OrderEventService) ListAfter(ctx context.Context, workflowID string, afterID int64) ([]*orderevent.Event, error) {
+	if workflowID == "" {
+		return nil, orderevent.NewValidationError("workflow_id is required")
+	}
+
+	return s.orderEventRepo.ListAfter(ctx, workflowID, afterID, orderevent.DefaultListLimit)
+}
+
+func (s *OrderEventService) Subscribe(workflowID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	if s.subscribers[workflowID] == nil {
+		s.subscribers[workflowID] = make(map[chan struct{}]struct{})
+	}
+	s.subscribers[workflowID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers[workflowID], ch)
+		if len(s.subscribers[workflowID]) == 0 {
+			delete(s.subscribers, workflowID)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// Run слушает уведомления Postgres и будит подписчиков соответствующего workflow.
+// При обрыве соединения переподключается и будит всех подписчиков, чтобы они
+// дочитали события, уведомления о которых могли быть потеряны.
+func (s *OrderEventService) Run(ctx context.Context) {
+	for {
+		err := s.orderEventRepo.Listen(ctx, func(workflowID string, _ int64) {
+			s.wake(workflowID)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger.Error("Order events listener stopped, reconnecting", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(orderEventsReconnectDelay):
+		}
+		s.wakeAll()
+	}
+}
+
+func (s *OrderEventService) wake(workflowID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers[workflowID] {
+		signalUpdate(ch)
+	}
+}
+
+func (s *OrderEventService) wakeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, subscribers := range s.subscribers {
+		for ch := range subscribers {
+			signalUpdate(ch)
+		}
+	}
+}
+
+// signalUpdate не блокируется: непрочитанный сигнал уже означает «есть новые события».
+func signalUpdate(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
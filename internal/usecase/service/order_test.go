@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/promotion"
+)
+
+// memoryOrderRepo хранит заказы в памяти и, как OrderPG, не даёт создать второй заказ
+// того же workflow. Каждый успешный Create резервирует погашение купона.
+type memoryOrderRepo struct {
+	order.Repository
+	orders      map[string]*order.Order
+	redemptions int
+}
+
+func (r *memoryOrderRepo) Create(ctx context.Context, o *order.Order) error {
+	for _, existing := range r.orders {
+		if o.WorkflowID != "" && existing.WorkflowID == o.WorkflowID {
+			return order.NewDuplicateWorkflowError(o.WorkflowID)
+		}
+	}
+	r.orders[o.ID] = o
+	if o.CouponCode != "" {
+		r.redemptions++
+	}
+	return nil
+}
+
+func (r *memoryOrderRepo) GetByID(ctx context.Context, id string) (*order.Order, error) {
+	o, ok := r.orders[id]
+	if !ok {
+		return nil, order.NewNotFoundError(id)
+	}
+	// Как и OrderPG, отдаёт копию: изменения видны только после Update
+	copied := *o
+	return &copied, nil
+}
+
+func (r *memoryOrderRepo) Update(ctx context.Context, o *order.Order) error {
+	r.orders[o.ID] = o
+	return nil
+}
+
+func (r *memoryOrderRepo) UpdateStatus(ctx context.Context, id string, status order.Status) error {
+	r.orders[id].Status = status
+	return nil
+}
+
+func (r *memoryOrderRepo) SetFailure(ctx context.Context, id, reason string) error {
+	r.orders[id].SetFailure(reason)
+	return nil
+}
+
+func (r *memoryOrderRepo) GetByWorkflowID(ctx context.Context, workflowID string) (*order.Order, error) {
+	for _, o := range r.orders {
+		if o.WorkflowID == workflowID {
+			return o, nil
+		}
+	}
+	return nil, order.NewNotFoundError(workflowID)
+}
+
+// couponPromotions даёт фиксированную скидку по купону и запоминает заказы,
+// чьи погашения освобождены; releaseErr — ошибка ReleaseRedemptions.
+type couponPromotions struct {
+	promotion.Service
+	released   []string
+	releaseErr error
+}
+
+func (*couponPromotions) Quote(ctx context.Context, req *promotion.QuoteRequest) (*promotion.Quote, error) {
+	return &promotion.Quote{Adjustments: []promotion.Adjustment{
+		{PromotionID: "p1", Code: req.CouponCode, Type: promotion.TypeFixed, Amount: 10, Description: "Welcome"},
+	}}, nil
+}
+
+func (p *couponPromotions) ReleaseRedemptions(ctx context.Context, orderID string) error {
+	if p.releaseErr != nil {
+		return p.releaseErr
+	}
+	p.released = append(p.released, orderID)
+	return nil
+}
+
+func TestOrderServiceCreateIsIdempotentPerWorkflow(t *testing.T) {
+	repo := &memoryOrderRepo{orders: make(map[string]*order.Order)}
+	svc := NewOrderService(repo, &couponPromotions{}, nil)
+	req := &order.CreateRequest{
+		CustomerID: "customer-1",
+		Items:      []order.Item{{ProductID: "prod-1", Quantity: 1, Price: 100}},
+		WorkflowID: "order-processing-customer-1-1",
+		CouponCode: "WELCOME",
+	}
+
+	first, err := svc.Create(context.Background(), req)
+	if err != nil {
+		t.Fatalf("first Create() error = %v", err)
+	}
+	// Повтор activity после того, как первая попытка зафиксировала заказ
+	retried, err := svc.Create(context.Background(), req)
+	if err != nil {
+		t.Fatalf("retried Create() error = %v", err)
+	}
+
+	if retried.ID != first.ID || len(repo.orders) != 1 {
+		t.Errorf("retry created order %s, want existing %s (orders: %d)", retried.ID, first.ID, len(repo.orders))
+	}
+	if repo.redemptions != 1 {
+		t.Errorf("coupon redemptions = %d, want 1", repo.redemptions)
+	}
+	if retried.TotalAmount != 90 {
+		t.Errorf("retried TotalAmount = %v, want 90", retried.TotalAmount)
+	}
+}
+
+func TestOrderServiceReleasesCouponsOnFailureAndCancel(t *testing.T) {
+	releaseFailed := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		status       order.Status
+		releaseErr   error
+		transition   func(svc *OrderService, id string) error
+		wantStatus   order.Status
+		wantReleased bool
+		wantErr      bool
+	}{
+		{"cancel", order.StatusPayment, nil,
+			func(svc *OrderService, id string) error { return svc.Cancel(context.Background(), id) },
+			order.StatusCancelled, true, false},
+		{"cancel completed order", order.StatusCompleted, nil,
+			func(svc *OrderService, id string) error { return svc.Cancel(context.Background(), id) },
+			order.StatusCompleted, false, true},
+		{"set failure", order.StatusValidating, nil,
+			func(svc *OrderService, id string) error {
+				return svc.SetFailure(context.Background(), id, "tax failed")
+			},
+			order.StatusFailed, true, false},
+		{"update status to failed", order.StatusValidating, nil,
+			func(svc *OrderService, id string) error {
+				return svc.UpdateStatus(context.Background(), id, order.StatusFailed)
+			},
+			order.StatusFailed, true, false},
+		{"update status to payment keeps coupons", order.StatusValidating, nil,
+			func(svc *OrderService, id string) error {
+				return svc.UpdateStatus(context.Background(), id, order.StatusPayment)
+			},
+			order.StatusPayment, false, false},
+		{"release failure keeps status for retry", order.StatusPayment, releaseFailed,
+			func(svc *OrderService, id string) error { return svc.Cancel(context.Background(), id) },
+			order.StatusPayment, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryOrderRepo{orders: map[string]*order.Order{
+				"order-1": {ID: "order-1", Status: tt.status, CouponCode: "WELCOME"},
+			}}
+			promotions := &couponPromotions{releaseErr: tt.releaseErr}
+			svc := NewOrderService(repo, promotions, nil)
+
+			err := tt.transition(svc, "order-1")
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := repo.orders["order-1"].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+			if released := len(promotions.released) > 0; released != tt.wantReleased {
+				t.Errorf("released = %v, want %v", promotions.released, tt.wantReleased)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"orderflow/internal/domain/outbox"
+	"orderflow/pkg/logger"
+)
+
+// OutboxRelay переносит события из таблицы outbox в EventPublisher.
+// Доставка «как минимум один раз»: событие помечается опубликованным только после
+// успешного Publish. Внутри агрегата порядок сохраняется: после ошибки остальные
+// события этого агрегата ждут, пока первое не будет доставлено.
+type OutboxRelay struct {
+	outboxRepo   outbox.Repository
+	publisher    outbox.EventPublisher
+	batchSize    int
+	pollInterval time.Duration
+}
+
+func NewOutboxRelay(outboxRepo outbox.Repository, publisher outbox.EventPublisher, batchSize int, pollInterval time.Duration) *OutboxRelay {
+	if batchSize <= 0 {
+		batchSize = outbox.DefaultRelayBatchSize
+	}
+	if pollInterval <= 0 {
+		pollInterval = outbox.DefaultRelayPollInterval
+	}
+	return &OutboxRelay{
+		outboxRepo:   outboxRepo,
+		publisher:    publisher,
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+	}
+}
+
+// Run обрабатывает outbox, пока не отменён ctx. Полная пачка означает, что в очереди
+// есть ещё события, и следующий проход начинается без ожидания.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	logger.Info("Starting outbox relay", "batch_size", r.batchSize, "poll_interval", r.pollInterval)
+
+	for {
+		processed, err := r.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Outbox relay pass failed", "error", err)
+		}
+
+		if processed < r.batchSize || err != nil {
+			select {
+			case <-ctx.Done():
+				logger.Info("Outbox relay stopped")
+				return
+			case <-time.After(r.pollInterval):
+			}
+		}
+	}
+}
+
+func (r *OutboxRelay) RunOnce(ctx context.Context) (int, error) {
+	return r.outboxRepo.ProcessPending(ctx, r.batchSize, r.publish)
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, events []*outbox.Event) map[int64]error {
+	results := make(map[int64]error, len(events))
+	blocked := make(map[string]struct{})
+
+	for _, event := range events {
+		if _, ok := blocked[event.AggregateKey()]; ok {
+			continue
+		}
+
+		err := r.publisher.Publish(ctx, event)
+		results[event.ID] = err
+		if err != nil {
+			blocked[event.AggregateKey()] = struct{}{}
+			logger.Warn("Failed to publish outbox event",
+				"event_id", event.ID,
+				"type", event.Type,
+				"aggregate_id", event.AggregateID,
+				"attempts", event.Attempts+1,
+				"error", err)
+		}
+	}
+
+	return results
+}
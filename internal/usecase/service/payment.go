@@ -175,6 +175,42 @@ func (service *PaymentService) CancelPayment(ctx context.Context, paymentID stri
 	return nil
 }
 
+func (service *PaymentService) HandleProviderEvent(ctx context.Context, event *payment.ProviderEvent) (*payment.ProviderEventResult, error) {
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	result, err := service.paymentRepo.ApplyProviderEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case result.Duplicate:
+		logger.Info("Duplicate payment provider event ignored",
+			"provider", event.Provider,
+			"event_id", event.ID,
+			"payment_id", result.Payment.ID)
+	case result.Changed:
+		logger.Info("Payment updated by provider event",
+			"provider", event.Provider,
+			"event_id", event.ID,
+			"type", event.Type,
+			"payment_id", result.Payment.ID,
+			"from", result.PreviousStatus,
+			"to", result.Payment.Status)
+	default:
+		logger.Warn("Payment provider event does not apply to current payment status",
+			"provider", event.Provider,
+			"event_id", event.ID,
+			"type", event.Type,
+			"payment_id", result.Payment.ID,
+			"status", result.Payment.Status)
+	}
+
+	return result, nil
+}
+
 func (service *PaymentService) GetPayments(ctx context.Context) ([]*payment.Payment, error) {
 	return service.paymentRepo.GetPayments(ctx)
 }
@@ -206,6 +242,8 @@ func (service *PaymentService) GetPaymentStatistics(ctx context.Context) (*Payme
 		case payment.StatusRefunded:
 			stats.RefundedPayments++
 			stats.RefundedAmount += paymentEntity.Amount
+		case payment.StatusDisputed:
+			stats.DisputedPayments++
 		}
 	}
 
@@ -221,6 +259,7 @@ type PaymentStatistics struct {
 	CompletedPayments int     `json:"completed_payments"`
 	FailedPayments    int     `json:"failed_payments"`
 	RefundedPayments  int     `json:"refunded_payments"`
+	DisputedPayments  int     `json:"disputed_payments"`
 	TotalAmount       float64 `json:"total_amount"`
 	RefundedAmount    float64 `json:"refunded_amount"`
 	SuccessRate       float64 `json:"success_rate"`
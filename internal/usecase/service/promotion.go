@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/promotion"
+	"orderflow/pkg/logger"
+)
+
+type PromotionService struct {
+	promotionRepo promotion.Repository
+}
+
+func NewPromotionService(promotionRepo promotion.Repository) *PromotionService {
+	return &PromotionService{promotionRepo: promotionRepo}
+}
+
+func (s *PromotionService) CreatePromotion(ctx context.Context, req *promotion.CreateRequest) (*promotion.Promotion, error) {
+	now := time.Now()
+	p := &promotion.Promotion{
+		ID:               uuid.New().String(),
+		Code:             promotion.NormalizeCode(req.Code),
+		Name:             req.Name,
+		Type:             req.Type,
+		Value:            req.Value,
+		ProductIDs:       req.ProductIDs,
+		BuyQuantity:      req.BuyQuantity,
+		GetQuantity:      req.GetQuantity,
+		MinSubtotal:      req.MinSubtotal,
+		UsageLimit:       req.UsageLimit,
+		PerCustomerLimit: req.PerCustomerLimit,
+		Active:           true,
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.promotionRepo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Promotion created", "promotion_id", p.ID, "code", p.Code, "type", p.Type)
+	return p, nil
+}
+
+func (s *PromotionService) GetPromotion(ctx context.Context, id string) (*promotion.Promotion, error) {
+	if id == "" {
+		return nil, promotion.NewValidationError("promotion id is required")
+	}
+	return s.promotionRepo.Get(ctx, id)
+}
+
+func (s *PromotionService) ListPromotions(ctx context.Context) ([]*promotion.Promotion, error) {
+	return s.promotionRepo.List(ctx)
+}
+
+func (s *PromotionService) UpdatePromotion(ctx context.Context, id string, req *promotion.UpdateRequest) (*promotion.Promotion, error) {
+	p, err := s.GetPromotion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		p.Name = *req.Name
+	}
+	if req.Active != nil {
+		p.Active = *req.Active
+	}
+	if req.StartsAt != nil {
+		p.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		p.EndsAt = req.EndsAt
+	}
+	if req.UsageLimit != nil {
+		p.UsageLimit = *req.UsageLimit
+	}
+	if req.PerCustomerLimit != nil {
+		p.PerCustomerLimit = *req.PerCustomerLimit
+	}
+	p.UpdatedAt = time.Now()
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.promotionRepo.Update(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *PromotionService) Quote(ctx context.Context, req *promotion.QuoteRequest) (*promotion.Quote, error) {
+	now := time.Now()
+	promotions, err := s.promotionRepo.ListAutomatic(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var coupon *promotion.Promotion
+	if code := promotion.NormalizeCode(req.CouponCode); code != "" {
+		coupon, err = s.checkCoupon(ctx, code, req.CustomerID, now)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, coupon)
+	}
+
+	quote := promotion.Apply(req.Lines, promotions)
+	if coupon != nil && !quote.Applied(coupon.ID) {
+		return nil, promotion.NewCouponError(coupon.Code, "order does not meet coupon conditions")
+	}
+	return quote, nil
+}
+
+// checkCoupon проверяет срок действия и лимиты купона. Окончательно лимит проверяется
+// при резервировании погашения вместе с созданием заказа.
+func (s *PromotionService) checkCoupon(ctx context.Context, code, customerID string, now time.Time) (*promotion.Promotion, error) {
+	coupon, err := s.promotionRepo.GetByCode(ctx, code)
+	var notFound *promotion.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, promotion.NewCouponError(code, "unknown coupon")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !coupon.IsAvailableAt(now) {
+		return nil, promotion.NewCouponError(code, "coupon is not active")
+	}
+
+	if coupon.UsageLimit > 0 || coupon.PerCustomerLimit > 0 {
+		usage, err := s.promotionRepo.GetUsage(ctx, coupon.ID, customerID)
+		if err != nil {
+			return nil, err
+		}
+		if coupon.UsageLimit > 0 && usage.Total >= coupon.UsageLimit {
+			return nil, promotion.NewCouponError(code, "usage limit reached")
+		}
+		if coupon.PerCustomerLimit > 0 && usage.Customer >= coupon.PerCustomerLimit {
+			return nil, promotion.NewCouponError(code, "customer usage limit reached")
+		}
+	}
+	return coupon, nil
+}
+
+func (s *PromotionService) ConfirmRedemptions(ctx context.Context, orderID string) error {
+	if orderID == "" {
+		return order.NewValidationError("order_id is required")
+	}
+	return s.promotionRepo.ConfirmRedemptions(ctx, orderID)
+}
+
+func (s *PromotionService) ReleaseRedemptions(ctx context.Context, orderID string) error {
+	if orderID == "" {
+		return order.NewValidationError("order_id is required")
+	}
+	return s.promotionRepo.ReleaseRedemptions(ctx, orderID)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/shipping"
+)
+
+// ShippingService считает вес корзины по каталогу и передаёт его провайдеру тарифов.
+type ShippingService struct {
+	inventoryService inventory.Service
+	provider         shipping.RateProvider
+}
+
+func NewShippingService(inventoryService inventory.Service, provider shipping.RateProvider) *ShippingService {
+	return &ShippingService{inventoryService: inventoryService, provider: provider}
+}
+
+func (s *ShippingService) Quote(ctx context.Context, req *shipping.QuoteRequest) ([]shipping.Rate, error) {
+	if shipping.NormalizeCode(req.Destination.Country) == "" {
+		return nil, shipping.NewValidationError("destination country is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, shipping.NewValidationError("items are required")
+	}
+
+	weight := 0.0
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, shipping.NewValidationError("quantity must be positive for product " + item.ProductID)
+		}
+		product, err := s.inventoryService.GetProduct(ctx, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		weight += product.Weight * float64(item.Quantity)
+	}
+
+	return s.provider.Rates(ctx, &shipping.RateRequest{
+		Destination: req.Destination,
+		Weight:      weight,
+		Subtotal:    req.Subtotal,
+	})
+}
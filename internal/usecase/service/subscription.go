@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"orderflow/internal/domain/subscription"
+	"orderflow/pkg/logger"
+)
+
+type SubscriptionService struct {
+	subscriptionRepo subscription.Repository
+}
+
+func NewSubscriptionService(subscriptionRepo subscription.Repository) *SubscriptionService {
+	return &SubscriptionService{
+		subscriptionRepo: subscriptionRepo,
+	}
+}
+
+func (s *SubscriptionService) Save(ctx context.Context, subscriptionEntity *subscription.Subscription) error {
+	if subscriptionEntity.ID == "" {
+		return subscription.NewValidationError("subscription_id is required")
+	}
+
+	if err := subscriptionEntity.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if subscriptionEntity.CreatedAt.IsZero() {
+		subscriptionEntity.CreatedAt = now
+	}
+	subscriptionEntity.UpdatedAt = now
+
+	if err := s.subscriptionRepo.Save(ctx, subscriptionEntity); err != nil {
+		return err
+	}
+
+	logger.Info("Subscription saved",
+		"subscription_id", subscriptionEntity.ID,
+		"status", subscriptionEntity.Status,
+		"cycles_completed", subscriptionEntity.CyclesCompleted,
+		"next_run_at", subscriptionEntity.NextRunAt)
+	return nil
+}
+
+func (s *SubscriptionService) GetByID(ctx context.Context, id string) (*subscription.Subscription, error) {
+	if id == "" {
+		return nil, subscription.NewValidationError("subscription_id is required")
+	}
+
+	return s.subscriptionRepo.GetByID(ctx, id)
+}
+
+func (s *SubscriptionService) GetByCustomerID(ctx context.Context, customerID string) ([]*subscription.Subscription, error) {
+	if customerID == "" {
+		return nil, subscription.NewValidationError("customer_id is required")
+	}
+
+	return s.subscriptionRepo.GetByCustomerID(ctx, customerID)
+}
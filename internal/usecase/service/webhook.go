@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"orderflow/internal/domain/outbox"
+	"orderflow/internal/domain/webhook"
+	"orderflow/pkg/logger"
+)
+
+type WebhookService struct {
+	webhookRepo webhook.Repository
+	sender      webhook.Sender
+	maxFailures int
+}
+
+func NewWebhookService(webhookRepo webhook.Repository, sender webhook.Sender, maxFailures int) *WebhookService {
+	if maxFailures <= 0 {
+		maxFailures = webhook.DefaultMaxConsecutiveFailures
+	}
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		sender:      sender,
+		maxFailures: maxFailures,
+	}
+}
+
+func (s *WebhookService) CreateSubscription(ctx context.Context, req *webhook.CreateRequest) (*webhook.Subscription, error) {
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = generated
+	}
+
+	now := time.Now()
+	subscription := &webhook.Subscription{
+		ID:         uuid.New().String(),
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+		Status:     webhook.StatusActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Webhook subscription created",
+		"subscription_id", subscription.ID,
+		"url", subscription.URL,
+		"event_types", subscription.EventTypes)
+	return subscription, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id string) (*webhook.Subscription, error) {
+	if id == "" {
+		return nil, webhook.NewValidationError("subscription id is required")
+	}
+	return s.webhookRepo.GetSubscription(ctx, id)
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*webhook.Subscription, error) {
+	return s.webhookRepo.ListSubscriptions(ctx)
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) error {
+	if id == "" {
+		return webhook.NewValidationError("subscription id is required")
+	}
+	if err := s.webhookRepo.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+
+	logger.Info("Webhook subscription deleted", "subscription_id", id)
+	return nil
+}
+
+func (s *WebhookService) EnableSubscription(ctx context.Context, id string) (*webhook.Subscription, error) {
+	if id == "" {
+		return nil, webhook.NewValidationError("subscription id is required")
+	}
+	if err := s.webhookRepo.Enable(ctx, id); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Webhook subscription enabled", "subscription_id", id)
+	return s.webhookRepo.GetSubscription(ctx, id)
+}
+
+func (s *WebhookService) CreateDeliveries(ctx context.Context, event *outbox.Event) ([]*webhook.Delivery, error) {
+	subscriptions, err := s.webhookRepo.ListActiveSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	var deliveries []*webhook.Delivery
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(event.Type) {
+			continue
+		}
+
+		if body == nil {
+			if body, err = json.Marshal(event); err != nil {
+				return nil, err
+			}
+		}
+
+		now := time.Now()
+		delivery := &webhook.Delivery{
+			ID:             webhook.DeliveryID(subscription.ID, event.DedupKey),
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			DedupKey:       event.DedupKey,
+			EventType:      event.Type,
+			Body:           body,
+			Status:         webhook.DeliveryPending,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, subscriptionID, deliveryID string) (*webhook.Delivery, error) {
+	delivery, err := s.getSubscriptionDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.webhookRepo.ListAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+	delivery.AttemptLog = attempts
+	return delivery, nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID string) ([]*webhook.Delivery, error) {
+	if _, err := s.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.ListDeliveries(ctx, subscriptionID, webhook.DefaultDeliveryLogLimit)
+}
+
+func (s *WebhookService) Deliver(ctx context.Context, deliveryID string) error {
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+	if delivery.Status == webhook.DeliverySucceeded {
+		return nil
+	}
+
+	subscription, err := s.webhookRepo.GetSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if !subscription.IsActive() {
+		return webhook.NewDisabledError(subscription.ID)
+	}
+
+	now := time.Now()
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	headers.Set(webhook.SignatureHeader, webhook.Sign(subscription.Secret, now, delivery.Body))
+	headers.Set(webhook.EventTypeHeader, delivery.EventType)
+	headers.Set(webhook.DeliveryIDHeader, delivery.ID)
+	headers.Set(webhook.IdempotencyHeader, delivery.DedupKey)
+
+	resp, sendErr := s.sender.Send(ctx, &webhook.SendRequest{
+		URL:     subscription.URL,
+		Headers: headers,
+		Body:    delivery.Body,
+	})
+
+	attempt := &webhook.Attempt{
+		DeliveryID: delivery.ID,
+		Duration:   time.Since(now).Milliseconds(),
+		CreatedAt:  now,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	} else {
+		attempt.StatusCode = resp.StatusCode
+		body := resp.Body
+		if len(body) > webhook.MaxStoredResponseBytes {
+			body = body[:webhook.MaxStoredResponseBytes]
+		}
+		attempt.ResponseBody = string(body)
+		if !attempt.Succeeded() {
+			attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		}
+	}
+
+	if err := s.webhookRepo.AddAttempt(ctx, attempt); err != nil {
+		return err
+	}
+
+	if attempt.Succeeded() {
+		logger.Info("Webhook delivered",
+			"delivery_id", delivery.ID,
+			"subscription_id", subscription.ID,
+			"event_type", delivery.EventType,
+			"status_code", attempt.StatusCode)
+		return nil
+	}
+
+	logger.Warn("Webhook delivery attempt failed",
+		"delivery_id", delivery.ID,
+		"subscription_id", subscription.ID,
+		"status_code", attempt.StatusCode,
+		"error", attempt.Error)
+	return webhook.NewDeliveryError(attempt.StatusCode, attempt.Error, isRetryableWebhookStatus(attempt.StatusCode))
+}
+
+func (s *WebhookService) CompleteDelivery(ctx context.Context, deliveryID string, delivered bool) (bool, error) {
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return false, err
+	}
+
+	status := webhook.DeliveryFailed
+	if delivered {
+		status = webhook.DeliverySucceeded
+	}
+	if err := s.webhookRepo.SetDeliveryStatus(ctx, deliveryID, status); err != nil {
+		return false, err
+	}
+
+	disabled, err := s.webhookRepo.RecordDeliveryOutcome(ctx, delivery.SubscriptionID, delivered, s.maxFailures)
+	if err != nil {
+		return false, err
+	}
+	if disabled {
+		logger.Warn("Webhook subscription disabled after consecutive failed deliveries",
+			"subscription_id", delivery.SubscriptionID,
+			"max_failures", s.maxFailures)
+	}
+	return disabled, nil
+}
+
+func (s *WebhookService) PrepareRedelivery(ctx context.Context, subscriptionID, deliveryID string) (*webhook.Delivery, error) {
+	subscription, err := s.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.IsActive() {
+		return nil, webhook.NewDisabledError(subscriptionID)
+	}
+
+	delivery, err := s.getSubscriptionDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.SetDeliveryStatus(ctx, delivery.ID, webhook.DeliveryPending); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Webhook redelivery requested",
+		"delivery_id", delivery.ID,
+		"subscription_id", subscriptionID,
+		"previous_status", delivery.Status)
+	return delivery, nil
+}
+
+func (s *WebhookService) getSubscriptionDelivery(ctx context.Context, subscriptionID, deliveryID string) (*webhook.Delivery, error) {
+	if subscriptionID == "" || deliveryID == "" {
+		return nil, webhook.NewValidationError("subscription id and delivery id are required")
+	}
+
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.SubscriptionID != subscriptionID {
+		return nil, webhook.NewDeliveryNotFoundError(deliveryID)
+	}
+	return delivery, nil
+}
+
+// isRetryableWebhookStatus: сетевые ошибки, 5xx, 408 и 429 повторяются,
+// остальные 4xx означают, что получатель отверг событие.
+func isRetryableWebhookStatus(statusCode int) bool {
+	if statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return statusCode < 400 || statusCode >= 500
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/outbox"
+	"orderflow/internal/domain/webhook"
+)
+
+// outboxOrderRepo, как OrderPG.updateWithOutbox, пишет событие outbox на каждую смену статуса в Update.
+type outboxOrderRepo struct {
+	*memoryOrderRepo
+	events []*outbox.Event
+}
+
+func (r *outboxOrderRepo) Update(ctx context.Context, o *order.Order) error {
+	previous := r.orders[o.ID].Status
+	if err := r.memoryOrderRepo.Update(ctx, o); err != nil {
+		return err
+	}
+	if previous == o.Status {
+		return nil
+	}
+
+	event, err := outbox.NewOrderStatusEvent(outbox.OrderStatusPayload{
+		OrderID:        o.ID,
+		CustomerID:     o.CustomerID,
+		Status:         o.Status,
+		PreviousStatus: previous,
+		TotalAmount:    o.TotalAmount,
+		PaymentID:      o.PaymentID,
+	}, time.Now())
+	if err != nil {
+		return err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+// memoryWebhookRepo отдаёт заданные подписки и запоминает созданные доставки.
+type memoryWebhookRepo struct {
+	webhook.Repository
+	subscriptions []*webhook.Subscription
+	deliveries    []*webhook.Delivery
+}
+
+func (r *memoryWebhookRepo) ListActiveSubscriptions(ctx context.Context) ([]*webhook.Subscription, error) {
+	return r.subscriptions, nil
+}
+
+func (r *memoryWebhookRepo) CreateDelivery(ctx context.Context, delivery *webhook.Delivery) error {
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func TestCompletedOrderCreatesOrderCompletedDelivery(t *testing.T) {
+	orders := &outboxOrderRepo{memoryOrderRepo: &memoryOrderRepo{orders: map[string]*order.Order{
+		"order-1": {ID: "order-1", CustomerID: "customer-1", Status: order.StatusPayment, TotalAmount: 99.99},
+	}}}
+	webhooks := &memoryWebhookRepo{subscriptions: []*webhook.Subscription{
+		{ID: "sub-orders", EventTypes: []string{"order.*"}, Status: webhook.StatusActive},
+		{ID: "sub-payments", EventTypes: []string{"payment.*"}, Status: webhook.StatusActive},
+	}}
+	orderService := NewOrderService(orders, nil, nil)
+	webhookService := NewWebhookService(webhooks, nil, 0)
+
+	if err := orderService.Complete(context.Background(), "order-1", "pay-1"); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	// Повтор activity не меняет статус и не даёт второго события
+	if err := orderService.Complete(context.Background(), "order-1", "pay-1"); err != nil {
+		t.Fatalf("repeated Complete() error = %v", err)
+	}
+	if len(orders.events) != 1 {
+		t.Fatalf("outbox events = %d, want 1", len(orders.events))
+	}
+
+	for _, event := range orders.events {
+		if _, err := webhookService.CreateDeliveries(context.Background(), event); err != nil {
+			t.Fatalf("CreateDeliveries() error = %v", err)
+		}
+	}
+
+	if len(webhooks.deliveries) != 1 {
+		t.Fatalf("deliveries = %d, want 1 for the order.* subscription", len(webhooks.deliveries))
+	}
+	delivery := webhooks.deliveries[0]
+	if delivery.SubscriptionID != "sub-orders" || delivery.EventType != "order.completed" {
+		t.Errorf("delivery = %s/%s, want sub-orders/order.completed", delivery.SubscriptionID, delivery.EventType)
+	}
+
+	var body struct {
+		Payload outbox.OrderStatusPayload `json:"payload"`
+	}
+	if err := json.Unmarshal(delivery.Body, &body); err != nil {
+		t.Fatalf("delivery body: %v", err)
+	}
+	if body.Payload.PaymentID != "pay-1" || body.Payload.PreviousStatus != order.StatusPayment {
+		t.Errorf("payload = %+v, want payment pay-1 after status payment", body.Payload)
+	}
+}
@@ -0,0 +1,109 @@
+package stockalerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/notification"
+	"orderflow/internal/domain/outbox"
+	"orderflow/pkg/logger"
+)
+
+// Notifier получает события из релея outbox и на каждое product.stock_low отправляет
+// уведомление TypeStockLow мерчантам. Получатели — ID клиентов из справочника: канал,
+// адрес и согласие на рассылку берутся оттуда же, как у уведомлений о заказах.
+// Ключ идемпотентности производен от события, поэтому повторная публикация события
+// релеем не приводит к повторной отправке.
+type Notifier struct {
+	notificationService notification.Service
+	recipients          []string
+}
+
+func NewNotifier(notificationService notification.Service, recipients []string) *Notifier {
+	return &Notifier{
+		notificationService: notificationService,
+		recipients:          recipients,
+	}
+}
+
+func (n *Notifier) Publish(ctx context.Context, event *outbox.Event) error {
+	if event.Type != outbox.EventTypeStockLow {
+		return nil
+	}
+
+	var alert inventory.StockLow
+	if err := json.Unmarshal(event.Payload, &alert); err != nil {
+		// Повтор не исправит payload: событие пропускается
+		logger.Error("Invalid stock_low event payload", "event_id", event.ID, "error", err)
+		return nil
+	}
+
+	for _, recipient := range n.recipients {
+		req := &notification.Request{
+			CustomerID: recipient,
+			Type:       notification.TypeStockLow,
+			Metadata: map[string]string{
+				"product_id": alert.ProductID,
+				"sku":        alert.SKU,
+			},
+			Data:           templateData(&alert),
+			IdempotencyKey: event.DedupKey + ":" + recipient,
+		}
+
+		err := n.notificationService.Send(ctx, req)
+		if isPermanent(err) {
+			logger.Error("Stock alert not delivered",
+				"product_id", alert.ProductID,
+				"sku", alert.SKU,
+				"recipient", recipient,
+				"error", err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to send stock alert to %s: %w", recipient, err)
+		}
+	}
+
+	logger.Info("Stock alert sent",
+		"product_id", alert.ProductID,
+		"sku", alert.SKU,
+		"free", alert.Free,
+		"reorder_point", alert.ReorderPoint,
+		"recipients", len(n.recipients))
+	return nil
+}
+
+func templateData(alert *inventory.StockLow) *notification.TemplateData {
+	return &notification.TemplateData{
+		Stock: &notification.TemplateStock{
+			ProductID:    alert.ProductID,
+			SKU:          alert.SKU,
+			Name:         alert.Name,
+			Available:    alert.Available,
+			Reserved:     alert.Reserved,
+			Free:         alert.Free,
+			ReorderPoint: alert.ReorderPoint,
+			WarehouseID:  alert.WarehouseID,
+		},
+	}
+}
+
+// isPermanent — ошибки, которые повтор публикации не исправит: уведомление уже помечено
+// dead или не может быть создано. Остальные ошибки (хранилище) релей повторит.
+func isPermanent(err error) bool {
+	var (
+		validationErr  *notification.ValidationError
+		templateErr    *notification.TemplateError
+		sendErr        *notification.SendError
+		recipientErr   *notification.RecipientNotFoundError
+		unsupportedErr *notification.UnsupportedChannelError
+	)
+	return errors.As(err, &validationErr) ||
+		errors.As(err, &templateErr) ||
+		errors.As(err, &sendErr) ||
+		errors.As(err, &recipientErr) ||
+		errors.As(err, &unsupportedErr)
+}
@@ -0,0 +1,101 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+
+	"orderflow/internal/domain/outbox"
+	"orderflow/internal/domain/webhook"
+	"orderflow/internal/domain/workflow"
+	"orderflow/pkg/logger"
+)
+
+// Dispatcher получает события из релея outbox, создаёт доставки для подходящих
+// подписок и запускает по WebhookDeliveryWorkflow на каждую доставку.
+// ID workflow производен от ID доставки, поэтому повторная публикация события
+// релеем не приводит к повторной отправке.
+type Dispatcher struct {
+	temporalClient client.Client
+	webhookService webhook.Service
+}
+
+func NewDispatcher(temporalClient client.Client, webhookService webhook.Service) *Dispatcher {
+	return &Dispatcher{
+		temporalClient: temporalClient,
+		webhookService: webhookService,
+	}
+}
+
+func (d *Dispatcher) Publish(ctx context.Context, event *outbox.Event) error {
+	deliveries, err := d.webhookService.CreateDeliveries(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		err := d.start(ctx, delivery, enumspb.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE)
+		if err != nil && !isAlreadyStarted(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redeliver отправляет доставку заново новым запуском workflow с тем же ID.
+func (d *Dispatcher) Redeliver(ctx context.Context, subscriptionID, deliveryID string) (*webhook.Delivery, error) {
+	delivery, err := d.webhookService.PrepareRedelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.start(ctx, delivery, enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE)
+	if isAlreadyStarted(err) {
+		return nil, webhook.NewRedeliveryInProgressError(deliveryID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Status = webhook.DeliveryPending
+	return delivery, nil
+}
+
+func (d *Dispatcher) start(ctx context.Context, delivery *webhook.Delivery, reusePolicy enumspb.WorkflowIdReusePolicy) error {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                                       WorkflowID(delivery.ID),
+		TaskQueue:                                workflow.OrderProcessingTaskQueue,
+		WorkflowIDReusePolicy:                    reusePolicy,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}
+
+	input := &workflow.WebhookDeliveryInput{
+		DeliveryID:     delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventType:      delivery.EventType,
+	}
+
+	run, err := d.temporalClient.ExecuteWorkflow(ctx, workflowOptions, workflow.WebhookDeliveryWorkflow, input)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Webhook delivery workflow started",
+		"delivery_id", delivery.ID,
+		"subscription_id", delivery.SubscriptionID,
+		"workflow_id", run.GetID(),
+		"run_id", run.GetRunID())
+	return nil
+}
+
+func isAlreadyStarted(err error) bool {
+	var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+	return errors.As(err, &alreadyStarted)
+}
+
+func WorkflowID(deliveryID string) string {
+	return workflow.WebhookDeliveryWorkflowIDPrefix + deliveryID
+}
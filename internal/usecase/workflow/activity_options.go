@@ -0,0 +1,74 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	workflowDomain "orderflow/internal/domain/workflow"
+)
+
+// activityConfigs задаётся один раз при старте воркера (ConfigureActivities) и дальше только читается.
+// Таймауты и retry policy не участвуют в сверке команд при replay, поэтому их изменение
+// в конфиге не ломает детерминизм уже запущенных workflow.
+var activityConfigs = workflowDomain.DefaultActivityConfigs()
+
+// ConfigureActivities накладывает настройки из конфига на значения по умолчанию.
+// Имена activities сравниваются без учёта регистра: viper приводит ключи к нижнему регистру.
+func ConfigureActivities(overrides map[string]workflowDomain.ActivityConfig) error {
+	configs := workflowDomain.DefaultActivityConfigs()
+
+	for name, override := range overrides {
+		matched := false
+		for activityName, base := range configs {
+			if strings.EqualFold(name, activityName) {
+				configs[activityName] = base.Merge(override)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("unknown activity in config: %s", name)
+		}
+	}
+
+	activityConfigs = configs
+	return nil
+}
+
+func activityOptions(name string) workflow.ActivityOptions {
+	cfg, ok := activityConfigs[name]
+	if !ok {
+		cfg = workflowDomain.DefaultActivityConfig()
+	}
+
+	return workflow.ActivityOptions{
+		StartToCloseTimeout:    cfg.StartToCloseTimeout,
+		ScheduleToCloseTimeout: cfg.ScheduleToCloseTimeout,
+		HeartbeatTimeout:       cfg.HeartbeatTimeout,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:        cfg.InitialInterval,
+			BackoffCoefficient:     cfg.BackoffCoefficient,
+			MaximumInterval:        cfg.MaximumInterval,
+			MaximumAttempts:        cfg.MaximumAttempts,
+			NonRetryableErrorTypes: cfg.NonRetryableErrorTypes,
+		},
+	}
+}
+
+// executeActivity запускает activity с её собственными ActivityOptions.
+func executeActivity(ctx workflow.Context, name string, args ...interface{}) workflow.Future {
+	return workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, activityOptions(name)), name, args...)
+}
+
+// executeLocalActivity запускает local activity с таймаутами и retry policy из её ActivityConfig.
+func executeLocalActivity(ctx workflow.Context, name string, args ...interface{}) workflow.Future {
+	options := activityOptions(name)
+	return workflow.ExecuteLocalActivity(workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{
+		StartToCloseTimeout:    options.StartToCloseTimeout,
+		ScheduleToCloseTimeout: options.ScheduleToCloseTimeout,
+		RetryPolicy:            options.RetryPolicy,
+	}), name, args...)
+}
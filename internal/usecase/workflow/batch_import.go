@@ -0,0 +1,123 @@
+package workflow
+
+import (
+	"fmt"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/workflow"
+
+	workflowDomain "orderflow/internal/domain/workflow"
+)
+
+func BatchOrderImportWorkflow(ctx workflow.Context, input *workflowDomain.BatchImportInput) (*workflowDomain.BatchImportReport, error) {
+	logger := workflow.GetLogger(ctx)
+
+	concurrency := input.Concurrency
+	if concurrency <= 0 {
+		concurrency = workflowDomain.DefaultBatchImportConcurrency
+	}
+	if concurrency > workflowDomain.MaxBatchImportConcurrency {
+		concurrency = workflowDomain.MaxBatchImportConcurrency
+	}
+
+	logger.Info("Starting BatchOrderImportWorkflow",
+		"batch_id", input.BatchID,
+		"lines", len(input.Lines),
+		"concurrency", concurrency)
+
+	report := workflowDomain.NewBatchImportReport(input)
+
+	err := workflow.SetQueryHandler(ctx, workflowDomain.BatchImportProgressQuery, func() (*workflowDomain.BatchImportReport, error) {
+		return report, nil
+	})
+	if err != nil {
+		logger.Error("Failed to set batch progress query handler", "error", err)
+		return nil, err
+	}
+
+	selector := workflow.NewSelector(ctx)
+	running := 0
+	next := 0
+
+	for next < len(input.Lines) || running > 0 {
+		for running < concurrency && next < len(input.Lines) {
+			idx := next
+			next++
+
+			if input.Lines[idx].Error != "" {
+				continue
+			}
+
+			future := startBatchLine(ctx, input.BatchID, input.Lines[idx], &report.Lines[idx])
+			running++
+			report.Recount()
+
+			selector.AddFuture(future, func(f workflow.Future) {
+				running--
+				completeBatchLine(ctx, f, &report.Lines[idx])
+				report.Recount()
+			})
+		}
+
+		if running > 0 {
+			selector.Select(ctx)
+		}
+	}
+
+	report.Done = true
+
+	logger.Info("BatchOrderImportWorkflow completed",
+		"batch_id", input.BatchID,
+		"completed", report.Completed,
+		"failed", report.Failed,
+		"cancelled", report.Cancelled,
+		"invalid", report.Invalid)
+
+	return report, nil
+}
+
+func startBatchLine(
+	ctx workflow.Context,
+	batchID string,
+	line workflowDomain.BatchOrderLine,
+	result *workflowDomain.BatchLineResult,
+) workflow.ChildWorkflowFuture {
+	result.WorkflowID = fmt.Sprintf("%s%s-line-%d", workflowDomain.BatchImportWorkflowIDPrefix, batchID, line.Line)
+	result.Status = workflowDomain.BatchLineStatusRunning
+
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:               result.WorkflowID,
+		TaskQueue:                workflowDomain.OrderProcessingTaskQueue,
+		WorkflowExecutionTimeout: OrderExecutionTimeout(),
+		// Заказы уже живут своей жизнью: остановка пакета не должна обрывать их на середине
+		ParentClosePolicy: enumspb.PARENT_CLOSE_POLICY_ABANDON,
+	})
+
+	return workflow.ExecuteChildWorkflow(childCtx, workflowDomain.OrderProcessingWorkflow, &workflowDomain.OrderProcessingInput{
+		CustomerID:      line.CustomerID,
+		Items:           line.Items,
+		CouponCode:      line.CouponCode,
+		TaxJurisdiction: line.TaxJurisdiction,
+		ShippingAddress: line.ShippingAddress,
+		BillingAddress:  line.BillingAddress,
+		ShippingMethod:  line.ShippingMethod,
+	})
+}
+
+func completeBatchLine(ctx workflow.Context, future workflow.Future, result *workflowDomain.BatchLineResult) {
+	var orderResult *workflowDomain.WorkflowResult
+	err := future.Get(ctx, &orderResult)
+	if err != nil {
+		result.Status = workflowDomain.BatchLineStatusFailed
+		result.ErrorCode, result.Error = classifyError(err, workflowDomain.ErrorCodeInternalError)
+		return
+	}
+
+	result.OrderID = orderResult.OrderID
+	if orderResult.Success {
+		result.Status = workflowDomain.BatchLineStatusCompleted
+	} else {
+		result.Status = workflowDomain.BatchLineStatusCancelled
+		result.Error = orderResult.Message
+	}
+}
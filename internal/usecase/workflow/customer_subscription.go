@@ -0,0 +1,276 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"orderflow/internal/domain/subscription"
+	workflowDomain "orderflow/internal/domain/workflow"
+)
+
+type subscriptionChannels struct {
+	pause  workflow.ReceiveChannel
+	resume workflow.ReceiveChannel
+	skip   workflow.ReceiveChannel
+	cancel workflow.ReceiveChannel
+}
+
+func CustomerSubscriptionWorkflow(ctx workflow.Context, input *workflowDomain.CustomerSubscriptionInput) (*subscription.Subscription, error) {
+	logger := workflow.GetLogger(ctx)
+
+	sub := input.Subscription
+	// Запуски, продолженные старым кодом, передают пропуск только в input
+	sub.SkipNext = sub.SkipNext || input.SkipNext
+
+	logger.Info("Starting CustomerSubscriptionWorkflow",
+		"subscription_id", sub.ID,
+		"customer_id", sub.CustomerID,
+		"cycles_completed", sub.CyclesCompleted)
+
+	err := workflow.SetQueryHandler(ctx, workflowDomain.SubscriptionStateQuery, func() (*workflowDomain.CustomerSubscriptionInput, error) {
+		return &workflowDomain.CustomerSubscriptionInput{Subscription: sub, SkipNext: sub.SkipNext, Persisted: true}, nil
+	})
+	if err != nil {
+		logger.Error("Failed to set subscription state query handler", "error", err)
+		return nil, err
+	}
+
+	channels := subscriptionChannels{
+		pause:  workflow.GetSignalChannel(ctx, workflowDomain.PauseSubscriptionSignal),
+		resume: workflow.GetSignalChannel(ctx, workflowDomain.ResumeSubscriptionSignal),
+		skip:   workflow.GetSignalChannel(ctx, workflowDomain.SkipSubscriptionSignal),
+		cancel: workflow.GetSignalChannel(ctx, workflowDomain.CancelSubscriptionSignal),
+	}
+
+	if !input.Persisted {
+		if sub.Status == "" {
+			sub.Status = subscription.StatusActive
+		}
+		if sub.NextRunAt.IsZero() {
+			sub.NextRunAt = workflow.Now(ctx)
+		}
+		sub.WorkflowID = workflow.GetInfo(ctx).WorkflowExecution.ID
+
+		if err := saveSubscription(ctx, &sub); err != nil {
+			return nil, err
+		}
+	}
+
+	cyclesThisRun := 0
+	for {
+		if sub.IsCancelled() {
+			logger.Info("Subscription cancelled", "subscription_id", sub.ID)
+			return &sub, nil
+		}
+
+		changed := false
+		timerFired := false
+		selector := workflow.NewSelector(ctx)
+		addSubscriptionSignals(ctx, selector, channels, &sub, &changed)
+
+		var cancelTimer workflow.CancelFunc
+		if sub.IsActive() {
+			wait := sub.NextRunAt.Sub(workflow.Now(ctx))
+			if wait > 0 {
+				timerCtx, cancel := workflow.WithCancel(ctx)
+				cancelTimer = cancel
+				selector.AddFuture(workflow.NewTimer(timerCtx, wait), func(f workflow.Future) {
+					timerFired = f.Get(timerCtx, nil) == nil
+				})
+			} else {
+				timerFired = true
+			}
+		}
+
+		if !timerFired {
+			selector.Select(ctx)
+		}
+		if cancelTimer != nil && !timerFired {
+			cancelTimer()
+		}
+
+		// Сигналы, пришедшие одновременно со срабатыванием таймера, применяем до запуска цикла
+		if drainSubscriptionSignals(ctx, channels, &sub) {
+			changed = true
+		}
+
+		if changed {
+			if err := saveSubscription(ctx, &sub); err != nil {
+				return nil, err
+			}
+		}
+
+		if !timerFired || !sub.IsActive() {
+			continue
+		}
+
+		if sub.SkipNext {
+			sub.SkipNext = false
+			sub.CyclesSkipped++
+			logger.Info("Subscription cycle skipped", "subscription_id", sub.ID)
+		} else {
+			runSubscriptionCycle(ctx, &sub)
+		}
+
+		sub.NextRunAt = nextSubscriptionRun(sub.NextRunAt, sub.Interval, workflow.Now(ctx))
+		if err := saveSubscription(ctx, &sub); err != nil {
+			return nil, err
+		}
+
+		cyclesThisRun++
+		if cyclesThisRun >= workflowDomain.SubscriptionCyclesPerRun || workflow.GetInfo(ctx).GetContinueAsNewSuggested() {
+			drainSubscriptionSignals(ctx, channels, &sub)
+
+			logger.Info("Continuing subscription as new", "subscription_id", sub.ID, "cycles_completed", sub.CyclesCompleted)
+			return nil, workflow.NewContinueAsNewError(ctx, workflowDomain.CustomerSubscriptionWorkflow, &workflowDomain.CustomerSubscriptionInput{
+				Subscription: sub,
+				SkipNext:     sub.SkipNext,
+				Persisted:    true,
+			})
+		}
+	}
+}
+
+func runSubscriptionCycle(ctx workflow.Context, sub *subscription.Subscription) {
+	logger := workflow.GetLogger(ctx)
+
+	cycle := sub.CyclesCompleted + 1
+	// ID дочернего workflow строим из ID подписки, а не из GetInfo: так он не зависит от ID запуска
+	childID := fmt.Sprintf("%s%s-cycle-%d", workflowDomain.SubscriptionWorkflowIDPrefix, sub.ID, cycle)
+
+	logger.Info("Starting subscription order", "subscription_id", sub.ID, "cycle", cycle, "order_workflow_id", childID)
+
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:               childID,
+		TaskQueue:                workflowDomain.OrderProcessingTaskQueue,
+		WorkflowExecutionTimeout: OrderExecutionTimeout(),
+	})
+
+	var result *workflowDomain.WorkflowResult
+	err := workflow.ExecuteChildWorkflow(childCtx, workflowDomain.OrderProcessingWorkflow, &workflowDomain.OrderProcessingInput{
+		CustomerID: sub.CustomerID,
+		Items:      sub.Items,
+	}).Get(ctx, &result)
+	if err != nil {
+		logger.Warn("Subscription order failed", "subscription_id", sub.ID, "cycle", cycle, "error", err)
+	} else {
+		logger.Info("Subscription order completed", "subscription_id", sub.ID, "cycle", cycle, "order_id", result.OrderID)
+	}
+
+	sub.CyclesCompleted = cycle
+	sub.LastOrderWorkflowID = childID
+}
+
+func saveSubscription(ctx workflow.Context, sub *subscription.Subscription) error {
+	err := executeActivity(ctx, workflowDomain.SaveSubscriptionActivity, sub).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Failed to save subscription", "subscription_id", sub.ID, "error", err)
+	}
+	return err
+}
+
+func nextSubscriptionRun(previous time.Time, interval time.Duration, now time.Time) time.Time {
+	next := previous.Add(interval)
+	if next.Before(now) {
+		// Воркер мог простаивать: не догоняем пропущенные циклы пачкой
+		next = now.Add(interval)
+	}
+	return next
+}
+
+func addSubscriptionSignals(
+	ctx workflow.Context,
+	selector workflow.Selector,
+	channels subscriptionChannels,
+	sub *subscription.Subscription,
+	changed *bool,
+) {
+	handle := func(name string) func(workflow.ReceiveChannel, bool) {
+		return func(c workflow.ReceiveChannel, more bool) {
+			var signal workflowDomain.SubscriptionSignalInput
+			c.Receive(ctx, &signal)
+			if applySubscriptionSignal(ctx, name, signal, sub) {
+				*changed = true
+			}
+		}
+	}
+
+	selector.AddReceive(channels.pause, handle(workflowDomain.PauseSubscriptionSignal))
+	selector.AddReceive(channels.resume, handle(workflowDomain.ResumeSubscriptionSignal))
+	selector.AddReceive(channels.skip, handle(workflowDomain.SkipSubscriptionSignal))
+	selector.AddReceive(channels.cancel, handle(workflowDomain.CancelSubscriptionSignal))
+}
+
+func drainSubscriptionSignals(
+	ctx workflow.Context,
+	channels subscriptionChannels,
+	sub *subscription.Subscription,
+) bool {
+	changed := false
+	pending := []struct {
+		name    string
+		channel workflow.ReceiveChannel
+	}{
+		{workflowDomain.PauseSubscriptionSignal, channels.pause},
+		{workflowDomain.ResumeSubscriptionSignal, channels.resume},
+		{workflowDomain.SkipSubscriptionSignal, channels.skip},
+		{workflowDomain.CancelSubscriptionSignal, channels.cancel},
+	}
+
+	for _, p := range pending {
+		for {
+			var signal workflowDomain.SubscriptionSignalInput
+			if !p.channel.ReceiveAsync(&signal) {
+				break
+			}
+			if applySubscriptionSignal(ctx, p.name, signal, sub) {
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// applySubscriptionSignal применяет сигнал к состоянию подписки и возвращает true,
+// если состояние нужно сохранить в БД.
+func applySubscriptionSignal(
+	ctx workflow.Context,
+	name string,
+	signal workflowDomain.SubscriptionSignalInput,
+	sub *subscription.Subscription,
+) bool {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Received subscription signal", "subscription_id", sub.ID, "signal", name, "reason", signal.Reason)
+
+	switch name {
+	case workflowDomain.PauseSubscriptionSignal:
+		if sub.IsActive() {
+			sub.Status = subscription.StatusPaused
+			return true
+		}
+	case workflowDomain.ResumeSubscriptionSignal:
+		if sub.IsPaused() {
+			sub.Status = subscription.StatusActive
+			if now := workflow.Now(ctx); sub.NextRunAt.Before(now) {
+				sub.NextRunAt = now
+			}
+			return true
+		}
+	case workflowDomain.SkipSubscriptionSignal:
+		if !sub.IsCancelled() && !sub.SkipNext {
+			sub.SkipNext = true
+			// Старый код хранил пропуск только в workflow и не сохранял подписку после сигнала
+			return getVersion(ctx, ChangeSubscriptionSkipPersisted) >= 1
+		}
+	case workflowDomain.CancelSubscriptionSignal:
+		if !sub.IsCancelled() {
+			sub.Status = subscription.StatusCancelled
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	workflowDomain "orderflow/internal/domain/workflow"
+)
+
+// orderTimeouts задаётся при старте воркера (ConfigureOrderTimeouts). Запущенный workflow
+// фиксирует значения через SideEffect, поэтому смена конфига не влияет на его replay.
+var orderTimeouts = workflowDomain.DefaultOrderTimeouts()
+
+// ConfigureOrderTimeouts накладывает дедлайн и SLA шагов из конфига на значения по умолчанию.
+// SLA можно задать только для шагов, которые ждут activity через selector.
+func ConfigureOrderTimeouts(override workflowDomain.OrderTimeouts) error {
+	defaults := workflowDomain.DefaultOrderTimeouts()
+	for step := range override.StepSLAs {
+		if _, ok := defaults.StepSLAs[step]; !ok {
+			return fmt.Errorf("unknown order step in step_sla: %s", step)
+		}
+	}
+
+	merged := defaults.Merge(override)
+	if merged.ExecutionTimeout > 0 && merged.Deadline > 0 && merged.ExecutionTimeout <= merged.Deadline {
+		return fmt.Errorf("order execution_timeout %s must exceed deadline %s", merged.ExecutionTimeout, merged.Deadline)
+	}
+
+	orderTimeouts = merged
+	return nil
+}
+
+// OrderExecutionTimeout — WorkflowExecutionTimeout для запуска OrderProcessingWorkflow (0 — без ограничения).
+func OrderExecutionTimeout() time.Duration {
+	return orderTimeouts.ExecutionTimeout
+}
+
+// orderDeadlines держит durable-таймеры общего дедлайна заказа и SLA текущего шага.
+type orderDeadlines struct {
+	ctx      workflow.Context
+	enabled  bool
+	timeouts workflowDomain.OrderTimeouts
+
+	deadline workflow.Future
+
+	step            string
+	stepTimer       workflow.Future
+	cancelStepTimer workflow.CancelFunc
+	cancelActivity  workflow.CancelFunc
+}
+
+func newOrderDeadlines(ctx workflow.Context) *orderDeadlines {
+	d := &orderDeadlines{ctx: ctx}
+	if getVersion(ctx, ChangeOrderDeadlines) < 1 {
+		return d
+	}
+
+	err := workflow.SideEffect(ctx, func(workflow.Context) interface{} {
+		return orderTimeouts
+	}).Get(&d.timeouts)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Failed to read order timeouts, deadlines disabled", "error", err)
+		return d
+	}
+
+	d.enabled = true
+	if d.timeouts.Deadline > 0 {
+		d.deadline = workflow.NewTimer(ctx, d.timeouts.Deadline)
+	}
+	return d
+}
+
+// startStep запускает SLA-таймер шага и возвращает контекст для activity шага,
+// который отменяется при нарушении дедлайна. Без версии ChangeOrderDeadlines
+// возвращается исходный контекст, чтобы не менять последовательность команд.
+func (d *orderDeadlines) startStep(ctx workflow.Context, step string) workflow.Context {
+	if !d.enabled {
+		return ctx
+	}
+	d.stopStep()
+
+	stepCtx, cancelActivity := workflow.WithCancel(ctx)
+	d.step = step
+	d.cancelActivity = cancelActivity
+
+	if sla := d.timeouts.StepSLAs[step]; sla > 0 {
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		d.stepTimer = workflow.NewTimer(timerCtx, sla)
+		d.cancelStepTimer = cancelTimer
+	}
+	return stepCtx
+}
+
+// stopStep отменяет SLA-таймер завершившегося шага.
+func (d *orderDeadlines) stopStep() {
+	if d.cancelStepTimer != nil {
+		d.cancelStepTimer()
+	}
+	d.step = ""
+	d.stepTimer = nil
+	d.cancelStepTimer = nil
+	d.cancelActivity = nil
+}
+
+// abortStep отменяет activity шага, на котором сработал дедлайн.
+func (d *orderDeadlines) abortStep() {
+	if d.cancelActivity != nil {
+		d.cancelActivity()
+	}
+	d.stopStep()
+}
+
+// addToSelector добавляет в selector срабатывание дедлайна заказа и SLA текущего шага.
+// Нарушение записывается в state, шаг после Select проверяет state.IsTimedOut.
+func (d *orderDeadlines) addToSelector(selector workflow.Selector, state *workflowDomain.State) {
+	if d.deadline != nil {
+		deadline := d.timeouts.Deadline
+		selector.AddFuture(d.deadline, func(f workflow.Future) {
+			workflow.GetLogger(d.ctx).Warn("Order deadline exceeded", "step", state.CurrentStep, "deadline", deadline)
+			state.SetTimeout(state.CurrentStep, deadline)
+		})
+	}
+
+	if d.stepTimer != nil {
+		step, sla := d.step, d.timeouts.StepSLAs[d.step]
+		selector.AddFuture(d.stepTimer, func(f workflow.Future) {
+			if err := f.Get(d.ctx, nil); err != nil {
+				return // таймер отменён
+			}
+			workflow.GetLogger(d.ctx).Warn("Step SLA exceeded", "step", step, "sla", sla)
+			state.SetTimeout(step, sla)
+		})
+	}
+}
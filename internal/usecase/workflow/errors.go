@@ -0,0 +1,48 @@
+package workflow
+
+import (
+	"errors"
+
+	"go.temporal.io/sdk/temporal"
+
+	workflowDomain "orderflow/internal/domain/workflow"
+)
+
+// classifyError возвращает код для State.ErrorCode и сообщение без обёрток Temporal.
+// Код берётся из типа ApplicationError, который выставляет activity; fallbackCode —
+// для ошибок без известного типа (таймауты, паники, ответы старых воркеров).
+func classifyError(err error, fallbackCode string) (string, string) {
+	var (
+		appErr      *temporal.ApplicationError
+		canceledErr *temporal.CanceledError
+		timeoutErr  *temporal.TimeoutError
+	)
+
+	switch {
+	case errors.As(err, &appErr):
+		if workflowDomain.IsErrorCode(appErr.Type()) {
+			return appErr.Type(), appErr.Message()
+		}
+		return fallbackCode, appErr.Message()
+	case errors.As(err, &canceledErr):
+		return workflowDomain.ErrorCodeOrderCancelled, err.Error()
+	case errors.As(err, &timeoutErr):
+		return fallbackCode, "timeout (" + timeoutErr.TimeoutType().String() + "): " + err.Error()
+	}
+
+	return fallbackCode, err.Error()
+}
+
+// setActivityError записывает ошибку activity в state, классифицируя её по типу.
+func setActivityError(state *workflowDomain.State, err error, fallbackCode string) {
+	state.SetError(classifyError(err, fallbackCode))
+}
+
+// workflowFailure — ошибка завершения workflow с тем же кодом, что и State.ErrorCode,
+// чтобы родительские workflow (пакетный импорт, подписки) видели тип ошибки.
+func workflowFailure(state *workflowDomain.State) error {
+	return temporal.NewNonRetryableApplicationError(state.ErrorMessage, state.ErrorCode, nil, workflowDomain.ErrorDetails{
+		Activity: workflowDomain.OrderProcessingWorkflow,
+		Step:     state.CurrentStep,
+	})
+}
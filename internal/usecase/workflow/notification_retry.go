@@ -0,0 +1,61 @@
+package workflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	"orderflow/internal/domain/notification"
+	workflowDomain "orderflow/internal/domain/workflow"
+)
+
+const defaultNotificationRetryMaxBatches = 50
+
+// NotificationRetryWorkflow запускается по расписанию и разбирает очередь повторов
+// уведомлений пачками, пока пачка заполнена целиком, но не больше MaxBatches за запуск.
+func NotificationRetryWorkflow(ctx workflow.Context, input *workflowDomain.NotificationRetryInput) (*workflowDomain.NotificationRetryResult, error) {
+	logger := workflow.GetLogger(ctx)
+
+	if input == nil {
+		input = &workflowDomain.NotificationRetryInput{}
+	}
+	batchSize := input.BatchSize
+	if batchSize <= 0 {
+		batchSize = notification.DefaultRetryBatchSize
+	}
+	maxBatches := input.MaxBatches
+	if maxBatches <= 0 {
+		maxBatches = defaultNotificationRetryMaxBatches
+	}
+
+	logger.Info("Starting NotificationRetryWorkflow", "batch_size", batchSize, "max_batches", maxBatches)
+
+	result := &workflowDomain.NotificationRetryResult{}
+
+	for batch := 0; batch < maxBatches; batch++ {
+		var output *workflowDomain.RetryNotificationsActivityOutput
+		err := executeActivity(ctx, workflowDomain.RetryNotificationsActivity,
+			&workflowDomain.RetryNotificationsActivityInput{BatchSize: batchSize}).Get(ctx, &output)
+		if err != nil {
+			logger.Error("Notification retry batch failed", "batch", batch, "error", err)
+			return result, err
+		}
+
+		result.Claimed += output.Claimed
+		result.Sent += output.Sent
+		result.Rescheduled += output.Rescheduled
+		result.Dead += output.Dead
+		result.Digested += output.Digested
+
+		if output.Claimed < batchSize {
+			break
+		}
+	}
+
+	logger.Info("NotificationRetryWorkflow completed",
+		"claimed", result.Claimed,
+		"sent", result.Sent,
+		"rescheduled", result.Rescheduled,
+		"dead", result.Dead,
+		"digested", result.Digested)
+
+	return result, nil
+}
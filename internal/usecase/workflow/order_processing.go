@@ -1,9 +1,6 @@
 package workflow
 
 import (
-	"time"
-
-	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
 	"orderflow/internal/domain/notification"
@@ -18,21 +15,9 @@ func OrderProcessingWorkflow(ctx workflow.Context, input *workflowDomain.OrderPr
 
 	state := workflowDomain.NewState("", input.CustomerID)
 
-	retryPolicy := &temporal.RetryPolicy{
-		InitialInterval:    workflowDomain.DefaultInitialInterval,
-		BackoffCoefficient: workflowDomain.DefaultBackoffCoefficient,
-		MaximumInterval:    workflowDomain.DefaultMaximumInterval,
-		MaximumAttempts:    workflowDomain.DefaultMaximumAttempts,
-	}
-
-	activityOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: 30 * time.Second,
-		RetryPolicy:         retryPolicy,
-	}
-
-	ctx = workflow.WithActivityOptions(ctx, activityOptions)
-
 	cancelChannel := workflow.GetSignalChannel(ctx, workflowDomain.CancelOrderSignal)
+	reservationExpiredChannel := workflow.GetSignalChannel(ctx, workflowDomain.ReservationExpiredSignal)
+	paymentEventChannel := workflow.GetSignalChannel(ctx, workflowDomain.PaymentProviderEventSignal)
 
 	err := workflow.SetQueryHandler(ctx, workflowDomain.OrderStatusQuery, func() (order.Status, error) {
 		return state.Status, nil
@@ -50,15 +35,33 @@ func OrderProcessingWorkflow(ctx workflow.Context, input *workflowDomain.OrderPr
 		return nil, err
 	}
 
+	deadlines := newOrderDeadlines(ctx)
+	events := newStepEvents(ctx)
+	// Каждый выход из workflow идёт через finish: последние переходы шагов публикуются явно,
+	// а не в defer, который срабатывал бы и при панике
+	finish := func(result *workflowDomain.WorkflowResult, err error) (*workflowDomain.WorkflowResult, error) {
+		events.publishFinal(state)
+		return result, err
+	}
+	// Версия фиксируется до создания заказа: у заказов, созданных старым кодом,
+	// в ответе CreateOrderActivity нет итоговой суммы
+	chargeOrderTotal := getVersion(ctx, ChangeOrderPricing) >= 1
+
 	var orderID string
 	var paymentID string
 
 	logger.Info("Step 1: Creating order")
 	state.UpdateStep(workflowDomain.StepCreateOrder)
+	events.publish(state)
 
 	createOrderInput := &workflowDomain.CreateOrderActivityInput{
-		CustomerID: input.CustomerID,
-		Items:      input.Items,
+		CustomerID:      input.CustomerID,
+		Items:           input.Items,
+		CouponCode:      input.CouponCode,
+		TaxJurisdiction: input.TaxJurisdiction,
+		ShippingAddress: input.ShippingAddress,
+		BillingAddress:  input.BillingAddress,
+		ShippingMethod:  input.ShippingMethod,
 	}
 
 	var createOrderOutput *workflowDomain.CreateOrderActivityOutput
@@ -71,22 +74,30 @@ func OrderProcessingWorkflow(ctx workflow.Context, input *workflowDomain.OrderPr
 		state.Cancel()
 	})
 
-	createOrderFuture := workflow.ExecuteActivity(ctx, workflowDomain.CreateOrderActivity, createOrderInput)
+	stepCtx := deadlines.startStep(ctx, workflowDomain.StepCreateOrder)
+	createOrderFuture := executeActivity(stepCtx, workflowDomain.CreateOrderActivity, createOrderInput)
 	selector.AddFuture(createOrderFuture, func(f workflow.Future) {
 		if err := f.Get(ctx, &createOrderOutput); err != nil {
 			logger.Error("Create order failed", "error", err)
-			state.SetError(workflowDomain.ErrorCodeInternalError, err.Error())
+			setActivityError(state, err, workflowDomain.ErrorCodeInternalError)
 		}
 	})
 
+	deadlines.addToSelector(selector, state)
 	selector.Select(ctx)
+	events.publish(state)
+
+	if state.IsTimedOut {
+		return finish(handleTimeout(ctx, state, deadlines, "", input.CustomerID))
+	}
+	deadlines.stopStep()
 
 	if state.IsCancelled {
-		return handleCancellation(ctx, "", "")
+		return finish(handleCancellation(ctx, "", ""))
 	}
 
 	if state.IsFailed() {
-		return handleFailure(ctx, state, "", input.CustomerID)
+		return finish(handleFailure(ctx, state, "", input.CustomerID))
 	}
 
 	orderID = createOrderOutput.OrderID
@@ -95,6 +106,7 @@ func OrderProcessingWorkflow(ctx workflow.Context, input *workflowDomain.OrderPr
 
 	logger.Info("Step 2: Checking inventory")
 	state.UpdateStep(workflowDomain.StepCheckInventory)
+	events.publish(state)
 
 	checkInventoryInput := &workflowDomain.CheckInventoryActivityInput{
 		OrderID: orderID,
@@ -111,40 +123,140 @@ func OrderProcessingWorkflow(ctx workflow.Context, input *workflowDomain.OrderPr
 		state.Cancel()
 	})
 
-	checkInventoryFuture := workflow.ExecuteActivity(ctx, workflowDomain.CheckInventoryActivity, checkInventoryInput)
+	stepCtx = deadlines.startStep(ctx, workflowDomain.StepCheckInventory)
+	checkInventoryFuture := executeActivity(stepCtx, workflowDomain.CheckInventoryActivity, checkInventoryInput)
 	selector.AddFuture(checkInventoryFuture, func(f workflow.Future) {
 		if err := f.Get(ctx, &checkInventoryOutput); err != nil {
 			logger.Error("Check inventory failed", "error", err)
-			state.SetError(workflowDomain.ErrorCodeInventoryUnavailable, err.Error())
+			setActivityError(state, err, workflowDomain.ErrorCodeInventoryUnavailable)
 		}
 	})
 
+	deadlines.addToSelector(selector, state)
 	selector.Select(ctx)
+	events.publish(state)
+
+	if state.IsTimedOut {
+		return finish(handleTimeout(ctx, state, deadlines, orderID, input.CustomerID))
+	}
+	deadlines.stopStep()
 
 	if state.IsCancelled {
-		return handleCancellation(ctx, orderID, input.CustomerID)
+		return finish(handleCancellation(ctx, orderID, input.CustomerID))
 	}
 
 	if state.IsFailed() {
-		return handleFailure(ctx, state, orderID, input.CustomerID)
+		return finish(handleStepFailure(ctx, state, orderID, input.CustomerID))
 	}
 
 	if !checkInventoryOutput.Available {
 		logger.Warn("Inventory not available", "unavailable_items", checkInventoryOutput.UnavailableItems)
 		state.SetError(workflowDomain.ErrorCodeInventoryUnavailable, "Some items are not available")
-		return handleFailure(ctx, state, orderID, input.CustomerID)
+		return finish(handleStepFailure(ctx, state, orderID, input.CustomerID))
 	}
 
 	logger.Info("Inventory check passed", "order_id", orderID)
+	state.Allocation = checkInventoryOutput.Allocation
 
-	logger.Info("Step 3: Processing payment")
-	state.UpdateStep(workflowDomain.StepProcessPayment)
+	if getVersion(ctx, ChangeReservationReReserve) >= 1 && drainReservationExpired(reservationExpiredChannel) {
+		logger.Warn("Reservation expired before payment, re-reserving items", "order_id", orderID)
+		state.UpdateStep(workflowDomain.StepCheckInventory)
+		events.publish(state)
+
+		var reReserveOutput *workflowDomain.CheckInventoryActivityOutput
+		err := executeActivity(ctx, workflowDomain.CheckInventoryActivity, checkInventoryInput).Get(ctx, &reReserveOutput)
+		if err != nil {
+			logger.Error("Re-reservation failed", "error", err)
+			code, message := classifyError(err, workflowDomain.ErrorCodeReservationExpired)
+			if code == workflowDomain.ErrorCodeInsufficientStock {
+				// Товар закончился, пока резерв был просрочен: для клиента это истёкший резерв
+				code = workflowDomain.ErrorCodeReservationExpired
+			}
+			state.SetError(code, message)
+			return finish(handleStepFailure(ctx, state, orderID, input.CustomerID))
+		}
+		if !reReserveOutput.Available {
+			state.SetError(workflowDomain.ErrorCodeReservationExpired, "Reservation expired and items are no longer available")
+			return finish(handleStepFailure(ctx, state, orderID, input.CustomerID))
+		}
+
+		state.Allocation = reReserveOutput.Allocation
+		logger.Info("Items re-reserved after expiration", "order_id", orderID)
+	}
 
 	var totalAmount float64
-	for _, item := range input.Items {
-		totalAmount += item.Price * float64(item.Quantity)
+	if chargeOrderTotal {
+		totalAmount = createOrderOutput.TotalAmount
+	} else {
+		for _, item := range input.Items {
+			totalAmount += item.Price * float64(item.Quantity)
+		}
+	}
+
+	// Налог считается по уже зарезервированному заказу, поэтому шаг стоит после
+	// повторного резервирования и сразу перед оплатой
+	if getVersion(ctx, ChangeOrderTax) >= 1 {
+		logger.Info("Step 3: Calculating tax")
+		state.UpdateStep(workflowDomain.StepCalculateTax)
+		events.publish(state)
+
+		calculateTaxInput := &workflowDomain.CalculateTaxActivityInput{OrderID: orderID}
+
+		var calculateTaxOutput *workflowDomain.CalculateTaxActivityOutput
+
+		selector = workflow.NewSelector(ctx)
+		selector.AddReceive(cancelChannel, func(c workflow.ReceiveChannel, more bool) {
+			var signal string
+			c.Receive(ctx, &signal)
+			logger.Info("Received cancel signal", "signal", signal)
+			state.Cancel()
+		})
+
+		stepCtx = deadlines.startStep(ctx, workflowDomain.StepCalculateTax)
+		calculateTaxFuture := executeActivity(stepCtx, workflowDomain.CalculateTaxActivity, calculateTaxInput)
+		selector.AddFuture(calculateTaxFuture, func(f workflow.Future) {
+			if err := f.Get(ctx, &calculateTaxOutput); err != nil {
+				logger.Error("Calculate tax failed", "error", err)
+				setActivityError(state, err, workflowDomain.ErrorCodeInternalError)
+			}
+		})
+
+		deadlines.addToSelector(selector, state)
+		selector.Select(ctx)
+		events.publish(state)
+
+		if state.IsTimedOut {
+			return finish(handleTimeout(ctx, state, deadlines, orderID, input.CustomerID))
+		}
+		deadlines.stopStep()
+
+		if state.IsCancelled {
+			return finish(handleCancellation(ctx, orderID, input.CustomerID))
+		}
+
+		if state.IsFailed() {
+			if getVersion(ctx, ChangeTaxFailureCompensation) >= 1 {
+				return finish(handleFailureAfterReservation(ctx, state, orderID, input.CustomerID))
+			}
+			return finish(handleFailure(ctx, state, orderID, input.CustomerID))
+		}
+
+		totalAmount = calculateTaxOutput.TotalAmount
+		logger.Info("Tax calculated", "order_id", orderID, "tax_amount", calculateTaxOutput.TaxAmount)
 	}
 
+	// Резерв мог истечь, пока считался налог или шла оплата: списывать деньги
+	// под уже освобождённый товар нельзя
+	checkReservationAtPayment := getVersion(ctx, ChangeReservationExpiredPayment) >= 1
+	if checkReservationAtPayment && drainReservationExpired(reservationExpiredChannel) {
+		state.SetError(workflowDomain.ErrorCodeReservationExpired, "Reservation expired before payment")
+		return finish(handleReservationExpired(ctx, state, orderID, input.CustomerID))
+	}
+
+	logger.Info("Step 4: Processing payment")
+	state.UpdateStep(workflowDomain.StepProcessPayment)
+	events.publish(state)
+
 	processPaymentInput := &workflowDomain.ProcessPaymentActivityInput{
 		OrderID:    orderID,
 		CustomerID: input.CustomerID,
@@ -162,62 +274,139 @@ func OrderProcessingWorkflow(ctx workflow.Context, input *workflowDomain.OrderPr
 		state.Cancel()
 	})
 
-	processPaymentFuture := workflow.ExecuteActivity(ctx, workflowDomain.ProcessPaymentActivity, processPaymentInput)
+	stepCtx = deadlines.startStep(ctx, workflowDomain.StepProcessPayment)
+	paymentCtx, cancelPayment := workflow.WithCancel(stepCtx)
+	reservationExpired := false
+	if checkReservationAtPayment {
+		selector.AddReceive(reservationExpiredChannel, func(c workflow.ReceiveChannel, more bool) {
+			var signal workflowDomain.ReservationExpiredSignalInput
+			c.Receive(ctx, &signal)
+			logger.Warn("Reservation expired during payment", "order_id", orderID, "reservation_ids", signal.ReservationIDs)
+			cancelPayment()
+			reservationExpired = true
+			state.SetError(workflowDomain.ErrorCodeReservationExpired, "Reservation expired during payment")
+		})
+	}
+
+	processPaymentFuture := executeActivity(paymentCtx, workflowDomain.ProcessPaymentActivity, processPaymentInput)
 	selector.AddFuture(processPaymentFuture, func(f workflow.Future) {
 		if err := f.Get(ctx, &processPaymentOutput); err != nil {
 			logger.Error("Process payment failed", "error", err)
-			state.SetError(workflowDomain.ErrorCodePaymentFailed, err.Error())
+			setActivityError(state, err, workflowDomain.ErrorCodePaymentFailed)
 		}
 	})
 
+	deadlines.addToSelector(selector, state)
 	selector.Select(ctx)
+	events.publish(state)
+
+	if state.IsTimedOut {
+		return finish(handleTimeout(ctx, state, deadlines, orderID, input.CustomerID))
+	}
+	deadlines.stopStep()
 
 	if state.IsCancelled {
-		return handleCancellation(ctx, orderID, input.CustomerID)
+		return finish(handleCancellation(ctx, orderID, input.CustomerID))
+	}
+
+	if reservationExpired {
+		return finish(handleReservationExpired(ctx, state, orderID, input.CustomerID))
 	}
 
 	if state.IsFailed() {
-		return handleFailure(ctx, state, orderID, input.CustomerID)
+		return finish(handleStepFailure(ctx, state, orderID, input.CustomerID))
 	}
 
 	paymentID = processPaymentOutput.PaymentID
 	state.PaymentID = paymentID
 	logger.Info("Payment processed successfully", "order_id", orderID, "payment_id", paymentID)
 
-	logger.Info("Step 4: Sending notification")
+	checkPaymentReversal := getVersion(ctx, ChangePaymentReversal) >= 1
+	if checkPaymentReversal {
+		if reversal := drainPaymentReversal(paymentEventChannel); reversal != nil {
+			return finish(handlePaymentReversal(ctx, state, reversal, orderID, input.CustomerID))
+		}
+	}
+
+	logger.Info("Step 5: Sending notification")
 	state.UpdateStep(workflowDomain.StepSendNotification)
+	events.publish(state)
 
 	sendNotificationInput := &workflowDomain.SendNotificationActivityInput{
 		CustomerID: input.CustomerID,
 		OrderID:    orderID,
 		Type:       notification.TypeOrderConfirmed,
-		Channel:    notification.ChannelEmail,
 		Message:    "",
 	}
 
-	err = workflow.ExecuteActivity(ctx, workflowDomain.SendNotificationActivity, sendNotificationInput).Get(ctx, nil)
+	err = executeActivity(ctx, workflowDomain.SendNotificationActivity, sendNotificationInput).Get(ctx, nil)
 	if err != nil {
 		logger.Warn("Failed to send notification, but continuing workflow", "error", err)
 	} else {
 		logger.Info("Notification sent successfully", "order_id", orderID)
 	}
 
-	logger.Info("Step 5: Completing workflow")
+	if checkPaymentReversal {
+		if reversal := drainPaymentReversal(paymentEventChannel); reversal != nil {
+			return finish(handlePaymentReversal(ctx, state, reversal, orderID, input.CustomerID))
+		}
+	}
+
+	logger.Info("Step 6: Completing workflow")
+	if getVersion(ctx, ChangeOrderCompletion) >= 1 {
+		// Деньги списаны и резерв подтверждён: ошибка записи не отменяет заказ,
+		// а остаётся в логах вместе с payment_id для ручного завершения
+		completeInput := &workflowDomain.CompleteOrderActivityInput{
+			OrderID:   orderID,
+			PaymentID: paymentID,
+		}
+		if err := executeActivity(ctx, workflowDomain.CompleteOrderActivity, completeInput).Get(ctx, nil); err != nil {
+			logger.Error("Failed to persist order completion", "error", err, "order_id", orderID, "payment_id", paymentID)
+		}
+	}
+
 	state.UpdateStep(workflowDomain.StepComplete)
 	state.UpdateStatus(order.StatusCompleted)
+	events.publish(state)
 
 	logger.Info("OrderProcessingWorkflow completed successfully",
 		"order_id", orderID,
 		"payment_id", paymentID,
 		"duration", state.GetDuration())
 
-	return &workflowDomain.WorkflowResult{
+	return finish(&workflowDomain.WorkflowResult{
 		OrderID:   orderID,
 		Status:    order.StatusCompleted,
 		Success:   true,
 		PaymentID: paymentID,
 		Message:   "Order processed successfully",
-	}, nil
+	}, nil)
+}
+
+func drainReservationExpired(ch workflow.ReceiveChannel) bool {
+	expired := false
+	for {
+		var signal workflowDomain.ReservationExpiredSignalInput
+		if !ch.ReceiveAsync(&signal) {
+			return expired
+		}
+		expired = true
+	}
+}
+
+// drainPaymentReversal читает накопленные события провайдера и возвращает первое,
+// после которого деньги по заказу не получены (отказ, возврат, chargeback).
+func drainPaymentReversal(ch workflow.ReceiveChannel) *workflowDomain.PaymentProviderEventSignalInput {
+	var reversal *workflowDomain.PaymentProviderEventSignalInput
+	for {
+		var signal workflowDomain.PaymentProviderEventSignalInput
+		if !ch.ReceiveAsync(&signal) {
+			return reversal
+		}
+		if reversal == nil && signal.ReversesPayment() {
+			reversal = &signal
+		}
+	}
 }
 
 func handleCancellation(ctx workflow.Context, orderID, customerID string) (*workflowDomain.WorkflowResult, error) {
@@ -231,7 +420,7 @@ func handleCancellation(ctx workflow.Context, orderID, customerID string) (*work
 			Reason:     "Customer cancellation",
 		}
 
-		err := workflow.ExecuteActivity(ctx, workflowDomain.CancelOrderActivity, cancelInput).Get(ctx, nil)
+		err := executeActivity(ctx, workflowDomain.CancelOrderActivity, cancelInput).Get(ctx, nil)
 		if err != nil {
 			logger.Error("Failed to cancel order", "error", err, "order_id", orderID)
 		}
@@ -240,10 +429,9 @@ func handleCancellation(ctx workflow.Context, orderID, customerID string) (*work
 			CustomerID: customerID,
 			OrderID:    orderID,
 			Type:       notification.TypeOrderCancelled,
-			Channel:    notification.ChannelEmail,
 		}
 
-		err = workflow.ExecuteActivity(ctx, workflowDomain.SendNotificationActivity, notificationInput).Get(ctx, nil)
+		err = executeActivity(ctx, workflowDomain.SendNotificationActivity, notificationInput).Get(ctx, nil)
 		if err != nil {
 			logger.Warn("Failed to send cancellation notification", "error", err)
 		}
@@ -274,11 +462,10 @@ func handleFailure(
 			CustomerID: customerID,
 			OrderID:    orderID,
 			Type:       notification.TypeOrderFailed,
-			Channel:    notification.ChannelEmail,
-			Message:    state.ErrorMessage,
+			Reason:     state.ErrorMessage,
 		}
 
-		err := workflow.ExecuteActivity(ctx, workflowDomain.SendNotificationActivity, notificationInput).Get(ctx, nil)
+		err := executeActivity(ctx, workflowDomain.SendNotificationActivity, notificationInput).Get(ctx, nil)
 		if err != nil {
 			logger.Warn("Failed to send failure notification", "error", err)
 		}
@@ -289,5 +476,156 @@ func handleFailure(
 		Status:  order.StatusFailed,
 		Success: false,
 		Message: state.ErrorMessage,
-	}, workflowDomain.NewActivityError("OrderProcessingWorkflow", state.CurrentStep, state.ErrorCode, state.ErrorMessage, false)
+	}, workflowFailure(state)
+}
+
+// handleFailureAfterReservation завершает ошибкой заказ, у которого уже есть резерв:
+// FailOrderActivity освобождает резерв и переводит заказ в failed, затем клиент получает
+// уведомление об ошибке.
+func handleFailureAfterReservation(
+	ctx workflow.Context,
+	state *workflowDomain.State,
+	orderID,
+	customerID string,
+) (*workflowDomain.WorkflowResult, error) {
+	logger := workflow.GetLogger(ctx)
+
+	failInput := &workflowDomain.FailOrderActivityInput{
+		OrderID: orderID,
+		Reason:  state.ErrorMessage,
+	}
+	if err := executeActivity(ctx, workflowDomain.FailOrderActivity, failInput).Get(ctx, nil); err != nil {
+		logger.Error("Failed to compensate failed order", "error", err, "order_id", orderID)
+	}
+
+	return handleFailure(ctx, state, orderID, customerID)
+}
+
+// handleStepFailure завершает заказ после ошибки склада или оплаты. Начиная с
+// ChangeFailureCompensation резерв освобождает и заказ переводит в failed FailOrderActivity,
+// в старой версии это делали сами activities.
+func handleStepFailure(
+	ctx workflow.Context,
+	state *workflowDomain.State,
+	orderID,
+	customerID string,
+) (*workflowDomain.WorkflowResult, error) {
+	if getVersion(ctx, ChangeFailureCompensation) >= 1 {
+		return handleFailureAfterReservation(ctx, state, orderID, customerID)
+	}
+	return handleFailure(ctx, state, orderID, customerID)
+}
+
+// handlePaymentReversal завершает заказ ошибкой, если провайдер отозвал платёж, пока заказ
+// ещё обрабатывался: резерв освобождается и заказ отменяется через CancelOrderActivity.
+// Возврат в ней не выполняется — платёж уже не в статусе completed.
+func handlePaymentReversal(
+	ctx workflow.Context,
+	state *workflowDomain.State,
+	reversal *workflowDomain.PaymentProviderEventSignalInput,
+	orderID,
+	customerID string,
+) (*workflowDomain.WorkflowResult, error) {
+	logger := workflow.GetLogger(ctx)
+
+	message := "Payment " + string(reversal.PaymentStatus) + " by provider"
+	if reversal.Reason != "" {
+		message += ": " + reversal.Reason
+	}
+	logger.Warn("Payment reversed by provider",
+		"order_id", orderID,
+		"payment_id", reversal.PaymentID,
+		"event_type", reversal.EventType,
+		"event_id", reversal.EventID)
+
+	state.SetError(workflowDomain.ErrorCodePaymentReversed, message)
+
+	cancelInput := &activity.CancelOrderActivityInput{
+		OrderID:    orderID,
+		CustomerID: customerID,
+		Reason:     message,
+	}
+	if err := executeActivity(ctx, workflowDomain.CancelOrderActivity, cancelInput).Get(ctx, nil); err != nil {
+		logger.Error("Failed to compensate order after payment reversal", "error", err, "order_id", orderID)
+	}
+
+	return handleFailure(ctx, state, orderID, customerID)
+}
+
+// handleReservationExpired завершает заказ ошибкой, если резерв истёк до списания или во время
+// него (activity оплаты к этому моменту уже отменена). Остаток резерва освобождается и заказ
+// отменяется через CancelOrderActivity.
+func handleReservationExpired(
+	ctx workflow.Context,
+	state *workflowDomain.State,
+	orderID,
+	customerID string,
+) (*workflowDomain.WorkflowResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Warn("Reservation expired, cancelling order before charge", "order_id", orderID)
+
+	cancelInput := &activity.CancelOrderActivityInput{
+		OrderID:    orderID,
+		CustomerID: customerID,
+		Reason:     state.ErrorMessage,
+	}
+	if err := executeActivity(ctx, workflowDomain.CancelOrderActivity, cancelInput).Get(ctx, nil); err != nil {
+		logger.Error("Failed to compensate order after reservation expiration", "error", err, "order_id", orderID)
+	}
+
+	return handleFailure(ctx, state, orderID, customerID)
+}
+
+// handleTimeout компенсирует заказ после нарушения дедлайна или SLA шага: отменяет
+// activity шага, возвращает платёж и резерв через CancelOrderActivity и уведомляет клиента.
+// Платёж CancelOrderActivity ищет по заказу, поэтому деньги возвращаются и тогда, когда
+// списание прошло, а payment_id в заказ ещё не записан. Списание, закончившееся после
+// отмены activity оплаты, возвращает сама ProcessPaymentActivity.
+// Если дедлайн сработал на создании заказа, orderID ещё неизвестен и компенсировать нечего.
+func handleTimeout(
+	ctx workflow.Context,
+	state *workflowDomain.State,
+	deadlines *orderDeadlines,
+	orderID,
+	customerID string,
+) (*workflowDomain.WorkflowResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Warn("Handling order timeout",
+		"order_id", orderID,
+		"step", state.TimedOutStep,
+		"error_message", state.ErrorMessage)
+
+	deadlines.abortStep()
+
+	if orderID != "" {
+		cancelInput := &activity.CancelOrderActivityInput{
+			OrderID:    orderID,
+			CustomerID: customerID,
+			Reason:     "Order timed out at step " + state.TimedOutStep,
+		}
+
+		err := executeActivity(ctx, workflowDomain.CancelOrderActivity, cancelInput).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to compensate timed out order", "error", err, "order_id", orderID)
+		}
+
+		notificationInput := &workflowDomain.SendNotificationActivityInput{
+			CustomerID: customerID,
+			OrderID:    orderID,
+			Type:       notification.TypeOrderTimeout,
+			Reason:     state.ErrorMessage,
+		}
+
+		err = executeActivity(ctx, workflowDomain.SendNotificationActivity, notificationInput).Get(ctx, nil)
+		if err != nil {
+			logger.Warn("Failed to send timeout notification", "error", err)
+		}
+	}
+
+	return &workflowDomain.WorkflowResult{
+		OrderID: orderID,
+		Status:  order.StatusTimedOut,
+		Success: false,
+		Message: state.ErrorMessage,
+	}, workflowFailure(state)
 }
@@ -0,0 +1,196 @@
+//go:build record
+
+package workflow
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+
+	"orderflow/internal/domain/inventory"
+	"orderflow/internal/domain/order"
+	"orderflow/internal/domain/orderevent"
+	"orderflow/internal/domain/subscription"
+	wf "orderflow/internal/domain/workflow"
+	usecaseActivity "orderflow/internal/usecase/activity"
+)
+
+// stubs заменяет activities заказа ответами без БД, чтобы историю можно было записать
+// на локальном сервере Temporal.
+type stubs struct {
+	unavailable  bool
+	taxErr       error
+	paymentErr   error
+	paymentDelay time.Duration
+	createDelay  time.Duration
+	total        float64
+}
+
+func (s *stubs) register(w worker.Worker) {
+	reg := func(fn interface{}, name string) {
+		w.RegisterActivityWithOptions(fn, activity.RegisterOptions{Name: name})
+	}
+	reg(func(ctx context.Context, in *wf.CreateOrderActivityInput) (*wf.CreateOrderActivityOutput, error) {
+		sleep(ctx, s.createDelay)
+		return &wf.CreateOrderActivityOutput{OrderID: "order-1", TotalAmount: s.total}, nil
+	}, wf.CreateOrderActivity)
+	reg(func(ctx context.Context, in *wf.CheckInventoryActivityInput) (*wf.CheckInventoryActivityOutput, error) {
+		if s.unavailable {
+			return &wf.CheckInventoryActivityOutput{Available: false, UnavailableItems: []inventory.UnavailableItem{
+				{ProductID: "prod-001", RequestedQuantity: 1},
+			}}, nil
+		}
+		return &wf.CheckInventoryActivityOutput{Available: true, Allocation: []order.AllocationLine{
+			{ProductID: "prod-001", WarehouseID: "wh-1", Quantity: 1},
+		}}, nil
+	}, wf.CheckInventoryActivity)
+	reg(func(ctx context.Context, in *wf.CalculateTaxActivityInput) (*wf.CalculateTaxActivityOutput, error) {
+		if s.taxErr != nil {
+			return nil, s.taxErr
+		}
+		return &wf.CalculateTaxActivityOutput{TaxAmount: 8, TotalAmount: s.total + 8}, nil
+	}, wf.CalculateTaxActivity)
+	reg(func(ctx context.Context, in *wf.ProcessPaymentActivityInput) (*wf.ProcessPaymentActivityOutput, error) {
+		sleep(ctx, s.paymentDelay)
+		if s.paymentErr != nil {
+			return nil, s.paymentErr
+		}
+		return &wf.ProcessPaymentActivityOutput{PaymentID: "pay-1", TransactionID: "tx-1"}, nil
+	}, wf.ProcessPaymentActivity)
+	reg(func(ctx context.Context, in *wf.SendNotificationActivityInput) error { return nil }, wf.SendNotificationActivity)
+	reg(func(ctx context.Context, in *usecaseActivity.CancelOrderActivityInput) error { return nil }, wf.CancelOrderActivity)
+	reg(func(ctx context.Context, events []*orderevent.Event) error { return nil }, wf.RecordStepEventsActivity)
+	reg(func(ctx context.Context, in *wf.FailOrderActivityInput) error { return nil }, wf.FailOrderActivity)
+	reg(func(ctx context.Context, in *wf.CompleteOrderActivityInput) error { return nil }, wf.CompleteOrderActivity)
+	reg(func(ctx context.Context, sub *subscription.Subscription) error { return nil }, wf.SaveSubscriptionActivity)
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	if d == 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// TestRecordHistory записывает историю сценария SCENARIO в testdata/<scenario>.json для
+// TestReplayRecordedHistories. Нужен запущенный сервер Temporal (TEMPORAL_ADDRESS, по умолчанию
+// localhost:7233) и CLI temporal (TEMPORAL_CLI):
+//
+//	SCENARIO=<scenario> go test -tags record -run TestRecordHistory ./internal/usecase/workflow/
+//
+// Историю нужно записывать кодом до изменения, которое она закрепляет.
+func TestRecordHistory(t *testing.T) {
+	scenario := os.Getenv("SCENARIO")
+	c, err := client.Dial(client.Options{HostPort: envOr("TEMPORAL_ADDRESS", client.DefaultHostPort)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	input := &wf.OrderProcessingInput{
+		CustomerID: "customer-001",
+		Items:      []order.Item{{ProductID: "prod-001", Name: "iPhone 15 Pro", Quantity: 1, Price: 999.99}},
+	}
+	s := &stubs{total: 999.99}
+	var act func(ctx context.Context, run client.WorkflowRun)
+	var workflowFn, workflowInput interface{} = OrderProcessingWorkflow, input
+
+	switch scenario {
+	case "order-processing-reservation-expired-payment":
+		s.paymentDelay = 3 * time.Second
+		act = func(ctx context.Context, run client.WorkflowRun) {
+			time.Sleep(1500 * time.Millisecond)
+			_ = c.SignalWorkflow(ctx, run.GetID(), "", wf.ReservationExpiredSignal,
+				&wf.ReservationExpiredSignalInput{OrderID: "order-1", ReservationIDs: []string{"res-1"}})
+		}
+	case "order-processing-inventory-unavailable-compensation":
+		s.unavailable = true
+	case "order-processing-payment-declined-compensation":
+		s.paymentErr = temporal.NewNonRetryableApplicationError("Card was declined by the bank", wf.ErrorCodePaymentDeclined, nil)
+	case "order-processing-pricing":
+		input.CouponCode = "WELCOME10"
+		input.ShippingMethod = "ground"
+		input.ShippingAddress = &order.Address{Name: "Jane Doe", Line1: "1 Market St", City: "San Francisco",
+			Region: "CA", PostalCode: "94105", Country: "US"}
+		s.total = 999.99 - 100 + 9.99
+	case "order-processing-completion":
+	case "order-processing-tax":
+		input.TaxJurisdiction = "US-CA"
+	case "order-processing-tax-failure", "order-processing-tax-failure-compensation":
+		input.TaxJurisdiction = "US-CA"
+		s.taxErr = temporal.NewApplicationError("tax service unavailable", wf.ErrorCodeInternalError)
+	case "customer-subscription-skip":
+		workflowFn = CustomerSubscriptionWorkflow
+		workflowInput = &wf.CustomerSubscriptionInput{Subscription: subscription.Subscription{
+			ID: "sub-1", CustomerID: input.CustomerID, Items: input.Items, Interval: time.Hour,
+			NextRunAt: time.Now().Add(time.Hour),
+		}}
+		act = func(ctx context.Context, run client.WorkflowRun) {
+			time.Sleep(500 * time.Millisecond)
+			_ = c.SignalWorkflow(ctx, run.GetID(), "", wf.SkipSubscriptionSignal, &wf.SubscriptionSignalInput{Reason: "vacation"})
+			time.Sleep(500 * time.Millisecond)
+			_ = c.SignalWorkflow(ctx, run.GetID(), "", wf.CancelSubscriptionSignal, &wf.SubscriptionSignalInput{})
+		}
+	case "order-processing-step-events-workflow-cancel":
+		s.createDelay = 3 * time.Second
+		act = func(ctx context.Context, run client.WorkflowRun) {
+			time.Sleep(1500 * time.Millisecond)
+			_ = c.CancelWorkflow(ctx, run.GetID(), "")
+		}
+	default:
+		t.Fatalf("unknown scenario %q", scenario)
+	}
+
+	queue := "order-processing"
+	w := worker.New(c, queue, worker.Options{})
+	w.RegisterWorkflow(OrderProcessingWorkflow)
+	w.RegisterWorkflow(CustomerSubscriptionWorkflow)
+	s.register(w)
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	ctx := context.Background()
+	id := "replay-" + scenario
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{ID: id, TaskQueue: queue}, workflowFn, workflowInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act != nil {
+		act(ctx, run)
+	}
+	var result interface{}
+	err = run.Get(ctx, &result)
+	var appErr *temporal.ApplicationError
+	if err != nil && !errors.As(err, &appErr) {
+		t.Logf("workflow error: %v", err)
+	}
+	t.Logf("result: %+v err: %v", result, err)
+
+	out, err := exec.Command(envOr("TEMPORAL_CLI", "temporal"), "workflow", "show", "-w", id, "-o", "json").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("testdata/"+scenario+".json", out, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
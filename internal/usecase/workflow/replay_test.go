@@ -0,0 +1,36 @@
+package workflow
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.temporal.io/sdk/worker"
+)
+
+// TestReplayRecordedHistories прогоняет текущий код workflow по историям из testdata/.
+// Падение означает недетерминированное изменение: его нужно закрыть workflow.GetVersion
+// и зарегистрировать в OrderProcessingVersions или CustomerSubscriptionVersions.
+func TestReplayRecordedHistories(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatalf("failed to list histories: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no recorded histories found in testdata/")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			replayer := worker.NewWorkflowReplayer()
+			replayer.RegisterWorkflow(OrderProcessingWorkflow)
+			replayer.RegisterWorkflow(ReservationCleanupWorkflow)
+			replayer.RegisterWorkflow(CustomerSubscriptionWorkflow)
+			replayer.RegisterWorkflow(BatchOrderImportWorkflow)
+			replayer.RegisterWorkflow(WebhookDeliveryWorkflow)
+
+			if err := replayer.ReplayWorkflowHistoryFromJSONFile(nil, file); err != nil {
+				t.Fatalf("replay of %s failed: %v", file, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package workflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	"orderflow/internal/domain/inventory"
+	workflowDomain "orderflow/internal/domain/workflow"
+)
+
+const defaultCleanupMaxBatches = 10
+
+func ReservationCleanupWorkflow(ctx workflow.Context, input *workflowDomain.ReservationCleanupInput) (*workflowDomain.ReservationCleanupResult, error) {
+	logger := workflow.GetLogger(ctx)
+
+	if input == nil {
+		input = &workflowDomain.ReservationCleanupInput{}
+	}
+	batchSize := input.BatchSize
+	if batchSize <= 0 {
+		batchSize = inventory.DefaultCleanupBatchSize
+	}
+	maxBatches := input.MaxBatches
+	if maxBatches <= 0 {
+		maxBatches = defaultCleanupMaxBatches
+	}
+
+	logger.Info("Starting ReservationCleanupWorkflow", "batch_size", batchSize, "max_batches", maxBatches)
+
+	result := &workflowDomain.ReservationCleanupResult{}
+
+	for batch := 0; batch < maxBatches; batch++ {
+		var output *workflowDomain.CleanupReservationsActivityOutput
+		err := executeActivity(ctx, workflowDomain.CleanupReservationsActivity,
+			&workflowDomain.CleanupReservationsActivityInput{BatchSize: batchSize}).Get(ctx, &output)
+		if err != nil {
+			logger.Error("Cleanup batch failed", "batch", batch, "error", err)
+			return result, err
+		}
+
+		result.Released += len(output.Released)
+		result.NotifiedWorkflows += notifyExpiredReservations(ctx, output.Released)
+
+		if len(output.Released) < batchSize {
+			break
+		}
+	}
+
+	logger.Info("ReservationCleanupWorkflow completed",
+		"released", result.Released,
+		"notified_workflows", result.NotifiedWorkflows)
+
+	return result, nil
+}
+
+// notifyExpiredReservations сигналит workflow заказов, резервы которых истекли.
+// Закрытые workflow возвращают ошибку на сигнал, это не считается сбоем очистки.
+func notifyExpiredReservations(ctx workflow.Context, released []workflowDomain.ExpiredReservation) int {
+	logger := workflow.GetLogger(ctx)
+
+	signals := make(map[string]*workflowDomain.ReservationExpiredSignalInput)
+	workflowIDs := make([]string, 0)
+	for _, reservation := range released {
+		if reservation.WorkflowID == "" {
+			continue
+		}
+		signal, ok := signals[reservation.WorkflowID]
+		if !ok {
+			signal = &workflowDomain.ReservationExpiredSignalInput{OrderID: reservation.OrderID}
+			signals[reservation.WorkflowID] = signal
+			workflowIDs = append(workflowIDs, reservation.WorkflowID)
+		}
+		signal.ReservationIDs = append(signal.ReservationIDs, reservation.ReservationID)
+	}
+
+	futures := make([]workflow.Future, len(workflowIDs))
+	for i, workflowID := range workflowIDs {
+		futures[i] = workflow.SignalExternalWorkflow(ctx, workflowID, "", workflowDomain.ReservationExpiredSignal, signals[workflowID])
+	}
+
+	notified := 0
+	for i, future := range futures {
+		if err := future.Get(ctx, nil); err != nil {
+			logger.Warn("Failed to signal order workflow about expired reservation",
+				"workflow_id", workflowIDs[i],
+				"error", err)
+			continue
+		}
+		notified++
+	}
+
+	return notified
+}
@@ -56,6 +56,8 @@ func (s *SignalHandlers) canCancelAtCurrentStep(currentStep string) bool {
 		return true
 	case workflowDomain.StepCheckInventory:
 		return true
+	case workflowDomain.StepCalculateTax:
+		return true
 	case workflowDomain.StepProcessPayment:
 		return true
 	case workflowDomain.StepSendNotification:
@@ -94,7 +96,7 @@ func (s *SignalHandlers) SetupWorkflowQueries(ctx workflow.Context, state *workf
 	}
 	
 	err = workflow.SetQueryHandler(ctx, "progress", func() (map[string]interface{}, error) {
-		totalSteps := 5 // create, check, payment, notification, complete
+		totalSteps := 6 // create, check, tax, payment, notification, complete
 		currentStepIndex := s.getStepIndex(state.CurrentStep)
 		
 		progress := map[string]interface{}{
@@ -120,12 +122,14 @@ func (s *SignalHandlers) getStepIndex(step string) int {
 		return 1
 	case workflowDomain.StepCheckInventory:
 		return 2
-	case workflowDomain.StepProcessPayment:
+	case workflowDomain.StepCalculateTax:
 		return 3
-	case workflowDomain.StepSendNotification:
+	case workflowDomain.StepProcessPayment:
 		return 4
-	case workflowDomain.StepComplete:
+	case workflowDomain.StepSendNotification:
 		return 5
+	case workflowDomain.StepComplete:
+		return 6
 	default:
 		return 0
 	}
@@ -0,0 +1,96 @@
+package workflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	"orderflow/internal/domain/orderevent"
+	workflowDomain "orderflow/internal/domain/workflow"
+)
+
+// stepEvents публикует изменения State.StepHistory в order_step_events, откуда их
+// читает SSE-поток /api/orders/{id}/events. Запись идёт через local activity и не
+// влияет на исход заказа: ошибка публикации логируется, а незаписанные события
+// отправляются ещё раз со следующей публикацией.
+type stepEvents struct {
+	ctx        workflow.Context
+	enabled    bool
+	workflowID string
+
+	seq int
+	// recorded — последний статус каждой записи StepHistory, для которого создано событие
+	recorded []string
+	// pending — события, которые RecordStepEventsActivity ещё не записала. Seq у них
+	// уже назначен, поэтому повторная запись частично сохранённой пачки не создаёт дублей
+	pending []*orderevent.Event
+}
+
+func newStepEvents(ctx workflow.Context) *stepEvents {
+	return &stepEvents{
+		ctx:        ctx,
+		enabled:    getVersion(ctx, ChangeStepEvents) >= 1,
+		workflowID: workflow.GetInfo(ctx).WorkflowExecution.ID,
+	}
+}
+
+// publish отправляет переходы шагов, появившиеся с прошлого вызова, одной local activity.
+func (e *stepEvents) publish(state *workflowDomain.State) {
+	e.publishIn(e.ctx, state)
+}
+
+// publishFinal публикует последние переходы перед выходом из workflow. У отменённого workflow
+// контекст уже отменён и local activity в нём не запустится, поэтому запись идёт
+// в отключённом контексте.
+func (e *stepEvents) publishFinal(state *workflowDomain.State) {
+	if !e.enabled {
+		return
+	}
+	if e.ctx.Err() != nil && getVersion(e.ctx, ChangeStepEventsOnCancel) >= 1 {
+		disconnectedCtx, _ := workflow.NewDisconnectedContext(e.ctx)
+		e.publishIn(disconnectedCtx, state)
+		return
+	}
+	e.publish(state)
+}
+
+func (e *stepEvents) publishIn(ctx workflow.Context, state *workflowDomain.State) {
+	if !e.enabled {
+		return
+	}
+
+	for i, execution := range state.StepHistory {
+		if i < len(e.recorded) && e.recorded[i] == execution.Status {
+			continue
+		}
+		if i >= len(e.recorded) {
+			e.recorded = append(e.recorded, "")
+		}
+		e.recorded[i] = execution.Status
+		e.seq++
+
+		event := &orderevent.Event{
+			WorkflowID:  e.workflowID,
+			Seq:         e.seq,
+			OrderID:     state.OrderID,
+			Step:        execution.Step,
+			StepStatus:  execution.Status,
+			OrderStatus: state.Status,
+			Error:       execution.Error,
+			OccurredAt:  workflow.Now(e.ctx),
+		}
+		if execution.Error != "" {
+			event.ErrorCode = state.ErrorCode
+		}
+		e.pending = append(e.pending, event)
+	}
+	if len(e.pending) == 0 {
+		return
+	}
+
+	err := executeLocalActivity(ctx, workflowDomain.RecordStepEventsActivity, e.pending).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Failed to record step events, will retry with the next transition",
+			"error", err, "seq", e.seq, "pending", len(e.pending))
+		return
+	}
+	e.pending = nil
+}
@@ -0,0 +1,123 @@
+package workflow
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Change ID для workflow.GetVersion. Любое изменение последовательности команд
+// в OrderProcessingWorkflow (новая activity, таймер, сигнал наружу, изменение порядка шагов)
+// должно идти под новым change ID, зарегистрированным в OrderProcessingVersions.
+// Уже зарегистрированные записи не удаляются, пока в истории могут быть заказы на старой версии.
+const (
+	ChangeReservationReReserve = "reservation-expired-rereserve"
+	ChangeOrderDeadlines       = "order-deadline-step-sla"
+	ChangeStepEvents           = "order-step-events"
+	ChangePaymentReversal      = "order-payment-reversal"
+	ChangeOrderPricing         = "order-pricing-total"
+	ChangeOrderTax             = "order-tax-step"
+	ChangeStepEventsOnCancel   = "order-step-events-on-cancel"
+	// ChangeReservationExpiredPayment — проверка истёкшего резерва перед списанием и во время него
+	ChangeReservationExpiredPayment = "reservation-expired-before-charge"
+	// ChangeTaxFailureCompensation — FailOrderActivity после любой ошибки расчёта налога.
+	// Резерв заказа старой версии, у которого налог не посчитан, освободит очистка резервов
+	ChangeTaxFailureCompensation = "order-tax-failure-compensation"
+	// ChangeFailureCompensation — FailOrderActivity после ошибки склада или оплаты вместо
+	// SetFailure внутри activities. Заказ старой версии, упавший на этих шагах, остаётся
+	// в прежнем статусе, его резерв освободит очистка резервов
+	ChangeFailureCompensation = "order-failure-compensation"
+	// ChangeOrderCompletion — CompleteOrderActivity записывает завершение заказа и платёж в БД
+	ChangeOrderCompletion = "order-completion"
+)
+
+// Change ID CustomerSubscriptionWorkflow, зарегистрированные в CustomerSubscriptionVersions.
+const (
+	// ChangeSubscriptionSkipPersisted — сигнал skip сохраняет подписку с skip_next
+	ChangeSubscriptionSkipPersisted = "subscription-skip-persisted"
+)
+
+type VersionedChange struct {
+	ChangeID    string
+	MaxVersion  workflow.Version
+	Description string
+}
+
+// OrderProcessingVersions - реестр изменений OrderProcessingWorkflow в порядке их появления.
+var OrderProcessingVersions = []VersionedChange{
+	{
+		ChangeID:    ChangeReservationReReserve,
+		MaxVersion:  1,
+		Description: "re-reserve items before payment when a reservation-expired signal was received",
+	},
+	{
+		ChangeID:    ChangeOrderDeadlines,
+		MaxVersion:  1,
+		Description: "order deadline and per-step SLA timers with compensation on breach",
+	},
+	{
+		ChangeID:    ChangeStepEvents,
+		MaxVersion:  1,
+		Description: "record step transitions via RecordStepEventsActivity local activity for SSE streaming",
+	},
+	{
+		ChangeID:    ChangePaymentReversal,
+		MaxVersion:  1,
+		Description: "fail and compensate the order when a payment provider event reverses the payment",
+	},
+	{
+		ChangeID:    ChangeOrderPricing,
+		MaxVersion:  1,
+		Description: "charge the order total returned by CreateOrderActivity (after discounts) instead of summing input items",
+	},
+	{
+		ChangeID:    ChangeOrderTax,
+		MaxVersion:  1,
+		Description: "calculate tax in CalculateTaxActivity before payment and charge the total including tax",
+	},
+	{
+		ChangeID:    ChangeStepEventsOnCancel,
+		MaxVersion:  1,
+		Description: "record the final step events of a cancelled workflow in a disconnected context",
+	},
+	{
+		ChangeID:    ChangeReservationExpiredPayment,
+		MaxVersion:  1,
+		Description: "cancel the order instead of charging when its reservation expired before or during payment",
+	},
+	{
+		ChangeID:    ChangeTaxFailureCompensation,
+		MaxVersion:  1,
+		Description: "release the reservation and fail the order in FailOrderActivity after any tax calculation error",
+	},
+	{
+		ChangeID:    ChangeFailureCompensation,
+		MaxVersion:  1,
+		Description: "release the reservation and fail the order in FailOrderActivity after inventory and payment errors",
+	},
+	{
+		ChangeID:    ChangeOrderCompletion,
+		MaxVersion:  1,
+		Description: "persist the completed status and payment_id in CompleteOrderActivity so order.completed reaches the outbox",
+	},
+}
+
+// CustomerSubscriptionVersions - реестр изменений CustomerSubscriptionWorkflow в порядке их появления.
+var CustomerSubscriptionVersions = []VersionedChange{
+	{
+		ChangeID:    ChangeSubscriptionSkipPersisted,
+		MaxVersion:  1,
+		Description: "save the subscription with skip_next when a skip signal arrives",
+	},
+}
+
+func getVersion(ctx workflow.Context, changeID string) workflow.Version {
+	for _, registry := range [][]VersionedChange{OrderProcessingVersions, CustomerSubscriptionVersions} {
+		for _, change := range registry {
+			if change.ChangeID == changeID {
+				return workflow.GetVersion(ctx, changeID, workflow.DefaultVersion, change.MaxVersion)
+			}
+		}
+	}
+	panic(fmt.Sprintf("workflow change %q is not registered in OrderProcessingVersions or CustomerSubscriptionVersions", changeID))
+}
@@ -0,0 +1,50 @@
+package workflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	workflowDomain "orderflow/internal/domain/workflow"
+)
+
+// WebhookDeliveryWorkflow доставляет одно событие одной подписке. Повторы с
+// экспоненциальной задержкой выполняет retry policy DeliverWebhookActivity; когда
+// попытки исчерпаны или получатель отверг событие, доставка помечается failed,
+// а подписка после нескольких таких доставок подряд отключается.
+func WebhookDeliveryWorkflow(ctx workflow.Context, input *workflowDomain.WebhookDeliveryInput) (*workflowDomain.WebhookDeliveryResult, error) {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Starting WebhookDeliveryWorkflow",
+		"delivery_id", input.DeliveryID,
+		"subscription_id", input.SubscriptionID,
+		"event_type", input.EventType)
+
+	result := &workflowDomain.WebhookDeliveryResult{DeliveryID: input.DeliveryID}
+
+	err := executeActivity(ctx, workflowDomain.DeliverWebhookActivity, input).Get(ctx, nil)
+	if err != nil {
+		result.ErrorCode, result.Error = classifyError(err, workflowDomain.ErrorCodeWebhookDeliveryFailed)
+		logger.Warn("Webhook delivery failed",
+			"delivery_id", input.DeliveryID,
+			"error_code", result.ErrorCode,
+			"error", result.Error)
+	} else {
+		result.Delivered = true
+	}
+
+	finalizeInput := &workflowDomain.FinalizeWebhookDeliveryActivityInput{
+		DeliveryID: input.DeliveryID,
+		Delivered:  result.Delivered,
+	}
+	var finalizeOutput workflowDomain.FinalizeWebhookDeliveryActivityOutput
+	if err := executeActivity(ctx, workflowDomain.FinalizeWebhookDeliveryActivity, finalizeInput).Get(ctx, &finalizeOutput); err != nil {
+		logger.Error("Failed to finalize webhook delivery", "delivery_id", input.DeliveryID, "error", err)
+		return nil, err
+	}
+	result.SubscriptionDisabled = finalizeOutput.SubscriptionDisabled
+
+	logger.Info("WebhookDeliveryWorkflow completed",
+		"delivery_id", input.DeliveryID,
+		"delivered", result.Delivered,
+		"subscription_disabled", result.SubscriptionDisabled)
+
+	return result, nil
+}
@@ -10,7 +10,16 @@ CREATE TABLE IF NOT EXISTS orders (
     failure_reason TEXT,
     created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    completed_at  TIMESTAMPTZ
+    completed_at  TIMESTAMPTZ,
+    workflow_id   TEXT,
+    -- Сумма позиций до скидок; total_amount = subtotal - discount_amount + shipping_amount + tax_amount
+    subtotal        NUMERIC(12,2) NOT NULL DEFAULT 0,
+    discount_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    coupon_code     TEXT,
+    tax_jurisdiction TEXT,
+    tax_amount      NUMERIC(12,2) NOT NULL DEFAULT 0,
+    shipping_method TEXT,
+    shipping_amount NUMERIC(12,2) NOT NULL DEFAULT 0
 );
 
 -- Таблица товаров заказа
@@ -23,26 +32,188 @@ CREATE TABLE IF NOT EXISTS order_items (
     price      NUMERIC(12,2) NOT NULL CHECK (price >= 0)
 );
 
+-- Распределение заказа по складам: откуда отгружается каждая позиция
+CREATE TABLE IF NOT EXISTS order_allocations (
+    id           BIGSERIAL PRIMARY KEY,
+    order_id     TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id   TEXT NOT NULL,
+    warehouse_id TEXT NOT NULL,
+    quantity     INT  NOT NULL CHECK (quantity > 0)
+);
+
+-- Состав наборов в заказе на момент резервирования: quantity — на один набор
+CREATE TABLE IF NOT EXISTS order_item_components (
+    id         BIGSERIAL PRIMARY KEY,
+    order_id   TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    bundle_id  TEXT NOT NULL,
+    product_id TEXT NOT NULL,
+    sku        TEXT NOT NULL,
+    quantity   INT  NOT NULL CHECK (quantity > 0)
+);
+
+-- Скидки заказа: product_id задан у скидки на позицию, пуст у скидки на весь заказ
+CREATE TABLE IF NOT EXISTS order_adjustments (
+    id           BIGSERIAL PRIMARY KEY,
+    order_id     TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    promotion_id TEXT NOT NULL,
+    code         TEXT,
+    type         TEXT NOT NULL,
+    product_id   TEXT,
+    amount       NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    description  TEXT NOT NULL DEFAULT ''
+);
+
+-- Адреса заказа: доставки и плательщика, не больше одного каждого вида
+CREATE TABLE IF NOT EXISTS order_addresses (
+    order_id    TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    kind        TEXT NOT NULL CHECK (kind IN ('shipping','billing')),
+    name        TEXT NOT NULL,
+    line1       TEXT NOT NULL,
+    line2       TEXT NOT NULL DEFAULT '',
+    city        TEXT NOT NULL,
+    region      TEXT NOT NULL DEFAULT '',
+    postal_code TEXT NOT NULL,
+    country     CHAR(2) NOT NULL,
+    phone       TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (order_id, kind)
+);
+
+-- Налог по позициям заказа; считается перед оплатой и заменяется при пересчёте
+CREATE TABLE IF NOT EXISTS order_tax_lines (
+    id             BIGSERIAL PRIMARY KEY,
+    order_id       TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id     TEXT NOT NULL,
+    tax_class      TEXT NOT NULL,
+    jurisdiction   TEXT NOT NULL,
+    name           TEXT NOT NULL DEFAULT '',
+    rate           NUMERIC(6,5) NOT NULL CHECK (rate >= 0),
+    taxable_amount NUMERIC(12,2) NOT NULL CHECK (taxable_amount >= 0),
+    amount         NUMERIC(12,2) NOT NULL CHECK (amount >= 0)
+);
+
+-- Промоакции. Без code применяются автоматически, с code — по купону;
+-- лимиты использований (0 — без ограничения) есть только у купонов
+CREATE TABLE IF NOT EXISTS promotions (
+    id                 TEXT PRIMARY KEY,
+    code               TEXT UNIQUE,
+    name               TEXT NOT NULL,
+    type               TEXT NOT NULL CHECK (type IN ('percentage', 'fixed', 'buy_x_get_y')),
+    value              NUMERIC(12,2) NOT NULL DEFAULT 0,
+    product_ids        TEXT[] NOT NULL DEFAULT '{}',
+    buy_quantity       INT NOT NULL DEFAULT 0,
+    get_quantity       INT NOT NULL DEFAULT 0,
+    min_subtotal       NUMERIC(12,2) NOT NULL DEFAULT 0,
+    usage_limit        INT NOT NULL DEFAULT 0 CHECK (usage_limit >= 0),
+    per_customer_limit INT NOT NULL DEFAULT 0 CHECK (per_customer_limit >= 0),
+    active             BOOLEAN NOT NULL DEFAULT TRUE,
+    starts_at          TIMESTAMPTZ,
+    ends_at            TIMESTAMPTZ,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Погашения купонов: reserved при создании заказа, redeemed после оплаты,
+-- released при отказе или отмене заказа. Лимиты считают reserved и redeemed
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id           BIGSERIAL PRIMARY KEY,
+    promotion_id TEXT NOT NULL REFERENCES promotions(id),
+    order_id     TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    customer_id  TEXT NOT NULL,
+    code         TEXT NOT NULL,
+    status       TEXT NOT NULL DEFAULT 'reserved' CHECK (status IN ('reserved', 'redeemed', 'released')),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (promotion_id, order_id)
+);
+
 -- Таблица товаров (склад)
 CREATE TABLE IF NOT EXISTS products (
     id         TEXT PRIMARY KEY,
     name       TEXT NOT NULL,
     sku        TEXT UNIQUE NOT NULL,
+    -- simple — товар с остатками (в том числе вариант), parent — карточка с вариантами,
+    -- bundle — набор из компонентов; у parent и bundle собственных остатков нет
+    kind       TEXT NOT NULL DEFAULT 'simple' CHECK (kind IN ('simple', 'parent', 'bundle')),
     price      NUMERIC(12,2) NOT NULL CHECK (price >= 0),
     available  INT NOT NULL DEFAULT 0 CHECK (available >= 0),
     reserved   INT NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+    -- Точка заказа: свободный остаток не выше неё — товар заканчивается; 0 — порог не задан
+    reorder_point INT NOT NULL DEFAULT 0 CHECK (reorder_point >= 0),
+    -- Вариант: родительская карточка и атрибуты варианта ({"size": "M", "color": "black"})
+    parent_id  TEXT REFERENCES products(id),
+    attributes JSONB NOT NULL DEFAULT '{}',
+    -- Налоговый класс: вместе с юрисдикцией заказа выбирает ставку из таблицы ставок
+    tax_class  TEXT NOT NULL DEFAULT 'standard',
+    -- Вес единицы в килограммах для расчёта доставки
+    weight     NUMERIC(10,3) NOT NULL DEFAULT 0 CHECK (weight >= 0),
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+-- Состав наборов: quantity — сколько единиц компонента в одном наборе
+CREATE TABLE IF NOT EXISTS bundle_components (
+    bundle_id    TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    component_id TEXT NOT NULL REFERENCES products(id),
+    quantity     INT  NOT NULL CHECK (quantity > 0),
+    position     INT  NOT NULL DEFAULT 0,
+    PRIMARY KEY (bundle_id, component_id)
+);
+
+-- Склады. shipping_cost — стоимость отправки единицы товара, priority — порядок выбора
+-- склада при равенстве (меньше — раньше)
+CREATE TABLE IF NOT EXISTS warehouses (
+    id            TEXT PRIMARY KEY,
+    name          TEXT NOT NULL,
+    latitude      DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (latitude BETWEEN -90 AND 90),
+    longitude     DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (longitude BETWEEN -180 AND 180),
+    shipping_cost NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (shipping_cost >= 0),
+    priority      INT NOT NULL DEFAULT 0,
+    active        BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Склад по умолчанию: на него попадают остатки без явного склада
+INSERT INTO warehouses (id, name) VALUES ('main', 'Основной склад') ON CONFLICT (id) DO NOTHING;
+
+-- Остатки товаров по складам; products.available и products.reserved — их сумма
+CREATE TABLE IF NOT EXISTS stock_levels (
+    product_id   TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    warehouse_id TEXT NOT NULL REFERENCES warehouses(id),
+    available    INT NOT NULL DEFAULT 0 CHECK (available >= 0),
+    reserved     INT NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, warehouse_id)
+);
+
+-- Журнал движений остатков: сумма дельт по товару и складу равна остатку в stock_levels.
+-- Записи только добавляются (см. триггер stock_movements_append_only)
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id              BIGSERIAL PRIMARY KEY,
+    product_id      TEXT NOT NULL REFERENCES products(id),
+    warehouse_id    TEXT NOT NULL DEFAULT 'main' REFERENCES warehouses(id),
+    type            TEXT NOT NULL CHECK (type IN ('receipt', 'adjustment', 'reserve', 'release', 'sale', 'return')),
+    available_delta INT NOT NULL,
+    reserved_delta  INT NOT NULL,
+    available_after INT NOT NULL,
+    reserved_after  INT NOT NULL,
+    reason          TEXT NOT NULL DEFAULT '',
+    order_id        TEXT NOT NULL DEFAULT '',
+    reservation_id  TEXT NOT NULL DEFAULT '',
+    note            TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
 -- Таблица резервирований
 CREATE TABLE IF NOT EXISTS reservations (
-    id         TEXT PRIMARY KEY,
-    order_id   TEXT NOT NULL,
-    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
-    quantity   INT NOT NULL CHECK (quantity > 0),
-    expires_at TIMESTAMPTZ NOT NULL,
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    id           TEXT PRIMARY KEY,
+    order_id     TEXT NOT NULL,
+    product_id   TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    warehouse_id TEXT NOT NULL DEFAULT 'main' REFERENCES warehouses(id),
+    quantity     INT NOT NULL CHECK (quantity > 0),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
 -- Таблица платежей
@@ -52,7 +223,7 @@ CREATE TABLE IF NOT EXISTS payments (
     customer_id    TEXT NOT NULL,
     amount         NUMERIC(12,2) NOT NULL CHECK (amount > 0),
     currency       TEXT NOT NULL,
-    status         TEXT NOT NULL CHECK (status IN ('pending', 'completed', 'failed', 'refunded')),
+    status         TEXT NOT NULL CHECK (status IN ('pending', 'completed', 'failed', 'refunded', 'disputed')),
     payment_method TEXT NOT NULL,
     transaction_id TEXT,
     failure_reason TEXT,
@@ -61,22 +232,160 @@ CREATE TABLE IF NOT EXISTS payments (
     updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
+-- Журнал callback-ов платёжных провайдеров: дедупликация по ID события провайдера
+CREATE TABLE IF NOT EXISTS payment_provider_events (
+    provider    TEXT NOT NULL,
+    event_id    TEXT NOT NULL,
+    event_type  TEXT NOT NULL,
+    payment_id  TEXT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    payload     JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, event_id)
+);
+
 -- Таблица уведомлений
 CREATE TABLE IF NOT EXISTS notifications (
     id         TEXT PRIMARY KEY,
     customer_id TEXT NOT NULL,
-    order_id   TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
-    type       TEXT NOT NULL CHECK (type IN ('order_confirmed', 'order_failed', 'order_cancelled', 'payment_failed')),
+    -- NULL — уведомление не о заказе (stock_low мерчанту)
+    order_id   TEXT REFERENCES orders(id) ON DELETE CASCADE,
+    type       TEXT NOT NULL CHECK (type IN ('order_confirmed', 'order_failed', 'order_cancelled', 'payment_failed', 'order_timeout', 'stock_low', 'digest')),
     channel    TEXT NOT NULL CHECK (channel IN ('email', 'sms', 'push')),
-    status     TEXT NOT NULL CHECK (status IN ('pending', 'sent', 'failed')),
+    recipient  TEXT NOT NULL DEFAULT '',
+    locale     TEXT NOT NULL DEFAULT '',
+    status     TEXT NOT NULL CHECK (status IN ('pending', 'sent', 'failed', 'dead', 'discarded', 'batched', 'digested')),
     subject    TEXT,
     message    TEXT NOT NULL,
+    html_message TEXT NOT NULL DEFAULT '',
     metadata   JSONB,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    idempotency_key TEXT,
     sent_at    TIMESTAMPTZ,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
+-- Справочник контактов клиентов для уведомлений
+CREATE TABLE IF NOT EXISTS customers (
+    id                 TEXT PRIMARY KEY,
+    name               TEXT NOT NULL DEFAULT '',
+    locale             TEXT NOT NULL DEFAULT 'en',
+    preferred_channels TEXT[] NOT NULL DEFAULT '{}',
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Один контакт на канал: email, телефон (E.164) или токен устройства
+CREATE TABLE IF NOT EXISTS customer_contacts (
+    customer_id TEXT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    channel     TEXT NOT NULL CHECK (channel IN ('email', 'sms', 'push')),
+    address     TEXT NOT NULL,
+    PRIMARY KEY (customer_id, channel)
+);
+
+-- Согласие (opted_in = true) или отказ от уведомлений типа; нет записи — согласие
+CREATE TABLE IF NOT EXISTS customer_consents (
+    customer_id       TEXT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    notification_type TEXT NOT NULL,
+    opted_in          BOOLEAN NOT NULL,
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (customer_id, notification_type)
+);
+
+-- Таблица подписок (регулярные заказы)
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id                     TEXT PRIMARY KEY,
+    customer_id            TEXT NOT NULL,
+    items                  JSONB NOT NULL,
+    interval_seconds       BIGINT NOT NULL CHECK (interval_seconds > 0),
+    status                 TEXT NOT NULL CHECK (status IN ('active', 'paused', 'cancelled')),
+    cycles_completed       INT NOT NULL DEFAULT 0,
+    cycles_skipped         INT NOT NULL DEFAULT 0,
+    skip_next              BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run_at            TIMESTAMPTZ NOT NULL,
+    last_order_workflow_id TEXT,
+    workflow_id            TEXT NOT NULL,
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at             TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- События переходов шагов заказа (SSE /api/orders/{id}/events)
+CREATE TABLE IF NOT EXISTS order_step_events (
+    id           BIGSERIAL PRIMARY KEY,
+    workflow_id  TEXT NOT NULL,
+    seq          INT  NOT NULL,
+    order_id     TEXT,
+    step         TEXT NOT NULL,
+    step_status  TEXT NOT NULL,
+    order_status TEXT NOT NULL,
+    error_code   TEXT,
+    error        TEXT,
+    occurred_at  TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (workflow_id, seq)
+);
+
+-- Transactional outbox: события пишутся в одной транзакции с изменением состояния
+CREATE TABLE IF NOT EXISTS outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    dedup_key       TEXT NOT NULL UNIQUE,
+    aggregate_type  TEXT NOT NULL,
+    aggregate_id    TEXT NOT NULL,
+    event_type      TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at    TIMESTAMPTZ,
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error      TEXT
+);
+
+-- Подписки мерчантов на исходящие вебхуки
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id                   TEXT PRIMARY KEY,
+    url                  TEXT NOT NULL,
+    event_types          TEXT[] NOT NULL,
+    secret               TEXT NOT NULL,
+    status               TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'disabled')),
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    disabled_reason      TEXT,
+    disabled_at          TIMESTAMPTZ,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Доставка события подписке; id детерминирован по (subscription_id, dedup_key)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               TEXT PRIMARY KEY,
+    subscription_id  TEXT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id         BIGINT NOT NULL,
+    dedup_key        TEXT NOT NULL,
+    event_type       TEXT NOT NULL,
+    body             TEXT NOT NULL,
+    status           TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts         INT NOT NULL DEFAULT 0,
+    last_status_code INT,
+    last_error       TEXT,
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (subscription_id, dedup_key)
+);
+
+-- Журнал HTTP-попыток доставки
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id            BIGSERIAL PRIMARY KEY,
+    delivery_id   TEXT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    status_code   INT,
+    error         TEXT,
+    response_body TEXT,
+    duration_ms   BIGINT NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
 -- Индексы для orders
 CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id);
 CREATE INDEX IF NOT EXISTS idx_orders_created_at  ON orders(created_at DESC);
@@ -85,6 +394,35 @@ CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
 -- Индексы для products
 CREATE INDEX IF NOT EXISTS idx_products_sku ON products(sku);
 CREATE INDEX IF NOT EXISTS idx_products_available ON products(available);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements(product_id, id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_order_id ON stock_movements(order_id) WHERE order_id <> '';
+
+CREATE INDEX IF NOT EXISTS idx_order_allocations_order_id ON order_allocations(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_item_components_order_id ON order_item_components(order_id);
+CREATE INDEX IF NOT EXISTS idx_products_parent_id ON products(parent_id) WHERE parent_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_bundle_components_component_id ON bundle_components(component_id);
+
+-- Индексы для скидок и купонов
+-- Один заказ на workflow: повтор CreateOrderActivity не создаёт второй заказ и второе погашение купона
+CREATE UNIQUE INDEX IF NOT EXISTS orders_workflow_id_key ON orders(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_order_adjustments_order_id ON order_adjustments(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_tax_lines_order_id ON order_tax_lines(order_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_order_id ON coupon_redemptions(order_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_usage ON coupon_redemptions(promotion_id, customer_id) WHERE status <> 'released';
+
+-- Товары, созданные до появления складов, хранятся на складе по умолчанию
+INSERT INTO stock_levels (product_id, warehouse_id, available, reserved)
+SELECT p.id, 'main', p.available, p.reserved
+FROM products p
+WHERE p.kind = 'simple'
+  AND NOT EXISTS (SELECT 1 FROM stock_levels l WHERE l.product_id = p.id);
+
+-- Начальный остаток товаров, созданных до появления журнала
+INSERT INTO stock_movements (product_id, type, available_delta, reserved_delta, available_after, reserved_after, reason, note)
+SELECT p.id, 'adjustment', p.available, p.reserved, p.available, p.reserved, 'stocktake', 'opening balance'
+FROM products p
+WHERE (p.available <> 0 OR p.reserved <> 0)
+  AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = p.id);
 
 -- Индексы для reservations
 CREATE INDEX IF NOT EXISTS idx_reservations_order_id ON reservations(order_id);
@@ -94,6 +432,7 @@ CREATE INDEX IF NOT EXISTS idx_reservations_expires_at ON reservations(expires_a
 -- Индексы для payments
 CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments(order_id);
 CREATE INDEX IF NOT EXISTS idx_payments_customer_id ON payments(customer_id);
+CREATE INDEX IF NOT EXISTS idx_payments_transaction_id ON payments(transaction_id);
 CREATE INDEX IF NOT EXISTS idx_payments_status ON payments(status);
 CREATE INDEX IF NOT EXISTS idx_payments_created_at ON payments(created_at DESC);
 
@@ -102,6 +441,42 @@ CREATE INDEX IF NOT EXISTS idx_notifications_order_id ON notifications(order_id)
 CREATE INDEX IF NOT EXISTS idx_notifications_customer_id ON notifications(customer_id);
 CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications(status);
 CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications(created_at DESC);
+-- Очередь повторов: только уведомления, ожидающие следующей попытки
+CREATE INDEX IF NOT EXISTS idx_notifications_retry_due ON notifications(next_attempt_at) WHERE status = 'failed';
+-- Защита от повторной отправки: один ключ идемпотентности — одно уведомление
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_idempotency_key ON notifications(idempotency_key) WHERE idempotency_key IS NOT NULL;
+-- Сводки: отложенные уведомления клиента по каналу
+CREATE INDEX IF NOT EXISTS idx_notifications_digest_due ON notifications(customer_id, channel, next_attempt_at) WHERE status = 'batched';
+
+-- Индексы для subscriptions
+CREATE INDEX IF NOT EXISTS idx_subscriptions_customer_id ON subscriptions(customer_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_status ON subscriptions(status);
+
+-- Индексы для outbox (только неопубликованные события)
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_aggregate ON outbox(aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
+
+-- Индексы для вебхуков
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, id);
+
+-- Индексы для order_step_events
+CREATE INDEX IF NOT EXISTS idx_order_step_events_workflow_id ON order_step_events(workflow_id, id);
+
+-- Уведомление API-сервера о новом событии шага
+CREATE OR REPLACE FUNCTION notify_order_step_event() RETURNS TRIGGER AS $$
+BEGIN
+  PERFORM pg_notify('order_step_events', json_build_object('id', NEW.id, 'workflow_id', NEW.workflow_id)::text);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Журнал движений остатков нельзя изменить задним числом
+CREATE OR REPLACE FUNCTION reject_stock_movement_change() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
 
 -- Триггер для обновления updated_at
 CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
@@ -141,4 +516,25 @@ BEGIN
     BEFORE UPDATE ON notifications
     FOR EACH ROW EXECUTE FUNCTION set_updated_at();
   END IF;
+  
+  -- subscriptions
+  IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'subscriptions_set_updated_at') THEN
+    CREATE TRIGGER subscriptions_set_updated_at
+    BEFORE UPDATE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+  END IF;
+
+  -- order_step_events
+  IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'order_step_events_notify') THEN
+    CREATE TRIGGER order_step_events_notify
+    AFTER INSERT ON order_step_events
+    FOR EACH ROW EXECUTE FUNCTION notify_order_step_event();
+  END IF;
+
+  -- stock_movements
+  IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'stock_movements_append_only') THEN
+    CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION reject_stock_movement_change();
+  END IF;
 END $$;
@@ -9,3 +9,27 @@ INSERT INTO products (id, name, sku, price, available, reserved, created_at, upd
 ('prod-007', 'iPhone 15', 'IPHONE-15-128', 799.99, 60, 0, NOW(), NOW()),
 ('prod-008', 'iPad Pro 12.9"', 'IPAD-PRO-12-9-256', 1099.99, 20, 0, NOW(), NOW())
 ON CONFLICT (id) DO NOTHING;
+
+-- Демо-набор: остатков у набора нет, резервируются компоненты
+INSERT INTO products (id, name, sku, kind, price, available, reserved, created_at, updated_at) VALUES
+('prod-009', 'iPhone 15 + AirPods Pro', 'BUNDLE-IPHONE-15-AIRPODS', 'bundle', 999.99, 0, 0, NOW(), NOW())
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO bundle_components (bundle_id, component_id, quantity, position) VALUES
+('prod-009', 'prod-007', 1, 0),
+('prod-009', 'prod-003', 1, 1)
+ON CONFLICT DO NOTHING;
+
+-- Остаток демо-товаров на складе по умолчанию
+INSERT INTO stock_levels (product_id, warehouse_id, available, reserved)
+SELECT p.id, 'main', p.available, 0
+FROM products p
+WHERE p.kind = 'simple'
+  AND NOT EXISTS (SELECT 1 FROM stock_levels l WHERE l.product_id = p.id);
+
+-- Начальный остаток демо-товаров в журнале движений
+INSERT INTO stock_movements (product_id, type, available_delta, reserved_delta, available_after, reserved_after, note)
+SELECT p.id, 'receipt', p.available, 0, p.available, 0, 'opening balance'
+FROM products p
+WHERE p.available <> 0
+  AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = p.id);
@@ -0,0 +1,57 @@
+package smtpcapture
+
+import "crypto/tls"
+
+type Option func(*Server)
+
+func Addr(addr string) Option {
+	return func(s *Server) {
+		s.addr = addr
+	}
+}
+
+// StartTLS включает расширение STARTTLS с указанной конфигурацией TLS.
+func StartTLS(config *tls.Config) Option {
+	return func(s *Server) {
+		s.tlsConfig = config
+	}
+}
+
+// ImplicitTLS принимает только TLS-соединения с первого байта (как SMTPS на порту 465).
+func ImplicitTLS(config *tls.Config) Option {
+	return func(s *Server) {
+		s.tlsConfig = config
+		s.implicitTLS = true
+	}
+}
+
+// Auth требует AUTH PLAIN с указанными учётными данными до команды MAIL.
+func Auth(username, password string) Option {
+	return func(s *Server) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// Dir сохраняет каждое принятое письмо в каталог как .eml-файл.
+func Dir(path string) Option {
+	return func(s *Server) {
+		s.dir = path
+	}
+}
+
+// RejectRecipients отвечает 550 на RCPT TO для указанных адресов.
+func RejectRecipients(addresses ...string) Option {
+	return func(s *Server) {
+		for _, address := range addresses {
+			s.rejected[address] = struct{}{}
+		}
+	}
+}
+
+// OnMessage вызывается для каждого принятого письма после его сохранения.
+func OnMessage(fn func(Message)) Option {
+	return func(s *Server) {
+		s.onMessage = fn
+	}
+}
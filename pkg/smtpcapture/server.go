@@ -0,0 +1,389 @@
+// Package smtpcapture — минимальный SMTP-сервер, который принимает письма и хранит их
+// в памяти (и при необходимости в каталоге .eml-файлов). Предназначен для локальной
+// проверки email-уведомлений без реального почтового сервера.
+package smtpcapture
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAddr = "127.0.0.1:2525"
+	hostname    = "orderflow-capture"
+	// maxMessageSize ограничивает размер принимаемого письма
+	maxMessageSize = 10 << 20
+	idleTimeout    = 5 * time.Minute
+)
+
+type Message struct {
+	From       string
+	To         []string
+	Data       []byte
+	TLS        bool
+	ReceivedAt time.Time
+	// Path — путь к .eml-файлу, если задан каталог
+	Path string
+}
+
+type Server struct {
+	addr      string
+	tlsConfig *tls.Config
+	// implicitTLS — TLS с первого байта вместо STARTTLS
+	implicitTLS bool
+	username    string
+	password    string
+	dir         string
+	rejected    map[string]struct{}
+	onMessage   func(Message)
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []Message
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+func New(opts ...Option) *Server {
+	s := &Server{
+		addr:     defaultAddr,
+		rejected: make(map[string]struct{}),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start открывает порт и обслуживает соединения в фоне. Адрес ":0" выбирает свободный порт,
+// фактический адрес возвращает Addr.
+func (s *Server) Start() error {
+	if s.dir != "" {
+		if err := os.MkdirAll(s.dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create capture directory: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if s.implicitTLS {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+	s.listener = listener
+
+	s.wg.Add(1)
+	go s.serve()
+	return nil
+}
+
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.addr
+	}
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	s.wg.Wait()
+	return err
+}
+
+// Messages возвращает копию списка принятых писем.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			s.handle(conn)
+		}()
+	}
+}
+
+type session struct {
+	text   *textproto.Conn
+	tls    bool
+	authed bool
+	mail   bool
+	from   string
+	to     []string
+}
+
+func (sess *session) reply(code int, lines ...string) error {
+	for i, line := range lines {
+		sep := " "
+		if i < len(lines)-1 {
+			sep = "-"
+		}
+		if err := sess.text.PrintfLine("%d%s%s", code, sep, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sess *session) reset() {
+	sess.mail = false
+	sess.from = ""
+	sess.to = nil
+}
+
+func (s *Server) handle(conn net.Conn) {
+	sess := &session{text: textproto.NewConn(conn)}
+	if _, ok := conn.(*tls.Conn); ok {
+		sess.tls = true
+	}
+
+	if sess.reply(220, hostname+" ESMTP ready") != nil {
+		return
+	}
+
+	for {
+		_ = conn.SetDeadline(time.Now().Add(idleTimeout))
+
+		line, err := sess.text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		verb = strings.ToUpper(verb)
+
+		switch verb {
+		case "EHLO":
+			sess.reset()
+			ext := []string{hostname, "8BITMIME", "SMTPUTF8", fmt.Sprintf("SIZE %d", maxMessageSize)}
+			if s.tlsConfig != nil && !sess.tls {
+				ext = append(ext, "STARTTLS")
+			}
+			if s.username != "" {
+				ext = append(ext, "AUTH PLAIN")
+			}
+			err = sess.reply(250, ext...)
+		case "HELO":
+			sess.reset()
+			err = sess.reply(250, hostname)
+		case "STARTTLS":
+			if s.tlsConfig == nil || sess.tls {
+				err = sess.reply(502, "5.5.1 STARTTLS not available")
+				break
+			}
+			if err = sess.reply(220, "2.0.0 Ready to start TLS"); err != nil {
+				return
+			}
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err = tlsConn.Handshake(); err != nil {
+				return
+			}
+			// После STARTTLS состояние сессии сбрасывается (RFC 3207)
+			sess = &session{text: textproto.NewConn(tlsConn), tls: true}
+			conn = tlsConn
+		case "AUTH":
+			err = s.handleAuth(sess, arg)
+		case "MAIL":
+			if s.username != "" && !sess.authed {
+				err = sess.reply(530, "5.7.0 Authentication required")
+				break
+			}
+			address, ok := parsePath(arg, "FROM:")
+			if !ok {
+				err = sess.reply(501, "5.5.4 Syntax: MAIL FROM:<address>")
+				break
+			}
+			sess.reset()
+			sess.mail = true
+			sess.from = address
+			err = sess.reply(250, "2.1.0 OK")
+		case "RCPT":
+			if !sess.mail {
+				err = sess.reply(503, "5.5.1 MAIL first")
+				break
+			}
+			address, ok := parsePath(arg, "TO:")
+			if !ok || address == "" {
+				err = sess.reply(501, "5.5.4 Syntax: RCPT TO:<address>")
+				break
+			}
+			if _, rejected := s.rejected[address]; rejected {
+				err = sess.reply(550, "5.1.1 Mailbox unavailable")
+				break
+			}
+			sess.to = append(sess.to, address)
+			err = sess.reply(250, "2.1.5 OK")
+		case "DATA":
+			if len(sess.to) == 0 {
+				err = sess.reply(503, "5.5.1 RCPT first")
+				break
+			}
+			err = s.handleData(sess)
+		case "RSET":
+			sess.reset()
+			err = sess.reply(250, "2.0.0 OK")
+		case "NOOP":
+			err = sess.reply(250, "2.0.0 OK")
+		case "QUIT":
+			_ = sess.reply(221, "2.0.0 Bye")
+			return
+		default:
+			err = sess.reply(502, "5.5.2 Command not recognized")
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handleAuth(sess *session, arg string) error {
+	if s.username == "" {
+		return sess.reply(502, "5.5.1 AUTH not available")
+	}
+	if sess.authed {
+		return sess.reply(503, "5.5.1 Already authenticated")
+	}
+
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mechanism, "PLAIN") {
+		return sess.reply(504, "5.5.4 Unrecognized authentication mechanism")
+	}
+
+	if initial == "" {
+		if err := sess.text.PrintfLine("334 "); err != nil {
+			return err
+		}
+		line, err := sess.text.ReadLine()
+		if err != nil {
+			return err
+		}
+		initial = line
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(initial))
+	if err != nil {
+		return sess.reply(501, "5.5.2 Invalid base64")
+	}
+	fields := strings.Split(string(decoded), "\x00")
+	if len(fields) != 3 || fields[1] != s.username || fields[2] != s.password {
+		return sess.reply(535, "5.7.8 Authentication credentials invalid")
+	}
+
+	sess.authed = true
+	return sess.reply(235, "2.7.0 Authentication successful")
+}
+
+func (s *Server) handleData(sess *session) error {
+	if err := sess.reply(354, "End data with <CR><LF>.<CR><LF>"); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(sess.text.DotReader(), maxMessageSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxMessageSize {
+		// Остаток письма нужно дочитать, иначе он будет принят за команды
+		_, _ = io.Copy(io.Discard, sess.text.DotReader())
+		sess.reset()
+		return sess.reply(552, "5.3.4 Message too big")
+	}
+
+	message := Message{
+		From:       sess.from,
+		To:         sess.to,
+		Data:       data,
+		TLS:        sess.tls,
+		ReceivedAt: time.Now(),
+	}
+	sess.reset()
+
+	s.mu.Lock()
+	seq := len(s.messages) + 1
+	if s.dir != "" {
+		name := fmt.Sprintf("%s-%04d.eml", message.ReceivedAt.UTC().Format("20060102T150405"), seq)
+		message.Path = filepath.Join(s.dir, name)
+		if err := os.WriteFile(message.Path, data, 0o644); err != nil {
+			s.mu.Unlock()
+			return sess.reply(451, "4.3.0 Failed to store message")
+		}
+	}
+	s.messages = append(s.messages, message)
+	s.mu.Unlock()
+
+	if s.onMessage != nil {
+		s.onMessage(message)
+	}
+
+	return sess.reply(250, fmt.Sprintf("2.0.0 OK queued as %d", seq))
+}
+
+// parsePath разбирает аргумент вида "FROM:<address> [params]".
+func parsePath(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	rest := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(rest, "<") {
+		return "", false
+	}
+	end := strings.Index(rest, ">")
+	if end < 0 {
+		return "", false
+	}
+	return rest[1:end], true
+}